@@ -0,0 +1,553 @@
+// Command mailsorter verifies addresses and sorts lists from the command
+// line, using the verifier package in-process rather than the HTTP service.
+//
+//	mailsorter verify user@example.com [more@example.com ...]
+//	mailsorter batch -f list.csv -o out/
+//	mailsorter domain example.com [example.org ...]
+//
+// Every subcommand takes --json and --concurrency; flags may come before or
+// after the arguments. Redis is optional: without it the verifier runs in
+// soft-fail mode (no shared cache, in-process rate limits).
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+const usage = `Usage: mailsorter <command> [flags] [arguments]
+
+Commands:
+  verify ADDRESS...   verify one or more addresses
+  batch -f FILE -o DIR
+                      verify a CSV list (gzipped when FILE ends in .gz, - for
+                      stdin) and write one CSV per status plus summary.json
+  domain DOMAIN...    show MX records and what is known about each domain
+
+Run "mailsorter <command> -h" for the flags of a command.
+`
+
+// batchChunk is how many addresses go to VerifyBatch at once
+const batchChunk = 1000
+
+// options are the flags every subcommand shares
+type options struct {
+	json        bool
+	concurrency int
+	depth       string
+	redis       string
+	mailFrom    string
+	ehlo        string
+	timeout     time.Duration
+	verbose     bool
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "verify":
+		err = runVerify(ctx, args)
+	case "batch":
+		err = runBatch(ctx, args)
+	case "domain":
+		err = runDomain(ctx, args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "mailsorter: unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mailsorter: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func newFlagSet(name, synopsis string) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: mailsorter %s\n\nFlags:\n", synopsis)
+		fs.PrintDefaults()
+	}
+
+	defaults := verifier.DefaultConfig()
+	opts := &options{}
+	fs.BoolVar(&opts.json, "json", false, "print JSON instead of text")
+	fs.IntVar(&opts.concurrency, "concurrency", defaults.BatchConcurrency, "addresses verified at once")
+	fs.StringVar(&opts.depth, "depth", verifier.DepthDeep, "syntax, dns, smtp or deep")
+	fs.StringVar(&opts.redis, "redis", os.Getenv("REDIS_HOST"), "Redis host[:port] for the shared cache and rate limits; empty runs without")
+	fs.StringVar(&opts.mailFrom, "mail-from", defaults.MailFrom, "MAIL FROM address for SMTP probes")
+	fs.StringVar(&opts.ehlo, "ehlo", defaults.EHLOHostname, "EHLO hostname for SMTP probes")
+	fs.DurationVar(&opts.timeout, "timeout", defaults.SMTPConnectTimeout, "SMTP connect timeout")
+	fs.BoolVar(&opts.verbose, "v", false, "log verifier warnings to stderr")
+	return fs, opts
+}
+
+// parseArgs parses flags wherever they appear among args and returns the
+// positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// newVerifier builds a verifier from opts; Redis is used only when given
+func newVerifier(opts *options) (*verifier.SMTPVerifier, error) {
+	if opts.concurrency <= 0 {
+		return nil, errors.New("--concurrency must be positive")
+	}
+	if !verifier.ValidDepth(opts.depth) {
+		return nil, fmt.Errorf("unknown --depth %q", opts.depth)
+	}
+
+	level := slog.LevelError
+	if opts.verbose {
+		level = slog.LevelWarn
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	config := verifier.DefaultConfig()
+	config.BatchConcurrency = opts.concurrency
+	config.MailFrom = opts.mailFrom
+	config.EHLOHostname = opts.ehlo
+	config.SMTPConnectTimeout = opts.timeout
+
+	// With no Redis the first command marks the store degraded and the
+	// verifier carries on without it
+	addr := opts.redis
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	} else if !strings.Contains(addr, ":") {
+		addr += ":6379"
+	}
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:        addr,
+		Password:    os.Getenv("REDIS_PASSWORD"),
+		DialTimeout: time.Second,
+		MaxRetries:  -1,
+	})
+	return verifier.NewSMTPVerifier(config, redisClient), nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// ============================================================================
+// VERIFY
+// ============================================================================
+
+// verifyResult is a result as the service's clean-list rows show it
+type verifyResult struct {
+	*verifier.ValidationResult
+	Score   int    `json:"score"`
+	Segment string `json:"segment"`
+}
+
+func runVerify(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("verify", "verify [flags] ADDRESS...")
+	normalize := fs.Bool("normalize", false, "check the provider's canonical form of each address")
+	noCache := fs.Bool("no-cache", false, "skip cached results")
+	emails := parseArgs(fs, args)
+	if len(emails) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	v, err := newVerifier(opts)
+	if err != nil {
+		return err
+	}
+
+	// Addresses run at up to --concurrency at once and print in order
+	results := make([]*verifyResult, len(emails))
+	errs := make([]error, len(emails))
+	sem := make(chan struct{}, opts.concurrency)
+	done := make(chan struct{})
+	for i, email := range emails {
+		go func(i int, email string) {
+			sem <- struct{}{}
+			defer func() { <-sem; done <- struct{}{} }()
+			result, err := v.VerifyWithOptions(ctx, email, verifier.VerifyOptions{
+				SkipCache: *noCache,
+				Normalize: *normalize,
+				Depth:     opts.depth,
+			})
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", email, err)
+				return
+			}
+			results[i] = &verifyResult{ValidationResult: result, Score: verifier.ScoreResult(result), Segment: verifier.SegmentFor(result.Status)}
+		}(i, email)
+	}
+	for range emails {
+		<-done
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if opts.json {
+		if len(results) == 1 {
+			return printJSON(results[0])
+		}
+		return printJSON(results)
+	}
+	for _, result := range results {
+		printResult(result)
+	}
+	return nil
+}
+
+func printResult(r *verifyResult) {
+	fmt.Printf("%s: %s (%s)\n", r.Email, r.Status, r.Reason)
+	fmt.Printf("  segment %s, score %d, quality %d, confidence %.2f\n", r.Segment, r.Score, r.QualityScore, r.Confidence)
+	if r.MXHost != "" {
+		fmt.Printf("  mx %s", r.MXHost)
+		if r.SMTPCode != 0 {
+			fmt.Printf(", %d %s", r.SMTPCode, r.SMTPResponse)
+		}
+		fmt.Println()
+	}
+	if r.Provider != "" {
+		fmt.Printf("  provider %s", r.Provider)
+		if r.AccountType != "" {
+			fmt.Printf(" (%s)", r.AccountType)
+		}
+		fmt.Println()
+	}
+	var flags []string
+	for name, set := range map[string]bool{"catch-all": r.IsCatchAll, "disposable": r.IsDisposable, "role": r.IsRole, "free provider": r.IsFreeProvider} {
+		if set {
+			flags = append(flags, name)
+		}
+	}
+	if len(flags) > 0 {
+		sort.Strings(flags)
+		fmt.Printf("  %s\n", strings.Join(flags, ", "))
+	}
+	if r.DidYouMean != "" {
+		fmt.Printf("  did you mean %s?\n", r.DidYouMean)
+	}
+	if len(r.DegradedChecks) > 0 {
+		fmt.Printf("  degraded: %s\n", strings.Join(r.DegradedChecks, ", "))
+	}
+}
+
+// ============================================================================
+// BATCH
+// ============================================================================
+
+// batchColumns are the per-status CSV columns, those of the service's
+// exports less client metadata
+var batchColumns = []string{"email", "status", "reason", "confidence", "score", "segment", "is_catch_all", "is_disposable", "mx_host", "checked_at"}
+
+// batchSummary is written to summary.json, like a bucket list job's
+type batchSummary struct {
+	Input         string         `json:"input"`
+	Rows          int            `json:"rows"`
+	Duplicates    int            `json:"duplicates"`
+	Verified      int            `json:"verified"`
+	StatusCounts  map[string]int `json:"status_counts"`
+	SegmentCounts map[string]int `json:"segment_counts"`
+	Files         []string       `json:"files"`
+	DurationMs    int64          `json:"duration_ms"`
+	CompletedAt   time.Time      `json:"completed_at"`
+}
+
+type batchOutput struct {
+	file *os.File
+	csv  *csv.Writer
+}
+
+func runBatch(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("batch", "batch -f FILE -o DIR [flags]")
+	input := fs.String("f", "", "CSV list to verify; the email column, or the first one")
+	outDir := fs.String("o", "", "directory for the per-status CSVs and summary.json")
+	if rest := parseArgs(fs, args); len(rest) > 0 || *input == "" || *outDir == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	v, err := newVerifier(opts)
+	if err != nil {
+		return err
+	}
+
+	src, err := openInput(*input)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return err
+	}
+
+	started := time.Now()
+	summary := &batchSummary{Input: *input, StatusCounts: make(map[string]int), SegmentCounts: make(map[string]int)}
+	outputs := make(map[string]*batchOutput)
+	defer func() {
+		for _, out := range outputs {
+			out.file.Close()
+		}
+	}()
+
+	flush := func(chunk []string) error {
+		for _, result := range v.VerifyBatch(ctx, chunk) {
+			row := &verifyResult{ValidationResult: result, Score: verifier.ScoreResult(result), Segment: verifier.SegmentFor(result.Status)}
+			if err := writeBatchRow(*outDir, outputs, row); err != nil {
+				return err
+			}
+			summary.StatusCounts[string(row.Status)]++
+			summary.SegmentCounts[row.Segment]++
+		}
+		summary.Verified += len(chunk)
+		if !opts.json {
+			fmt.Fprintf(os.Stderr, "\rverified %d", summary.Verified)
+		}
+		return ctx.Err()
+	}
+
+	reader := newEmailReader(src)
+	seen := make(map[string]struct{})
+	chunk := make([]string, 0, batchChunk)
+	for {
+		email, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %w", *input, err)
+		}
+		summary.Rows++
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email == "" {
+			continue
+		}
+		if _, dup := seen[email]; dup {
+			summary.Duplicates++
+			continue
+		}
+		seen[email] = struct{}{}
+		if chunk = append(chunk, email); len(chunk) == batchChunk {
+			if err := flush(chunk); err != nil {
+				return err
+			}
+			chunk = chunk[:0]
+		}
+	}
+	if len(chunk) > 0 {
+		if err := flush(chunk); err != nil {
+			return err
+		}
+	}
+	if !opts.json && summary.Verified > 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	for _, out := range outputs {
+		out.csv.Flush()
+		if err := out.csv.Error(); err != nil {
+			return err
+		}
+		summary.Files = append(summary.Files, filepath.Base(out.file.Name()))
+	}
+	sort.Strings(summary.Files)
+	summary.DurationMs = time.Since(started).Milliseconds()
+	summary.CompletedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(*outDir, "summary.json"), append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	if opts.json {
+		return printJSON(summary)
+	}
+	fmt.Printf("%d rows, %d duplicates, %d verified in %s\n", summary.Rows, summary.Duplicates, summary.Verified, time.Duration(summary.DurationMs)*time.Millisecond)
+	statuses := make([]string, 0, len(summary.StatusCounts))
+	for status := range summary.StatusCounts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Printf("  %-10s %d\n", status, summary.StatusCounts[status])
+	}
+	fmt.Printf("results in %s\n", *outDir)
+	return nil
+}
+
+// openInput opens path, or stdin for "-", decompressing .gz files
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(bufio.NewReader(os.Stdin)), nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+	gz, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, file}, nil
+}
+
+// writeBatchRow appends row to its status file, creating it on first use
+func writeBatchRow(dir string, outputs map[string]*batchOutput, row *verifyResult) error {
+	status := string(row.Status)
+	out := outputs[status]
+	if out == nil {
+		file, err := os.Create(filepath.Join(dir, strings.ReplaceAll(status, "-", "_")+".csv"))
+		if err != nil {
+			return err
+		}
+		out = &batchOutput{file: file, csv: csv.NewWriter(file)}
+		outputs[status] = out
+		if err := out.csv.Write(batchColumns); err != nil {
+			return err
+		}
+	}
+	return out.csv.Write([]string{
+		row.Email,
+		status,
+		row.Reason,
+		strconv.FormatFloat(row.Confidence, 'f', -1, 64),
+		strconv.Itoa(row.Score),
+		row.Segment,
+		strconv.FormatBool(row.IsCatchAll),
+		strconv.FormatBool(row.IsDisposable),
+		row.MXHost,
+		row.CheckedAt.Format(time.RFC3339),
+	})
+}
+
+// emailReader reads the address column of a CSV list one row at a time: the
+// column headed "email" when the first row is a header, else the first
+type emailReader struct {
+	csv    *csv.Reader
+	column int
+	line   int
+}
+
+func newEmailReader(r io.Reader) *emailReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	return &emailReader{csv: reader}
+}
+
+// Next returns the next address, or io.EOF after the last row
+func (er *emailReader) Next() (string, error) {
+	for {
+		record, err := er.csv.Read()
+		if err != nil {
+			return "", err
+		}
+
+		if er.line++; er.line == 1 {
+			header := false
+			for i, field := range record {
+				if strings.EqualFold(strings.TrimSpace(field), "email") {
+					er.column, header = i, true
+					break
+				}
+			}
+			if header {
+				continue
+			}
+		}
+
+		if er.column < len(record) {
+			return record[er.column], nil
+		}
+	}
+}
+
+// ============================================================================
+// DOMAIN
+// ============================================================================
+
+func runDomain(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("domain", "domain [flags] DOMAIN...")
+	domains := parseArgs(fs, args)
+	if len(domains) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	v, err := newVerifier(opts)
+	if err != nil {
+		return err
+	}
+
+	results, err := v.PreflightDomains(ctx, domains, true)
+	if err != nil {
+		return err
+	}
+	if opts.json {
+		if len(results) == 1 {
+			return printJSON(results[0])
+		}
+		return printJSON(results)
+	}
+	for _, d := range results {
+		fmt.Printf("%s:\n", d.Domain)
+		if d.MXValid == nil || !*d.MXValid {
+			fmt.Println("  mx: none; does not accept mail")
+		} else {
+			for _, mx := range d.MXRecords {
+				fmt.Printf("  mx: %d %s\n", mx.Priority, mx.Exchange)
+			}
+		}
+		fmt.Printf("  disposable: %t\n", d.IsDisposable)
+		if d.IsCatchAll != nil {
+			fmt.Printf("  catch-all: %t\n", *d.IsCatchAll)
+		} else {
+			fmt.Println("  catch-all: not yet known")
+		}
+	}
+	return nil
+}
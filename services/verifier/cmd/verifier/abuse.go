@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// PROBE AUDIT AND ABUSE REPORTS
+// ============================================================================
+
+// defaultAbuseWindow is searched when a request gives no from
+const defaultAbuseWindow = 7 * 24 * time.Hour
+
+type AbuseReportRequest struct {
+	Reporter    string    `json:"reporter,omitempty"`
+	Notes       string    `json:"notes,omitempty"`
+	Domain      string    `json:"domain,omitempty"`
+	MX          string    `json:"mx,omitempty"`
+	EgressIP    string    `json:"egress_ip,omitempty"`
+	From        time.Time `json:"from,omitempty"`
+	To          time.Time `json:"to,omitempty"`
+	DoNotVerify *bool     `json:"do_not_verify,omitempty"` // Default true
+}
+
+type DoNotVerifyRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// abuseWindow fills in a missing end (now) and start (a week before it)
+func abuseWindow(from, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultAbuseWindow)
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+// handleSearchProbes lists audited probes; from and to are RFC 3339
+func (s *Server) handleSearchProbes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var from, to time.Time
+	for name, t := range map[string]*time.Time{"from": &from, "to": &to} {
+		if raw := query.Get(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, name+" must be an RFC 3339 timestamp")
+				return
+			}
+			*t = parsed
+		}
+	}
+	from, to, err := abuseWindow(from, to)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+		return
+	}
+	limit := 1000
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > verifier.MaxProbeAuditResults {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", verifier.MaxProbeAuditResults))
+			return
+		}
+		limit = n
+	}
+
+	probes, truncated, err := s.verifier.SearchProbes(r.Context(), verifier.ProbeQuery{
+		From:     from,
+		To:       to,
+		Domain:   strings.ToLower(strings.TrimSpace(query.Get("domain"))),
+		MXSuffix: strings.ToLower(strings.TrimSpace(query.Get("mx"))),
+		EgressIP: strings.TrimSpace(query.Get("egress_ip")),
+		Limit:    limit,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to search probe audit")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"probes": probes, "truncated": truncated})
+}
+
+// handleFileAbuseReport records a complaint and answers it with the probes
+// it covers; unless do_not_verify is false the domains involved stop being
+// verified
+func (s *Server) handleFileAbuseReport(w http.ResponseWriter, r *http.Request) {
+	var req AbuseReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request")
+		return
+	}
+	req.Domain = strings.ToLower(strings.TrimSpace(req.Domain))
+	req.MX = strings.ToLower(strings.TrimSpace(req.MX))
+	req.EgressIP = strings.TrimSpace(req.EgressIP)
+	if req.Domain == "" && req.MX == "" && req.EgressIP == "" {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "domain, mx or egress_ip is required")
+		return
+	}
+	from, to, err := abuseWindow(req.From, req.To)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+		return
+	}
+
+	report := &verifier.AbuseReport{
+		Reporter: req.Reporter,
+		Notes:    req.Notes,
+		Domain:   req.Domain,
+		MXSuffix: req.MX,
+		EgressIP: req.EgressIP,
+		From:     from,
+		To:       to,
+	}
+	if err := s.verifier.FileAbuseReport(r.Context(), report, req.DoNotVerify == nil || *req.DoNotVerify); err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to file abuse report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (s *Server) handleGetAbuseReport(w http.ResponseWriter, r *http.Request) {
+	report, err := s.verifier.GetAbuseReport(r.Context(), mux.Vars(r)["id"])
+	if err == redis.Nil {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "Report not found")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load abuse report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (s *Server) handleListDoNotVerify(w http.ResponseWriter, r *http.Request) {
+	domains, err := s.verifier.DoNotVerifyDomains(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to list do-not-verify domains")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"domains": domains})
+}
+
+func (s *Server) handleSetDoNotVerify(w http.ResponseWriter, r *http.Request) {
+	var req DoNotVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request")
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "api"
+	}
+
+	domain := strings.ToLower(mux.Vars(r)["domain"])
+	if err := s.verifier.SetDoNotVerify(r.Context(), domain, req.Reason); err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to update do-not-verify list")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleLiftDoNotVerify(w http.ResponseWriter, r *http.Request) {
+	domain := strings.ToLower(mux.Vars(r)["domain"])
+	if err := s.verifier.LiftDoNotVerify(r.Context(), domain); err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to update do-not-verify list")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
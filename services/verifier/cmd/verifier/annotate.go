@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// SENDING-PATH ANNOTATION
+// ============================================================================
+
+// POST /v1/annotate takes a raw outbound message (RFC 5322) as the request
+// body, plus any envelope recipients as repeated ?rcpt= parameters, and
+// returns a verdict per recipient from the suppression list and cached
+// results only; nothing is probed. Only the header block is read, so the
+// body and attachments cost nothing. The suggested headers use the same
+// format as the SMTP proxy's flag mode.
+
+const (
+	annotateMaxRecipients  = 1000
+	annotateMaxHeaderBytes = 1 << 20
+)
+
+var annotateHeaders = []string{"To", "Cc", "Bcc"}
+
+type AnnotatedRecipient struct {
+	Email     string                    `json:"email"`
+	Field     string                    `json:"field"`   // to, cc, bcc or envelope
+	Verdict   string                    `json:"verdict"` // ok, invalid, suppressed or unverified
+	Reason    string                    `json:"reason,omitempty"`
+	Status    verifier.ValidationStatus `json:"status,omitempty"` // Cached status, when there is one
+	CheckedAt *time.Time                `json:"checked_at,omitempty"`
+}
+
+type AnnotateResponse struct {
+	Recipients []AnnotatedRecipient `json:"recipients"`
+	Blocked    int                  `json:"blocked"`           // Invalid or suppressed recipients
+	Headers    map[string]string    `json:"headers,omitempty"` // Suggested headers to add before sending
+}
+
+func (s *Server) handleAnnotate(w http.ResponseWriter, r *http.Request) {
+	msg, err := mail.ReadMessage(http.MaxBytesReader(w, r.Body, annotateMaxHeaderBytes))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid message: "+err.Error())
+		return
+	}
+
+	var emails, fields []string
+	seen := make(map[string]bool)
+	add := func(addr, field string) {
+		key := strings.ToLower(addr)
+		if !seen[key] {
+			seen[key] = true
+			emails = append(emails, addr)
+			fields = append(fields, field)
+		}
+	}
+	for _, name := range annotateHeaders {
+		if msg.Header.Get(name) == "" {
+			continue
+		}
+		list, err := msg.Header.AddressList(name)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, fmt.Sprintf("Invalid %s header: %v", name, err))
+			return
+		}
+		for _, addr := range list {
+			add(addr.Address, strings.ToLower(name))
+		}
+	}
+	for _, addr := range r.URL.Query()["rcpt"] {
+		if addr = strings.Trim(strings.TrimSpace(addr), "<>"); addr != "" {
+			add(addr, "envelope")
+		}
+	}
+	if len(emails) == 0 {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "No recipients found")
+		return
+	}
+	if len(emails) > annotateMaxRecipients {
+		writeErrorDetails(w, r, http.StatusBadRequest, ErrBatchTooLarge, fmt.Sprintf("Too many recipients (max %d)", annotateMaxRecipients), map[string]interface{}{"max": annotateMaxRecipients})
+		return
+	}
+
+	resp := AnnotateResponse{Recipients: make([]AnnotatedRecipient, len(emails))}
+	var flagged []string
+	for i, rcpt := range s.verifier.CheckRecipientsCached(r.Context(), emails) {
+		annotated := AnnotatedRecipient{
+			Email:   rcpt.Email,
+			Field:   fields[i],
+			Verdict: string(rcpt.Verdict),
+			Reason:  rcpt.Reason,
+		}
+		if rcpt.Verdict == verifier.RecipientOK {
+			annotated.Verdict = "ok"
+		}
+		if rcpt.Result != nil {
+			annotated.Status = rcpt.Result.Status
+			annotated.CheckedAt = &rcpt.Result.CheckedAt
+		}
+		if rcpt.Verdict.Blocked() {
+			resp.Blocked++
+			flagged = append(flagged, rcpt.Email+"="+string(rcpt.Verdict))
+		}
+		resp.Recipients[i] = annotated
+	}
+	if len(flagged) > 0 {
+		resp.Headers = map[string]string{verifier.RecipientHeader: strings.Join(flagged, "; ")}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
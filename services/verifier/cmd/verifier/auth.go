@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// API KEYS
+// ============================================================================
+
+// Every /v1 and /v2 request is authenticated by its X-API-Key, checked
+// against auth.api_key_hashes (SHA-256 hex digests, so the config never
+// holds a credential). An unknown key gets 401. Without a key, requests get
+// 401 too unless auth.api_key_required is off; they then run as the
+// anonymous tenant, which is metered and held to the default quotas like
+// any key. Health, metrics and the API reference need no key.
+//
+// The tenant of a known key is key_ plus the first 12 hex characters of its
+// digest, so usage stats never contain credentials.
+//
+// Operator endpoints that change fleet-wide behaviour or read data across
+// tenants (kill switches, task controls, blocklists, abuse searches, cache
+// invalidation, DMARC reports) are wrapped in requireAdmin: the key
+// must also be in auth.admin_key_hashes, otherwise 403. With no admin keys
+// configured those endpoints are closed to everyone. Admin keys are
+// accepted wherever an API key is.
+
+// authMiddleware attributes API requests to the caller's key and refuses
+// those without a valid one
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil || !(strings.HasPrefix(template, "/v1/") || strings.HasPrefix(template, "/v2/")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cfg := s.verifier.Config()
+		key := r.Header.Get("X-API-Key")
+		switch {
+		case key != "":
+			digest, ok := knownKey(cfg.APIKeyHashes, key)
+			if !ok {
+				digest, ok = knownKey(cfg.AdminKeyHashes, key)
+			}
+			if !ok {
+				writeError(w, r, http.StatusUnauthorized, ErrUnauthorized, "Unknown API key")
+				return
+			}
+			r = r.WithContext(verifier.WithTenant(r.Context(), "key_"+digest[:12]))
+		case cfg.APIKeyRequired:
+			writeError(w, r, http.StatusUnauthorized, ErrUnauthorized, "An API key is required")
+			return
+		default:
+			r = r.WithContext(verifier.WithTenant(r.Context(), verifier.AnonymousTenant))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdmin refuses requests whose key is not an admin key
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions {
+			key := r.Header.Get("X-API-Key")
+			if _, ok := knownKey(s.verifier.Config().AdminKeyHashes, key); key == "" || !ok {
+				writeError(w, r, http.StatusForbidden, ErrForbidden, "An admin API key is required")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// knownKey returns the hex digest of key when it is one of hashes
+func knownKey(hashes []string, key string) (string, bool) {
+	sum := sha256.Sum256([]byte(key))
+	digest := hex.EncodeToString(sum[:])
+	for _, hash := range hashes {
+		if subtle.ConstantTimeCompare([]byte(strings.ToLower(hash)), []byte(digest)) == 1 {
+			return digest, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// ============================================================================
+// CACHE INVALIDATION
+// ============================================================================
+
+func (s *Server) handleInvalidateDomain(w http.ResponseWriter, r *http.Request) {
+	domain := strings.ToLower(mux.Vars(r)["domain"])
+	if err := s.verifier.InvalidateDomain(r.Context(), domain); err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to invalidate domain")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// ============================================================================
+// CATCH-ALL PROBE CONTROLS
+// ============================================================================
+
+type KillSwitchRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type CatchAllDomainRequest struct {
+	Disabled         bool   `json:"disabled"`
+	Reason           string `json:"reason,omitempty"`
+	DailyProbeBudget *int   `json:"daily_probe_budget,omitempty"` // 0 = unlimited
+}
+
+func (s *Server) handleGetCatchAllControls(w http.ResponseWriter, r *http.Request) {
+	controls, err := s.verifier.CatchAllControls(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load catch-all controls")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(controls)
+}
+
+func (s *Server) handleSetCatchAllKillSwitch(w http.ResponseWriter, r *http.Request) {
+	var req KillSwitchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request")
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "api"
+	}
+
+	if err := s.verifier.SetCatchAllKillSwitch(r.Context(), req.Enabled, req.Reason); err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to update kill switch")
+		return
+	}
+	s.handleGetCatchAllControls(w, r)
+}
+
+func (s *Server) handleGetCatchAllDomain(w http.ResponseWriter, r *http.Request) {
+	domain := strings.ToLower(mux.Vars(r)["domain"])
+	control, err := s.verifier.CatchAllDomainControl(r.Context(), domain)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load catch-all controls")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(control)
+}
+
+func (s *Server) handleSetCatchAllDomain(w http.ResponseWriter, r *http.Request) {
+	var req CatchAllDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request")
+		return
+	}
+	if req.DailyProbeBudget != nil && *req.DailyProbeBudget < 0 {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "daily_probe_budget must be 0 or more")
+		return
+	}
+	if req.Disabled && req.Reason == "" {
+		req.Reason = "api"
+	}
+
+	domain := strings.ToLower(mux.Vars(r)["domain"])
+	if err := s.verifier.SetCatchAllDomainControl(r.Context(), domain, req.Disabled, req.Reason, req.DailyProbeBudget); err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to update catch-all controls")
+		return
+	}
+	s.handleGetCatchAllDomain(w, r)
+}
+
+func (s *Server) handleClearCatchAllDomain(w http.ResponseWriter, r *http.Request) {
+	domain := strings.ToLower(mux.Vars(r)["domain"])
+	if err := s.verifier.ClearCatchAllDomainControl(r.Context(), domain); err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to update catch-all controls")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// PER-CLIENT RATE LIMITING
+// ============================================================================
+
+// Requests to /v1 and /v2 take a token from the caller's bucket (see
+// pkg/verifier/client-ratelimit.go) once the route is matched, so
+// api.rate_limits.routes can be keyed by route template. Limited responses
+// carry X-RateLimit-Limit (bucket size), X-RateLimit-Remaining and
+// X-RateLimit-Reset (Unix time the bucket is full again); refused requests
+// get 429 with Retry-After. Health, metrics and CORS preflights are never
+// limited.
+
+// rateLimitMiddleware refuses requests once the caller's bucket is empty
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil || !(strings.HasPrefix(template, "/v1/") || strings.HasPrefix(template, "/v2/")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		limit, scope := s.verifier.ClientRateLimitFor(template)
+		if limit.Requests <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		decision := s.verifier.TakeClientRateLimit(r.Context(), scope, s.rateLimitClient(r), limit)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(decision.Reset.Unix(), 10))
+		if !decision.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+			writeErrorDetails(w, r, http.StatusTooManyRequests, ErrRateLimited, "Rate limit exceeded", map[string]interface{}{
+				"limit":    decision.Limit,
+				"reset_at": decision.Reset.UTC().Truncate(time.Second),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitClient identifies the caller: its API key (as a tenant ID), or
+// its source IP without one
+func (s *Server) rateLimitClient(r *http.Request) string {
+	if tenant := verifier.TenantFrom(r.Context()); tenant != verifier.DefaultTenant && tenant != verifier.AnonymousTenant {
+		return tenant
+	}
+	if s.config.TrustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			ip, _, _ := strings.Cut(forwarded, ",")
+			return "ip:" + strings.TrimSpace(ip)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// CONFIGURATION FROM THE ENVIRONMENT
+// ============================================================================
+
+// Two ways to configure from the environment, for secrets and for
+// per-deployment values that should not be baked into the file:
+//
+//   - ${VAR} (or ${VAR:-default}) anywhere in config.yaml is replaced with
+//     the variable before parsing. A variable that is unset and has no
+//     default is an error rather than an empty string.
+//   - VERIFIER_<SECTION>_<KEY> overrides any setting: the YAML path,
+//     upper-cased and joined with underscores (VERIFIER_SMTP_MAIL_FROM,
+//     VERIFIER_SERVER_TLS_CERT_FILE). Values are parsed as YAML, so
+//     durations read "30s" and maps or lists of records take flow syntax
+//     ({a: 1}, [x, y]); plain lists also accept "a,b,c".
+
+const configEnvPrefix = "VERIFIER_"
+
+var configEnvRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolateEnv replaces ${VAR} references in a config file
+func interpolateEnv(data []byte) ([]byte, error) {
+	var missing []string
+	out := configEnvRef.ReplaceAllFunc(data, func(ref []byte) []byte {
+		m := configEnvRef.FindSubmatch(ref)
+		if value, ok := os.LookupEnv(string(m[1])); ok {
+			return []byte(value)
+		}
+		if m[2] != nil {
+			return m[3]
+		}
+		missing = append(missing, string(m[1]))
+		return nil
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("unset environment variables referenced without a default: %s", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// applyEnvOverrides sets every field of the YAML struct at ptr that has a
+// VERIFIER_ variable, and reports the variables that could not be parsed
+func applyEnvOverrides(ptr interface{}) error {
+	var problems []string
+	walkConfigEnv(reflect.ValueOf(ptr).Elem(), strings.TrimSuffix(configEnvPrefix, "_"), &problems)
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid environment overrides:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+func walkConfigEnv(v reflect.Value, name string, problems *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+		env := name + "_" + strings.ToUpper(tag)
+		fv := v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			walkConfigEnv(fv, env, problems)
+			continue
+		}
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(fv, value); err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: %v", env, err))
+		}
+	}
+}
+
+// setFromEnv parses value into v
+func setFromEnv(v reflect.Value, value string) error {
+	target := v
+	if v.Kind() == reflect.Pointer {
+		target = reflect.New(v.Type().Elem()).Elem()
+	}
+	switch {
+	case target.Kind() == reflect.String:
+		target.SetString(value)
+	case target.Kind() == reflect.Slice && target.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(value), "["):
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		target.Set(reflect.ValueOf(items).Convert(target.Type()))
+	default:
+		if err := yaml.Unmarshal([]byte(value), target.Addr().Interface()); err != nil {
+			return fmt.Errorf("cannot parse %q as %s", value, target.Type())
+		}
+	}
+	if v.Kind() == reflect.Pointer {
+		v.Set(target.Addr())
+	}
+	return nil
+}
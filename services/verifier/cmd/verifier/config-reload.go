@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ============================================================================
+// CONFIGURATION HOT RELOAD
+// ============================================================================
+
+// The config file is re-read on SIGHUP and whenever its modification time
+// changes (checked every configPollInterval, which also catches Kubernetes
+// ConfigMap updates). A file that fails to load or validate is logged and
+// ignored; the running config stays. Otherwise the verifier applies the
+// reloadable settings (see pkg/verifier/config-reload.go) and every changed
+// value is logged, including those that need a restart.
+
+const configPollInterval = 5 * time.Second
+
+// watchConfig reloads the configuration until ctx is done
+func (s *Server) watchConfig(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	configPath := getEnv("CONFIG_PATH", "config/config.yaml")
+	modTime := configModTime(configPath)
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("reloading config", "path", configPath, "trigger", "SIGHUP")
+		case <-ticker.C:
+			mt := configModTime(configPath)
+			if mt.Equal(modTime) {
+				continue
+			}
+			modTime = mt
+			slog.Info("reloading config", "path", configPath, "trigger", "file changed")
+		}
+		s.reloadConfig()
+	}
+}
+
+func configModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func (s *Server) reloadConfig() {
+	next, err := loadConfig()
+	if err != nil {
+		slog.Warn("config not reloaded, keeping the current one", "error", err)
+		return
+	}
+	report := s.verifier.Reload(next)
+	for _, change := range report.Applied {
+		slog.Info("config changed", "field", change.Field, "old", change.Old, "new", change.New)
+	}
+	for _, change := range report.RestartRequired {
+		slog.Warn("config change needs a restart to take effect", "field", change.Field, "old", change.Old, "new", change.New)
+	}
+	if len(report.Applied) == 0 && len(report.RestartRequired) == 0 {
+		slog.Info("config unchanged")
+	}
+	for _, change := range report.Applied {
+		if change.Field == "LogLevel" || change.Field == "LogFormat" {
+			setupLogging(s.verifier.Config())
+			break
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// NO-CODE CONNECTOR ENDPOINTS
+// ============================================================================
+
+const (
+	defaultFeedPollLimit = 50
+	maxFeedPollLimit     = 500
+)
+
+// handleSimpleVerify accepts ?email= or {"email": ...} and returns one verdict
+func (s *Server) handleSimpleVerify(w http.ResponseWriter, r *http.Request) {
+	email := r.URL.Query().Get("email")
+	if email == "" && r.Method == http.MethodPost {
+		var req ValidateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request")
+			return
+		}
+		email = req.Email
+	}
+
+	if email == "" {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Email is required")
+		return
+	}
+
+	if !s.checkQuota(w, r, 1) {
+		return
+	}
+
+	result, err := s.verifier.Verify(r.Context(), email)
+	if err != nil {
+		writeVerifyError(w, r, err, http.StatusServiceUnavailable, "Validation failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(verifier.ToSimpleResult(result))
+}
+
+func (s *Server) handleSimpleResultFeed(w http.ResponseWriter, r *http.Request) {
+	limit := int64(defaultFeedPollLimit)
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 || n > maxFeedPollLimit {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+
+	resp, err := s.verifier.ResultFeed(r.Context(), r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to read result feed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
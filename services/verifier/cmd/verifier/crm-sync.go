@@ -0,0 +1,764 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// CRM SYNC (HUBSPOT / SALESFORCE)
+// ============================================================================
+
+const (
+	CRMHubSpot    = "hubspot"
+	CRMSalesforce = "salesforce"
+
+	crmConnectionsKey    = "crm:connections"
+	maxCRMContacts       = 100000
+	crmWriteBackChunk    = 100
+	crmSyncLockTTL       = 6 * time.Hour
+	defaultCRMSyncHours  = 24
+	salesforceAPIVersion = "v59.0"
+	hubspotAPIBase       = "https://api.hubapi.com"
+	hubspotTokenURL      = "https://api.hubapi.com/oauth/v1/token"
+	salesforceTokenURL   = "https://login.salesforce.com/services/oauth2/token"
+)
+
+var (
+	errCredentialStoreDisabled = errors.New("CRM_CREDENTIALS_KEY is not configured")
+	errCRMNoTenant             = errors.New("CRM connections belong to an API key")
+	errSyncInProgress          = errors.New("sync already in progress")
+)
+
+// CRMConnection is the public, non-secret part of a CRM integration. It
+// belongs to the API key that created it; other keys cannot see, sync or
+// delete it.
+type CRMConnection struct {
+	ID                string     `json:"id"`
+	Tenant            string     `json:"tenant"`
+	Provider          string     `json:"provider"`
+	InstanceURL       string     `json:"instance_url,omitempty"`
+	StatusField       string     `json:"status_field"`
+	ScoreField        string     `json:"score_field"`
+	SyncIntervalHours int        `json:"sync_interval_hours"`
+	LastSyncAt        *time.Time `json:"last_sync_at,omitempty"`
+	NextSyncAt        time.Time  `json:"next_sync_at"`
+	LastJobID         string     `json:"last_job_id,omitempty"`
+	LastError         string     `json:"last_error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// crmCredentials are stored encrypted and never returned over the API
+type crmCredentials struct {
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret"`
+	RefreshToken string    `json:"refresh_token"`
+	AccessToken  string    `json:"access_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+}
+
+type CreateCRMConnectionRequest struct {
+	Provider          string `json:"provider"`
+	InstanceURL       string `json:"instance_url,omitempty"`
+	ClientID          string `json:"client_id"`
+	ClientSecret      string `json:"client_secret"`
+	RefreshToken      string `json:"refresh_token"`
+	StatusField       string `json:"status_field,omitempty"`
+	ScoreField        string `json:"score_field,omitempty"`
+	SyncIntervalHours int    `json:"sync_interval_hours,omitempty"`
+}
+
+type crmContact struct {
+	ID    string
+	Email string
+}
+
+type crmUpdate struct {
+	ID     string
+	Status string
+	Score  int
+}
+
+// crmClient abstracts the provider-specific contact APIs
+type crmClient interface {
+	ListContacts(ctx context.Context, cursor string) ([]crmContact, string, error)
+	UpdateContacts(ctx context.Context, updates []crmUpdate) error
+}
+
+// CRMSync manages connections, credentials and sync runs
+type CRMSync struct {
+	redis    *redis.Client
+	verifier *verifier.SMTPVerifier
+	jobs     *JobStore
+	http     *http.Client
+	key      []byte // AES-256 key for credentials; nil disables storage
+}
+
+func NewCRMSync(redisClient *redis.Client, v *verifier.SMTPVerifier, jobs *JobStore, hexKey string) *CRMSync {
+	sync := &CRMSync{
+		redis:    redisClient,
+		verifier: v,
+		jobs:     jobs,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+	if key, err := hex.DecodeString(hexKey); err == nil && len(key) == 32 {
+		sync.key = key
+	} else if hexKey != "" {
+		log.Printf("Warning: CRM_CREDENTIALS_KEY must be 64 hex characters; CRM sync disabled")
+	}
+	return sync
+}
+
+// Create registers a connection for the API key in ctx
+func (c *CRMSync) Create(ctx context.Context, req CreateCRMConnectionRequest) (*CRMConnection, error) {
+	if c.key == nil {
+		return nil, errCredentialStoreDisabled
+	}
+	tenant := verifier.TenantFrom(ctx)
+	if tenant == verifier.DefaultTenant || tenant == verifier.AnonymousTenant {
+		return nil, errCRMNoTenant
+	}
+
+	conn := &CRMConnection{
+		ID:                verifier.NewID(),
+		Tenant:            tenant,
+		Provider:          req.Provider,
+		InstanceURL:       strings.TrimSuffix(req.InstanceURL, "/"),
+		StatusField:       req.StatusField,
+		ScoreField:        req.ScoreField,
+		SyncIntervalHours: req.SyncIntervalHours,
+		NextSyncAt:        time.Now(),
+		CreatedAt:         time.Now(),
+	}
+	if conn.SyncIntervalHours <= 0 {
+		conn.SyncIntervalHours = defaultCRMSyncHours
+	}
+	if conn.StatusField == "" {
+		conn.StatusField = defaultCRMField(req.Provider, "status")
+	}
+	if conn.ScoreField == "" {
+		conn.ScoreField = defaultCRMField(req.Provider, "score")
+	}
+
+	creds := &crmCredentials{
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		RefreshToken: req.RefreshToken,
+	}
+	if err := c.saveCredentials(ctx, conn.ID, creds); err != nil {
+		return nil, err
+	}
+	if err := c.save(ctx, conn); err != nil {
+		return nil, err
+	}
+	return conn, c.redis.SAdd(ctx, crmConnectionsKey, conn.ID).Err()
+}
+
+// Get returns the caller's connection, or redis.Nil
+func (c *CRMSync) Get(ctx context.Context, id string) (*CRMConnection, error) {
+	conn, err := c.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if conn.Tenant != verifier.TenantFrom(ctx) {
+		return nil, redis.Nil
+	}
+	return conn, nil
+}
+
+// load reads a connection whoever owns it, for the scheduler
+func (c *CRMSync) load(ctx context.Context, id string) (*CRMConnection, error) {
+	val, err := c.redis.Get(ctx, "crm:connection:"+id).Result()
+	if err != nil {
+		return nil, err
+	}
+	var conn CRMConnection
+	if err := json.Unmarshal([]byte(val), &conn); err != nil {
+		return nil, err
+	}
+	return &conn, nil
+}
+
+// Delete removes the caller's connection and its credentials, or returns
+// redis.Nil
+func (c *CRMSync) Delete(ctx context.Context, id string) error {
+	if _, err := c.Get(ctx, id); err != nil {
+		return err
+	}
+	pipe := c.redis.TxPipeline()
+	pipe.Del(ctx, "crm:connection:"+id, "crm:credentials:"+id)
+	pipe.SRem(ctx, crmConnectionsKey, id)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *CRMSync) save(ctx context.Context, conn *CRMConnection) error {
+	data, err := json.Marshal(conn)
+	if err != nil {
+		return err
+	}
+	return c.redis.Set(ctx, "crm:connection:"+conn.ID, data, 0).Err()
+}
+
+// Start creates a sync job for the connection and runs it in the background.
+// A Redis lock ensures only one replica syncs a given connection at a time.
+func (c *CRMSync) Start(ctx context.Context, conn *CRMConnection) (*Job, error) {
+	locked, err := c.redis.SetNX(ctx, "lock:crm:"+conn.ID, "sync", crmSyncLockTTL).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, errSyncInProgress
+	}
+
+	// The job belongs to the connection's tenant, whoever triggered the run
+	ctx = verifier.WithTenant(ctx, conn.Tenant)
+	job, err := c.jobs.Create(ctx, "crm_sync", verifier.ClientMetadata{Metadata: map[string]string{"crm_connection_id": conn.ID}}, "pull", "verify", "write_back")
+	if err != nil {
+		c.redis.Del(ctx, "lock:crm:"+conn.ID)
+		return nil, err
+	}
+	go c.run(conn, job)
+	return job, nil
+}
+
+func (c *CRMSync) run(conn *CRMConnection, job *Job) {
+	ctx := verifier.WithTenant(context.Background(), conn.Tenant)
+	now := time.Now()
+	job.Status = JobProcessing
+	job.StartedAt = &now
+	c.jobs.Save(ctx, job)
+
+	err := c.sync(ctx, conn, job)
+
+	finished := time.Now()
+	job.CompletedAt = &finished
+	conn.LastSyncAt = &finished
+	conn.NextSyncAt = finished.Add(time.Duration(conn.SyncIntervalHours) * time.Hour)
+	conn.LastJobID = job.ID
+	conn.LastError = ""
+	job.Status = JobCompleted
+	if err != nil {
+		log.Printf("CRM sync %s (%s) failed: %v", conn.ID, conn.Provider, err)
+		job.Status = JobFailed
+		job.Error = err.Error()
+		conn.LastError = err.Error()
+	}
+	c.jobs.Save(ctx, job)
+	c.save(ctx, conn)
+	c.redis.Del(ctx, "lock:crm:"+conn.ID)
+}
+
+func (c *CRMSync) sync(ctx context.Context, conn *CRMConnection, job *Job) error {
+	client, err := c.client(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	// Pull
+	job.startStage("pull", 0)
+	var contacts []crmContact
+	cursor := ""
+	for {
+		page, next, err := client.ListContacts(ctx, cursor)
+		if err != nil {
+			return fmt.Errorf("pull contacts: %w", err)
+		}
+		for _, contact := range page {
+			if contact.Email != "" {
+				contacts = append(contacts, contact)
+			}
+		}
+		if next == "" || len(contacts) >= maxCRMContacts {
+			break
+		}
+		cursor = next
+	}
+	job.stage("pull").Total = len(contacts)
+	job.TotalEmails = len(contacts)
+	job.completeStage("pull")
+	c.jobs.Save(ctx, job)
+
+	// Verify
+	verifyStage := job.startStage("verify", len(contacts))
+	updates := make([]crmUpdate, 0, len(contacts))
+	job.StatusCounts = make(map[string]int)
+	for start := 0; start < len(contacts); start += crmWriteBackChunk {
+		end := start + crmWriteBackChunk
+		if end > len(contacts) {
+			end = len(contacts)
+		}
+		emails := make([]string, 0, end-start)
+		for _, contact := range contacts[start:end] {
+			emails = append(emails, contact.Email)
+		}
+		for i, result := range c.verifier.VerifyBatch(ctx, emails) {
+			updates = append(updates, crmUpdate{
+				ID:     contacts[start+i].ID,
+				Status: string(result.Status),
+				Score:  verifier.ScoreResult(result),
+			})
+			job.StatusCounts[string(result.Status)]++
+		}
+		verifyStage.Processed = end
+		job.EmailsProcessed = end
+		c.jobs.Save(ctx, job)
+	}
+	job.completeStage("verify")
+
+	// Write back
+	writeStage := job.startStage("write_back", len(updates))
+	for start := 0; start < len(updates); start += crmWriteBackChunk {
+		end := start + crmWriteBackChunk
+		if end > len(updates) {
+			end = len(updates)
+		}
+		if err := client.UpdateContacts(ctx, updates[start:end]); err != nil {
+			return fmt.Errorf("write back: %w", err)
+		}
+		writeStage.Processed = end
+		c.jobs.Save(ctx, job)
+	}
+	job.completeStage("write_back")
+
+	return nil
+}
+
+// StartDueSyncs starts every connection whose next sync is due; the
+// scheduler runs it as crm_sync
+func (c *CRMSync) StartDueSyncs(ctx context.Context) error {
+	if c.key == nil {
+		return nil
+	}
+
+	ids, err := c.redis.SMembers(ctx, crmConnectionsKey).Result()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		conn, err := c.load(ctx, id)
+		if err != nil || time.Now().Before(conn.NextSyncAt) {
+			continue
+		}
+		if _, err := c.Start(ctx, conn); err != nil && err != errSyncInProgress {
+			slog.WarnContext(ctx, "CRM sync could not start", "connection_id", id, "error", err)
+		}
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Credentials and OAuth
+// ----------------------------------------------------------------------------
+
+func (c *CRMSync) saveCredentials(ctx context.Context, id string, creds *crmCredentials) error {
+	if c.key == nil {
+		return errCredentialStoreDisabled
+	}
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, plaintext, []byte(id))
+	return c.redis.Set(ctx, "crm:credentials:"+id, sealed, 0).Err()
+}
+
+func (c *CRMSync) loadCredentials(ctx context.Context, id string) (*crmCredentials, error) {
+	if c.key == nil {
+		return nil, errCredentialStoreDisabled
+	}
+	sealed, err := c.redis.Get(ctx, "crm:credentials:"+id).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("corrupt credentials")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt credentials: %w", err)
+	}
+
+	var creds crmCredentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// accessToken returns a valid access token, refreshing it when expired
+func (c *CRMSync) accessToken(ctx context.Context, conn *CRMConnection) (string, error) {
+	creds, err := c.loadCredentials(ctx, conn.ID)
+	if err != nil {
+		return "", err
+	}
+	if creds.AccessToken != "" && time.Now().Add(time.Minute).Before(creds.ExpiresAt) {
+		return creds.AccessToken, nil
+	}
+
+	tokenURL := hubspotTokenURL
+	if conn.Provider == CRMSalesforce {
+		tokenURL = salesforceTokenURL
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {creds.ClientID},
+		"client_secret": {creds.ClientSecret},
+		"refresh_token": {creds.RefreshToken},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		InstanceURL  string `json:"instance_url"`
+	}
+	if err := c.doJSON(req, &token); err != nil {
+		return "", fmt.Errorf("refresh token: %w", err)
+	}
+
+	creds.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		creds.RefreshToken = token.RefreshToken
+	}
+	// Salesforce omits expires_in; sessions default to two hours
+	expiresIn := time.Duration(token.ExpiresIn) * time.Second
+	if expiresIn == 0 {
+		expiresIn = 2 * time.Hour
+	}
+	creds.ExpiresAt = time.Now().Add(expiresIn)
+	if token.InstanceURL != "" {
+		conn.InstanceURL = token.InstanceURL
+	}
+
+	return creds.AccessToken, c.saveCredentials(ctx, conn.ID, creds)
+}
+
+func (c *CRMSync) client(ctx context.Context, conn *CRMConnection) (crmClient, error) {
+	token, err := c.accessToken(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch conn.Provider {
+	case CRMHubSpot:
+		return &hubspotClient{sync: c, token: token, conn: conn}, nil
+	case CRMSalesforce:
+		if conn.InstanceURL == "" {
+			return nil, errors.New("salesforce connection has no instance_url")
+		}
+		return &salesforceClient{sync: c, token: token, conn: conn}, nil
+	default:
+		return nil, fmt.Errorf("unsupported CRM provider %q", conn.Provider)
+	}
+}
+
+func (c *CRMSync) doJSON(req *http.Request, out interface{}) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: HTTP %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func defaultCRMField(provider, kind string) string {
+	switch {
+	case provider == CRMSalesforce && kind == "status":
+		return "Email_Verification_Status__c"
+	case provider == CRMSalesforce:
+		return "Email_Risk_Score__c"
+	case kind == "status":
+		return "email_verification_status"
+	default:
+		return "email_risk_score"
+	}
+}
+
+// ----------------------------------------------------------------------------
+// HubSpot CRM v3
+// ----------------------------------------------------------------------------
+
+type hubspotClient struct {
+	sync  *CRMSync
+	token string
+	conn  *CRMConnection
+}
+
+func (h *hubspotClient) ListContacts(ctx context.Context, cursor string) ([]crmContact, string, error) {
+	query := url.Values{"limit": {"100"}, "properties": {"email"}}
+	if cursor != "" {
+		query.Set("after", cursor)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hubspotAPIBase+"/crm/v3/objects/contacts?"+query.Encode(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+h.token)
+
+	var page struct {
+		Results []struct {
+			ID         string            `json:"id"`
+			Properties map[string]string `json:"properties"`
+		} `json:"results"`
+		Paging struct {
+			Next struct {
+				After string `json:"after"`
+			} `json:"next"`
+		} `json:"paging"`
+	}
+	if err := h.sync.doJSON(req, &page); err != nil {
+		return nil, "", err
+	}
+
+	contacts := make([]crmContact, 0, len(page.Results))
+	for _, r := range page.Results {
+		contacts = append(contacts, crmContact{ID: r.ID, Email: r.Properties["email"]})
+	}
+	return contacts, page.Paging.Next.After, nil
+}
+
+func (h *hubspotClient) UpdateContacts(ctx context.Context, updates []crmUpdate) error {
+	type input struct {
+		ID         string            `json:"id"`
+		Properties map[string]string `json:"properties"`
+	}
+	body := struct {
+		Inputs []input `json:"inputs"`
+	}{}
+	for _, u := range updates {
+		body.Inputs = append(body.Inputs, input{
+			ID: u.ID,
+			Properties: map[string]string{
+				h.conn.StatusField: u.Status,
+				h.conn.ScoreField:  fmt.Sprint(u.Score),
+			},
+		})
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hubspotAPIBase+"/crm/v3/objects/contacts/batch/update", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+h.token)
+	req.Header.Set("Content-Type", "application/json")
+	return h.sync.doJSON(req, nil)
+}
+
+// ----------------------------------------------------------------------------
+// Salesforce REST
+// ----------------------------------------------------------------------------
+
+type salesforceClient struct {
+	sync  *CRMSync
+	token string
+	conn  *CRMConnection
+}
+
+// ListContacts runs a SOQL query; the cursor is Salesforce's nextRecordsUrl
+func (s *salesforceClient) ListContacts(ctx context.Context, cursor string) ([]crmContact, string, error) {
+	endpoint := s.conn.InstanceURL + cursor
+	if cursor == "" {
+		soql := "SELECT Id, Email FROM Contact WHERE Email != null"
+		endpoint = fmt.Sprintf("%s/services/data/%s/query?q=%s", s.conn.InstanceURL, salesforceAPIVersion, url.QueryEscape(soql))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	var page struct {
+		Records []struct {
+			ID    string `json:"Id"`
+			Email string `json:"Email"`
+		} `json:"records"`
+		NextRecordsURL string `json:"nextRecordsUrl"`
+	}
+	if err := s.sync.doJSON(req, &page); err != nil {
+		return nil, "", err
+	}
+
+	contacts := make([]crmContact, 0, len(page.Records))
+	for _, r := range page.Records {
+		contacts = append(contacts, crmContact{ID: r.ID, Email: r.Email})
+	}
+	return contacts, page.NextRecordsURL, nil
+}
+
+func (s *salesforceClient) UpdateContacts(ctx context.Context, updates []crmUpdate) error {
+	records := make([]map[string]interface{}, 0, len(updates))
+	for _, u := range updates {
+		records = append(records, map[string]interface{}{
+			"attributes":       map[string]string{"type": "Contact"},
+			"id":               u.ID,
+			s.conn.StatusField: u.Status,
+			s.conn.ScoreField:  u.Score,
+		})
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"allOrNone": false, "records": records})
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/services/data/%s/composite/sobjects", s.conn.InstanceURL, salesforceAPIVersion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Content-Type", "application/json")
+	return s.sync.doJSON(req, nil)
+}
+
+// ----------------------------------------------------------------------------
+// HTTP handlers
+// ----------------------------------------------------------------------------
+
+func (s *Server) handleCreateCRMConnection(w http.ResponseWriter, r *http.Request) {
+	var req CreateCRMConnectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request")
+		return
+	}
+
+	if req.Provider != CRMHubSpot && req.Provider != CRMSalesforce {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "provider must be hubspot or salesforce")
+		return
+	}
+	if req.ClientID == "" || req.ClientSecret == "" || req.RefreshToken == "" {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "client_id, client_secret and refresh_token are required")
+		return
+	}
+
+	conn, err := s.crm.Create(r.Context(), req)
+	if errors.Is(err, errCRMNoTenant) {
+		writeError(w, r, http.StatusUnauthorized, ErrUnauthorized, "An API key is required")
+		return
+	}
+	if errors.Is(err, errCredentialStoreDisabled) {
+		writeError(w, r, http.StatusServiceUnavailable, ErrNotConfigured, "CRM credential storage is not configured")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to create connection")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(conn)
+}
+
+func (s *Server) handleGetCRMConnection(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.crm.Get(r.Context(), mux.Vars(r)["id"])
+	if err == redis.Nil {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "Connection not found")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load connection")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conn)
+}
+
+func (s *Server) handleDeleteCRMConnection(w http.ResponseWriter, r *http.Request) {
+	err := s.crm.Delete(r.Context(), mux.Vars(r)["id"])
+	if err == redis.Nil {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "Connection not found")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to delete connection")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleTriggerCRMSync(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.crm.Get(r.Context(), mux.Vars(r)["id"])
+	if err == redis.Nil {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "Connection not found")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load connection")
+		return
+	}
+
+	job, err := s.crm.Start(r.Context(), conn)
+	if err == errSyncInProgress {
+		writeError(w, r, http.StatusConflict, ErrConflict, "Sync already in progress")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to start sync")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
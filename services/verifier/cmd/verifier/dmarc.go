@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// DMARC AGGREGATE (RUA) REPORTS
+// ============================================================================
+
+const maxDMARCSummaryDays = 90
+
+func (s *Server) handleDMARCIngest(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, verifier.MaxDMARCReportSize))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Report too large or unreadable")
+		return
+	}
+
+	feedback, err := verifier.ParseDMARCReport(data)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+		return
+	}
+
+	resp, err := s.verifier.IngestDMARCReport(r.Context(), feedback)
+	status := http.StatusCreated
+	if errors.Is(err, verifier.ErrDuplicateDMARCReport) {
+		status = http.StatusOK
+	} else if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to store report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleDMARCSummary(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxDMARCSummaryDays {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "days must be between 1 and 90")
+			return
+		}
+		days = n
+	}
+
+	summary, err := s.verifier.DMARCSummary(r.Context(), mux.Vars(r)["domain"], days)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load DMARC summary")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// DOMAIN REPORTS
+// ============================================================================
+
+func (s *Server) handleDomainReport(w http.ResponseWriter, r *http.Request) {
+	report, err := s.verifier.DomainReport(r.Context(), mux.Vars(r)["domain"])
+	if errors.Is(err, verifier.ErrInvalidDomain) {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid domain")
+		return
+	}
+	if err != nil {
+		writeVerifyError(w, r, err, http.StatusInternalServerError, "Failed to build domain report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ============================================================================
+// GRACEFUL DRAIN
+// ============================================================================
+
+// On SIGTERM the process stops taking new work but finishes what it has,
+// for up to server.shutdown_timeout:
+//
+//  1. /health answers 503 "draining", so load balancers route elsewhere;
+//     the replacement process is already listening (see listen.go).
+//  2. Listeners close; HTTP requests in progress run to completion.
+//  3. Queue, Kafka and NATS/AMQP consumers stop reading and finish the
+//     messages they hold; clean-list jobs and other in-flight
+//     verifications finish too.
+//  4. When the grace period runs out, the rest is cancelled. Unfinished
+//     queue messages go back on their stream for another replica, Kafka
+//     messages are redelivered (their offsets were never committed), and
+//     interrupted clean-list jobs fail with an error saying so.
+
+const drainPollInterval = 100 * time.Millisecond
+
+// goJob runs a background job that the drain waits for
+func (s *Server) goJob(job func()) {
+	s.jobsRunning.Add(1)
+	go func() {
+		defer s.jobsRunning.Done()
+		job()
+	}()
+}
+
+// drain runs steps 1-3 above and returns when they are done or ctx ends
+func (s *Server) drain(ctx context.Context, httpServer *http.Server, closeListeners func()) {
+	s.draining.Store(true)
+	closeListeners()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		slog.Warn("HTTP requests still running at the end of the grace period", "error", err)
+	}
+	s.queue.Drain(ctx)
+	if s.kafka != nil {
+		s.kafka.Drain(ctx)
+	}
+	if s.mq != nil {
+		s.mq.Drain(ctx)
+	}
+
+	jobsDone := make(chan struct{})
+	go func() {
+		s.jobsRunning.Wait()
+		close(jobsDone)
+	}()
+	select {
+	case <-jobsDone:
+	case <-ctx.Done():
+		slog.Warn("clean-list jobs still running at the end of the grace period")
+		return
+	}
+
+	// Anything else verifying, e.g. a CRM sync
+	for s.verifier.LoadStats().InFlightVerifications > 0 {
+		select {
+		case <-time.After(drainPollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
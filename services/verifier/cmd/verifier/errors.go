@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// ERROR RESPONSES
+// ============================================================================
+
+// Every error response is a JSON envelope with a stable, machine-readable
+// code; message is for people and may change between releases. request_id
+// matches the X-Request-ID header, so a support ticket can quote either.
+//
+//	{"code": "batch_too_large", "message": "Maximum 1000 emails per batch",
+//	 "details": {"max": 1000}, "request_id": "..."}
+
+// Error codes; documented in api/api-spec.yaml (ErrorCode)
+const (
+	ErrInvalidRequest   = "invalid_request" // Malformed body or parameters
+	ErrBatchTooLarge    = "batch_too_large" // More items than the endpoint accepts
+	ErrUnauthorized     = "unauthorized"    // The endpoint needs an API key
+	ErrForbidden        = "forbidden"       // The endpoint needs an admin key
+	ErrNotFound         = "not_found"       // Route or resource does not exist
+	ErrMethodNotAllowed = "method_not_allowed"
+	ErrConflict         = "conflict"         // The resource is busy
+	ErrRateLimited      = "rate_limited"     // Rate limit bucket empty; see Retry-After
+	ErrQuotaExceeded    = "quota_exceeded"   // Daily or monthly quota used up; see Retry-After
+	ErrOverloaded       = "overloaded"       // Shedding load; see Retry-After
+	ErrUpstreamTimeout  = "upstream_timeout" // DNS or SMTP did not answer in time
+	ErrNotConfigured    = "not_configured"   // The feature is disabled on this deployment
+	ErrInternal         = "internal_error"
+)
+
+// APIError is the body of every error response
+type APIError struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+// writeError answers with status and an APIError
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeErrorDetails(w, r, status, code, message, nil)
+}
+
+// writeErrorDetails is writeError with structured details, such as the
+// limit a request went over
+func writeErrorDetails(w http.ResponseWriter, r *http.Request, status int, code, message string, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(APIError{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: verifier.RequestIDFrom(r.Context()),
+	})
+}
+
+// writeVerifyError answers a verification that failed with err: shed load
+// and timeouts get their own codes, anything else status
+func writeVerifyError(w http.ResponseWriter, r *http.Request, err error, status int, message string) {
+	switch {
+	case errors.Is(err, verifier.ErrOverloaded):
+		w.Header().Set("Retry-After", "5")
+		writeError(w, r, http.StatusServiceUnavailable, ErrOverloaded, "Service overloaded, retry later")
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, r, http.StatusGatewayTimeout, ErrUpstreamTimeout, "Verification timed out")
+	default:
+		writeError(w, r, status, ErrInternal, message)
+	}
+}
+
+// notFoundHandler and methodNotAllowedHandler replace the router's
+// plain-text answers for unmatched requests
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, ErrNotFound, "No such endpoint")
+}
+
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed, r.Method+" is not allowed on this endpoint")
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// RESULT EXPLANATIONS
+// ============================================================================
+
+func (s *Server) handleExplainResult(w http.ResponseWriter, r *http.Request) {
+	explanation, err := s.verifier.Explain(r.Context(), mux.Vars(r)["hash"])
+	if err == redis.Nil {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "Result not found")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load result")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(explanation)
+}
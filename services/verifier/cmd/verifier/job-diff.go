@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// LIST COMPARISON
+// ============================================================================
+
+// A completed job is compared with a later job (GET ?to=) or with a freshly
+// uploaded list (POST, same body as clean-list) for month-over-month hygiene
+// audits. An uploaded list has no verdicts yet, so only additions and
+// removals are reported for it.
+
+var errJobNotReady = errors.New("job not found or not completed")
+
+// DiffEntry is one address in a list comparison. From fields describe the
+// earlier job, To fields the later one.
+type DiffEntry struct {
+	Email      string                    `json:"email"`
+	FromStatus verifier.ValidationStatus `json:"from_status,omitempty"`
+	FromReason string                    `json:"from_reason,omitempty"`
+	ToStatus   verifier.ValidationStatus `json:"to_status,omitempty"`
+	ToReason   string                    `json:"to_reason,omitempty"`
+}
+
+type DiffSummary struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
+
+type JobDiff struct {
+	FromJobID string      `json:"from_job_id"`
+	ToJobID   string      `json:"to_job_id,omitempty"` // Empty for an uploaded list
+	Summary   DiffSummary `json:"summary"`
+	Added     []DiffEntry `json:"added"`
+	Removed   []DiffEntry `json:"removed"`
+	Changed   []DiffEntry `json:"changed"`
+}
+
+// diffRow is the part of a stored result row a comparison needs
+type diffRow struct {
+	Email  string                    `json:"email"`
+	Status verifier.ValidationStatus `json:"status"`
+	Reason string                    `json:"reason"`
+}
+
+// jobRows loads a completed job's results keyed by normalized address
+func (s *Server) jobRows(r *http.Request, id string) (map[string]diffRow, error) {
+	job, err := s.jobs.Get(r.Context(), id)
+	if err == redis.Nil || (err == nil && job.Status != JobCompleted) {
+		return nil, errJobNotReady
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, _, err := s.jobs.Results(r.Context(), id, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	rows := make(map[string]diffRow, len(raw))
+	for _, data := range raw {
+		var row diffRow
+		if json.Unmarshal(data, &row) != nil || row.Email == "" {
+			continue
+		}
+		rows[strings.ToLower(strings.TrimSpace(row.Email))] = row
+	}
+	return rows, nil
+}
+
+// diffRows compares from with to; rows in to without a status (an uploaded
+// list) count as unchanged when present in both
+func diffRows(from, to map[string]diffRow) *JobDiff {
+	diff := &JobDiff{Added: []DiffEntry{}, Removed: []DiffEntry{}, Changed: []DiffEntry{}}
+	for email, old := range from {
+		cur, ok := to[email]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, DiffEntry{Email: email, FromStatus: old.Status, FromReason: old.Reason})
+		case cur.Status != "" && cur.Status != old.Status:
+			diff.Changed = append(diff.Changed, DiffEntry{Email: email, FromStatus: old.Status, FromReason: old.Reason, ToStatus: cur.Status, ToReason: cur.Reason})
+		default:
+			diff.Summary.Unchanged++
+		}
+	}
+	for email, cur := range to {
+		if _, ok := from[email]; !ok {
+			diff.Added = append(diff.Added, DiffEntry{Email: email, ToStatus: cur.Status, ToReason: cur.Reason})
+		}
+	}
+
+	for _, entries := range [][]DiffEntry{diff.Added, diff.Removed, diff.Changed} {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Email < entries[j].Email })
+	}
+	diff.Summary.Added = len(diff.Added)
+	diff.Summary.Removed = len(diff.Removed)
+	diff.Summary.Changed = len(diff.Changed)
+	return diff
+}
+
+// handleJobDiff compares a job with a later job (GET ?to=) or an uploaded
+// list (POST)
+func (s *Server) handleJobDiff(w http.ResponseWriter, r *http.Request) {
+	fromID := mux.Vars(r)["job_id"]
+
+	var to map[string]diffRow
+	toID := ""
+	if r.Method == http.MethodPost {
+		req, err := readEmailList(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+			return
+		}
+		if len(req.Emails) == 0 {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Emails are required")
+			return
+		}
+		if len(req.Emails) > maxWorkflowEmails {
+			writeErrorDetails(w, r, http.StatusBadRequest, ErrBatchTooLarge, "Maximum 100000 emails per comparison", map[string]interface{}{"max": 100000})
+			return
+		}
+		to = make(map[string]diffRow, len(req.Emails))
+		for _, email := range req.Emails {
+			if key := strings.ToLower(strings.TrimSpace(email)); key != "" {
+				to[key] = diffRow{Email: key}
+			}
+		}
+	} else {
+		toID = r.URL.Query().Get("to")
+		if toID == "" {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "to is required")
+			return
+		}
+		var err error
+		if to, err = s.jobRows(r, toID); err != nil {
+			writeJobDiffError(w, r, toID, err)
+			return
+		}
+	}
+
+	from, err := s.jobRows(r, fromID)
+	if err != nil {
+		writeJobDiffError(w, r, fromID, err)
+		return
+	}
+
+	diff := diffRows(from, to)
+	diff.FromJobID = fromID
+	diff.ToJobID = toID
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+func writeJobDiffError(w http.ResponseWriter, r *http.Request, id string, err error) {
+	if errors.Is(err, errJobNotReady) {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, fmt.Sprintf("Job %s not found or not completed", id))
+		return
+	}
+	writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load results")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// DUPLICATE LIST DETECTION
+// ============================================================================
+
+// Customers often re-upload the same file. A list is fingerprinted as the
+// hash of its sorted, de-duplicated, normalized addresses, so row order,
+// case and repeats do not matter. Fingerprints are kept per tenant: the same
+// file uploaded under another API key is a new job.
+
+func validDuplicateAction(action string) bool {
+	return action == verifier.DuplicateWarn || action == verifier.DuplicateReuse
+}
+
+// listFingerprint hashes the normalized set of addresses
+func listFingerprint(emails []string) string {
+	set := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		if key := strings.ToLower(strings.TrimSpace(email)); key != "" {
+			set[key] = true
+		}
+	}
+	sorted := make([]string, 0, len(set))
+	for email := range set {
+		sorted = append(sorted, email)
+	}
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, email := range sorted {
+		h.Write([]byte(email))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func jobFingerprintKey(tenant, jobType, fingerprint string) string {
+	return "job:fingerprint:" + tenant + ":" + jobType + ":" + fingerprint
+}
+
+// FindDuplicate returns the caller's most recent job of jobType for the same
+// list within the window, or nil. Failed jobs are never considered duplicates.
+func (js *JobStore) FindDuplicate(ctx context.Context, jobType, fingerprint string) (*Job, error) {
+	id, err := js.db(ctx).Get(ctx, jobFingerprintKey(verifier.TenantFrom(ctx), jobType, fingerprint)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := js.Get(ctx, id)
+	if err == redis.Nil || (err == nil && job.Status == JobFailed) {
+		return nil, nil
+	}
+	return job, err
+}
+
+// RememberFingerprint points the fingerprint at job for window
+func (js *JobStore) RememberFingerprint(ctx context.Context, job *Job, window time.Duration) error {
+	return js.db(ctx).Set(ctx, jobFingerprintKey(job.Tenant, job.Type, job.Fingerprint), job.ID, window).Err()
+}
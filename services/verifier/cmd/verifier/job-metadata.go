@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// JOB HISTORY
+// ============================================================================
+
+// Jobs are indexed per tenant by creation time so history can be listed and
+// filtered by the client metadata attached to them.
+
+const (
+	jobHistoryKeyPrefix = "jobs:history:" // jobs:history:{tenant}
+	jobHistoryScanMax   = 5000
+)
+
+func jobHistoryKey(tenant string) string {
+	return jobHistoryKeyPrefix + tenant
+}
+
+// metadataFromQuery reads repeated tag=... and meta.<key>=... parameters, used
+// for CSV uploads and history filters
+func metadataFromQuery(query url.Values) verifier.ClientMetadata {
+	var m verifier.ClientMetadata
+	m.Tags = query["tag"]
+	for name, values := range query {
+		if key := strings.TrimPrefix(name, "meta."); key != name && len(values) > 0 {
+			if m.Metadata == nil {
+				m.Metadata = make(map[string]string)
+			}
+			m.Metadata[key] = values[0]
+		}
+	}
+	return m
+}
+
+// recordHistory indexes a new job by creation time
+func (js *JobStore) recordHistory(ctx context.Context, job *Job) error {
+	key := jobHistoryKey(job.Tenant)
+	pipe := js.db(ctx).TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(job.CreatedAt.Unix()), Member: job.ID})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(time.Now().Add(-js.ttl).Unix(), 10))
+	pipe.Expire(ctx, key, js.ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// History returns the caller's newest jobs matching jobType (if set) and
+// filter
+func (js *JobStore) History(ctx context.Context, jobType string, filter verifier.ClientMetadata, limit int) ([]*Job, error) {
+	const page = 200
+	key := jobHistoryKey(verifier.TenantFrom(ctx))
+	var jobs []*Job
+	for start := int64(0); start < jobHistoryScanMax && len(jobs) < limit; start += page {
+		ids, err := js.db(ctx).ZRevRange(ctx, key, start, start+page-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			job, err := js.Get(ctx, id)
+			if err == redis.Nil {
+				js.db(ctx).ZRem(ctx, key, id)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if (jobType == "" || job.Type == jobType) && job.ClientMetadata.Matches(filter) {
+				jobs = append(jobs, job)
+				if len(jobs) == limit {
+					break
+				}
+			}
+		}
+		if len(ids) < page {
+			break
+		}
+	}
+	return jobs, nil
+}
+
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 50
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 200 {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "limit must be between 1 and 200")
+			return
+		}
+		limit = n
+	}
+
+	jobs, err := s.jobs.History(r.Context(), query.Get("type"), metadataFromQuery(query), limit)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load jobs")
+		return
+	}
+	if jobs == nil {
+		jobs = []*Job{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"jobs": jobs})
+}
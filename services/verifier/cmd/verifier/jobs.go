@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// BACKGROUND JOBS
+// ============================================================================
+
+type JobState string
+
+const (
+	JobPending    JobState = "pending"
+	JobProcessing JobState = "processing"
+	JobCompleted  JobState = "completed"
+	JobFailed     JobState = "failed"
+)
+
+type JobStage struct {
+	Name        string     `json:"name"`
+	Status      JobState   `json:"status"`
+	Processed   int        `json:"processed"`
+	Total       int        `json:"total"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Job tracks a long-running request. Field names follow the JobStatus schema
+// in api/api-spec.yaml.
+type Job struct {
+	ID              string         `json:"job_id"`
+	Type            string         `json:"type"`
+	Tenant          string         `json:"tenant"` // API key that created the job; only it can read the job
+	Status          JobState       `json:"status"`
+	TotalEmails     int            `json:"total_emails"`
+	EmailsProcessed int            `json:"emails_processed"`
+	StatusCounts    map[string]int `json:"status_counts,omitempty"`
+	SegmentCounts   map[string]int `json:"segment_counts,omitempty"`
+	ProgressPercent float64        `json:"progress_percent"`
+	Stages          []*JobStage    `json:"stages,omitempty"`
+	Error           string         `json:"error,omitempty"`
+	Fingerprint     string         `json:"fingerprint,omitempty"`  // Hash of the normalized address set
+	DuplicateOf     string         `json:"duplicate_of,omitempty"` // Earlier job for the same list
+	InputURL        string         `json:"input_url,omitempty"`    // Bucket object the list was read from
+	OutputURL       string         `json:"output_url,omitempty"`   // Bucket prefix the results went to
+	OutputFiles     []string       `json:"output_files,omitempty"`
+	verifier.ClientMetadata
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// stage returns the named stage, or nil if the job has no such stage
+func (j *Job) stage(name string) *JobStage {
+	for _, st := range j.Stages {
+		if st.Name == name {
+			return st
+		}
+	}
+	return nil
+}
+
+func (j *Job) startStage(name string, total int) *JobStage {
+	now := time.Now()
+	st := j.stage(name)
+	st.Status = JobProcessing
+	st.Total = total
+	st.StartedAt = &now
+	return st
+}
+
+func (j *Job) completeStage(name string) {
+	now := time.Now()
+	st := j.stage(name)
+	st.Status = JobCompleted
+	st.Processed = st.Total
+	st.CompletedAt = &now
+
+	done := 0
+	for _, s := range j.Stages {
+		if s.Status == JobCompleted {
+			done++
+		}
+	}
+	j.ProgressPercent = 100 * float64(done) / float64(len(j.Stages))
+}
+
+// JobStore persists job state and results in Redis, in the tenant's
+// residency region when it has one. Jobs belong to the tenant that created
+// them: to anyone else Get and Results report redis.Nil, like a missing job.
+type JobStore struct {
+	redis    *redis.Client
+	regional func(context.Context) *redis.Client
+	ttl      time.Duration
+}
+
+func NewJobStore(redisClient *redis.Client, ttl time.Duration) *JobStore {
+	return &JobStore{redis: redisClient, ttl: ttl}
+}
+
+// WithRegions routes each tenant's jobs to the store picked by regional
+func (js *JobStore) WithRegions(regional func(context.Context) *redis.Client) *JobStore {
+	js.regional = regional
+	return js
+}
+
+func (js *JobStore) db(ctx context.Context) *redis.Client {
+	if js.regional != nil {
+		return js.regional(ctx)
+	}
+	return js.redis
+}
+
+func (js *JobStore) Create(ctx context.Context, jobType string, meta verifier.ClientMetadata, stages ...string) (*Job, error) {
+	job := &Job{
+		ID:             verifier.NewID(),
+		Type:           jobType,
+		Tenant:         verifier.TenantFrom(ctx),
+		Status:         JobPending,
+		ClientMetadata: meta,
+		CreatedAt:      time.Now(),
+	}
+	for _, name := range stages {
+		job.Stages = append(job.Stages, &JobStage{Name: name, Status: JobPending})
+	}
+	if err := js.Save(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, js.recordHistory(ctx, job)
+}
+
+func (js *JobStore) Save(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return js.db(ctx).Set(ctx, "job:"+job.ID, data, js.ttl).Err()
+}
+
+// Get returns the caller's job, or redis.Nil
+func (js *JobStore) Get(ctx context.Context, id string) (*Job, error) {
+	val, err := js.db(ctx).Get(ctx, "job:"+id).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(val), &job); err != nil {
+		return nil, err
+	}
+	if job.Tenant != verifier.TenantFrom(ctx) {
+		return nil, redis.Nil
+	}
+	return &job, nil
+}
+
+// AppendResults stores finished rows; rows are any JSON-encodable values
+func (js *JobStore) AppendResults(ctx context.Context, id string, rows []interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	encoded := make([]interface{}, len(rows))
+	for i, row := range rows {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		encoded[i] = data
+	}
+
+	key := "job:" + id + ":results"
+	pipe := js.db(ctx).TxPipeline()
+	pipe.RPush(ctx, key, encoded...)
+	pipe.Expire(ctx, key, js.ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Results returns raw JSON rows of the caller's job in insertion order, or
+// redis.Nil. A zero limit returns every row from offset onwards.
+func (js *JobStore) Results(ctx context.Context, id string, offset, limit int64) ([]json.RawMessage, int64, error) {
+	if _, err := js.Get(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	key := "job:" + id + ":results"
+	total, err := js.db(ctx).LLen(ctx, key).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	vals, err := js.db(ctx).LRange(ctx, key, offset, offset+limit-1).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows := make([]json.RawMessage, len(vals))
+	for i, val := range vals {
+		rows[i] = json.RawMessage(val)
+	}
+	return rows, total, nil
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := s.jobs.Get(r.Context(), mux.Vars(r)["job_id"])
+	if err == redis.Nil {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "Job not found")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func (s *Server) handleGetJobResults(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["job_id"]
+	query := r.URL.Query()
+
+	offset, limit := int64(0), int64(1000)
+	if raw := query.Get("offset"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid offset")
+			return
+		}
+		offset = n
+	}
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 || n > 10000 {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "limit must be between 1 and 10000")
+			return
+		}
+		limit = n
+	}
+
+	job, err := s.jobs.Get(r.Context(), id)
+	if err == redis.Nil || (err == nil && job.Status != JobCompleted) {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "Job not found or not completed")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load job")
+		return
+	}
+
+	var rows []json.RawMessage
+	var total int64
+	if segment := query.Get("segment"); segment != "" {
+		// Segments are filtered server-side, so paginate after filtering
+		all, _, err := s.jobs.Results(r.Context(), id, 0, 0)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load results")
+			return
+		}
+		rows = filterRowsBySegment(all, segment)
+		total = int64(len(rows))
+		rows = rows[min64(offset, total):min64(offset+limit, total)]
+	} else {
+		rows, total, err = s.jobs.Results(r.Context(), id, offset, limit)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load results")
+			return
+		}
+	}
+
+	format := "json"
+	if query.Get("format") == "csv" {
+		format = "csv"
+	}
+
+	// Tenants with a registered key only ever get ciphertext
+	out, finish, err := s.encryptedDownload(w, r, job, fmt.Sprintf("job-%s-results.%s", id, format))
+	if errors.Is(err, errNotJobOwner) {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "Job not found or not completed")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to encrypt results")
+		return
+	}
+	defer finish()
+
+	if format == "csv" {
+		if out == w {
+			w.Header().Set("Content-Type", "text/csv")
+		}
+		writeResultsCSV(out, rows)
+		return
+	}
+
+	if out == w {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	json.NewEncoder(out).Encode(map[string]interface{}{
+		"results": rows,
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+	})
+}
+
+func filterRowsBySegment(rows []json.RawMessage, segment string) []json.RawMessage {
+	filtered := rows[:0]
+	for _, row := range rows {
+		var probe struct {
+			Segment string `json:"segment"`
+		}
+		if json.Unmarshal(row, &probe) == nil && probe.Segment == segment {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered
+}
+
+var resultCSVColumns = []string{"email", "status", "reason", "confidence", "score", "segment", "is_catch_all", "is_disposable", "mx_host", "checked_at", "tags", "metadata"}
+
+func writeResultsCSV(w io.Writer, rows []json.RawMessage) {
+	cw := csv.NewWriter(w)
+	cw.Write(resultCSVColumns)
+	for _, row := range rows {
+		if record := resultCSVRecord(row); record != nil {
+			cw.Write(record)
+		}
+	}
+	cw.Flush()
+}
+
+// resultCSVRecord flattens one JSON result row into resultCSVColumns
+func resultCSVRecord(row json.RawMessage) []string {
+	var fields map[string]interface{}
+	if json.Unmarshal(row, &fields) != nil {
+		return nil
+	}
+	record := make([]string, len(resultCSVColumns))
+	for i, col := range resultCSVColumns {
+		switch val := fields[col].(type) {
+		case nil:
+		case []interface{}, map[string]interface{}:
+			data, _ := json.Marshal(val)
+			record[i] = string(data)
+		default:
+			record[i] = fmt.Sprint(val)
+		}
+	}
+	return record
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
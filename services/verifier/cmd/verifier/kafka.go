@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// KAFKA BRIDGE
+// ============================================================================
+
+// With kafka.brokers set, workers also consume verification requests from
+// kafka.input_topic and publish each ValidationResult to kafka.output_topic,
+// keyed like the request. Every worker runs kafka.consumers readers in
+// kafka.group_id, so adding replicas (up to the partition count) adds
+// throughput.
+//
+// Delivery is at least once: offsets are committed only after the results
+// of a fetch are published, so a crash or rebalance redelivers them. A
+// message that can never succeed (not JSON, no address, bad metadata) goes
+// to kafka.dead_letter_topic with the reason in an x-error header and is
+// committed. Requests are QueueRequests (see message-queue.go).
+
+const (
+	kafkaBatchWait      = 100 * time.Millisecond // Fill a batch this long after its first message
+	kafkaPublishBackoff = time.Second
+	kafkaMaxBackoff     = 30 * time.Second
+)
+
+type KafkaBridge struct {
+	verifier *verifier.SMTPVerifier
+	config   *verifier.Config
+	results  *kafka.Writer
+	dead     *kafka.Writer
+
+	stopReading chan struct{} // closed by Drain
+	stopOnce    sync.Once
+	consumers   sync.WaitGroup
+}
+
+func NewKafkaBridge(v *verifier.SMTPVerifier, config *verifier.Config) *KafkaBridge {
+	writer := func(topic string) *kafka.Writer {
+		return &kafka.Writer{
+			Addr:         kafka.TCP(config.KafkaBrokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{}, // Results follow their request key's partition
+			RequiredAcks: kafka.RequireAll,
+		}
+	}
+	return &KafkaBridge{
+		verifier:    v,
+		config:      config,
+		results:     writer(config.KafkaOutputTopic),
+		dead:        writer(config.KafkaDeadLetterTopic),
+		stopReading: make(chan struct{}),
+	}
+}
+
+// Run starts KafkaConsumers readers and blocks until they stop
+func (k *KafkaBridge) Run(ctx context.Context) {
+	for i := 0; i < k.config.KafkaConsumers; i++ {
+		k.consumers.Add(1)
+		go func() {
+			defer k.consumers.Done()
+			k.consume(ctx)
+		}()
+	}
+	k.consumers.Wait()
+	k.results.Close()
+	k.dead.Close()
+}
+
+// Drain stops fetching and waits until the readers have published and
+// committed what they hold, or ctx ends. Anything uncommitted is
+// redelivered to another member of the group.
+func (k *KafkaBridge) Drain(ctx context.Context) {
+	k.stopOnce.Do(func() { close(k.stopReading) })
+	done := make(chan struct{})
+	go func() {
+		k.consumers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("Kafka messages still in progress at the end of the grace period")
+	}
+}
+
+func (k *KafkaBridge) draining() bool {
+	select {
+	case <-k.stopReading:
+		return true
+	default:
+		return false
+	}
+}
+
+func (k *KafkaBridge) consume(ctx context.Context) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        k.config.KafkaBrokers,
+		GroupID:        k.config.KafkaGroupID,
+		Topic:          k.config.KafkaInputTopic,
+		CommitInterval: 0, // Commit synchronously, after publishing
+	})
+	defer reader.Close()
+
+	// Fetches end at Drain as well as at shutdown
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-k.stopReading:
+			cancel()
+		case <-fetchCtx.Done():
+		}
+	}()
+
+	for ctx.Err() == nil && !k.draining() {
+		msgs, err := k.fetch(fetchCtx, reader)
+		if err != nil && len(msgs) == 0 {
+			if fetchCtx.Err() == nil {
+				slog.Error("Kafka fetch failed", "topic", k.config.KafkaInputTopic, "error", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if !k.process(ctx, msgs) {
+			return // Cancelled before publishing; redelivered after the rebalance
+		}
+		if err := reader.CommitMessages(ctx, msgs...); err != nil {
+			slog.Error("Kafka commit failed; messages will be redelivered", "count", len(msgs), "error", err)
+		}
+	}
+}
+
+// fetch blocks for one message, then takes whatever else arrives within
+// kafkaBatchWait, up to KafkaBatchSize
+func (k *KafkaBridge) fetch(ctx context.Context, reader *kafka.Reader) ([]kafka.Message, error) {
+	first, err := reader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	msgs := []kafka.Message{first}
+
+	fill, cancel := context.WithTimeout(ctx, kafkaBatchWait)
+	defer cancel()
+	for len(msgs) < k.config.KafkaBatchSize {
+		msg, err := reader.FetchMessage(fill)
+		if err != nil {
+			break
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// process verifies a fetch and publishes every outcome; false means ctx
+// ended first and nothing may be committed
+func (k *KafkaBridge) process(ctx context.Context, msgs []kafka.Message) bool {
+	requests := make([]*QueueRequest, len(msgs))
+	var results, dead []kafka.Message
+	for i, msg := range msgs {
+		req, err := parseQueueRequest(msg.Value)
+		if err != nil {
+			dead = append(dead, deadLetter(msg, err))
+			continue
+		}
+		requests[i] = req
+	}
+	for i, result := range verifyQueueRequests(ctx, k.verifier, requests) {
+		if result == nil {
+			continue
+		}
+		msg := msgs[i]
+		value, err := json.Marshal(result)
+		if err != nil {
+			dead = append(dead, deadLetter(msg, err))
+			continue
+		}
+		key := msg.Key
+		if len(key) == 0 {
+			key = []byte(result.EmailHash)
+		}
+		results = append(results, kafka.Message{Key: key, Value: value, Headers: msg.Headers})
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	return k.publish(ctx, k.results, results) && k.publish(ctx, k.dead, dead)
+}
+
+// publish retries with backoff until the broker takes msgs or ctx ends
+func (k *KafkaBridge) publish(ctx context.Context, w *kafka.Writer, msgs []kafka.Message) bool {
+	backoff := kafkaPublishBackoff
+	for len(msgs) > 0 {
+		err := w.WriteMessages(ctx, msgs...)
+		if err == nil {
+			return true
+		}
+		slog.Error("Kafka publish failed; retrying", "topic", w.Topic, "count", len(msgs), "error", err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > kafkaMaxBackoff {
+			backoff = kafkaMaxBackoff
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// deadLetter copies msg for the dead-letter topic with where it came from
+// and why it failed
+func deadLetter(msg kafka.Message, reason error) kafka.Message {
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: "x-error", Value: []byte(reason.Error())},
+		kafka.Header{Key: "x-source-topic", Value: []byte(msg.Topic)},
+		kafka.Header{Key: "x-source-partition", Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: "x-source-offset", Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+	return kafka.Message{Key: msg.Key, Value: msg.Value, Headers: headers}
+}
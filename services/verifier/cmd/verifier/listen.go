@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// LISTENERS FOR ZERO-DOWNTIME RESTARTS
+// ============================================================================
+
+// During a deploy the replacement process must accept connections before
+// the old one stops. Two ways are supported:
+//
+//   - Inherited sockets: under systemd socket activation (LISTEN_FDS, see
+//     deploy/verifier.socket) the sockets outlive the process, so a restart
+//     never refuses a connection. Sockets are matched by FileDescriptorName
+//     ("http", "smtp-proxy", "milter", "policy"); a single unnamed socket is
+//     the HTTP one.
+//   - SO_REUSEPORT: otherwise TCP listeners set it, so the new process binds
+//     the same port while the old one drains (see drain.go).
+
+// listenFdsStart is the first descriptor systemd passes (SD_LISTEN_FDS_START)
+const listenFdsStart = 3
+
+// listen opens a listener for the named server
+func listen(name, network, addr string) (net.Listener, error) {
+	if ln, err := inheritedListener(name); ln != nil || err != nil {
+		return ln, err
+	}
+	if network != "tcp" {
+		return net.Listen(network, addr)
+	}
+	lc := net.ListenConfig{Control: reusePort}
+	return lc.Listen(context.Background(), network, addr)
+}
+
+// inheritedListener returns the socket systemd passed for name, or nil when
+// there is none
+func inheritedListener(name string) (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, nil
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < count; i++ {
+		fdName := ""
+		if i < len(names) {
+			fdName = names[i]
+		}
+		if fdName == name || (count == 1 && fdName == "" && name == "http") {
+			f := os.NewFile(uintptr(listenFdsStart+i), name)
+			ln, err := net.FileListener(f)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("inherited socket %q: %w", name, err)
+			}
+			return ln, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package main
+
+import "syscall"
+
+// reusePort is a no-op: Windows has no SO_REUSEPORT, and the service
+// manager stops the old process before starting the new one
+func reusePort(network, address string, c syscall.RawConn) error {
+	return nil
+}
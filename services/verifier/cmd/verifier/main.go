@@ -0,0 +1,1254 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+type Server struct {
+	verifier  *verifier.SMTPVerifier
+	jobs      *JobStore
+	queue     *BatchQueue
+	kafka     *KafkaBridge        // nil unless kafka.brokers is set
+	mq        *MessageQueueWorker // nil unless message_queue.driver is set
+	crm       *CRMSync
+	scheduler *Scheduler
+	router    *mux.Router
+	config    *verifier.Config
+	roles     roleSet
+
+	// Graceful drain (see drain.go)
+	draining    atomic.Bool
+	jobsCtx     context.Context
+	jobsRunning sync.WaitGroup
+}
+
+type ValidateRequest struct {
+	Email       string `json:"email"`
+	SkipCache   bool   `json:"skip_cache,omitempty"`
+	Normalize   bool   `json:"normalize,omitempty"`
+	Depth       string `json:"depth,omitempty"`
+	Debug       bool   `json:"debug,omitempty"`        // Return the SMTP transcript
+	CallbackURL string `json:"callback_url,omitempty"` // Receives the final result of a greylisted address
+	verifier.ClientMetadata
+}
+
+type ValidateResponse struct {
+	*verifier.ValidationResult
+}
+
+type BatchValidateRequest struct {
+	Emails      []string `json:"emails"`
+	Priority    string   `json:"priority,omitempty"`
+	CallbackURL string   `json:"callback_url,omitempty"` // Answer 202 and POST the results here
+	verifier.ClientMetadata
+}
+
+type BatchValidateResponse struct {
+	Results []*verifier.ValidationResult `json:"results"`
+}
+
+func main() {
+	role := flag.String("role", getEnv("VERIFIER_ROLE", RoleAll), "api, worker, scheduler or all; combine with commas")
+	flag.StringVar(role, "mode", *role, "same as --role")
+	flag.Parse()
+	roles, err := parseRoles(*role)
+	if err != nil {
+		log.Fatalf("Invalid --role: %v", err)
+	}
+
+	// verifier service install|uninstall|start|stop|status (see service_*.go)
+	if flag.Arg(0) == "service" {
+		if err := serviceCommand(flag.Args()[1:], roles); err != nil {
+			log.Fatalf("Service %s failed: %v", flag.Arg(1), err)
+		}
+		return
+	}
+	if runningAsService() {
+		if err := runService(roles); err != nil {
+			log.Fatalf("Service failed: %v", err)
+		}
+		return
+	}
+
+	// Stop on Ctrl+C or SIGTERM; on Windows, closing the console or logging
+	// off also arrives as SIGTERM
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	stop := make(chan struct{})
+	go func() {
+		<-quit
+		close(stop)
+	}()
+	run(roles, stop)
+}
+
+// run starts the server in the given roles and shuts it down gracefully
+// once stop is closed
+func run(roles roleSet, stop <-chan struct{}) {
+	// Load configuration
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	setupLogging(config)
+	if key, err := hex.DecodeString(config.StorageCredentialsKey); config.StorageCredentialsKey != "" && (err != nil || len(key) != 32) {
+		log.Printf("Warning: STORAGE_CREDENTIALS_KEY must be 64 hex characters; object storage jobs disabled")
+	}
+
+	// Initialize Redis
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", getEnv("REDIS_HOST", "localhost"), 6379),
+		Password: getEnv("REDIS_PASSWORD", ""),
+		DB:       0,
+	})
+
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+	log.Println("✓ Connected to Redis")
+
+	// Tracing; Redis commands become child spans of traced requests
+	shutdownTracing, err := setupTracing(ctx, config)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	redisClient.AddHook(verifier.RedisTracingHook{})
+	if config.OTLPEndpoint != "" {
+		log.Printf("✓ Exporting traces to %s", config.OTLPEndpoint)
+	}
+
+	// Initialize SMTP Verifier
+	v := verifier.NewSMTPVerifier(config, redisClient)
+
+	// Create server
+	jobs := NewJobStore(redisClient, config.JobRetention).WithRegions(v.TenantStore)
+	server := &Server{
+		verifier: v,
+		jobs:     jobs,
+		queue:    NewBatchQueue(redisClient, v, config),
+		crm:      NewCRMSync(redisClient, v, jobs, os.Getenv("CRM_CREDENTIALS_KEY")),
+		router:   mux.NewRouter(),
+		config:   config,
+		roles:    roles,
+	}
+	log.Printf("✓ Running as %s", roles)
+
+	// Clean-list jobs outlive their requests; they are cancelled only when a
+	// shutdown drain runs out of time
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	server.jobsCtx = jobsCtx
+
+	server.scheduler, err = NewScheduler(redisClient, v, server.crm, config, roles)
+	if err != nil {
+		log.Fatalf("Invalid task schedule: %v", err)
+	}
+
+	// Background workers per role (see roles.go). Every role verifies, so
+	// each follows cache events from other replicas and pools SMTP sessions.
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	go v.RunCacheEvents(backgroundCtx)
+	go v.RunSMTPPool(backgroundCtx)
+	queueDone := make(chan struct{})
+	if roles.has(RoleWorker) {
+		go func() {
+			server.queue.Run(backgroundCtx)
+			close(queueDone)
+		}()
+	} else {
+		close(queueDone)
+	}
+	kafkaDone := make(chan struct{})
+	if roles.has(RoleWorker) && len(config.KafkaBrokers) > 0 {
+		server.kafka = NewKafkaBridge(v, config)
+		log.Printf("✓ Consuming Kafka topic %s as %s", config.KafkaInputTopic, config.KafkaGroupID)
+		go func() {
+			server.kafka.Run(backgroundCtx)
+			close(kafkaDone)
+		}()
+	} else {
+		close(kafkaDone)
+	}
+	mqDone := make(chan struct{})
+	if roles.has(RoleWorker) && config.MQDriver != "" {
+		server.mq = NewMessageQueueWorker(v, config)
+		log.Printf("✓ Consuming %s from %s", config.MQRequests, config.MQDriver)
+		go func() {
+			server.mq.Run(backgroundCtx)
+			close(mqDone)
+		}()
+	} else {
+		close(mqDone)
+	}
+	// Periodic tasks of this process's roles (see scheduler.go)
+	go server.scheduler.Run(backgroundCtx)
+	// Re-read the config on SIGHUP or file change (see config-reload.go)
+	go server.watchConfig(backgroundCtx)
+
+	// Setup routes; other roles serve only health and metrics
+	if roles.has(RoleAPI) {
+		server.setupRoutes()
+	} else {
+		server.setupOpsRoutes()
+	}
+
+	// Start HTTP server, over TLS when configured (see tls.go)
+	tlsConfig, err := serverTLSConfig(config)
+	if err != nil {
+		log.Fatalf("Invalid server.tls config: %v", err)
+	}
+	addr := fmt.Sprintf(":%s", getEnv("SERVER_PORT", "8080"))
+	httpServer := &http.Server{
+		Addr:         addr,
+		Handler:      server.router,
+		TLSConfig:    tlsConfig,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// Inherited from systemd or bound with SO_REUSEPORT (see listen.go)
+	ln, err := listen("http", "tcp", addr)
+	if err != nil {
+		log.Fatalf("Server failed: %v", err)
+	}
+	go func() {
+		var err error
+		if tlsConfig != nil {
+			log.Printf("🚀 Email Validator API starting on %s (HTTPS)", addr)
+			err = httpServer.ServeTLS(ln, "", "")
+		} else {
+			log.Printf("🚀 Email Validator API starting on %s", addr)
+			err = httpServer.Serve(ln)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// Optional SMTP proxy in front of the outbound MTA
+	var proxy *SMTPProxy
+	if config.SMTPProxyAddr != "" && roles.has(RoleAPI) {
+		if config.SMTPProxyUpstream == "" {
+			log.Fatalf("smtp_proxy.upstream is required when smtp_proxy.listen_addr is set")
+		}
+		proxy = NewSMTPProxy(v, config)
+		go func() {
+			log.Printf("📮 SMTP proxy listening on %s (upstream %s, mode %s)", config.SMTPProxyAddr, config.SMTPProxyUpstream, config.SMTPProxyMode)
+			if err := proxy.ListenAndServe(); err != nil {
+				log.Fatalf("SMTP proxy failed: %v", err)
+			}
+		}()
+	}
+
+	// Optional milter for Sendmail/Postfix
+	var milter *MilterServer
+	if config.MilterAddr != "" && roles.has(RoleAPI) {
+		milter = NewMilterServer(v, config)
+		go func() {
+			log.Printf("📮 Milter listening on %s (mode %s)", config.MilterAddr, config.MilterMode)
+			if err := milter.ListenAndServe(); err != nil {
+				log.Fatalf("Milter failed: %v", err)
+			}
+		}()
+	}
+
+	// Optional Postfix policy delegation service
+	var policy *PolicyServer
+	if config.PolicyAddr != "" && roles.has(RoleAPI) {
+		policy = NewPolicyServer(v, config)
+		go func() {
+			log.Printf("📮 Postfix policy service listening on %s", config.PolicyAddr)
+			if err := policy.ListenAndServe(); err != nil {
+				log.Fatalf("Policy service failed: %v", err)
+			}
+		}()
+	}
+
+	<-stop
+
+	log.Printf("🛑 Draining for up to %s...", config.ShutdownGracePeriod)
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownGracePeriod)
+	defer cancel()
+	server.drain(ctx, httpServer, func() {
+		if proxy != nil {
+			proxy.Close()
+		}
+		if milter != nil {
+			milter.Close()
+		}
+		if policy != nil {
+			policy.Close()
+		}
+	})
+
+	// Whatever is left is cancelled; queue consumers hand their messages
+	// back before returning
+	cancelJobs()
+	stopBackground()
+	select {
+	case <-queueDone:
+	case <-time.After(2 * queueHandBackTime):
+		log.Println("Warning: queue consumers did not stop")
+	}
+	select {
+	case <-kafkaDone:
+	case <-time.After(2 * queueHandBackTime):
+		log.Println("Warning: Kafka consumers did not stop")
+	}
+	select {
+	case <-mqDone:
+	case <-time.After(2 * queueHandBackTime):
+		log.Println("Warning: message queue consumers did not stop")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Warning: flushing traces failed: %v", err)
+	}
+
+	log.Println("✓ Server exited")
+}
+
+func (s *Server) setupRoutes() {
+	// API routes
+	api := s.router.PathPrefix("/v1").Subrouter()
+	api.HandleFunc("/validate", s.handleValidate).Methods("POST", "OPTIONS")
+	api.HandleFunc("/validate/batch", s.handleBatchValidate).Methods("POST", "OPTIONS")
+	api.HandleFunc("/validate/sample", s.handleSampleValidate).Methods("POST", "OPTIONS")
+	api.HandleFunc("/annotate", s.handleAnnotate).Methods("POST", "OPTIONS")
+	api.HandleFunc("/domains/preflight", s.handleDomainPreflight).Methods("POST", "OPTIONS")
+	api.HandleFunc("/domains/{domain}/cache", s.requireAdmin(s.handleInvalidateDomain)).Methods("DELETE")
+	api.HandleFunc("/domain/{domain}", s.handleDomainReport).Methods("GET")
+	api.HandleFunc("/domain/{domain}/auth", s.handleDomainAuth).Methods("GET")
+	api.HandleFunc("/admin/ratelimits", s.requireAdmin(s.handleRateLimits)).Methods("GET")
+	api.HandleFunc("/admin/tasks", s.handleListTasks).Methods("GET")
+	api.HandleFunc("/admin/tasks/{task}/run", s.requireAdmin(s.handleRunTask)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/tasks/{task}/pause", s.requireAdmin(s.handlePauseTask)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/admin/tasks/{task}/resume", s.requireAdmin(s.handleResumeTask)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/probe-blocks", s.handleListProbeBlocks).Methods("GET")
+	api.HandleFunc("/probe-blocks/{egress}/{mx}", s.requireAdmin(s.handleClearProbeBlock)).Methods("DELETE")
+	api.HandleFunc("/status", s.handleStatus).Methods("GET")
+	api.HandleFunc("/abuse/probes", s.requireAdmin(s.handleSearchProbes)).Methods("GET")
+	api.HandleFunc("/abuse/reports", s.requireAdmin(s.handleFileAbuseReport)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/abuse/reports/{id}", s.requireAdmin(s.handleGetAbuseReport)).Methods("GET")
+	api.HandleFunc("/abuse/do-not-verify", s.requireAdmin(s.handleListDoNotVerify)).Methods("GET")
+	api.HandleFunc("/abuse/do-not-verify/{domain}", s.requireAdmin(s.handleSetDoNotVerify)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/abuse/do-not-verify/{domain}", s.requireAdmin(s.handleLiftDoNotVerify)).Methods("DELETE")
+	api.HandleFunc("/catch-all/controls", s.handleGetCatchAllControls).Methods("GET")
+	api.HandleFunc("/catch-all/kill-switch", s.requireAdmin(s.handleSetCatchAllKillSwitch)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/catch-all/domains/{domain}", s.handleGetCatchAllDomain).Methods("GET")
+	api.HandleFunc("/catch-all/domains/{domain}", s.requireAdmin(s.handleSetCatchAllDomain)).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/catch-all/domains/{domain}", s.requireAdmin(s.handleClearCatchAllDomain)).Methods("DELETE")
+	api.HandleFunc("/dmarc/reports", s.requireAdmin(s.handleDMARCIngest)).Methods("POST", "OPTIONS")
+	api.HandleFunc("/dmarc/domains/{domain}/summary", s.requireAdmin(s.handleDMARCSummary)).Methods("GET")
+	api.HandleFunc("/workflows/clean-list", s.handleCleanListWorkflow).Methods("POST", "OPTIONS")
+	api.HandleFunc("/results/{hash}/explain", s.handleExplainResult).Methods("GET")
+	api.HandleFunc("/encryption-key", s.handleGetEncryptionKey).Methods("GET")
+	api.HandleFunc("/encryption-key", s.handleSetEncryptionKey).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/encryption-key", s.handleDeleteEncryptionKey).Methods("DELETE")
+	api.HandleFunc("/storage-credentials/{provider}", s.handleGetStorageCredentials).Methods("GET")
+	api.HandleFunc("/storage-credentials/{provider}", s.handleSetStorageCredentials).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/storage-credentials/{provider}", s.handleDeleteStorageCredentials).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}", s.handleGetWebhook).Methods("GET")
+	api.HandleFunc("/usage", s.handleUsage).Methods("GET")
+	api.HandleFunc("/jobs", s.handleListJobs).Methods("GET")
+	api.HandleFunc("/jobs/{job_id}", s.handleGetJob).Methods("GET")
+	api.HandleFunc("/jobs/{job_id}/results", s.handleGetJobResults).Methods("GET")
+	api.HandleFunc("/jobs/{job_id}/diff", s.handleJobDiff).Methods("GET", "POST", "OPTIONS")
+
+	// Flat endpoints for no-code connectors
+	api.HandleFunc("/simple/verify", s.handleSimpleVerify).Methods("GET", "POST", "OPTIONS")
+	api.HandleFunc("/simple/results", s.handleSimpleResultFeed).Methods("GET")
+
+	// CRM integrations
+	api.HandleFunc("/integrations/crm", s.handleCreateCRMConnection).Methods("POST", "OPTIONS")
+	api.HandleFunc("/integrations/crm/{id}", s.handleGetCRMConnection).Methods("GET")
+	api.HandleFunc("/integrations/crm/{id}", s.handleDeleteCRMConnection).Methods("DELETE")
+	api.HandleFunc("/integrations/crm/{id}/sync", s.handleTriggerCRMSync).Methods("POST", "OPTIONS")
+
+	// API reference (see openapi.go)
+	s.router.HandleFunc("/openapi.json", s.handleOpenAPI).Methods("GET")
+	s.router.HandleFunc("/docs", s.handleDocs).Methods("GET")
+
+	// v2: results with a per-check breakdown (see v2.go)
+	v2 := s.router.PathPrefix("/v2").Subrouter()
+	v2.HandleFunc("/validate", s.handleValidateV2).Methods("POST", "OPTIONS")
+
+	s.setupOpsRoutes()
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	result, ok := s.validate(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// validate runs a single-address request for /v1 and /v2, writing the error
+// response when it fails
+func (s *Server) validate(w http.ResponseWriter, r *http.Request) (*verifier.ValidationResult, bool) {
+	var req ValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request")
+		return nil, false
+	}
+
+	if req.Email == "" {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Email is required")
+		return nil, false
+	}
+
+	if !verifier.ValidDepth(req.Depth) {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "depth must be syntax, dns, smtp or deep")
+		return nil, false
+	}
+
+	if err := req.ClientMetadata.Validate(); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+		return nil, false
+	}
+
+	if req.CallbackURL != "" && !validCallbackURL(req.CallbackURL) {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "callback_url must be an absolute http or https URL")
+		return nil, false
+	}
+
+	if !s.checkQuota(w, r, 1) {
+		return nil, false
+	}
+
+	ctx := r.Context()
+	result, err := s.verifier.VerifyWithOptions(ctx, req.Email, verifier.VerifyOptions{SkipCache: req.SkipCache, Normalize: req.Normalize, Depth: req.Depth, Debug: req.Debug})
+	if err != nil {
+		writeVerifyError(w, r, err, http.StatusInternalServerError, fmt.Sprintf("Validation failed: %v", err))
+		return nil, false
+	}
+	req.ClientMetadata.Attach(result)
+	if result.RetryAt != nil && req.CallbackURL != "" {
+		delivery, err := s.verifier.SetDeferredCallback(ctx, result, req.CallbackURL)
+		if err != nil {
+			slog.WarnContext(ctx, "deferred callback not registered", "email_hash", result.EmailHash, "error", err)
+		} else {
+			w.Header().Set("X-Webhook-ID", delivery.ID)
+		}
+	}
+	return result, true
+}
+
+func validCallbackURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+func (s *Server) handleBatchValidate(w http.ResponseWriter, r *http.Request) {
+	var req BatchValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request")
+		return
+	}
+
+	if len(req.Emails) == 0 {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Emails array is required")
+		return
+	}
+
+	if len(req.Emails) > 1000 {
+		writeErrorDetails(w, r, http.StatusBadRequest, ErrBatchTooLarge, "Maximum 1000 emails per batch", map[string]interface{}{"max": 1000})
+		return
+	}
+
+	if req.Priority == "" {
+		req.Priority = verifier.PriorityStandard
+	}
+	if !validPriority(req.Priority) {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "priority must be express, standard or bulk")
+		return
+	}
+
+	if err := req.ClientMetadata.Validate(); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+		return
+	}
+
+	if !s.checkQuota(w, r, len(req.Emails)) {
+		return
+	}
+
+	if req.CallbackURL != "" {
+		if !validCallbackURL(req.CallbackURL) {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "callback_url must be an absolute http or https URL")
+			return
+		}
+		s.submitBatchWithCallback(w, r, req)
+		return
+	}
+
+	results, err := s.queue.Submit(r.Context(), req.Priority, req.Emails)
+	if err != nil {
+		writeVerifyError(w, r, err, http.StatusServiceUnavailable, "Batch validation failed")
+		return
+	}
+	for _, result := range results {
+		req.ClientMetadata.Attach(result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BatchValidateResponse{Results: results})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	status, code := "healthy", http.StatusOK
+	if s.draining.Load() {
+		// Take this process out of load balancing while it drains
+		status, code = "draining", http.StatusServiceUnavailable
+	}
+	health := map[string]interface{}{
+		"status":    status,
+		"version":   "1.0.0",
+		"role":      s.roles.String(),
+		"timestamp": time.Now().Format(time.RFC3339),
+		"checks": map[string]bool{
+			"redis": s.verifier.Ping(r.Context()) == nil,
+		},
+		"load":       s.verifier.LoadStats(),
+		"redis":      s.verifier.RedisStats(),
+		"classifier": s.verifier.ClassifierStats(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(health)
+}
+
+// handleStatus is the public provider status: how each mailbox provider has
+// treated our probes over the last few minutes
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.verifier.Status(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load status")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	// Basic Prometheus metrics
+	// In production, use github.com/prometheus/client_golang
+	w.Header().Set("Content-Type", "text/plain")
+	// Per tenant: tenants with a config entry, "default" (no API key) and
+	// "other" (every other API key)
+	tenantStats := s.verifier.TenantStats()
+	fmt.Fprintf(w, "# HELP email_validator_validations_total Results served, fresh or cached\n")
+	fmt.Fprintf(w, "# TYPE email_validator_validations_total counter\n")
+	for _, stat := range tenantStats {
+		fmt.Fprintf(w, "email_validator_validations_total{tenant=%q,status=%q} %d\n", stat.Tenant, stat.Status, stat.Verifications)
+	}
+	fmt.Fprintf(w, "# HELP email_validator_cache_hits_total Results served from the cache or a shared in-flight check\n")
+	fmt.Fprintf(w, "# TYPE email_validator_cache_hits_total counter\n")
+	for _, stat := range tenantStats {
+		fmt.Fprintf(w, "email_validator_cache_hits_total{tenant=%q,status=%q} %d\n", stat.Tenant, stat.Status, stat.CacheHits)
+	}
+
+	redisStats := s.verifier.RedisStats()
+	fmt.Fprintf(w, "# HELP email_validator_redis_degraded Redis stores in soft-fail mode\n")
+	fmt.Fprintf(w, "# TYPE email_validator_redis_degraded gauge\n")
+	fmt.Fprintf(w, "email_validator_redis_degraded %d\n", len(redisStats.Degraded))
+	fmt.Fprintf(w, "# HELP email_validator_redis_cache_skips_total Cache reads and writes skipped while Redis was degraded\n")
+	fmt.Fprintf(w, "# TYPE email_validator_redis_cache_skips_total counter\n")
+	fmt.Fprintf(w, "email_validator_redis_cache_skips_total %d\n", redisStats.CacheSkips)
+	fmt.Fprintf(w, "# HELP email_validator_local_rate_limits_total Rate limit checks served in-process while Redis was degraded\n")
+	fmt.Fprintf(w, "# TYPE email_validator_local_rate_limits_total counter\n")
+	fmt.Fprintf(w, "email_validator_local_rate_limits_total %d\n", redisStats.LocalRateLimits)
+
+	classifierStats := s.verifier.ClassifierStats()
+	fmt.Fprintf(w, "# HELP email_validator_cache_rescored_total Cached results reclassified after a classifier upgrade\n")
+	fmt.Fprintf(w, "# TYPE email_validator_cache_rescored_total counter\n")
+	fmt.Fprintf(w, "email_validator_cache_rescored_total %d\n", classifierStats.Rescored)
+	fmt.Fprintf(w, "# HELP email_validator_cache_invalidated_total Cached results from an older classifier served as misses\n")
+	fmt.Fprintf(w, "# TYPE email_validator_cache_invalidated_total counter\n")
+	fmt.Fprintf(w, "email_validator_cache_invalidated_total %d\n", classifierStats.Invalidated)
+}
+
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, X-Encryption, X-Encryption-Key, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// loadConfig reads CONFIG_PATH, applies the environment (see config-env.go)
+// and validates the result. Without a file, defaults and the environment
+// are used.
+func loadConfig() (*verifier.Config, error) {
+	configPath := getEnv("CONFIG_PATH", "config/config.yaml")
+
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Warning: No config file at %s, using defaults and the environment", configPath)
+	} else if err != nil {
+		return nil, err
+	}
+	if data, err = interpolateEnv(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
+	}
+
+	var fileConfig struct {
+		Server struct {
+			ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+			TLS             struct {
+				CertFile         string   `yaml:"cert_file"`
+				KeyFile          string   `yaml:"key_file"`
+				AutocertDomains  []string `yaml:"autocert_domains"`
+				AutocertCacheDir string   `yaml:"autocert_cache_dir"`
+				AutocertEmail    string   `yaml:"autocert_email"`
+				ClientCAFile     string   `yaml:"client_ca_file"`
+				ClientAuth       string   `yaml:"client_auth"`
+			} `yaml:"tls"`
+		} `yaml:"server"`
+		SMTP struct {
+			ConnectTimeout time.Duration `yaml:"connect_timeout"`
+			ReadTimeout    time.Duration `yaml:"read_timeout"`
+			WriteTimeout   time.Duration `yaml:"write_timeout"`
+			EHLOHostname   string        `yaml:"ehlo_hostname"`
+			MailFrom       string        `yaml:"mail_from"`
+
+			MaxRetries         *int           `yaml:"max_retries"`
+			RetryBackoff       *time.Duration `yaml:"retry_backoff"`
+			RetryBackoffFactor float64        `yaml:"retry_backoff_factor"`
+
+			NormalizeAddresses bool `yaml:"normalize_addresses"`
+
+			PoolMaxIdle          *int          `yaml:"pool_max_idle"`
+			PoolIdleTimeout      time.Duration `yaml:"pool_idle_timeout"`
+			MaxRcptPerConnection *int          `yaml:"max_rcpt_per_connection"`
+
+			EnableCatchAllDetection  *bool          `yaml:"enable_catch_all_detection"`
+			CatchAllProbeCount       int            `yaml:"catch_all_probe_count"`
+			CatchAllKillSwitch       bool           `yaml:"catch_all_kill_switch"`
+			CatchAllDisabledDomains  []string       `yaml:"catch_all_disabled_domains"`
+			CatchAllDailyProbeBudget *int           `yaml:"catch_all_daily_probe_budget"`
+			CatchAllProbeBudgets     map[string]int `yaml:"catch_all_probe_budgets"`
+
+			ResponseRules []verifier.ResponseRule `yaml:"response_rules"`
+
+			ProbeBlockCooldown time.Duration `yaml:"probe_block_cooldown"`
+			ProbeRetryDelay    time.Duration `yaml:"probe_retry_delay"`
+
+			GreylistRetryDelay  time.Duration `yaml:"greylist_retry_delay"`
+			GreylistMaxAttempts *int          `yaml:"greylist_max_attempts"`
+
+			DNSServers         []string      `yaml:"dns_servers"`
+			DNSTimeout         time.Duration `yaml:"dns_timeout"`
+			ImplicitMXFallback bool          `yaml:"implicit_mx_fallback"`
+		} `yaml:"smtp"`
+		Workers struct {
+			MaxInFlightVerifications int           `yaml:"max_inflight_verifications"`
+			MaxSMTPSessions          int           `yaml:"max_smtp_sessions"`
+			DegradeCatchAllAt        float64       `yaml:"degrade_catch_all_at"`
+			DegradeEnrichmentAt      float64       `yaml:"degrade_enrichment_at"`
+			OverloadQueueTimeout     time.Duration `yaml:"overload_queue_timeout"`
+			BatchConcurrency         int           `yaml:"batch_concurrency"`
+			MaxConcurrentPerDomain   int           `yaml:"max_concurrent_per_domain"`
+			MaxConcurrentPerMX       int           `yaml:"max_concurrent_per_mx"`
+			DomainRateLimit          time.Duration `yaml:"domain_rate_limit"`
+			DomainRateBurst          int           `yaml:"domain_rate_burst"`
+			MXRateLimit              time.Duration `yaml:"mx_rate_limit"`
+			MXRateBurst              int           `yaml:"mx_rate_burst"`
+		} `yaml:"workers"`
+		DMARC struct {
+			ReportRetention time.Duration `yaml:"report_retention"`
+		} `yaml:"dmarc"`
+		Kafka struct {
+			Brokers         []string `yaml:"brokers"`
+			GroupID         string   `yaml:"group_id"`
+			InputTopic      string   `yaml:"input_topic"`
+			OutputTopic     string   `yaml:"output_topic"`
+			DeadLetterTopic string   `yaml:"dead_letter_topic"`
+			Consumers       int      `yaml:"consumers"`
+			BatchSize       int      `yaml:"batch_size"`
+		} `yaml:"kafka"`
+		MessageQueue struct {
+			Driver     string `yaml:"driver"`
+			URL        string `yaml:"url"`
+			Requests   string `yaml:"requests"`
+			Results    string `yaml:"results"`
+			DeadLetter string `yaml:"dead_letter"`
+			Group      string `yaml:"group"`
+			Consumers  int    `yaml:"consumers"`
+			BatchSize  int    `yaml:"batch_size"`
+		} `yaml:"message_queue"`
+		Webhooks struct {
+			SigningSecret string        `yaml:"signing_secret"`
+			Timeout       time.Duration `yaml:"timeout"`
+			MaxAttempts   int           `yaml:"max_attempts"`
+			RetryBackoff  time.Duration `yaml:"retry_backoff"`
+			Retention     time.Duration `yaml:"retention"`
+		} `yaml:"webhooks"`
+		Retention struct {
+			CompletedJobsRetentionDays int            `yaml:"completed_jobs_retention_days"`
+			ProbeAudit                 *time.Duration `yaml:"probe_audit"`
+		} `yaml:"retention"`
+		SMTPProxy struct {
+			ListenAddr     string        `yaml:"listen_addr"`
+			Upstream       string        `yaml:"upstream"`
+			Mode           string        `yaml:"mode"`
+			VerifyTimeout  time.Duration `yaml:"verify_timeout"`
+			MaxMessageSize int64         `yaml:"max_message_size"`
+		} `yaml:"smtp_proxy"`
+		Queue struct {
+			StreamNameExpress  string         `yaml:"stream_name_express"`
+			StreamNameStandard string         `yaml:"stream_name_standard"`
+			StreamNameBulk     string         `yaml:"stream_name_bulk"`
+			ConsumerGroup      string         `yaml:"consumer_group"`
+			ConsumerCount      int            `yaml:"consumer_count"`
+			BatchSize          int64          `yaml:"batch_size"`
+			BlockTime          time.Duration  `yaml:"block_time"`
+			Weights            map[string]int `yaml:"weights"`
+		} `yaml:"queue"`
+		DisposableDomains struct {
+			EnableBuiltinList           *bool         `yaml:"enable_builtin_list"`
+			ExternalListURL             string        `yaml:"external_list_url"`
+			ExternalListRefreshInterval time.Duration `yaml:"external_list_refresh_interval"`
+			CustomDisposableDomains     []string      `yaml:"custom_disposable_domains"`
+		} `yaml:"disposable_domains"`
+		FreeProviders struct {
+			EnableBuiltinList           *bool         `yaml:"enable_builtin_list"`
+			ExternalListURL             string        `yaml:"external_list_url"`
+			ExternalListRefreshInterval time.Duration `yaml:"external_list_refresh_interval"`
+			CustomFreeProviders         []string      `yaml:"custom_free_providers"`
+		} `yaml:"free_providers"`
+		DomainSecurity struct {
+			Enabled  bool          `yaml:"enabled"`
+			Timeout  time.Duration `yaml:"timeout"`
+			CacheTTL time.Duration `yaml:"cache_ttl"`
+		} `yaml:"domain_security"`
+		DNSBL struct {
+			Zones    []string      `yaml:"zones"`
+			CacheTTL time.Duration `yaml:"cache_ttl"`
+		} `yaml:"dnsbl"`
+		RiskyDomains struct {
+			TLDs           []string      `yaml:"tlds"`
+			ParkingMXHosts []string      `yaml:"parking_mx_hosts"`
+			WildcardCheck  *bool         `yaml:"wildcard_check"`
+			ParkedCacheTTL time.Duration `yaml:"parked_cache_ttl"`
+		} `yaml:"risky_domains"`
+		Spamtraps struct {
+			Patterns        []string      `yaml:"patterns"`
+			OperatorDomains []string      `yaml:"operator_domains"`
+			History         time.Duration `yaml:"history"`
+			RDAPURL         string        `yaml:"rdap_url"`
+		} `yaml:"spamtraps"`
+		LDAP struct {
+			Directories []verifier.LDAPDirectory `yaml:"directories"`
+		} `yaml:"ldap"`
+		DirectoryConnectors []*verifier.DirectoryConnector `yaml:"directory_connectors"`
+		ProviderHints       struct {
+			OverrideFile string `yaml:"override_file"`
+		} `yaml:"provider_hints"`
+		Milter struct {
+			ListenAddr    string        `yaml:"listen_addr"`
+			Mode          string        `yaml:"mode"`
+			VerifyTimeout time.Duration `yaml:"verify_timeout"`
+		} `yaml:"milter"`
+		PolicyService struct {
+			ListenAddr      string        `yaml:"listen_addr"`
+			VerifyTimeout   time.Duration `yaml:"verify_timeout"`
+			DeferUnverified bool          `yaml:"defer_unverified"`
+		} `yaml:"policy_service"`
+		Redis struct {
+			MXCacheTTL           time.Duration  `yaml:"mx_cache_ttl"`
+			ResultCacheTTL       time.Duration  `yaml:"result_cache_ttl"`
+			ResultFeedMaxLen     int64          `yaml:"result_feed_max_len"`
+			DomainMetaCacheTTL   *time.Duration `yaml:"domain_meta_cache_ttl"`
+			LocalCacheTTL        *time.Duration `yaml:"local_cache_ttl"`
+			LocalCacheMaxEntries int            `yaml:"local_cache_max_entries"`
+			RecoveryInterval     time.Duration  `yaml:"recovery_interval"`
+			MXNegativeCacheTTL   *time.Duration `yaml:"mx_negative_cache_ttl"`
+		} `yaml:"redis"`
+		Logging struct {
+			Level  string `yaml:"level"`
+			Format string `yaml:"format"`
+		} `yaml:"logging"`
+		Jobs struct {
+			DuplicateWindow       *time.Duration `yaml:"duplicate_window"`
+			DuplicateAction       string         `yaml:"duplicate_action"`
+			ObjectMaxEmails       int            `yaml:"object_max_emails"`
+			StorageCredentialsKey string         `yaml:"storage_credentials_key"`
+		} `yaml:"jobs"`
+		WarehouseExport struct {
+			Dir      string        `yaml:"dir"`
+			Interval time.Duration `yaml:"interval"`
+		} `yaml:"warehouse_export"`
+		Scheduler struct {
+			Tasks map[string]string `yaml:"tasks"`
+		} `yaml:"scheduler"`
+		Tenants     map[string]verifier.TenantConfig `yaml:"tenants"`
+		Domains     map[string]verifier.DomainPolicy `yaml:"domains"`
+		DomainLists struct {
+			Allowlist  []string `yaml:"allowlist"`
+			Blocklist  []string `yaml:"blocklist"`
+			NeverProbe []string `yaml:"never_probe"`
+		} `yaml:"domain_lists"`
+		API struct {
+			RateLimits struct {
+				Default           verifier.ClientRateLimit            `yaml:"default"`
+				Routes            map[string]verifier.ClientRateLimit `yaml:"routes"`
+				TrustForwardedFor bool                                `yaml:"trust_forwarded_for"`
+			} `yaml:"rate_limits"`
+		} `yaml:"api"`
+		Auth struct {
+			APIKeyHashes   []string `yaml:"api_key_hashes"`
+			APIKeyRequired *bool    `yaml:"api_key_required"`
+			AdminKeyHashes []string `yaml:"admin_key_hashes"`
+		} `yaml:"auth"`
+		Quotas struct {
+			Daily      int64                     `yaml:"daily"`
+			Monthly    int64                     `yaml:"monthly"`
+			Tenants    map[string]verifier.Quota `yaml:"tenants"`
+			FailClosed bool                      `yaml:"fail_closed"`
+		} `yaml:"quotas"`
+		Residency struct {
+			Regions []verifier.Region `yaml:"regions"`
+			Tenants map[string]string `yaml:"tenants"`
+		} `yaml:"residency"`
+		Tracing struct {
+			Enabled     bool     `yaml:"enabled"`
+			Endpoint    string   `yaml:"endpoint"`
+			Insecure    bool     `yaml:"insecure"`
+			ServiceName string   `yaml:"service_name"`
+			SampleRate  *float64 `yaml:"sample_rate"`
+		} `yaml:"tracing"`
+		Security struct {
+			EgressIPs []string `yaml:"egress_ips"`
+		} `yaml:"security"`
+	}
+
+	if err := yaml.Unmarshal(data, &fileConfig); err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
+	}
+	if err := applyEnvOverrides(&fileConfig); err != nil {
+		return nil, err
+	}
+
+	config := verifier.DefaultConfig()
+	config.TLSCertFile = fileConfig.Server.TLS.CertFile
+	config.TLSKeyFile = fileConfig.Server.TLS.KeyFile
+	config.TLSAutocertDomains = fileConfig.Server.TLS.AutocertDomains
+	config.TLSAutocertCacheDir = fileConfig.Server.TLS.AutocertCacheDir
+	config.TLSAutocertEmail = fileConfig.Server.TLS.AutocertEmail
+	config.TLSClientCAFile = fileConfig.Server.TLS.ClientCAFile
+	config.TLSClientAuth = fileConfig.Server.TLS.ClientAuth
+	if fileConfig.Server.ShutdownTimeout > 0 {
+		config.ShutdownGracePeriod = fileConfig.Server.ShutdownTimeout
+	}
+	if fileConfig.SMTP.ConnectTimeout > 0 {
+		config.SMTPConnectTimeout = fileConfig.SMTP.ConnectTimeout
+	}
+	if fileConfig.SMTP.ReadTimeout > 0 {
+		config.SMTPReadTimeout = fileConfig.SMTP.ReadTimeout
+	}
+	if fileConfig.SMTP.WriteTimeout > 0 {
+		config.SMTPWriteTimeout = fileConfig.SMTP.WriteTimeout
+	}
+	if fileConfig.SMTP.EHLOHostname != "" {
+		config.EHLOHostname = fileConfig.SMTP.EHLOHostname
+	}
+	if fileConfig.SMTP.MailFrom != "" {
+		config.MailFrom = fileConfig.SMTP.MailFrom
+	}
+	config.NormalizeAddresses = fileConfig.SMTP.NormalizeAddresses
+	if fileConfig.SMTP.MaxRetries != nil {
+		config.MaxRetries = *fileConfig.SMTP.MaxRetries
+	}
+	if fileConfig.SMTP.RetryBackoff != nil {
+		config.RetryBackoff = *fileConfig.SMTP.RetryBackoff
+	}
+	if fileConfig.SMTP.RetryBackoffFactor != 0 {
+		config.RetryBackoffFactor = fileConfig.SMTP.RetryBackoffFactor
+	}
+	if fileConfig.SMTP.PoolMaxIdle != nil {
+		config.SMTPPoolMaxIdle = *fileConfig.SMTP.PoolMaxIdle
+	}
+	if fileConfig.SMTP.PoolIdleTimeout > 0 {
+		config.SMTPPoolIdleTimeout = fileConfig.SMTP.PoolIdleTimeout
+	}
+	if fileConfig.SMTP.MaxRcptPerConnection != nil {
+		config.MaxRcptPerConnection = *fileConfig.SMTP.MaxRcptPerConnection
+	}
+	config.DNSServers = fileConfig.SMTP.DNSServers
+	if fileConfig.SMTP.DNSTimeout > 0 {
+		config.DNSTimeout = fileConfig.SMTP.DNSTimeout
+	}
+	config.ImplicitMXFallback = fileConfig.SMTP.ImplicitMXFallback
+	if fileConfig.SMTP.EnableCatchAllDetection != nil {
+		config.EnableCatchAllDetection = *fileConfig.SMTP.EnableCatchAllDetection
+	}
+	if fileConfig.SMTP.CatchAllProbeCount > 0 {
+		config.CatchAllProbeCount = fileConfig.SMTP.CatchAllProbeCount
+	}
+	config.CatchAllKillSwitch = fileConfig.SMTP.CatchAllKillSwitch
+	config.CatchAllDisabledDomains = fileConfig.SMTP.CatchAllDisabledDomains
+	if fileConfig.SMTP.CatchAllDailyProbeBudget != nil {
+		config.CatchAllDailyProbeBudget = *fileConfig.SMTP.CatchAllDailyProbeBudget
+	}
+	config.CatchAllProbeBudgets = fileConfig.SMTP.CatchAllProbeBudgets
+	config.ResponseRules = fileConfig.SMTP.ResponseRules
+	if fileConfig.SMTP.ProbeBlockCooldown > 0 {
+		config.ProbeBlockCooldown = fileConfig.SMTP.ProbeBlockCooldown
+	}
+	if fileConfig.SMTP.ProbeRetryDelay > 0 {
+		config.ProbeRetryDelay = fileConfig.SMTP.ProbeRetryDelay
+	}
+	if fileConfig.SMTP.GreylistRetryDelay > 0 {
+		config.GreylistRetryDelay = fileConfig.SMTP.GreylistRetryDelay
+	}
+	if fileConfig.SMTP.GreylistMaxAttempts != nil {
+		config.GreylistMaxAttempts = *fileConfig.SMTP.GreylistMaxAttempts
+	}
+	config.EgressIPs = fileConfig.Security.EgressIPs
+	if fileConfig.Workers.MaxInFlightVerifications > 0 {
+		config.MaxInFlightVerifications = fileConfig.Workers.MaxInFlightVerifications
+	}
+	if fileConfig.Workers.MaxConcurrentPerDomain > 0 {
+		config.MaxConcurrentPerDomain = fileConfig.Workers.MaxConcurrentPerDomain
+	}
+	if fileConfig.Workers.MaxConcurrentPerMX > 0 {
+		config.MaxConcurrentPerMX = fileConfig.Workers.MaxConcurrentPerMX
+	}
+	if fileConfig.Workers.DomainRateLimit > 0 {
+		config.DomainRateLimit = fileConfig.Workers.DomainRateLimit
+	}
+	if fileConfig.Workers.DomainRateBurst > 0 {
+		config.DomainRateBurst = fileConfig.Workers.DomainRateBurst
+	}
+	if fileConfig.Workers.MXRateLimit > 0 {
+		config.MXRateLimit = fileConfig.Workers.MXRateLimit
+	}
+	if fileConfig.Workers.MXRateBurst > 0 {
+		config.MXRateBurst = fileConfig.Workers.MXRateBurst
+	}
+	if fileConfig.Workers.MaxSMTPSessions > 0 {
+		config.MaxSMTPSessions = fileConfig.Workers.MaxSMTPSessions
+	}
+	if fileConfig.Workers.DegradeCatchAllAt > 0 {
+		config.DegradeCatchAllAt = fileConfig.Workers.DegradeCatchAllAt
+	}
+	if fileConfig.Workers.DegradeEnrichmentAt > 0 {
+		config.DegradeEnrichmentAt = fileConfig.Workers.DegradeEnrichmentAt
+	}
+	if fileConfig.Workers.OverloadQueueTimeout > 0 {
+		config.OverloadQueueTimeout = fileConfig.Workers.OverloadQueueTimeout
+	}
+	if fileConfig.Workers.BatchConcurrency > 0 {
+		config.BatchConcurrency = fileConfig.Workers.BatchConcurrency
+	}
+	if fileConfig.DMARC.ReportRetention > 0 {
+		config.DMARCReportRetention = fileConfig.DMARC.ReportRetention
+	}
+	if fileConfig.Retention.CompletedJobsRetentionDays > 0 {
+		config.JobRetention = time.Duration(fileConfig.Retention.CompletedJobsRetentionDays) * 24 * time.Hour
+	}
+	if fileConfig.Retention.ProbeAudit != nil {
+		config.ProbeAuditRetention = *fileConfig.Retention.ProbeAudit
+	}
+	if fileConfig.SMTPProxy.ListenAddr != "" {
+		config.SMTPProxyAddr = fileConfig.SMTPProxy.ListenAddr
+	}
+	if fileConfig.SMTPProxy.Upstream != "" {
+		config.SMTPProxyUpstream = fileConfig.SMTPProxy.Upstream
+	}
+	if fileConfig.SMTPProxy.Mode != "" {
+		config.SMTPProxyMode = fileConfig.SMTPProxy.Mode
+	}
+	if fileConfig.SMTPProxy.VerifyTimeout > 0 {
+		config.SMTPProxyVerifyTimeout = fileConfig.SMTPProxy.VerifyTimeout
+	}
+	if fileConfig.SMTPProxy.MaxMessageSize > 0 {
+		config.SMTPProxyMaxMessageSize = fileConfig.SMTPProxy.MaxMessageSize
+	}
+	for priority, stream := range map[string]string{
+		verifier.PriorityExpress:  fileConfig.Queue.StreamNameExpress,
+		verifier.PriorityStandard: fileConfig.Queue.StreamNameStandard,
+		verifier.PriorityBulk:     fileConfig.Queue.StreamNameBulk,
+	} {
+		if stream != "" {
+			config.QueueStreams[priority] = stream
+		}
+	}
+	for priority, weight := range fileConfig.Queue.Weights {
+		if validPriority(priority) && weight >= 0 {
+			config.QueueWeights[priority] = weight
+		}
+	}
+	if fileConfig.Queue.ConsumerGroup != "" {
+		config.QueueConsumerGroup = fileConfig.Queue.ConsumerGroup
+	}
+	if fileConfig.Queue.ConsumerCount > 0 {
+		config.QueueConsumers = fileConfig.Queue.ConsumerCount
+	}
+	if fileConfig.Queue.BatchSize > 0 {
+		config.QueueReadCount = fileConfig.Queue.BatchSize
+	}
+	if fileConfig.Queue.BlockTime > 0 {
+		config.QueueBlockTime = fileConfig.Queue.BlockTime
+	}
+	if fileConfig.DisposableDomains.EnableBuiltinList != nil {
+		config.DisposableBuiltin = *fileConfig.DisposableDomains.EnableBuiltinList
+	}
+	if fileConfig.DisposableDomains.ExternalListURL != "" {
+		config.DisposableListURL = fileConfig.DisposableDomains.ExternalListURL
+	}
+	if fileConfig.DisposableDomains.ExternalListRefreshInterval > 0 {
+		config.DisposableRefreshInterval = fileConfig.DisposableDomains.ExternalListRefreshInterval
+	}
+	config.DisposableCustomDomains = fileConfig.DisposableDomains.CustomDisposableDomains
+	if fileConfig.FreeProviders.EnableBuiltinList != nil {
+		config.FreeProviderBuiltin = *fileConfig.FreeProviders.EnableBuiltinList
+	}
+	if fileConfig.FreeProviders.ExternalListURL != "" {
+		config.FreeProviderListURL = fileConfig.FreeProviders.ExternalListURL
+	}
+	if fileConfig.FreeProviders.ExternalListRefreshInterval > 0 {
+		config.FreeProviderRefreshInterval = fileConfig.FreeProviders.ExternalListRefreshInterval
+	}
+	config.FreeProviderCustomDomains = fileConfig.FreeProviders.CustomFreeProviders
+	config.DomainSecurityChecks = fileConfig.DomainSecurity.Enabled
+	if fileConfig.DomainSecurity.Timeout > 0 {
+		config.DomainSecurityTimeout = fileConfig.DomainSecurity.Timeout
+	}
+	if fileConfig.DomainSecurity.CacheTTL > 0 {
+		config.DomainSecurityCacheTTL = fileConfig.DomainSecurity.CacheTTL
+	}
+	config.DNSBLZones = fileConfig.DNSBL.Zones
+	if fileConfig.DNSBL.CacheTTL > 0 {
+		config.DNSBLCacheTTL = fileConfig.DNSBL.CacheTTL
+	}
+	if fileConfig.RiskyDomains.TLDs != nil {
+		config.RiskyTLDs = fileConfig.RiskyDomains.TLDs
+	}
+	if fileConfig.RiskyDomains.ParkingMXHosts != nil {
+		config.ParkingMXHosts = fileConfig.RiskyDomains.ParkingMXHosts
+	}
+	if fileConfig.RiskyDomains.WildcardCheck != nil {
+		config.ParkedWildcardCheck = *fileConfig.RiskyDomains.WildcardCheck
+	}
+	if fileConfig.RiskyDomains.ParkedCacheTTL > 0 {
+		config.ParkedCacheTTL = fileConfig.RiskyDomains.ParkedCacheTTL
+	}
+	if fileConfig.Spamtraps.Patterns != nil {
+		config.TrapPatterns = fileConfig.Spamtraps.Patterns
+	}
+	config.TrapOperatorDomains = fileConfig.Spamtraps.OperatorDomains
+	if fileConfig.Spamtraps.History > 0 {
+		config.TrapHistory = fileConfig.Spamtraps.History
+	}
+	config.TrapRDAPURL = fileConfig.Spamtraps.RDAPURL
+	config.LDAPDirectories = fileConfig.LDAP.Directories
+	config.DirectoryConnectors = fileConfig.DirectoryConnectors
+	config.ProviderHintsFile = fileConfig.ProviderHints.OverrideFile
+	if fileConfig.Milter.ListenAddr != "" {
+		config.MilterAddr = fileConfig.Milter.ListenAddr
+	}
+	if fileConfig.Milter.Mode != "" {
+		config.MilterMode = fileConfig.Milter.Mode
+	}
+	if fileConfig.Milter.VerifyTimeout > 0 {
+		config.MilterVerifyTimeout = fileConfig.Milter.VerifyTimeout
+	}
+	if fileConfig.PolicyService.ListenAddr != "" {
+		config.PolicyAddr = fileConfig.PolicyService.ListenAddr
+	}
+	if fileConfig.PolicyService.VerifyTimeout > 0 {
+		config.PolicyVerifyTimeout = fileConfig.PolicyService.VerifyTimeout
+	}
+	config.PolicyDeferUnverified = fileConfig.PolicyService.DeferUnverified
+	if fileConfig.Redis.MXCacheTTL != 0 {
+		config.MXCacheTTL = fileConfig.Redis.MXCacheTTL
+	}
+	if fileConfig.Redis.ResultCacheTTL != 0 {
+		config.ResultCacheTTL = fileConfig.Redis.ResultCacheTTL
+	}
+	if fileConfig.Redis.ResultFeedMaxLen != 0 {
+		config.ResultFeedMaxLen = fileConfig.Redis.ResultFeedMaxLen
+	}
+	if fileConfig.Redis.DomainMetaCacheTTL != nil {
+		config.DomainMetaCacheTTL = *fileConfig.Redis.DomainMetaCacheTTL
+	}
+	if fileConfig.Redis.LocalCacheTTL != nil {
+		config.LocalCacheTTL = *fileConfig.Redis.LocalCacheTTL
+	}
+	if fileConfig.Redis.LocalCacheMaxEntries > 0 {
+		config.LocalCacheMaxEntries = fileConfig.Redis.LocalCacheMaxEntries
+	}
+	if fileConfig.Redis.MXNegativeCacheTTL != nil {
+		config.MXNegativeCacheTTL = *fileConfig.Redis.MXNegativeCacheTTL
+	}
+	if fileConfig.Redis.RecoveryInterval > 0 {
+		config.RedisRecoveryInterval = fileConfig.Redis.RecoveryInterval
+	}
+	if fileConfig.Logging.Level != "" {
+		config.LogLevel = fileConfig.Logging.Level
+	}
+	if fileConfig.Logging.Format != "" {
+		config.LogFormat = fileConfig.Logging.Format
+	}
+	if fileConfig.Jobs.DuplicateWindow != nil {
+		config.DuplicateJobWindow = *fileConfig.Jobs.DuplicateWindow
+	}
+	if fileConfig.Jobs.DuplicateAction != "" {
+		config.DuplicateJobAction = fileConfig.Jobs.DuplicateAction
+	}
+	if fileConfig.Jobs.ObjectMaxEmails > 0 {
+		config.ObjectListMaxEmails = fileConfig.Jobs.ObjectMaxEmails
+	}
+	config.StorageCredentialsKey = fileConfig.Jobs.StorageCredentialsKey
+	config.WarehouseExportDir = fileConfig.WarehouseExport.Dir
+	if fileConfig.WarehouseExport.Interval > 0 {
+		config.WarehouseExportInterval = fileConfig.WarehouseExport.Interval
+	}
+	config.KafkaBrokers = fileConfig.Kafka.Brokers
+	if fileConfig.Kafka.GroupID != "" {
+		config.KafkaGroupID = fileConfig.Kafka.GroupID
+	}
+	if fileConfig.Kafka.InputTopic != "" {
+		config.KafkaInputTopic = fileConfig.Kafka.InputTopic
+	}
+	if fileConfig.Kafka.OutputTopic != "" {
+		config.KafkaOutputTopic = fileConfig.Kafka.OutputTopic
+	}
+	if fileConfig.Kafka.DeadLetterTopic != "" {
+		config.KafkaDeadLetterTopic = fileConfig.Kafka.DeadLetterTopic
+	}
+	if fileConfig.Kafka.Consumers > 0 {
+		config.KafkaConsumers = fileConfig.Kafka.Consumers
+	}
+	if fileConfig.Kafka.BatchSize > 0 {
+		config.KafkaBatchSize = fileConfig.Kafka.BatchSize
+	}
+	config.MQDriver = fileConfig.MessageQueue.Driver
+	config.MQURL = fileConfig.MessageQueue.URL
+	if fileConfig.MessageQueue.Requests != "" {
+		config.MQRequests = fileConfig.MessageQueue.Requests
+	}
+	if fileConfig.MessageQueue.Results != "" {
+		config.MQResults = fileConfig.MessageQueue.Results
+	}
+	if fileConfig.MessageQueue.DeadLetter != "" {
+		config.MQDeadLetter = fileConfig.MessageQueue.DeadLetter
+	}
+	if fileConfig.MessageQueue.Group != "" {
+		config.MQGroup = fileConfig.MessageQueue.Group
+	}
+	if fileConfig.MessageQueue.Consumers > 0 {
+		config.MQConsumers = fileConfig.MessageQueue.Consumers
+	}
+	if fileConfig.MessageQueue.BatchSize > 0 {
+		config.MQBatchSize = fileConfig.MessageQueue.BatchSize
+	}
+	config.WebhookSigningSecret = fileConfig.Webhooks.SigningSecret
+	if fileConfig.Webhooks.Timeout > 0 {
+		config.WebhookTimeout = fileConfig.Webhooks.Timeout
+	}
+	if fileConfig.Webhooks.MaxAttempts > 0 {
+		config.WebhookMaxAttempts = fileConfig.Webhooks.MaxAttempts
+	}
+	if fileConfig.Webhooks.RetryBackoff > 0 {
+		config.WebhookRetryBackoff = fileConfig.Webhooks.RetryBackoff
+	}
+	if fileConfig.Webhooks.Retention > 0 {
+		config.WebhookRetention = fileConfig.Webhooks.Retention
+	}
+	config.TaskSchedules = fileConfig.Scheduler.Tasks
+	config.Tenants = fileConfig.Tenants
+	config.DomainPolicies = fileConfig.Domains
+	config.AllowlistDomains = fileConfig.DomainLists.Allowlist
+	config.BlocklistDomains = fileConfig.DomainLists.Blocklist
+	config.NeverProbeDomains = fileConfig.DomainLists.NeverProbe
+	config.APIKeyHashes = fileConfig.Auth.APIKeyHashes
+	config.AdminKeyHashes = fileConfig.Auth.AdminKeyHashes
+	if fileConfig.Auth.APIKeyRequired != nil {
+		config.APIKeyRequired = *fileConfig.Auth.APIKeyRequired
+	}
+	config.QuotaDaily = fileConfig.Quotas.Daily
+	config.QuotaMonthly = fileConfig.Quotas.Monthly
+	config.TenantQuotas = fileConfig.Quotas.Tenants
+	config.QuotaFailClosed = fileConfig.Quotas.FailClosed
+	config.ClientRateLimit = fileConfig.API.RateLimits.Default
+	config.ClientRouteRateLimits = fileConfig.API.RateLimits.Routes
+	config.TrustForwardedFor = fileConfig.API.RateLimits.TrustForwardedFor
+	config.Regions = fileConfig.Residency.Regions
+	config.TenantRegions = fileConfig.Residency.Tenants
+	if fileConfig.Tracing.Enabled {
+		config.OTLPEndpoint = fileConfig.Tracing.Endpoint
+	}
+	config.OTLPInsecure = fileConfig.Tracing.Insecure
+	if fileConfig.Tracing.ServiceName != "" {
+		config.TracingServiceName = fileConfig.Tracing.ServiceName
+	}
+	if fileConfig.Tracing.SampleRate != nil {
+		config.TracingSampleRatio = *fileConfig.Tracing.SampleRate
+	}
+
+	// Variables from before VERIFIER_ overrides existed
+	if secret := os.Getenv("WEBHOOK_SIGNING_SECRET"); secret != "" {
+		config.WebhookSigningSecret = secret
+	}
+	if key := os.Getenv("STORAGE_CREDENTIALS_KEY"); key != "" {
+		config.StorageCredentialsKey = key
+	}
+	if url := os.Getenv("MESSAGE_QUEUE_URL"); url != "" {
+		config.MQURL = url
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
+	}
+	return config, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
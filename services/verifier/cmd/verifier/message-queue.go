@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// MESSAGE QUEUE WORKERS (NATS JETSTREAM / AMQP)
+// ============================================================================
+
+// For teams without Kafka, workers can take verification requests from a
+// NATS JetStream subject or a RabbitMQ (AMQP 0-9-1) queue instead, chosen
+// by message_queue.driver. Requests and results look as they do on Kafka;
+// a result carries the request's correlation ID (the NATS Msg-Id header or
+// AMQP correlation_id) so callers can match them up.
+//
+// Requests are acknowledged once their results are published, so delivery
+// is at least once. Requests that can never succeed go to
+// message_queue.dead_letter with the reason in an x-error header and are
+// acknowledged; when publishing fails they are handed back for redelivery.
+// On NATS the requests subject must be in a stream; the durable pull
+// consumer message_queue.group is created on first use. On AMQP the
+// queues are declared durable.
+
+// Message queue drivers
+const (
+	MQDriverNATS = "nats"
+	MQDriverAMQP = "amqp"
+)
+
+const (
+	mqFetchWait  = 5 * time.Second // Longest blocking fetch, so drains are noticed
+	mqRetryDelay = time.Second
+)
+
+// QueueRequest is one verification request on Kafka, NATS or AMQP: a bare
+// address or
+//
+//	{"email": "...", "request_id": "...", "tenant": "...", "metadata": {...}, "tags": [...]}
+type QueueRequest struct {
+	Email     string `json:"email"`
+	RequestID string `json:"request_id,omitempty"`
+	Tenant    string `json:"tenant,omitempty"` // Usage and residency tenant; the default tenant when empty
+	verifier.ClientMetadata
+}
+
+func parseQueueRequest(value []byte) (*QueueRequest, error) {
+	trimmed := strings.TrimSpace(string(value))
+	if !strings.HasPrefix(trimmed, "{") {
+		if trimmed == "" {
+			return nil, errors.New("empty message")
+		}
+		return &QueueRequest{Email: trimmed}, nil
+	}
+
+	var req QueueRequest
+	if err := json.Unmarshal(value, &req); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if strings.TrimSpace(req.Email) == "" {
+		return nil, errors.New("email is required")
+	}
+	if err := req.ClientMetadata.Validate(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// verifyQueueRequests verifies requests per originating request, as the
+// batch queue does, so log lines carry its request ID and usage is counted
+// for its tenant. Results line up with requests; nil requests get none.
+func verifyQueueRequests(ctx context.Context, v *verifier.SMTPVerifier, requests []*QueueRequest) []*verifier.ValidationResult {
+	type origin struct{ requestID, tenant string }
+	groups := make(map[origin][]int)
+	for i, req := range requests {
+		if req != nil {
+			o := origin{req.RequestID, req.Tenant}
+			groups[o] = append(groups[o], i)
+		}
+	}
+
+	results := make([]*verifier.ValidationResult, len(requests))
+	for o, indexes := range groups {
+		emails := make([]string, len(indexes))
+		for j, i := range indexes {
+			emails[j] = requests[i].Email
+		}
+		originCtx := verifier.WithTenant(verifier.WithRequestID(ctx, o.requestID), o.tenant)
+		for j, result := range v.VerifyBatch(originCtx, emails) {
+			requests[indexes[j]].ClientMetadata.Attach(result)
+			results[indexes[j]] = result
+		}
+	}
+	return results
+}
+
+// mqMessage is one delivery from either driver
+type mqMessage struct {
+	body          []byte
+	correlationID string
+	ack           func() error
+	requeue       func() error
+}
+
+// mqBroker is one consumer's connection to the broker
+type mqBroker interface {
+	// Fetch blocks until a message arrives, up to mqFetchWait, then returns
+	// whatever else is ready, up to max
+	Fetch(ctx context.Context, max int) ([]*mqMessage, error)
+	Publish(ctx context.Context, dest, correlationID string, body []byte, headers map[string]string) error
+	Close()
+}
+
+// MessageQueueWorker runs message_queue.consumers brokers in the worker role
+type MessageQueueWorker struct {
+	verifier *verifier.SMTPVerifier
+	config   *verifier.Config
+
+	stopReading chan struct{} // closed by Drain
+	stopOnce    sync.Once
+	consumers   sync.WaitGroup
+}
+
+func NewMessageQueueWorker(v *verifier.SMTPVerifier, config *verifier.Config) *MessageQueueWorker {
+	return &MessageQueueWorker{verifier: v, config: config, stopReading: make(chan struct{})}
+}
+
+// Run starts the consumers and blocks until they stop
+func (m *MessageQueueWorker) Run(ctx context.Context) {
+	for i := 0; i < m.config.MQConsumers; i++ {
+		m.consumers.Add(1)
+		go func() {
+			defer m.consumers.Done()
+			m.consume(ctx)
+		}()
+	}
+	m.consumers.Wait()
+}
+
+// Drain stops fetching and waits until the consumers have published and
+// acknowledged what they hold, or ctx ends
+func (m *MessageQueueWorker) Drain(ctx context.Context) {
+	m.stopOnce.Do(func() { close(m.stopReading) })
+	done := make(chan struct{})
+	go func() {
+		m.consumers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("message queue requests still in progress at the end of the grace period")
+	}
+}
+
+func (m *MessageQueueWorker) draining() bool {
+	select {
+	case <-m.stopReading:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *MessageQueueWorker) connect() (mqBroker, error) {
+	switch m.config.MQDriver {
+	case MQDriverNATS:
+		return dialNATS(m.config)
+	case MQDriverAMQP:
+		return dialAMQP(m.config)
+	}
+	return nil, fmt.Errorf("unknown message_queue.driver %q (want nats or amqp)", m.config.MQDriver)
+}
+
+func (m *MessageQueueWorker) consume(ctx context.Context) {
+	var broker mqBroker
+	defer func() {
+		if broker != nil {
+			broker.Close()
+		}
+	}()
+
+	for ctx.Err() == nil && !m.draining() {
+		if broker == nil {
+			var err error
+			if broker, err = m.connect(); err != nil {
+				slog.Error("message queue connect failed", "driver", m.config.MQDriver, "error", err)
+				broker = nil
+				time.Sleep(mqRetryDelay)
+				continue
+			}
+		}
+
+		msgs, err := broker.Fetch(ctx, m.config.MQBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("message queue fetch failed; reconnecting", "driver", m.config.MQDriver, "error", err)
+				broker.Close()
+				broker = nil
+				time.Sleep(mqRetryDelay)
+			}
+			continue
+		}
+		if len(msgs) > 0 {
+			m.process(ctx, broker, msgs)
+		}
+	}
+}
+
+// process verifies a fetch, publishes every outcome and acknowledges the
+// requests; anything not published is handed back
+func (m *MessageQueueWorker) process(ctx context.Context, broker mqBroker, msgs []*mqMessage) {
+	requests := make([]*QueueRequest, len(msgs))
+	done := make([]bool, len(msgs))
+	for i, msg := range msgs {
+		req, err := parseQueueRequest(msg.body)
+		if err != nil {
+			headers := map[string]string{"x-error": err.Error(), "x-source": m.config.MQRequests}
+			done[i] = broker.Publish(ctx, m.config.MQDeadLetter, msg.correlationID, msg.body, headers) == nil
+			continue
+		}
+		requests[i] = req
+	}
+
+	results := verifyQueueRequests(ctx, m.verifier, requests)
+	if ctx.Err() == nil {
+		for i, result := range results {
+			if result == nil {
+				continue
+			}
+			body, err := json.Marshal(result)
+			if err == nil {
+				err = broker.Publish(ctx, m.config.MQResults, msgs[i].correlationID, body, nil)
+			}
+			if err != nil {
+				slog.Error("message queue publish failed; request handed back", "driver", m.config.MQDriver, "error", err)
+				continue
+			}
+			done[i] = true
+		}
+	}
+
+	for i, msg := range msgs {
+		var err error
+		if done[i] {
+			err = msg.ack()
+		} else {
+			err = msg.requeue()
+		}
+		if err != nil {
+			slog.Warn("message queue acknowledgement failed; the request may be redelivered", "driver", m.config.MQDriver, "error", err)
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// NATS JetStream
+// ----------------------------------------------------------------------------
+
+type natsBroker struct {
+	conn *nats.Conn
+	js   nats.JetStreamContext
+	sub  *nats.Subscription
+}
+
+func dialNATS(config *verifier.Config) (mqBroker, error) {
+	conn, err := nats.Connect(config.MQURL, nats.Name("email-validator"))
+	if err != nil {
+		return nil, err
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	sub, err := js.PullSubscribe(config.MQRequests, config.MQGroup, nats.ManualAck())
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("subscribe %s: %w", config.MQRequests, err)
+	}
+	return &natsBroker{conn: conn, js: js, sub: sub}, nil
+}
+
+func (b *natsBroker) Fetch(ctx context.Context, max int) ([]*mqMessage, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, mqFetchWait)
+	defer cancel()
+	msgs, err := b.sub.Fetch(max, nats.Context(fetchCtx))
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, nats.ErrTimeout) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*mqMessage, len(msgs))
+	for i, msg := range msgs {
+		msg := msg
+		out[i] = &mqMessage{
+			body:          msg.Data,
+			correlationID: msg.Header.Get(nats.MsgIdHdr),
+			ack:           func() error { return msg.Ack() },
+			requeue:       func() error { return msg.Nak() },
+		}
+	}
+	return out, nil
+}
+
+func (b *natsBroker) Publish(ctx context.Context, dest, correlationID string, body []byte, headers map[string]string) error {
+	msg := nats.NewMsg(dest)
+	msg.Data = body
+	if correlationID != "" {
+		msg.Header.Set("Correlation-Id", correlationID)
+	}
+	for k, v := range headers {
+		msg.Header.Set(k, v)
+	}
+	_, err := b.js.PublishMsg(msg, nats.Context(ctx))
+	return err
+}
+
+func (b *natsBroker) Close() {
+	b.conn.Close()
+}
+
+// ----------------------------------------------------------------------------
+// AMQP (RabbitMQ)
+// ----------------------------------------------------------------------------
+
+type amqpBroker struct {
+	conn       *amqp.Connection
+	ch         *amqp.Channel
+	deliveries <-chan amqp.Delivery
+}
+
+func dialAMQP(config *verifier.Config) (mqBroker, error) {
+	conn, err := amqp.Dial(config.MQURL)
+	if err != nil {
+		return nil, err
+	}
+	b := &amqpBroker{conn: conn}
+	if err := b.setup(config); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *amqpBroker) setup(config *verifier.Config) (err error) {
+	if b.ch, err = b.conn.Channel(); err != nil {
+		return err
+	}
+	for _, queue := range []string{config.MQRequests, config.MQResults, config.MQDeadLetter} {
+		if _, err := b.ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("declare %s: %w", queue, err)
+		}
+	}
+	// Never hold more than one fetch's worth unacknowledged
+	if err := b.ch.Qos(config.MQBatchSize, 0, false); err != nil {
+		return err
+	}
+	// Publisher confirms: a request is acknowledged only once the broker
+	// has its result
+	if err := b.ch.Confirm(false); err != nil {
+		return err
+	}
+	b.deliveries, err = b.ch.Consume(config.MQRequests, "", false, false, false, false, nil)
+	return err
+}
+
+func (b *amqpBroker) Fetch(ctx context.Context, max int) ([]*mqMessage, error) {
+	var out []*mqMessage
+	add := func(d amqp.Delivery) {
+		out = append(out, &mqMessage{
+			body:          d.Body,
+			correlationID: d.CorrelationId,
+			ack:           func() error { return d.Ack(false) },
+			requeue:       func() error { return d.Nack(false, true) },
+		})
+	}
+
+	select {
+	case d, ok := <-b.deliveries:
+		if !ok {
+			return nil, errors.New("AMQP channel closed")
+		}
+		add(d)
+	case <-time.After(mqFetchWait):
+		return nil, nil
+	case <-ctx.Done():
+		return nil, nil
+	}
+	for len(out) < max {
+		select {
+		case d, ok := <-b.deliveries:
+			if !ok {
+				return out, nil
+			}
+			add(d)
+		default:
+			return out, nil
+		}
+	}
+	return out, nil
+}
+
+func (b *amqpBroker) Publish(ctx context.Context, dest, correlationID string, body []byte, headers map[string]string) error {
+	table := amqp.Table{}
+	for k, v := range headers {
+		table[k] = v
+	}
+	confirm, err := b.ch.PublishWithDeferredConfirmWithContext(ctx, "", dest, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: correlationID,
+		Headers:       table,
+		Body:          body,
+	})
+	if err != nil {
+		return err
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err == nil && !acked {
+		err = fmt.Errorf("broker refused the message for %s", dest)
+	}
+	return err
+}
+
+func (b *amqpBroker) Close() {
+	b.conn.Close()
+}
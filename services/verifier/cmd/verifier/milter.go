@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// MILTER (SENDMAIL / POSTFIX)
+// ============================================================================
+
+// Speaks milter protocol version 6 so an existing MTA can gate recipients on
+// verifier results, e.g. Postfix:
+//
+//	smtpd_milters = inet:127.0.0.1:8891
+//
+// Only the MAIL and RCPT stages are negotiated; end-of-message is always sent
+// and is where flag mode adds its header.
+// Inbound classification hooks will be added alongside the sorter service.
+
+const (
+	milterVersion     = 6
+	milterMaxPacket   = 1 << 20
+	milterIdleTimeout = 5 * time.Minute
+
+	// Commands from the MTA
+	smficAbort   = 'A'
+	smficBody    = 'B'
+	smficConnect = 'C'
+	smficMacro   = 'D'
+	smficBodyEOB = 'E'
+	smficHelo    = 'H'
+	smficQuitNC  = 'K'
+	smficHeader  = 'L'
+	smficMail    = 'M'
+	smficEOH     = 'N'
+	smficOptNeg  = 'O'
+	smficQuit    = 'Q'
+	smficRcpt    = 'R'
+	smficData    = 'T'
+	smficUnknown = 'U'
+
+	// Replies to the MTA
+	smfirAddHeader = 'h'
+	smfirContinue  = 'c'
+	smfirReplyCode = 'y'
+
+	// Actions we may perform
+	smfifAddHeaders = 0x01
+
+	// Protocol steps we ask the MTA to skip
+	smfipNoConnect = 0x001
+	smfipNoHelo    = 0x002
+	smfipNoBody    = 0x010
+	smfipNoHeaders = 0x020
+	smfipNoEOH     = 0x040
+	smfipNoUnknown = 0x100
+	smfipNoData    = 0x200
+)
+
+type MilterServer struct {
+	verifier *verifier.SMTPVerifier
+	config   *verifier.Config
+	listener net.Listener
+}
+
+func NewMilterServer(v *verifier.SMTPVerifier, config *verifier.Config) *MilterServer {
+	return &MilterServer{verifier: v, config: config}
+}
+
+// ListenAndServe accepts MTA connections. Addresses starting with "/" are
+// treated as unix sockets.
+func (m *MilterServer) ListenAndServe() error {
+	network := "tcp"
+	if strings.HasPrefix(m.config.MilterAddr, "/") {
+		network = "unix"
+	}
+	ln, err := listen("milter", network, m.config.MilterAddr)
+	if err != nil {
+		return err
+	}
+	m.listener = ln
+
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			log.Printf("Milter accept failed: %v", err)
+			continue
+		}
+		go m.serve(conn)
+	}
+}
+
+func (m *MilterServer) Close() error {
+	if m.listener == nil {
+		return nil
+	}
+	return m.listener.Close()
+}
+
+func (m *MilterServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	var flagged []string
+
+	for {
+		conn.SetDeadline(time.Now().Add(milterIdleTimeout))
+		cmd, data, err := readMilterPacket(r)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Milter read failed: %v", err)
+			}
+			return
+		}
+
+		switch cmd {
+		case smficOptNeg:
+			if len(data) < 12 {
+				return
+			}
+			mtaProtocol := binary.BigEndian.Uint32(data[8:12])
+			skip := uint32(smfipNoConnect | smfipNoHelo | smfipNoData | smfipNoUnknown |
+				smfipNoHeaders | smfipNoEOH | smfipNoBody)
+			reply := make([]byte, 12)
+			binary.BigEndian.PutUint32(reply[0:4], milterVersion)
+			binary.BigEndian.PutUint32(reply[4:8], smfifAddHeaders)
+			binary.BigEndian.PutUint32(reply[8:12], skip&mtaProtocol)
+			err = writeMilterPacket(conn, smficOptNeg, reply)
+
+		case smficMacro:
+			// Macros need no reply
+
+		case smficMail:
+			flagged = nil
+			err = writeMilterPacket(conn, smfirContinue, nil)
+
+		case smficRcpt:
+			args := splitMilterStrings(data)
+			if len(args) == 0 {
+				err = writeMilterPacket(conn, smfirContinue, nil)
+				break
+			}
+			addr := strings.Trim(args[0], "<>")
+			verdict, reason := m.verifier.CheckRecipient(context.Background(), addr, m.config.MilterVerifyTimeout)
+			switch {
+			case !verdict.Blocked():
+				err = writeMilterPacket(conn, smfirContinue, nil)
+			case m.config.MilterMode == verifier.GateModeFlag:
+				flagged = append(flagged, addr+"="+string(verdict))
+				err = writeMilterPacket(conn, smfirContinue, nil)
+			default:
+				err = writeMilterPacket(conn, smfirReplyCode, append([]byte(verifier.RejectReply(addr, verdict, reason)), 0))
+			}
+
+		case smficBodyEOB:
+			if len(flagged) > 0 {
+				var header bytes.Buffer
+				header.WriteString(verifier.RecipientHeader)
+				header.WriteByte(0)
+				header.WriteString(strings.Join(flagged, "; "))
+				header.WriteByte(0)
+				if err = writeMilterPacket(conn, smfirAddHeader, header.Bytes()); err != nil {
+					break
+				}
+			}
+			flagged = nil
+			err = writeMilterPacket(conn, smfirContinue, nil)
+
+		case smficAbort, smficQuitNC:
+			// Message or connection reset; no reply expected
+			flagged = nil
+
+		case smficQuit:
+			return
+
+		case smficConnect, smficHelo, smficData, smficHeader, smficEOH, smficBody, smficUnknown:
+			err = writeMilterPacket(conn, smfirContinue, nil)
+
+		default:
+			log.Printf("Milter: unexpected command %q", cmd)
+			return
+		}
+
+		if err != nil {
+			log.Printf("Milter write failed: %v", err)
+			return
+		}
+	}
+}
+
+// readMilterPacket reads one length-prefixed milter command
+func readMilterPacket(r *bufio.Reader) (byte, []byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return 0, nil, err
+	}
+	if size == 0 || size > milterMaxPacket {
+		return 0, nil, fmt.Errorf("invalid milter packet size %d", size)
+	}
+
+	packet := make([]byte, size)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return 0, nil, err
+	}
+	return packet[0], packet[1:], nil
+}
+
+func writeMilterPacket(w io.Writer, cmd byte, data []byte) error {
+	packet := make([]byte, 5+len(data))
+	binary.BigEndian.PutUint32(packet[0:4], uint32(1+len(data)))
+	packet[4] = cmd
+	copy(packet[5:], data)
+	_, err := w.Write(packet)
+	return err
+}
+
+// splitMilterStrings splits NUL-terminated argument lists
+func splitMilterStrings(data []byte) []string {
+	var out []string
+	for _, part := range bytes.Split(bytes.TrimRight(data, "\x00"), []byte{0}) {
+		out = append(out, string(part))
+	}
+	return out
+}
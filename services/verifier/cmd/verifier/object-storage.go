@@ -0,0 +1,418 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// OBJECT STORAGE LIST JOBS
+// ============================================================================
+
+// A clean-list request with input_url and output_url reads its list from
+// the tenant's bucket (a CSV like the upload form, gzipped when the name
+// ends in .gz) and writes the sorted results back under
+// {output_url}/{job_id}/:
+//
+//	valid.csv, invalid.csv, catch_all.csv, risky.csv, unknown.csv
+//	summary.json
+//
+// The list is streamed in chunks, so it may run to jobs.object_max_emails
+// rows; results are not kept in Redis, only the job's progress and counts.
+// Per-status files are encrypted when the tenant registered a key (see
+// tenant-encryption.go); the summary holds no addresses and never is.
+// Credentials come from PUT /v1/storage-credentials/{provider}.
+
+const objectListChunk = 1000
+
+// Stages of a bucket job; reading, dedupe and scoring stream into verify
+var objectListStages = []string{StageVerify, StageExport}
+
+// objectListSummary is written as summary.json next to the result files
+type objectListSummary struct {
+	JobID         string         `json:"job_id"`
+	InputURL      string         `json:"input_url"`
+	Rows          int            `json:"rows"`
+	Duplicates    int            `json:"duplicates"`
+	Verified      int            `json:"verified"`
+	StatusCounts  map[string]int `json:"status_counts"`
+	SegmentCounts map[string]int `json:"segment_counts"`
+	Files         []string       `json:"files"`
+	CompletedAt   time.Time      `json:"completed_at"`
+}
+
+// objectListOutput is one per-status file, spooled to disk until export
+type objectListOutput struct {
+	name string
+	file *os.File
+	enc  io.WriteCloser // Encrypting writer over file, or nil
+	csv  *csv.Writer
+}
+
+func (s *Server) startObjectListJob(w http.ResponseWriter, r *http.Request, req *CleanListRequest) {
+	if req.InputURL == "" || req.OutputURL == "" {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "input_url and output_url go together")
+		return
+	}
+	if len(req.Emails) > 0 {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Send either emails or input_url")
+		return
+	}
+	if err := req.ClientMetadata.Validate(); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+		return
+	}
+	if !s.checkQuota(w, r, 1) {
+		return
+	}
+	input, err := verifier.ParseObjectURL(req.InputURL)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, fmt.Sprintf("input_url: %v", err))
+		return
+	}
+	output, err := verifier.ParseObjectURL(req.OutputURL)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, fmt.Sprintf("output_url: %v", err))
+		return
+	}
+	for _, obj := range []*verifier.ObjectURL{input, output} {
+		err := s.verifier.CheckStorageAccess(r.Context(), obj)
+		if errors.Is(err, verifier.ErrStorageDisabled) {
+			writeError(w, r, http.StatusServiceUnavailable, ErrNotConfigured, "Object storage is not enabled")
+			return
+		}
+		if errors.Is(err, verifier.ErrNoStorageCredentials) {
+			writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, fmt.Sprintf("Register %s credentials first (PUT /v1/storage-credentials/%s)", obj.Provider, obj.Provider))
+			return
+		}
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load storage credentials")
+			return
+		}
+	}
+
+	job, err := s.jobs.Create(r.Context(), "clean_list", req.ClientMetadata, objectListStages...)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to create job")
+		return
+	}
+	job.InputURL = input.String()
+	job.OutputURL = output.Join(job.ID).String()
+	s.jobs.Save(r.Context(), job)
+
+	// Runs past the request, like clean-list jobs (see drain.go)
+	ctx := verifier.WithRequestID(s.jobsCtx, verifier.RequestIDFrom(r.Context()))
+	ctx = verifier.WithTenant(ctx, verifier.TenantFrom(r.Context()))
+	s.goJob(func() { s.runObjectListJob(ctx, job, input, output.Join(job.ID)) })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+func (s *Server) runObjectListJob(ctx context.Context, job *Job, input, output *verifier.ObjectURL) {
+	now := time.Now()
+	job.Status = JobProcessing
+	job.StartedAt = &now
+	s.jobs.Save(ctx, job)
+
+	outputs := make(map[string]*objectListOutput)
+	defer func() {
+		for _, out := range outputs {
+			out.file.Close()
+			os.Remove(out.file.Name())
+		}
+	}()
+
+	summary, err := s.sortObjectList(ctx, job, input, outputs)
+	if err == nil {
+		err = s.exportObjectList(ctx, job, output, outputs, summary)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = errJobInterrupted
+		}
+		slog.ErrorContext(ctx, "bucket list job failed", "job_id", job.ID, "error", err)
+		job.Status = JobFailed
+		job.Error = err.Error()
+		s.jobs.Save(context.WithoutCancel(ctx), job)
+		return
+	}
+
+	completed := time.Now()
+	job.Status = JobCompleted
+	job.CompletedAt = &completed
+	s.jobs.Save(ctx, job)
+}
+
+// sortObjectList streams the input through dedupe, verification and
+// scoring into one spool file per status
+func (s *Server) sortObjectList(ctx context.Context, job *Job, input *verifier.ObjectURL, outputs map[string]*objectListOutput) (*objectListSummary, error) {
+	body, err := s.verifier.GetObject(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("read input: %w", err)
+	}
+	defer body.Close()
+	var src io.Reader = bufio.NewReader(body)
+	if strings.HasSuffix(input.Key, ".gz") {
+		gz, err := gzip.NewReader(src)
+		if err != nil {
+			return nil, fmt.Errorf("read input: %w", err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	key, err := s.verifier.EncryptionKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &objectListSummary{JobID: job.ID, InputURL: input.String()}
+	job.StatusCounts = make(map[string]int)
+	job.SegmentCounts = make(map[string]int)
+	verifyStage := job.startStage(StageVerify, 0)
+
+	reader := newEmailReader(src)
+	seen := make(map[string]struct{})
+	chunk := make([]string, 0, objectListChunk)
+	for done := false; !done; {
+		email, err := reader.Next()
+		switch {
+		case err == io.EOF:
+			done = true
+		case err != nil:
+			return nil, fmt.Errorf("read input: %w", err)
+		default:
+			summary.Rows++
+			if summary.Rows > s.config.ObjectListMaxEmails {
+				return nil, fmt.Errorf("input has more than %d rows", s.config.ObjectListMaxEmails)
+			}
+			email = strings.ToLower(strings.TrimSpace(email))
+			if email == "" {
+				continue
+			}
+			if _, dup := seen[email]; dup {
+				summary.Duplicates++
+				continue
+			}
+			seen[email] = struct{}{}
+			chunk = append(chunk, email)
+		}
+		if len(chunk) < objectListChunk && !(done && len(chunk) > 0) {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err := s.verifier.CheckQuota(ctx, len(chunk)); err != nil {
+			return nil, err
+		}
+		for _, result := range s.verifier.VerifyBatch(ctx, chunk) {
+			job.ClientMetadata.Attach(result)
+			row := &CleanListResult{ValidationResult: result, Score: verifier.ScoreResult(result), Segment: verifier.SegmentFor(result.Status)}
+			if err := writeObjectListRow(outputs, key, row); err != nil {
+				return nil, fmt.Errorf("spool results: %w", err)
+			}
+			job.StatusCounts[string(row.Status)]++
+			job.SegmentCounts[row.Segment]++
+		}
+		summary.Verified += len(chunk)
+		chunk = chunk[:0]
+		verifyStage.Processed = summary.Verified
+		verifyStage.Total = summary.Verified
+		job.TotalEmails = summary.Verified
+		job.EmailsProcessed = summary.Verified
+		s.jobs.Save(ctx, job)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	job.completeStage(StageVerify)
+	summary.StatusCounts = job.StatusCounts
+	summary.SegmentCounts = job.SegmentCounts
+	return summary, nil
+}
+
+// writeObjectListRow appends row to its status file, creating it on first use
+func writeObjectListRow(outputs map[string]*objectListOutput, key *verifier.EncryptionKey, row *CleanListResult) error {
+	status := string(row.Status)
+	out := outputs[status]
+	if out == nil {
+		name := strings.ReplaceAll(status, "-", "_") + ".csv"
+		file, err := os.CreateTemp("", "object-list-*-"+name)
+		if err != nil {
+			return err
+		}
+		out = &objectListOutput{name: name, file: file}
+		outputs[status] = out
+		var w io.Writer = file
+		if key != nil {
+			if out.enc, err = key.Encrypt(file, name); err != nil {
+				return err
+			}
+			out.name += key.FileExtension()
+			w = out.enc
+		}
+		out.csv = csv.NewWriter(w)
+		out.csv.Write(resultCSVColumns)
+	}
+
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	return out.csv.Write(resultCSVRecord(data))
+}
+
+// exportObjectList uploads the spooled files and the summary under output
+func (s *Server) exportObjectList(ctx context.Context, job *Job, output *verifier.ObjectURL, outputs map[string]*objectListOutput, summary *objectListSummary) error {
+	statuses := make([]string, 0, len(outputs))
+	for status := range outputs {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	exportStage := job.startStage(StageExport, len(statuses)+1)
+	s.jobs.Save(ctx, job)
+	for i, status := range statuses {
+		out := outputs[status]
+		out.csv.Flush()
+		if err := out.csv.Error(); err != nil {
+			return fmt.Errorf("write %s: %w", out.name, err)
+		}
+		if out.enc != nil {
+			if err := out.enc.Close(); err != nil {
+				return fmt.Errorf("encrypt %s: %w", out.name, err)
+			}
+		}
+		size, err := out.file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if _, err := out.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		contentType := "text/csv"
+		if out.enc != nil {
+			contentType = "application/octet-stream"
+		}
+		obj := output.Join(out.name)
+		if err := s.verifier.PutObject(ctx, obj, out.file, size, contentType); err != nil {
+			return fmt.Errorf("write output: %w", err)
+		}
+		summary.Files = append(summary.Files, out.name)
+		job.OutputFiles = append(job.OutputFiles, obj.String())
+		exportStage.Processed = i + 1
+		s.jobs.Save(ctx, job)
+	}
+
+	summary.CompletedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	obj := output.Join("summary.json")
+	if err := s.verifier.PutObject(ctx, obj, bytes.NewReader(data), int64(len(data)), "application/json"); err != nil {
+		return fmt.Errorf("write summary: %w", err)
+	}
+	job.OutputFiles = append(job.OutputFiles, obj.String())
+	job.completeStage(StageExport)
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Storage credentials
+// ----------------------------------------------------------------------------
+
+type storageCredentialsRequest struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	Region          string `json:"region,omitempty"`
+	Endpoint        string `json:"endpoint,omitempty"`
+}
+
+func (s *Server) handleGetStorageCredentials(w http.ResponseWriter, r *http.Request) {
+	creds, err := s.verifier.StorageCredentials(r.Context(), mux.Vars(r)["provider"])
+	if errors.Is(err, verifier.ErrNoStorageCredentials) {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "No storage credentials registered")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load storage credentials")
+		return
+	}
+	creds.SecretAccessKey = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(creds)
+}
+
+func (s *Server) handleSetStorageCredentials(w http.ResponseWriter, r *http.Request) {
+	var req storageCredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request body")
+		return
+	}
+	creds := &verifier.StorageCredentials{
+		Provider:        mux.Vars(r)["provider"],
+		AccessKeyID:     req.AccessKeyID,
+		SecretAccessKey: req.SecretAccessKey,
+		Region:          req.Region,
+		Endpoint:        req.Endpoint,
+	}
+	if err := creds.Validate(); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+		return
+	}
+
+	err := s.verifier.SetStorageCredentials(r.Context(), creds)
+	if errors.Is(err, verifier.ErrNoTenant) {
+		writeError(w, r, http.StatusUnauthorized, ErrUnauthorized, "An API key is required")
+		return
+	}
+	if errors.Is(err, verifier.ErrStorageDisabled) {
+		writeError(w, r, http.StatusServiceUnavailable, ErrNotConfigured, "Object storage is not enabled")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to save storage credentials")
+		return
+	}
+	creds.SecretAccessKey = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(creds)
+}
+
+func (s *Server) handleDeleteStorageCredentials(w http.ResponseWriter, r *http.Request) {
+	err := s.verifier.DeleteStorageCredentials(r.Context(), mux.Vars(r)["provider"])
+	if errors.Is(err, verifier.ErrNoTenant) {
+		writeError(w, r, http.StatusUnauthorized, ErrUnauthorized, "An API key is required")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to delete storage credentials")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// OPENAPI DOCUMENT AND SWAGGER UI
+// ============================================================================
+
+// api/api-spec.yaml is the hand-written source of the API reference; `go
+// generate` copies it next to this file so it can be embedded. The binary
+// serves it as /openapi.json, with Swagger UI at /docs.
+//
+// Response schemas backed by a Go struct (openAPIStructs) are reconciled
+// with the struct when the document is first served: properties are the
+// struct's JSON fields, keeping the spec's descriptions and examples where
+// it has them and generating the rest, so a field added in Go shows up in
+// generated clients without a spec edit.
+
+//go:generate cp ../../../../api/api-spec.yaml data/openapi.yaml
+
+//go:embed data/openapi.yaml
+var openAPISpec []byte
+
+// openAPIStructs maps schema names to the structs they describe
+var openAPIStructs = map[string]interface{}{
+	"ValidationResult": verifier.ValidationResult{},
+	"MXRecord":         verifier.MXRecord{},
+	"Usage":            verifier.TenantUsage{},
+	"UsagePeriod":      verifier.UsagePeriod{},
+	"Error":            APIError{},
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// openAPIDocument renders the document once
+var openAPIDocument = sync.OnceValues(func() ([]byte, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(openAPISpec, &doc); err != nil {
+		return nil, fmt.Errorf("parse embedded spec: %w", err)
+	}
+	components, _ := doc["components"].(map[string]interface{})
+	schemas, _ := components["schemas"].(map[string]interface{})
+	if schemas == nil {
+		return nil, fmt.Errorf("embedded spec has no components.schemas")
+	}
+
+	refs := make(map[reflect.Type]string, len(openAPIStructs))
+	for name, v := range openAPIStructs {
+		refs[reflect.TypeOf(v)] = name
+	}
+	for name, v := range openAPIStructs {
+		schema, _ := schemas[name].(map[string]interface{})
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object"}
+		}
+		existing, _ := schema["properties"].(map[string]interface{})
+		schema["properties"] = structProperties(reflect.TypeOf(v), existing, refs)
+		schemas[name] = schema
+	}
+
+	// Relative to /openapi.json, so "try it out" calls this server
+	servers, _ := doc["servers"].([]interface{})
+	doc["servers"] = append([]interface{}{map[string]interface{}{"url": "/v1", "description": "This server"}}, servers...)
+	return json.Marshal(doc)
+})
+
+// structProperties returns the JSON properties of t, taking each from
+// existing when the spec already describes it
+func structProperties(t reflect.Type, existing map[string]interface{}, refs map[reflect.Type]string) map[string]interface{} {
+	props := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for name, prop := range structProperties(field.Type, existing, refs) {
+				props[name] = prop
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if prop, ok := existing[name]; ok {
+			props[name] = prop
+		} else {
+			props[name] = typeSchema(field.Type, refs)
+		}
+	}
+	return props
+}
+
+// typeSchema generates the schema of a Go type
+func typeSchema(t reflect.Type, refs map[reflect.Type]string) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if name, ok := refs[t]; ok {
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), refs)}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), refs)}
+	case t.Kind() == reflect.Struct:
+		return map[string]interface{}{"type": "object", "properties": structProperties(t, nil, refs)}
+	}
+	return map[string]interface{}{}
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	doc, err := openAPIDocument()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to render the API document")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(doc)
+}
+
+// swaggerUIPage loads Swagger UI's assets from a CDN and points it at
+// /openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Email Validation API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui", persistAuthorization: true});
+  </script>
+</body>
+</html>
+`
+
+func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
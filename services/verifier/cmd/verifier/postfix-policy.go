@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// POSTFIX POLICY DELEGATION
+// ============================================================================
+
+// Implements the check_policy_service protocol: Postfix sends name=value
+// attribute lines ended by a blank line and reads back one action=... line.
+//
+//	smtpd_recipient_restrictions =
+//	    permit_mynetworks, reject_unauth_destination,
+//	    check_policy_service inet:127.0.0.1:10040
+//
+// Deliverable recipients get DUNNO rather than OK: OK would also skip every
+// restriction listed after the policy check.
+
+const (
+	policyIdleTimeout = 5 * time.Minute
+	policyMaxLine     = 8192
+)
+
+type PolicyServer struct {
+	verifier *verifier.SMTPVerifier
+	config   *verifier.Config
+	listener net.Listener
+}
+
+func NewPolicyServer(v *verifier.SMTPVerifier, config *verifier.Config) *PolicyServer {
+	return &PolicyServer{verifier: v, config: config}
+}
+
+// ListenAndServe accepts Postfix connections. Addresses starting with "/" are
+// treated as unix sockets.
+func (p *PolicyServer) ListenAndServe() error {
+	network := "tcp"
+	if strings.HasPrefix(p.config.PolicyAddr, "/") {
+		network = "unix"
+	}
+	ln, err := listen("policy", network, p.config.PolicyAddr)
+	if err != nil {
+		return err
+	}
+	p.listener = ln
+
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			log.Printf("Policy service accept failed: %v", err)
+			continue
+		}
+		go p.serve(conn)
+	}
+}
+
+func (p *PolicyServer) Close() error {
+	if p.listener == nil {
+		return nil
+	}
+	return p.listener.Close()
+}
+
+// serve handles a connection; Postfix reuses it for many requests
+func (p *PolicyServer) serve(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, policyMaxLine), policyMaxLine)
+	attrs := make(map[string]string)
+
+	for {
+		conn.SetDeadline(time.Now().Add(policyIdleTimeout))
+		if !scanner.Scan() {
+			return
+		}
+
+		line := scanner.Text()
+		if line != "" {
+			if name, value, ok := strings.Cut(line, "="); ok {
+				attrs[name] = value
+			}
+			continue
+		}
+
+		action := p.decide(attrs)
+		if _, err := fmt.Fprintf(conn, "action=%s\n\n", action); err != nil {
+			return
+		}
+		attrs = make(map[string]string)
+	}
+}
+
+// decide maps one policy request to a Postfix action
+func (p *PolicyServer) decide(attrs map[string]string) string {
+	if attrs["request"] != "smtpd_access_policy" || attrs["protocol_state"] != "RCPT" {
+		return "DUNNO"
+	}
+	recipient := attrs["recipient"]
+	if recipient == "" {
+		return "DUNNO"
+	}
+
+	verdict, reason := p.verifier.CheckRecipient(context.Background(), recipient, p.config.PolicyVerifyTimeout)
+	switch verdict {
+	case verifier.RecipientSuppressed:
+		return "REJECT 5.7.1 Recipient is suppressed"
+	case verifier.RecipientInvalid:
+		return "REJECT 5.1.1 Recipient address rejected: " + reason
+	case verifier.RecipientUnverified:
+		if p.config.PolicyDeferUnverified {
+			return "DEFER_IF_PERMIT 4.7.1 Recipient could not be verified, try again later"
+		}
+	}
+	return "DUNNO"
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// DOMAIN PRE-FLIGHT
+// ============================================================================
+
+const maxPreflightDomains = 10000
+
+type PreflightRequest struct {
+	Domains []string `json:"domains"`
+	// ResolveMX performs a DNS lookup for domains without cached MX records.
+	// No SMTP connection is ever made.
+	ResolveMX bool `json:"resolve_mx,omitempty"`
+}
+
+func (s *Server) handleDomainPreflight(w http.ResponseWriter, r *http.Request) {
+	var req PreflightRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request")
+		return
+	}
+
+	if len(req.Domains) == 0 {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Domains array is required")
+		return
+	}
+
+	if len(req.Domains) > maxPreflightDomains {
+		writeErrorDetails(w, r, http.StatusBadRequest, ErrBatchTooLarge, "Maximum 10000 domains per pre-flight", map[string]interface{}{"max": 10000})
+		return
+	}
+
+	results, err := s.verifier.PreflightDomains(r.Context(), req.Domains, req.ResolveMX)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Pre-flight lookup failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(verifier.PreflightResponse{Domains: results})
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// PRIORITY BATCH QUEUES
+// ============================================================================
+
+// Batch emails are spread over one Redis stream per priority class and drained
+// by consumers on every replica. Each consumer cycles through the classes in
+// proportion to their weights, so express work overtakes bulk work without
+// starving it; idle capacity falls through to whichever class has messages.
+
+const (
+	batchResultTTL     = time.Hour
+	batchPollInterval  = 200 * time.Millisecond
+	queueReclaimIdle   = 10 * time.Minute
+	queueReclaimPeriod = time.Minute
+	queueHandBackTime  = 5 * time.Second
+)
+
+// priorityOrder is the fall-through order when the weighted pick is empty
+var priorityOrder = []string{verifier.PriorityExpress, verifier.PriorityStandard, verifier.PriorityBulk}
+
+func validPriority(priority string) bool {
+	for _, p := range priorityOrder {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+type BatchQueue struct {
+	redis    *redis.Client
+	verifier *verifier.SMTPVerifier
+	config   *verifier.Config
+	cycle    []string // weighted round-robin schedule of priority classes
+
+	stopReading chan struct{} // closed by Drain
+	stopOnce    sync.Once
+	consumers   sync.WaitGroup
+}
+
+func NewBatchQueue(redisClient *redis.Client, v *verifier.SMTPVerifier, config *verifier.Config) *BatchQueue {
+	return &BatchQueue{
+		redis:    redisClient,
+		verifier: v,
+		config:   config,
+		cycle:    weightedCycle(config.QueueWeights),
+
+		stopReading: make(chan struct{}),
+	}
+}
+
+// weightedCycle interleaves classes so that, e.g., weights 3:2:1 produce
+// express, standard, bulk, express, standard, express
+func weightedCycle(weights map[string]int) []string {
+	var cycle []string
+	remaining := make(map[string]int, len(weights))
+	for p, w := range weights {
+		remaining[p] = w
+	}
+	for {
+		added := false
+		for _, p := range priorityOrder {
+			if remaining[p] > 0 {
+				cycle = append(cycle, p)
+				remaining[p]--
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	if len(cycle) == 0 {
+		cycle = append(cycle, priorityOrder...)
+	}
+	return cycle
+}
+
+// Submit enqueues a batch and waits for every result, preserving input order
+func (q *BatchQueue) Submit(ctx context.Context, priority string, emails []string) ([]*verifier.ValidationResult, error) {
+	batchID := verifier.NewID()
+	pendingKey := "batch:" + batchID + ":pending"
+	resultsKey := "batch:" + batchID + ":results"
+
+	pipe := q.redis.TxPipeline()
+	pipe.Set(ctx, pendingKey, len(emails), batchResultTTL)
+	for i, email := range emails {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: q.config.QueueStreams[priority],
+			Values: map[string]interface{}{"batch_id": batchID, "index": i, "email": email, "request_id": verifier.RequestIDFrom(ctx), "tenant": verifier.TenantFrom(ctx)},
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("enqueue batch: %w", err)
+	}
+
+	ticker := time.NewTicker(batchPollInterval)
+	defer ticker.Stop()
+	for {
+		pending, err := q.redis.Get(ctx, pendingKey).Int()
+		if err != nil {
+			return nil, err
+		}
+		if pending <= 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	raw, err := q.redis.HGetAll(ctx, resultsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	q.redis.Del(ctx, pendingKey, resultsKey)
+
+	results := make([]*verifier.ValidationResult, len(emails))
+	for field, val := range raw {
+		i, err := strconv.Atoi(field)
+		if err != nil || i < 0 || i >= len(results) {
+			continue
+		}
+		var result verifier.ValidationResult
+		if json.Unmarshal([]byte(val), &result) == nil {
+			results[i] = &result
+		}
+	}
+	for i, result := range results {
+		if result == nil {
+			results[i] = &verifier.ValidationResult{Email: emails[i], Status: verifier.StatusUnknown, Reason: "Result missing", CheckedAt: time.Now()}
+		}
+	}
+	return results, nil
+}
+
+// Run starts QueueConsumers consumers and blocks until ctx is cancelled
+func (q *BatchQueue) Run(ctx context.Context) {
+	for _, priority := range priorityOrder {
+		err := q.redis.XGroupCreateMkStream(ctx, q.config.QueueStreams[priority], q.config.QueueConsumerGroup, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			log.Printf("Warning: could not create consumer group on %s: %v", q.config.QueueStreams[priority], err)
+		}
+	}
+
+	host, _ := os.Hostname()
+	for i := 0; i < q.config.QueueConsumers; i++ {
+		q.consumers.Add(1)
+		go func(name string) {
+			defer q.consumers.Done()
+			q.consume(ctx, name)
+		}(fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i))
+	}
+	q.consumers.Wait()
+}
+
+// Drain stops reading new messages and waits until the consumers have
+// finished the ones they hold, or ctx ends. Consumers cancelled after that
+// hand their messages back (see process).
+func (q *BatchQueue) Drain(ctx context.Context) {
+	q.stopOnce.Do(func() { close(q.stopReading) })
+	done := make(chan struct{})
+	go func() {
+		q.consumers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("queue messages still in progress at the end of the grace period")
+	}
+}
+
+func (q *BatchQueue) draining() bool {
+	select {
+	case <-q.stopReading:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *BatchQueue) consume(ctx context.Context, consumer string) {
+	next := 0
+	lastReclaim := time.Now()
+
+	for ctx.Err() == nil && !q.draining() {
+		if time.Since(lastReclaim) > queueReclaimPeriod {
+			q.reclaim(ctx, consumer)
+			lastReclaim = time.Now()
+		}
+
+		// Weighted pick first, then fall through in priority order
+		preferred := q.cycle[next%len(q.cycle)]
+		next++
+		stream, msgs := q.readOne(ctx, consumer, preferred)
+		for _, priority := range priorityOrder {
+			if len(msgs) > 0 {
+				break
+			}
+			if priority != preferred {
+				stream, msgs = q.readOne(ctx, consumer, priority)
+			}
+		}
+
+		// Everything is empty: block on all classes at once
+		if len(msgs) == 0 {
+			streams := make([]string, 0, 2*len(priorityOrder))
+			for _, priority := range priorityOrder {
+				streams = append(streams, q.config.QueueStreams[priority])
+			}
+			for range priorityOrder {
+				streams = append(streams, ">")
+			}
+			res, err := q.redis.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    q.config.QueueConsumerGroup,
+				Consumer: consumer,
+				Streams:  streams,
+				Count:    q.config.QueueReadCount,
+				Block:    q.config.QueueBlockTime,
+			}).Result()
+			if err != nil {
+				if err != redis.Nil && ctx.Err() == nil {
+					log.Printf("Queue read failed: %v", err)
+					time.Sleep(time.Second)
+				}
+				continue
+			}
+			for _, s := range res {
+				q.process(ctx, s.Stream, s.Messages)
+			}
+			continue
+		}
+
+		q.process(ctx, stream, msgs)
+	}
+}
+
+// readOne does a non-blocking read of new messages from one class
+func (q *BatchQueue) readOne(ctx context.Context, consumer, priority string) (string, []redis.XMessage) {
+	stream := q.config.QueueStreams[priority]
+	res, err := q.redis.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    q.config.QueueConsumerGroup,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    q.config.QueueReadCount,
+		Block:    -1,
+	}).Result()
+	if err != nil || len(res) == 0 {
+		return stream, nil
+	}
+	return stream, res[0].Messages
+}
+
+// reclaim takes over messages left unacknowledged by crashed consumers
+func (q *BatchQueue) reclaim(ctx context.Context, consumer string) {
+	for _, priority := range priorityOrder {
+		stream := q.config.QueueStreams[priority]
+		msgs, _, err := q.redis.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    q.config.QueueConsumerGroup,
+			MinIdle:  queueReclaimIdle,
+			Start:    "0",
+			Count:    q.config.QueueReadCount,
+			Consumer: consumer,
+		}).Result()
+		if err == nil && len(msgs) > 0 {
+			q.process(ctx, stream, msgs)
+		}
+	}
+}
+
+// process verifies one read of messages and records each result
+func (q *BatchQueue) process(ctx context.Context, stream string, msgs []redis.XMessage) {
+	if len(msgs) == 0 {
+		return
+	}
+
+	// Verify per originating request so log lines carry its request ID and
+	// usage is counted for its tenant
+	type origin struct{ requestID, tenant string }
+	results := make([]*verifier.ValidationResult, len(msgs))
+	groups := make(map[origin][]int)
+	for i, msg := range msgs {
+		var o origin
+		o.requestID, _ = msg.Values["request_id"].(string)
+		o.tenant, _ = msg.Values["tenant"].(string)
+		groups[o] = append(groups[o], i)
+	}
+	for o, indexes := range groups {
+		emails := make([]string, len(indexes))
+		for j, i := range indexes {
+			emails[j], _ = msgs[i].Values["email"].(string)
+		}
+		originCtx := verifier.WithTenant(verifier.WithRequestID(ctx, o.requestID), o.tenant)
+		for j, result := range q.verifier.VerifyBatch(originCtx, emails) {
+			results[indexes[j]] = result
+		}
+	}
+	if ctx.Err() != nil {
+		q.handBack(stream, msgs)
+		return
+	}
+
+	pipe := q.redis.Pipeline()
+	for i, msg := range msgs {
+		batchID, _ := msg.Values["batch_id"].(string)
+		index, _ := msg.Values["index"].(string)
+		if data, err := json.Marshal(results[i]); err == nil && batchID != "" {
+			resultsKey := "batch:" + batchID + ":results"
+			pipe.HSet(ctx, resultsKey, index, data)
+			pipe.Expire(ctx, resultsKey, batchResultTTL)
+			pipe.Decr(ctx, "batch:"+batchID+":pending")
+			pipe.Expire(ctx, "batch:"+batchID+":pending", batchResultTTL)
+		}
+		pipe.XAck(ctx, stream, q.config.QueueConsumerGroup, msg.ID)
+		pipe.XDel(ctx, stream, msg.ID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		slog.ErrorContext(ctx, "queue result write failed", "stream", stream, "error", err)
+	}
+}
+
+// handBack re-adds messages we were stopped from finishing as new entries,
+// so a running replica takes them at once instead of reclaiming them after
+// queueReclaimIdle. Should that fail they stay pending for reclaim.
+func (q *BatchQueue) handBack(stream string, msgs []redis.XMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueHandBackTime)
+	defer cancel()
+	pipe := q.redis.TxPipeline()
+	for _, msg := range msgs {
+		pipe.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: msg.Values})
+		pipe.XAck(ctx, stream, q.config.QueueConsumerGroup, msg.ID)
+		pipe.XDel(ctx, stream, msg.ID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Error("queue hand-back failed; messages stay pending for reclaim", "stream", stream, "count", len(msgs), "error", err)
+		return
+	}
+	slog.Info("queue messages handed back", "stream", stream, "count", len(msgs))
+}
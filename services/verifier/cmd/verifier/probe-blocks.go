@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ============================================================================
+// BLOCKED PROBES
+// ============================================================================
+
+func (s *Server) handleListProbeBlocks(w http.ResponseWriter, r *http.Request) {
+	blocks, err := s.verifier.ProbeBlocks(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to list probe blocks")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"blocks": blocks})
+}
+
+// handleClearProbeBlock returns an egress IP to rotation for one MX, e.g.
+// after a delisting ("default" names the OS-chosen source IP)
+func (s *Server) handleClearProbeBlock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := s.verifier.ClearProbeBlock(r.Context(), vars["egress"], vars["mx"]); err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to clear probe block")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
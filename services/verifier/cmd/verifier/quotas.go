@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// API KEY QUOTAS
+// ============================================================================
+
+// Verification endpoints check the caller's quota before doing any work and
+// answer 429 with Retry-After (seconds until the period resets) when the
+// request's addresses would not fit, or 503 when the quota could not be
+// read. Sampled verifications are charged for their sample; bucket lists,
+// whose size is only known once read, need quota left to start and are
+// checked again before each chunk (see object-storage.go). Metering itself
+// happens in the verifier (see pkg/verifier/quotas.go).
+
+// checkQuota writes the 429 or 503 and returns false when n verifications
+// would exceed the caller's quota or the quota could not be checked
+func (s *Server) checkQuota(w http.ResponseWriter, r *http.Request, n int) bool {
+	err := s.verifier.CheckQuota(r.Context(), n)
+	if err == nil {
+		return true
+	}
+	var exceeded *verifier.QuotaExceededError
+	if !errors.As(err, &exceeded) {
+		w.Header().Set("Retry-After", "5")
+		writeError(w, r, http.StatusServiceUnavailable, ErrInternal, "Quota check failed, retry later")
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(exceeded.RetryAfter().Seconds()))))
+	writeErrorDetails(w, r, http.StatusTooManyRequests, ErrQuotaExceeded, exceeded.Error(), map[string]interface{}{
+		"period":    exceeded.Period,
+		"limit":     exceeded.Limit,
+		"used":      exceeded.Used,
+		"resets_at": exceeded.ResetsAt,
+	})
+	return false
+}
+
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := s.verifier.Usage(r.Context())
+	if errors.Is(err, verifier.ErrNoTenant) {
+		writeError(w, r, http.StatusUnauthorized, ErrUnauthorized, "An API key is required")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to read usage")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
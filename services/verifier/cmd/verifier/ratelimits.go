@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ============================================================================
+// RATE-LIMIT INTROSPECTION
+// ============================================================================
+
+// handleRateLimits shows the throttles on a domain and its MX hosts. mx may
+// be repeated or comma-separated; without it the cached MX records are used.
+func (s *Server) handleRateLimits(w http.ResponseWriter, r *http.Request) {
+	domain := strings.TrimSpace(r.URL.Query().Get("domain"))
+	if domain == "" {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "domain is required")
+		return
+	}
+	var mxHosts []string
+	for _, param := range r.URL.Query()["mx"] {
+		for _, host := range strings.Split(param, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				mxHosts = append(mxHosts, host)
+			}
+		}
+	}
+
+	state, err := s.verifier.RateLimitState(r.Context(), domain, mxHosts)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to read rate limits")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// PROCESS ROLES
+// ============================================================================
+
+// One binary, three roles sharing Redis, so each tier scales on its own:
+//
+//	api        HTTP API, SMTP proxy, milter and Postfix policy service
+//	worker     priority batch queues, Kafka/NATS/AMQP consumers and
+//	           blocked-probe retries
+//	scheduler  disposable and free provider list refresh, scheduled CRM
+//	           syncs, warehouse export
+//
+// "all" (the default) runs everything in one process. Roles combine with
+// commas, e.g. --role=worker,scheduler; --mode is the same flag. Every role verifies addresses (the
+// API for synchronous requests and clean-list jobs, schedulers for CRM
+// syncs), so each keeps the SMTP pool and cache events running and serves
+// /health and /metrics.
+
+const (
+	RoleAPI       = "api"
+	RoleWorker    = "worker"
+	RoleScheduler = "scheduler"
+	RoleAll       = "all"
+)
+
+type roleSet map[string]bool
+
+func parseRoles(spec string) (roleSet, error) {
+	roles := make(roleSet)
+	for _, role := range strings.Split(spec, ",") {
+		role = strings.ToLower(strings.TrimSpace(role))
+		switch role {
+		case RoleAPI, RoleWorker, RoleScheduler, RoleAll:
+			roles[role] = true
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown role %q (want api, worker, scheduler or all)", role)
+		}
+	}
+	if len(roles) == 0 {
+		return nil, errors.New("no role given")
+	}
+	return roles, nil
+}
+
+func (r roleSet) has(role string) bool {
+	return r[RoleAll] || r[role]
+}
+
+func (r roleSet) String() string {
+	if r[RoleAll] {
+		return RoleAll
+	}
+	names := make([]string, 0, len(r))
+	for role := range r {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// setupOpsRoutes registers health, metrics and middleware; processes without
+// the api role serve only these
+func (s *Server) setupOpsRoutes() {
+	// Health check
+	s.router.HandleFunc("/health", s.handleHealth).Methods("GET")
+
+	// Metrics (Prometheus-compatible)
+	s.router.HandleFunc("/metrics", s.handleMetrics).Methods("GET")
+
+	// JSON errors for unmatched requests (see errors.go)
+	s.router.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+	s.router.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowedHandler)
+
+	// CORS middleware - must be first
+	s.router.Use(corsMiddleware)
+	s.router.Use(requestIDMiddleware)
+	s.router.Use(s.authMiddleware)
+	s.router.Use(s.rateLimitMiddleware)
+	s.router.Use(tracingMiddleware)
+	s.router.Use(loggingMiddleware)
+}
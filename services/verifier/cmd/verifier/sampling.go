@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// SAMPLED VERIFICATION
+// ============================================================================
+
+const (
+	defaultSampleSizePerDomain = 50
+	maxSampleInputEmails       = 1000000
+)
+
+type SampleValidateRequest struct {
+	Emails              []string `json:"emails"`
+	SampleSizePerDomain int      `json:"sample_size_per_domain,omitempty"`
+	// Seed makes the sample reproducible: the same list and seed always
+	// select the same addresses.
+	Seed       string  `json:"seed,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"` // 0.90, 0.95 or 0.99
+}
+
+func (s *Server) handleSampleValidate(w http.ResponseWriter, r *http.Request) {
+	var req SampleValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request")
+		return
+	}
+
+	if len(req.Emails) == 0 {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Emails array is required")
+		return
+	}
+
+	if len(req.Emails) > maxSampleInputEmails {
+		writeErrorDetails(w, r, http.StatusBadRequest, ErrBatchTooLarge, "Maximum 1000000 emails per sampled verification", map[string]interface{}{"max": 1000000})
+		return
+	}
+
+	sampleSize := req.SampleSizePerDomain
+	if sampleSize <= 0 {
+		sampleSize = defaultSampleSizePerDomain
+	}
+	if !s.checkQuota(w, r, verifier.SampleCount(req.Emails, sampleSize)) {
+		return
+	}
+
+	resp := s.verifier.VerifySample(r.Context(), req.Emails, sampleSize, req.Seed, req.Confidence)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// TASK SCHEDULER
+// ============================================================================
+
+// Background tasks run on cron expressions from scheduler.tasks in config
+// (see cron.go). Each process runs the tasks of its roles; an exclusive task
+// runs on one replica per occurrence, claimed through its state hash. State
+// lives in Redis so the API can show, pause and trigger tasks that run in
+// other processes:
+//
+//	scheduler:task:{name}  hash: last_slot, paused, last run fields
+//	scheduler:triggers     set of task names queued for a manual run
+//
+// A task that missed its last occurrence (no scheduler was up) runs once at
+// startup. Pausing skips scheduled runs; a manual trigger still runs.
+
+const (
+	TaskDisposableRefresh   = "disposable_refresh"
+	TaskFreeProviderRefresh = "free_provider_refresh"
+	TaskCRMSync             = "crm_sync"
+	TaskWarehouseExport     = "warehouse_export"
+	TaskProbeRetries        = "probe_retries"
+	TaskGreylistRetries     = "greylist_retries"
+	TaskWebhookDeliveries   = "webhook_deliveries"
+
+	schedulerTaskPrefix = "scheduler:task:"
+	schedulerTriggerKey = "scheduler:triggers"
+	schedulerTick       = time.Second
+	schedulerNextRuns   = 3
+)
+
+var errUnknownTask = errors.New("unknown task")
+
+// claimTaskSlot records an occurrence as taken unless a replica took it (or a
+// later one) already.
+// ARGV: slot (unix seconds)
+var claimTaskSlot = redis.NewScript(`
+local last = tonumber(redis.call('HGET', KEYS[1], 'last_slot') or '0')
+if last >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'last_slot', ARGV[1])
+return 1
+`)
+
+type TaskRun struct {
+	Trigger    string     `json:"trigger"` // schedule or manual
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // Unset while running
+	DurationMs int64      `json:"duration_ms,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type ScheduledTask struct {
+	Name      string      `json:"name"`
+	Role      string      `json:"role"`
+	Schedule  string      `json:"schedule"`
+	Paused    bool        `json:"paused"`
+	Triggered bool        `json:"triggered"` // Manual run queued
+	NextRuns  []time.Time `json:"next_runs"`
+	LastRun   *TaskRun    `json:"last_run,omitempty"`
+}
+
+type schedulerTask struct {
+	name      string
+	role      string
+	spec      string
+	schedule  *cronSchedule
+	exclusive bool // One replica per occurrence; otherwise every replica
+	run       func(ctx context.Context) error
+
+	next    time.Time // Owned by Run
+	running atomic.Bool
+}
+
+type Scheduler struct {
+	redis *redis.Client
+	tasks []*schedulerTask
+	roles roleSet
+}
+
+// defaultSchedules keeps the intervals the tasks ran at before they became
+// configurable
+func defaultSchedules(config *verifier.Config) map[string]string {
+	return map[string]string{
+		TaskDisposableRefresh:   "@every " + config.DisposableRefreshInterval.String(),
+		TaskFreeProviderRefresh: "@every " + config.FreeProviderRefreshInterval.String(),
+		TaskCRMSync:             "* * * * *",
+		TaskWarehouseExport:     "@every " + config.WarehouseExportInterval.String(),
+		TaskProbeRetries:        "@every 30s",
+		TaskGreylistRetries:     "@every 30s",
+		TaskWebhookDeliveries:   "@every 10s",
+	}
+}
+
+// NewScheduler registers the background tasks with their configured
+// schedules; an unknown task name or bad expression is an error
+func NewScheduler(redisClient *redis.Client, v *verifier.SMTPVerifier, crm *CRMSync, config *verifier.Config, roles roleSet) (*Scheduler, error) {
+	specs := defaultSchedules(config)
+	for name, spec := range config.TaskSchedules {
+		if _, ok := specs[name]; !ok {
+			return nil, fmt.Errorf("scheduler.tasks: %w %q", errUnknownTask, name)
+		}
+		specs[name] = spec
+	}
+
+	s := &Scheduler{redis: redisClient, roles: roles}
+	add := func(name, role string, exclusive bool, run func(context.Context) error) error {
+		schedule, err := parseCron(specs[name])
+		if err != nil {
+			return fmt.Errorf("scheduler.tasks.%s: %w", name, err)
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("scheduler.tasks.%s: %q never runs", name, specs[name])
+		}
+		s.tasks = append(s.tasks, &schedulerTask{
+			name:      name,
+			role:      role,
+			spec:      specs[name],
+			schedule:  schedule,
+			exclusive: exclusive,
+			run:       run,
+		})
+		return nil
+	}
+
+	disposable := verifier.NewDisposableList(redisClient, config)
+	freeProviders := verifier.NewFreeProviderList(redisClient, config)
+	exporter := NewWarehouseExporter(v, config)
+	for _, err := range []error{
+		add(TaskDisposableRefresh, RoleScheduler, true, func(ctx context.Context) error {
+			// A failed download still refreshes the other entries; the
+			// error is reported as the run's
+			n, err := disposable.Refresh(ctx)
+			if n > 0 || err == nil {
+				slog.InfoContext(ctx, "disposable list refreshed", "domains", n)
+			}
+			return err
+		}),
+		add(TaskFreeProviderRefresh, RoleScheduler, true, func(ctx context.Context) error {
+			n, err := freeProviders.Refresh(ctx)
+			if n > 0 || err == nil {
+				slog.InfoContext(ctx, "free provider list refreshed", "domains", n)
+			}
+			return err
+		}),
+		add(TaskCRMSync, RoleScheduler, true, crm.StartDueSyncs),
+		add(TaskWarehouseExport, RoleScheduler, true, exporter.Export),
+		// Entries are claimed one by one, so every worker helps drain the queue
+		add(TaskProbeRetries, RoleWorker, false, v.RetryBlockedProbes),
+		add(TaskGreylistRetries, RoleWorker, false, v.RetryGreylisted),
+		add(TaskWebhookDeliveries, RoleWorker, false, v.RetryWebhooks),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Scheduler) task(name string) *schedulerTask {
+	for _, t := range s.tasks {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Run fires this process's tasks on schedule and picks up manual triggers
+// until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	var local []*schedulerTask
+	now := time.Now()
+	for _, t := range s.tasks {
+		if !s.roles.has(t.role) {
+			continue
+		}
+		local = append(local, t)
+		t.next = t.schedule.Next(now)
+		last, err := s.redis.HGet(ctx, schedulerTaskPrefix+t.name, "last_slot").Int64()
+		switch {
+		case err == redis.Nil:
+			t.next = now.UTC().Truncate(time.Minute) // Never ran anywhere
+		case err == nil:
+			if missed := t.schedule.Next(time.Unix(last, 0)); missed.Before(t.next) {
+				t.next = missed
+			}
+		}
+	}
+	if len(local) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		for _, t := range local {
+			if now.Before(t.next) {
+				continue
+			}
+			slot := t.next
+			t.next = t.schedule.Next(now)
+			s.fire(ctx, t, slot)
+		}
+		s.runTriggered(ctx, local)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fire starts a scheduled occurrence unless the task is paused, still
+// running here, or (exclusive tasks) taken by another replica
+func (s *Scheduler) fire(ctx context.Context, t *schedulerTask, slot time.Time) {
+	key := schedulerTaskPrefix + t.name
+	if t.running.Load() {
+		slog.WarnContext(ctx, "scheduled task skipped, previous run still going", "task", t.name)
+		return
+	}
+	if paused, _ := s.redis.HExists(ctx, key, "paused").Result(); paused {
+		return
+	}
+	if t.exclusive {
+		claimed, err := claimTaskSlot.Run(ctx, s.redis, []string{key}, slot.Unix()).Int()
+		if err != nil || claimed == 0 {
+			return
+		}
+	} else {
+		s.redis.HSet(ctx, key, "last_slot", slot.Unix())
+	}
+	s.start(ctx, t, "schedule")
+}
+
+// runTriggered claims queued manual runs for tasks this process runs
+func (s *Scheduler) runTriggered(ctx context.Context, local []*schedulerTask) {
+	names, err := s.redis.SMembers(ctx, schedulerTriggerKey).Result()
+	if err != nil || len(names) == 0 {
+		return
+	}
+	for _, t := range local {
+		queued := false
+		for _, name := range names {
+			queued = queued || name == t.name
+		}
+		if !queued || t.running.Load() {
+			continue
+		}
+		if claimed, _ := s.redis.SRem(ctx, schedulerTriggerKey, t.name).Result(); claimed == 1 {
+			s.start(ctx, t, "manual")
+		}
+	}
+}
+
+func (s *Scheduler) start(ctx context.Context, t *schedulerTask, trigger string) {
+	t.running.Store(true)
+	go func() {
+		defer t.running.Store(false)
+		key := schedulerTaskPrefix + t.name
+		started := time.Now()
+		s.redis.HSet(ctx, key, "last_trigger", trigger, "last_started", started.Unix())
+		s.redis.HDel(ctx, key, "last_finished", "last_duration_ms", "last_error")
+
+		err := t.run(ctx)
+		fields := []interface{}{
+			"last_finished", time.Now().Unix(),
+			"last_duration_ms", time.Since(started).Milliseconds(),
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "scheduled task failed", "task", t.name, "trigger", trigger, "error", err)
+			fields = append(fields, "last_error", err.Error())
+		}
+		s.redis.HSet(context.WithoutCancel(ctx), key, fields...)
+	}()
+}
+
+// ----------------------------------------------------------------------------
+// State
+// ----------------------------------------------------------------------------
+
+func (s *Scheduler) Tasks(ctx context.Context) ([]ScheduledTask, error) {
+	triggered, err := s.redis.SMembers(ctx, schedulerTriggerKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]ScheduledTask, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		state, err := s.state(ctx, t, triggered)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *state)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks, nil
+}
+
+func (s *Scheduler) Task(ctx context.Context, name string) (*ScheduledTask, error) {
+	t := s.task(name)
+	if t == nil {
+		return nil, errUnknownTask
+	}
+	triggered, err := s.redis.SMembers(ctx, schedulerTriggerKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	return s.state(ctx, t, triggered)
+}
+
+func (s *Scheduler) state(ctx context.Context, t *schedulerTask, triggered []string) (*ScheduledTask, error) {
+	fields, err := s.redis.HGetAll(ctx, schedulerTaskPrefix+t.name).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &ScheduledTask{
+		Name:     t.name,
+		Role:     t.role,
+		Schedule: t.spec,
+		NextRuns: make([]time.Time, 0, schedulerNextRuns),
+	}
+	_, state.Paused = fields["paused"]
+	for _, name := range triggered {
+		state.Triggered = state.Triggered || name == t.name
+	}
+	next := time.Now()
+	for i := 0; i < schedulerNextRuns; i++ {
+		next = t.schedule.Next(next)
+		state.NextRuns = append(state.NextRuns, next)
+	}
+
+	if started, err := strconv.ParseInt(fields["last_started"], 10, 64); err == nil {
+		run := &TaskRun{
+			Trigger:   fields["last_trigger"],
+			StartedAt: time.Unix(started, 0).UTC(),
+			Error:     fields["last_error"],
+		}
+		if finished, err := strconv.ParseInt(fields["last_finished"], 10, 64); err == nil {
+			at := time.Unix(finished, 0).UTC()
+			run.FinishedAt = &at
+			run.DurationMs, _ = strconv.ParseInt(fields["last_duration_ms"], 10, 64)
+		}
+		state.LastRun = run
+	}
+	return state, nil
+}
+
+// Trigger queues a manual run for the next process that runs the task
+func (s *Scheduler) Trigger(ctx context.Context, name string) error {
+	if s.task(name) == nil {
+		return errUnknownTask
+	}
+	return s.redis.SAdd(ctx, schedulerTriggerKey, name).Err()
+}
+
+func (s *Scheduler) SetPaused(ctx context.Context, name string, paused bool) error {
+	if s.task(name) == nil {
+		return errUnknownTask
+	}
+	key := schedulerTaskPrefix + name
+	if paused {
+		return s.redis.HSet(ctx, key, "paused", time.Now().Unix()).Err()
+	}
+	return s.redis.HDel(ctx, key, "paused").Err()
+}
+
+// ----------------------------------------------------------------------------
+// HTTP Handlers
+// ----------------------------------------------------------------------------
+
+func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := s.scheduler.Tasks(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load tasks")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"tasks": tasks})
+}
+
+func (s *Server) handleRunTask(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["task"]
+	if err := s.scheduler.Trigger(r.Context(), name); err != nil {
+		writeTaskError(w, r, err)
+		return
+	}
+	s.writeTask(w, r, name, http.StatusAccepted)
+}
+
+func (s *Server) handlePauseTask(w http.ResponseWriter, r *http.Request) {
+	s.setTaskPaused(w, r, true)
+}
+
+func (s *Server) handleResumeTask(w http.ResponseWriter, r *http.Request) {
+	s.setTaskPaused(w, r, false)
+}
+
+func (s *Server) setTaskPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	name := mux.Vars(r)["task"]
+	if err := s.scheduler.SetPaused(r.Context(), name, paused); err != nil {
+		writeTaskError(w, r, err)
+		return
+	}
+	s.writeTask(w, r, name, http.StatusOK)
+}
+
+func (s *Server) writeTask(w http.ResponseWriter, r *http.Request, name string, status int) {
+	task, err := s.scheduler.Task(r.Context(), name)
+	if err != nil {
+		writeTaskError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(task)
+}
+
+func writeTaskError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errUnknownTask) {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "Task not found")
+		return
+	}
+	writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to update task")
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// ============================================================================
+// SERVICE MANAGEMENT (UNIX)
+// ============================================================================
+
+// On Linux and macOS the init system supervises the process directly; see
+// deploy/verifier.service for a systemd unit. SIGTERM triggers the same
+// graceful shutdown as a Windows service stop.
+
+func runningAsService() bool {
+	return false
+}
+
+func runService(roles roleSet) error {
+	return errors.New("not running under the Windows service manager")
+}
+
+func serviceCommand(args []string, roles roleSet) error {
+	return errors.New("service commands are only available on Windows; use systemd (deploy/verifier.service) or launchd")
+}
@@ -0,0 +1,206 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// ============================================================================
+// WINDOWS SERVICE
+// ============================================================================
+
+// verifier.exe registers itself with the service control manager:
+//
+//	verifier.exe --role=all service install
+//	verifier.exe service start|stop|status|uninstall
+//
+// Under the SCM the working directory is the executable's, so
+// config\config.yaml resolves next to it, and logs go to verifier.log there
+// since a service has no console. REDIS_HOST and friends come from the
+// system environment. A stop or shutdown request runs the same graceful
+// shutdown as Ctrl+C.
+
+const (
+	serviceName        = "EmailVerifier"
+	serviceDisplayName = "Email Verifier"
+	serviceDescription = "Email address verification API, workers and schedulers"
+	serviceLogFile     = "verifier.log"
+	serviceStopTimeout = 45 * time.Second // HTTP drain is 30s
+)
+
+func runningAsService() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+type verifierService struct {
+	roles roleSet
+}
+
+// Execute implements svc.Handler
+func (s *verifierService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		run(s.roles, stop)
+		close(done)
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending, WaitHint: uint32(serviceStopTimeout / time.Millisecond)}
+				close(stop)
+				<-done
+				return false, 0
+			}
+		case <-done:
+			// Exited without being asked to; the SCM's recovery actions restart it
+			return false, 1
+		}
+	}
+}
+
+// runService hands the process to the SCM until it is stopped
+func runService(roles roleSet) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(exe)
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(filepath.Join(dir, serviceLogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	os.Stdout, os.Stderr = logFile, logFile
+
+	return svc.Run(serviceName, &verifierService{roles: roles})
+}
+
+func serviceCommand(args []string, roles roleSet) error {
+	if len(args) != 1 {
+		return errors.New("usage: verifier [--role=...] service install|uninstall|start|stop|status")
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connect to service manager (run as Administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	if args[0] == "install" {
+		return installService(m, roles)
+	}
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed: %w", serviceName, err)
+	}
+	defer s.Close()
+
+	switch args[0] {
+	case "uninstall":
+		s.Control(svc.Stop)
+		return s.Delete()
+	case "start":
+		return s.Start()
+	case "stop":
+		if _, err := s.Control(svc.Stop); err != nil {
+			return err
+		}
+		return waitForServiceState(s, svc.Stopped)
+	case "status":
+		st, err := s.Query()
+		if err != nil {
+			return err
+		}
+		fmt.Println(serviceStateName(st.State))
+		return nil
+	default:
+		return fmt.Errorf("unknown service command %q", args[0])
+	}
+}
+
+// installService registers the executable to start at boot in roles, and to
+// restart after a crash
+func installService(m *mgr.Mgr, roles roleSet) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", serviceName)
+	}
+
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: serviceDisplayName,
+		Description: serviceDescription,
+		StartType:   mgr.StartAutomatic,
+	}, "--role="+roles.String())
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: 30 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: time.Minute},
+	}, uint32((24 * time.Hour).Seconds()))
+}
+
+func waitForServiceState(s *mgr.Service, want svc.State) error {
+	deadline := time.Now().Add(serviceStopTimeout)
+	for time.Now().Before(deadline) {
+		st, err := s.Query()
+		if err != nil {
+			return err
+		}
+		if st.State == want {
+			return nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("service did not reach %s within %s", serviceStateName(want), serviceStopTimeout)
+}
+
+func serviceStateName(state svc.State) string {
+	switch state {
+	case svc.Stopped:
+		return "stopped"
+	case svc.StartPending:
+		return "start pending"
+	case svc.StopPending:
+		return "stop pending"
+	case svc.Running:
+		return "running"
+	case svc.ContinuePending:
+		return "continue pending"
+	case svc.PausePending:
+		return "pause pending"
+	case svc.Paused:
+		return "paused"
+	default:
+		return fmt.Sprintf("state %d", state)
+	}
+}
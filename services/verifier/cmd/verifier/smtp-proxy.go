@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// SMTP PROXY (PRE-SEND VALIDATION)
+// ============================================================================
+
+// The proxy sits in front of the real outbound MTA. Recipients are checked at
+// RCPT time (cache-first through Verify) and, depending on SMTPProxyMode,
+// either rejected or flagged in a header; accepted mail is relayed upstream.
+// It does not speak STARTTLS or AUTH, so bind it to a trusted network only.
+
+const (
+	proxyMaxRecipients  = 100
+	proxyCommandTimeout = 5 * time.Minute
+)
+
+type SMTPProxy struct {
+	verifier *verifier.SMTPVerifier
+	config   *verifier.Config
+	listener net.Listener
+}
+
+func NewSMTPProxy(v *verifier.SMTPVerifier, config *verifier.Config) *SMTPProxy {
+	return &SMTPProxy{verifier: v, config: config}
+}
+
+func (p *SMTPProxy) ListenAndServe() error {
+	ln, err := listen("smtp-proxy", "tcp", p.config.SMTPProxyAddr)
+	if err != nil {
+		return err
+	}
+	p.listener = ln
+
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			log.Printf("SMTP proxy accept failed: %v", err)
+			continue
+		}
+		go p.serve(conn)
+	}
+}
+
+func (p *SMTPProxy) Close() error {
+	if p.listener == nil {
+		return nil
+	}
+	return p.listener.Close()
+}
+
+// proxySession is the state of one client SMTP transaction
+type proxySession struct {
+	helo    string
+	mail    bool
+	from    string
+	rcpts   []string
+	flagged []string // "addr=verdict" pairs for flag mode
+}
+
+func (s *proxySession) reset() {
+	s.mail = false
+	s.from = ""
+	s.rcpts = nil
+	s.flagged = nil
+}
+
+func (p *SMTPProxy) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	session := &proxySession{}
+
+	text.PrintfLine("220 %s ESMTP verifier proxy", p.config.EHLOHostname)
+	for {
+		conn.SetDeadline(time.Now().Add(proxyCommandTimeout))
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			session.helo = arg
+			session.reset()
+			text.PrintfLine("250-%s", p.config.EHLOHostname)
+			text.PrintfLine("250-8BITMIME")
+			text.PrintfLine("250 SIZE %d", p.config.SMTPProxyMaxMessageSize)
+		case "HELO":
+			session.helo = arg
+			session.reset()
+			text.PrintfLine("250 %s", p.config.EHLOHostname)
+		case "MAIL":
+			addr, ok := parsePathArg(arg, "FROM:")
+			if session.helo == "" {
+				text.PrintfLine("503 5.5.1 Send EHLO first")
+			} else if !ok {
+				text.PrintfLine("501 5.5.4 Syntax: MAIL FROM:<address>")
+			} else {
+				session.reset()
+				session.mail = true
+				session.from = addr
+				text.PrintfLine("250 2.1.0 Ok")
+			}
+		case "RCPT":
+			p.handleRcpt(text, session, arg)
+		case "DATA":
+			p.handleData(text, session)
+		case "RSET":
+			session.reset()
+			text.PrintfLine("250 2.0.0 Ok")
+		case "NOOP":
+			text.PrintfLine("250 2.0.0 Ok")
+		case "VRFY":
+			text.PrintfLine("252 2.5.2 Cannot VRFY user")
+		case "QUIT":
+			text.PrintfLine("221 2.0.0 Bye")
+			return
+		default:
+			text.PrintfLine("502 5.5.2 Command not recognized")
+		}
+	}
+}
+
+func (p *SMTPProxy) handleRcpt(text *textproto.Conn, session *proxySession, arg string) {
+	addr, ok := parsePathArg(arg, "TO:")
+	switch {
+	case !session.mail:
+		text.PrintfLine("503 5.5.1 Need MAIL command")
+		return
+	case !ok || addr == "":
+		text.PrintfLine("501 5.5.4 Syntax: RCPT TO:<address>")
+		return
+	case len(session.rcpts) >= proxyMaxRecipients:
+		text.PrintfLine("452 4.5.3 Too many recipients")
+		return
+	}
+
+	verdict, reason := p.verifier.CheckRecipient(context.Background(), addr, p.config.SMTPProxyVerifyTimeout)
+	if verdict.Blocked() && p.config.SMTPProxyMode != verifier.GateModeFlag {
+		text.PrintfLine("%s", verifier.RejectReply(addr, verdict, reason))
+		return
+	}
+	if verdict.Blocked() {
+		session.flagged = append(session.flagged, addr+"="+string(verdict))
+	}
+
+	session.rcpts = append(session.rcpts, addr)
+	text.PrintfLine("250 2.1.5 Ok")
+}
+
+func (p *SMTPProxy) handleData(text *textproto.Conn, session *proxySession) {
+	if len(session.rcpts) == 0 {
+		text.PrintfLine("503 5.5.1 Need RCPT command")
+		return
+	}
+	text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+
+	limit := p.config.SMTPProxyMaxMessageSize
+	dot := text.DotReader()
+	body, err := io.ReadAll(io.LimitReader(dot, limit+1))
+	if err != nil {
+		return
+	}
+	// Drain the rest of an oversized message before answering
+	if int64(len(body)) > limit {
+		io.Copy(io.Discard, dot)
+		text.PrintfLine("552 5.3.4 Message size exceeds fixed limit")
+		session.reset()
+		return
+	}
+
+	if len(session.flagged) > 0 {
+		header := fmt.Sprintf("%s: %s\r\n", verifier.RecipientHeader, strings.Join(session.flagged, "; "))
+		body = append([]byte(header), body...)
+	}
+
+	if err := p.relay(session.from, session.rcpts, body); err != nil {
+		var tpErr *textproto.Error
+		if errors.As(err, &tpErr) {
+			text.PrintfLine("%d %s", tpErr.Code, tpErr.Msg)
+		} else {
+			log.Printf("SMTP proxy relay failed: %v", err)
+			text.PrintfLine("451 4.4.1 Upstream unavailable, try again later")
+		}
+		session.reset()
+		return
+	}
+
+	text.PrintfLine("250 2.0.0 Ok: queued")
+	session.reset()
+}
+
+// relay hands an accepted message to the upstream MTA
+func (p *SMTPProxy) relay(from string, rcpts []string, body []byte) error {
+	conn, err := net.DialTimeout("tcp", p.config.SMTPProxyUpstream, p.config.SMTPConnectTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(proxyCommandTimeout))
+
+	host, _, _ := net.SplitHostPort(p.config.SMTPProxyUpstream)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Hello(p.config.EHLOHostname); err != nil {
+		return err
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range rcpts {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, bytes.NewReader(body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// parsePathArg extracts the address from "FROM:<addr> PARAMS" style arguments
+func parsePathArg(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(path, "<") {
+		return "", false
+	}
+	end := strings.Index(path, ">")
+	if end < 0 {
+		return "", false
+	}
+	return path[1:end], true
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// TENANT ENCRYPTION KEYS
+// ============================================================================
+
+// PUT /v1/encryption-key registers the caller's public key (see
+// pkg/verifier/tenant-encryption.go); from then on its job result downloads
+// are encrypted to it. GET shows the key, DELETE goes back to plaintext.
+
+type encryptionKeyRequest struct {
+	Format    string `json:"format"`
+	PublicKey string `json:"public_key"`
+}
+
+func (s *Server) handleGetEncryptionKey(w http.ResponseWriter, r *http.Request) {
+	key, err := s.verifier.EncryptionKey(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load encryption key")
+		return
+	}
+	if key == nil {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "No encryption key registered")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
+
+func (s *Server) handleSetEncryptionKey(w http.ResponseWriter, r *http.Request) {
+	var req encryptionKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Invalid request body")
+		return
+	}
+	key, err := verifier.ParseEncryptionKey(req.Format, req.PublicKey)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+		return
+	}
+
+	err = s.verifier.SetEncryptionKey(r.Context(), key)
+	if errors.Is(err, verifier.ErrNoTenant) {
+		writeError(w, r, http.StatusUnauthorized, ErrUnauthorized, "An API key is required")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to save encryption key")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
+
+func (s *Server) handleDeleteEncryptionKey(w http.ResponseWriter, r *http.Request) {
+	err := s.verifier.DeleteEncryptionKey(r.Context())
+	if errors.Is(err, verifier.ErrNoTenant) {
+		writeError(w, r, http.StatusUnauthorized, ErrUnauthorized, "An API key is required")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to delete encryption key")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// errNotJobOwner refuses to export a job to anyone but the tenant that owns it
+var errNotJobOwner = errors.New("job belongs to another tenant")
+
+// encryptedDownload returns where to write a file of job's data named
+// filename: w itself, or an encrypting writer over w when the job's owner
+// has registered a key. Only the owner gets the file, so the key that
+// applies is always the one registered for the data. finish must run after
+// the last write.
+func (s *Server) encryptedDownload(w http.ResponseWriter, r *http.Request, job *Job, filename string) (io.Writer, func(), error) {
+	if job.Tenant != verifier.TenantFrom(r.Context()) {
+		return nil, nil, errNotJobOwner
+	}
+	key, err := s.verifier.EncryptionKey(verifier.WithTenant(r.Context(), job.Tenant))
+	if err != nil || key == nil {
+		return w, func() {}, err
+	}
+	enc, err := key.Encrypt(w, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+key.FileExtension()))
+	w.Header().Set("X-Encryption", key.Format)
+	w.Header().Set("X-Encryption-Key", key.Fingerprint)
+	return enc, func() { enc.Close() }, nil
+}
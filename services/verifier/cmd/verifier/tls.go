@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// API SERVER TLS
+// ============================================================================
+
+// The API serves HTTPS when server.tls has a certificate:
+//
+//   - cert_file/key_file: a PEM pair, as mounted by cert-manager or a
+//     secrets agent. The files are checked for changes at most every
+//     certReloadInterval, so renewed certificates are picked up without a
+//     restart.
+//   - autocert_domains: certificates from Let's Encrypt, answered through
+//     the TLS-ALPN-01 challenge, so the API must be reachable on port 443
+//     under each name. Issued certificates are kept in autocert_cache_dir.
+//
+// With client_ca_file, clients present certificates chaining to those CAs:
+// client_auth "require" refuses connections without one (service-to-service
+// deployments), "optional" checks those that are offered. Load balancer and
+// Kubernetes health probes do not present certificates, so they need
+// "optional" or a probe that does.
+
+const certReloadInterval = time.Minute
+
+// Client certificate policies
+const (
+	ClientAuthRequire  = "require"
+	ClientAuthOptional = "optional"
+)
+
+// serverTLSConfig returns the API's TLS settings, or nil to serve plain HTTP
+func serverTLSConfig(config *verifier.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch {
+	case config.TLSCertFile != "" && len(config.TLSAutocertDomains) > 0:
+		return nil, errors.New("set either cert_file or autocert_domains")
+	case config.TLSCertFile != "":
+		if config.TLSKeyFile == "" {
+			return nil, errors.New("key_file is required with cert_file")
+		}
+		pair, err := newCertReloader(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetCertificate = pair.getCertificate
+	case len(config.TLSAutocertDomains) > 0:
+		cacheDir := config.TLSAutocertCacheDir
+		if cacheDir == "" {
+			dir, err := os.UserCacheDir()
+			if err != nil {
+				return nil, fmt.Errorf("autocert_cache_dir: %w", err)
+			}
+			cacheDir = filepath.Join(dir, "email-validator", "autocert")
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(config.TLSAutocertDomains...),
+			Cache:      autocert.DirCache(cacheDir),
+			Email:      config.TLSAutocertEmail,
+		}
+		tlsConfig.GetCertificate = manager.GetCertificate
+		tlsConfig.NextProtos = []string{"h2", "http/1.1", "acme-tls/1"}
+	default:
+		if config.TLSClientCAFile != "" {
+			return nil, errors.New("client_ca_file needs cert_file or autocert_domains")
+		}
+		return nil, nil
+	}
+
+	if config.TLSClientCAFile == "" {
+		if config.TLSClientAuth != "" {
+			return nil, errors.New("client_auth needs client_ca_file")
+		}
+		return tlsConfig, nil
+	}
+	pem, err := os.ReadFile(config.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("client_ca_file: %w", err)
+	}
+	tlsConfig.ClientCAs = x509.NewCertPool()
+	if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("client_ca_file: no PEM certificates in %s", config.TLSClientCAFile)
+	}
+	switch config.TLSClientAuth {
+	case ClientAuthRequire, "":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	case ClientAuthOptional:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("client_auth must be %s or %s", ClientAuthRequire, ClientAuthOptional)
+	}
+	return tlsConfig, nil
+}
+
+// certReloader serves a certificate pair from disk, reloading it after the
+// files change
+type certReloader struct {
+	certFile, keyFile string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) load() error {
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert, r.modTime = &cert, info.ModTime()
+	return nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checkedAt) < certReloadInterval {
+		return r.cert, nil
+	}
+	r.checkedAt = time.Now()
+	if info, err := os.Stat(r.certFile); err == nil && !info.ModTime().Equal(r.modTime) {
+		// A half-written pair fails to load; the old one is served meanwhile
+		if err := r.load(); err != nil {
+			slog.Warn("TLS certificate not reloaded, keeping the current one", "path", r.certFile, "error", err)
+		} else {
+			slog.Info("TLS certificate reloaded", "path", r.certFile)
+		}
+	}
+	return r.cert, nil
+}
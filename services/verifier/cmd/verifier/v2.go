@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// V2 API
+// ============================================================================
+
+// v2 results are v1 results plus a checks object saying what each check
+// found (see pkg/verifier/checks.go). v1 responses are unchanged.
+
+type ValidateV2Response struct {
+	*verifier.ValidationResult
+	Checks verifier.Checks `json:"checks"`
+}
+
+func (s *Server) handleValidateV2(w http.ResponseWriter, r *http.Request) {
+	result, ok := s.validate(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ValidateV2Response{ValidationResult: result, Checks: verifier.BuildChecks(result)})
+}
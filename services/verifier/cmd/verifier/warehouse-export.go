@@ -0,0 +1,146 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// WAREHOUSE EXPORT
+// ============================================================================
+
+// Completed UTC days of usage stats are written once as gzipped CSV under a
+// Hive-style partition, so BigQuery, Athena or Snowflake external tables can
+// read them directly:
+//
+//	{dir}/verification_stats/day=2025-11-20/verification_stats.csv.gz
+//
+// dir is usually a mounted bucket (gcsfuse, s3fs) or a volume synced to
+// object storage. One replica exports each day; the rest skip it.
+
+const (
+	exportLockTTL  = 10 * time.Minute
+	exportDoneTTL  = 30 * 24 * time.Hour
+	exportLookback = 7 // Days retried after an outage; usage stats live 8
+)
+
+var exportColumns = []string{"day", "tenant", "domain", "status", "verifications", "cache_hits", "total_duration_ms"}
+
+type WarehouseExporter struct {
+	verifier *verifier.SMTPVerifier
+	dirs     map[string]string // Residency region ("" = home) -> export dir
+}
+
+// NewWarehouseExporter exports home usage to warehouse_export.dir and each
+// residency region's usage to its own warehouse_export_dir, never mixing them
+func NewWarehouseExporter(v *verifier.SMTPVerifier, config *verifier.Config) *WarehouseExporter {
+	dirs := make(map[string]string)
+	if config.WarehouseExportDir != "" {
+		dirs[""] = config.WarehouseExportDir
+	}
+	for _, region := range config.Regions {
+		if region.WarehouseExportDir != "" {
+			dirs[region.Name] = region.WarehouseExportDir
+		}
+	}
+	return &WarehouseExporter{verifier: v, dirs: dirs}
+}
+
+// Export writes the completed days not exported yet; the scheduler runs it
+// as warehouse_export
+func (e *WarehouseExporter) Export(ctx context.Context) error {
+	for region, dir := range e.dirs {
+		e.exportPending(verifier.WithRegion(ctx, region), dir)
+	}
+	return nil
+}
+
+// exportPending exports every completed day in the lookback window that
+// no replica has exported yet. Markers live next to the stats, in the
+// region's Redis.
+func (e *WarehouseExporter) exportPending(ctx context.Context, dir string) {
+	store := e.verifier.TenantStore(ctx)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for i := exportLookback; i >= 1; i-- {
+		day := today.AddDate(0, 0, -i)
+		stamp := day.Format("20060102")
+
+		done, err := store.Exists(ctx, "export:done:"+stamp).Result()
+		if err != nil || done > 0 {
+			continue
+		}
+		locked, err := store.SetNX(ctx, "lock:export:"+stamp, "export", exportLockTTL).Result()
+		if err != nil || !locked {
+			continue
+		}
+
+		rows, err := e.exportDay(ctx, dir, day)
+		if err != nil {
+			slog.ErrorContext(ctx, "warehouse export failed", "region", e.verifier.TenantRegion(ctx), "day", stamp, "error", err)
+			store.Del(ctx, "lock:export:"+stamp)
+			continue
+		}
+		store.Set(ctx, "export:done:"+stamp, rows, exportDoneTTL)
+		store.Del(ctx, "lock:export:"+stamp)
+		slog.InfoContext(ctx, "warehouse export written", "region", e.verifier.TenantRegion(ctx), "day", stamp, "rows", rows)
+	}
+}
+
+// exportDay writes one day's partition, replacing any earlier file
+// atomically, and returns the number of rows
+func (e *WarehouseExporter) exportDay(ctx context.Context, dir string, day time.Time) (int, error) {
+	rows, err := e.verifier.UsageStats(ctx, day)
+	if err != nil {
+		return 0, fmt.Errorf("read usage stats: %w", err)
+	}
+
+	partition := filepath.Join(dir, "verification_stats", "day="+day.Format("2006-01-02"))
+	if err := os.MkdirAll(partition, 0o755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(partition, ".verification_stats-*.csv.gz")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	w := csv.NewWriter(gz)
+	w.Write(exportColumns)
+	for _, row := range rows {
+		w.Write([]string{
+			row.Day,
+			row.Tenant,
+			row.Domain,
+			string(row.Status),
+			strconv.FormatInt(row.Verifications, 10),
+			strconv.FormatInt(row.CacheHits, 10),
+			strconv.FormatInt(row.TotalDurationMs, 10),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(partition, "verification_stats.csv.gz")); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// WEBHOOK CALLBACKS
+// ============================================================================
+
+// A batch sent with callback_url is answered 202 with the webhook ID; the
+// results arrive as a batch.completed webhook (see pkg/verifier/webhooks.go)
+// and GET /v1/webhooks/{id} tracks the delivery.
+
+type BatchCallbackResponse struct {
+	WebhookID string `json:"webhook_id"`
+	Status    string `json:"status"`
+}
+
+// submitBatchWithCallback queues the batch in the background and answers
+// at once
+func (s *Server) submitBatchWithCallback(w http.ResponseWriter, r *http.Request, req BatchValidateRequest) {
+	delivery, err := s.verifier.CreateWebhook(r.Context(), req.CallbackURL, verifier.WebhookBatchCompleted)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to register callback")
+		return
+	}
+
+	// Outlives the request, like clean-list jobs (see drain.go)
+	ctx := verifier.WithRequestID(s.jobsCtx, verifier.RequestIDFrom(r.Context()))
+	ctx = verifier.WithTenant(ctx, verifier.TenantFrom(r.Context()))
+	s.goJob(func() { s.runBatchWithCallback(ctx, req, delivery) })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(BatchCallbackResponse{WebhookID: delivery.ID, Status: delivery.Status})
+}
+
+func (s *Server) runBatchWithCallback(ctx context.Context, req BatchValidateRequest, delivery *verifier.WebhookDelivery) {
+	results, err := s.queue.Submit(ctx, req.Priority, req.Emails)
+	if err != nil {
+		slog.ErrorContext(ctx, "callback batch failed", "webhook_id", delivery.ID, "error", err)
+		s.verifier.SendWebhook(context.WithoutCancel(ctx), delivery, map[string]string{"error": "batch validation failed"})
+		return
+	}
+	for _, result := range results {
+		req.ClientMetadata.Attach(result)
+	}
+	s.verifier.SendWebhook(ctx, delivery, BatchValidateResponse{Results: results})
+}
+
+func (s *Server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	delivery, err := s.verifier.GetWebhook(r.Context(), mux.Vars(r)["id"])
+	if err == redis.Nil {
+		writeError(w, r, http.StatusNotFound, ErrNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to load webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourusername/email-validator/pkg/verifier"
+)
+
+// ============================================================================
+// LIST CLEANING WORKFLOW
+// ============================================================================
+
+const (
+	maxWorkflowEmails   = 100000
+	workflowVerifyChunk = 100
+)
+
+// Workflow stages, in execution order
+const (
+	StageUpload    = "upload"
+	StageDedupe    = "dedupe"
+	StageNormalize = "normalize"
+	StageVerify    = "verify"
+	StageScore     = "score"
+	StageSegment   = "segment"
+	StageExport    = "export"
+)
+
+var cleanListStages = []string{StageUpload, StageDedupe, StageNormalize, StageVerify, StageScore, StageSegment, StageExport}
+
+type CleanListRequest struct {
+	Emails      []string `json:"emails"`
+	OnDuplicate string   `json:"on_duplicate,omitempty"` // warn or reuse; defaults to jobs.duplicate_action
+	InputURL    string   `json:"input_url,omitempty"`    // s3:// or gs:// object instead of emails
+	OutputURL   string   `json:"output_url,omitempty"`   // s3:// or gs:// prefix for the results
+	verifier.ClientMetadata
+}
+
+var errJobInterrupted = errors.New("interrupted by a service restart; submit the list again")
+
+// CleanListResult is one exported row of a clean-list job
+type CleanListResult struct {
+	*verifier.ValidationResult
+	Score   int    `json:"score"`
+	Segment string `json:"segment"`
+}
+
+func (s *Server) handleCleanListWorkflow(w http.ResponseWriter, r *http.Request) {
+	req, err := readEmailList(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if req.InputURL != "" || req.OutputURL != "" {
+		s.startObjectListJob(w, r, req)
+		return
+	}
+	emails := req.Emails
+
+	if len(emails) == 0 {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "Emails are required")
+		return
+	}
+
+	if len(emails) > maxWorkflowEmails {
+		writeErrorDetails(w, r, http.StatusBadRequest, ErrBatchTooLarge, "Maximum 100000 emails per workflow", map[string]interface{}{"max": 100000})
+		return
+	}
+
+	if err := req.ClientMetadata.Validate(); err != nil {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+		return
+	}
+
+	if !s.checkQuota(w, r, len(emails)) {
+		return
+	}
+
+	action := req.OnDuplicate
+	if action == "" {
+		action = s.config.DuplicateJobAction
+	}
+	if !validDuplicateAction(action) {
+		writeError(w, r, http.StatusBadRequest, ErrInvalidRequest, "on_duplicate must be warn or reuse")
+		return
+	}
+
+	// Same list submitted recently: reuse the earlier job or flag the new one
+	fingerprint := listFingerprint(emails)
+	var prior *Job
+	if s.config.DuplicateJobWindow > 0 {
+		prior, err = s.jobs.FindDuplicate(r.Context(), "clean_list", fingerprint)
+		if err != nil {
+			slog.WarnContext(r.Context(), "duplicate list check failed", "error", err)
+		}
+	}
+	if prior != nil && action == verifier.DuplicateReuse {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(prior)
+		return
+	}
+
+	job, err := s.jobs.Create(r.Context(), "clean_list", req.ClientMetadata, cleanListStages...)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, ErrInternal, "Failed to create job")
+		return
+	}
+	job.Fingerprint = fingerprint
+	if prior != nil {
+		job.DuplicateOf = prior.ID
+	}
+	if s.config.DuplicateJobWindow > 0 {
+		s.jobs.RememberFingerprint(r.Context(), job, s.config.DuplicateJobWindow)
+	}
+
+	// Upload is complete once the list is parsed and the job exists
+	job.TotalEmails = len(emails)
+	job.startStage(StageUpload, len(emails))
+	job.completeStage(StageUpload)
+	s.jobs.Save(r.Context(), job)
+
+	// Runs past the request; cancelled only when a shutdown outlasts the
+	// grace period (see drain.go)
+	ctx := verifier.WithRequestID(s.jobsCtx, verifier.RequestIDFrom(r.Context()))
+	ctx = verifier.WithTenant(ctx, verifier.TenantFrom(r.Context()))
+	s.goJob(func() { s.runCleanListWorkflow(ctx, job, emails) })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// runCleanListWorkflow executes every stage after upload, saving progress as
+// it goes so GET /v1/jobs/{id} reflects the current stage.
+func (s *Server) runCleanListWorkflow(ctx context.Context, job *Job, emails []string) {
+	now := time.Now()
+	job.Status = JobProcessing
+	job.StartedAt = &now
+	s.jobs.Save(ctx, job)
+
+	fail := func(err error) {
+		slog.ErrorContext(ctx, "clean-list job failed", "job_id", job.ID, "error", err)
+		job.Status = JobFailed
+		job.Error = err.Error()
+		s.jobs.Save(context.WithoutCancel(ctx), job)
+	}
+
+	// Dedupe: exact duplicates, ignoring case and surrounding whitespace
+	job.startStage(StageDedupe, len(emails))
+	seen := make(map[string]bool, len(emails))
+	unique := emails[:0]
+	for _, email := range emails {
+		key := strings.ToLower(strings.TrimSpace(email))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, email)
+	}
+	emails = unique
+	job.completeStage(StageDedupe)
+	s.jobs.Save(ctx, job)
+
+	// Normalize
+	job.startStage(StageNormalize, len(emails))
+	for i, email := range emails {
+		emails[i] = strings.ToLower(strings.TrimSpace(email))
+	}
+	job.completeStage(StageNormalize)
+	s.jobs.Save(ctx, job)
+
+	// Verify in chunks so progress is visible while SMTP checks run
+	verifyStage := job.startStage(StageVerify, len(emails))
+	results := make([]*verifier.ValidationResult, 0, len(emails))
+	for start := 0; start < len(emails); start += workflowVerifyChunk {
+		if ctx.Err() != nil {
+			fail(errJobInterrupted)
+			return
+		}
+		end := start + workflowVerifyChunk
+		if end > len(emails) {
+			end = len(emails)
+		}
+		results = append(results, s.verifier.VerifyBatch(ctx, emails[start:end])...)
+		verifyStage.Processed = len(results)
+		job.EmailsProcessed = len(results)
+		s.jobs.Save(ctx, job)
+	}
+	if ctx.Err() != nil {
+		fail(errJobInterrupted)
+		return
+	}
+	job.completeStage(StageVerify)
+
+	// Score
+	job.startStage(StageScore, len(results))
+	rows := make([]*CleanListResult, len(results))
+	for i, result := range results {
+		job.ClientMetadata.Attach(result)
+		rows[i] = &CleanListResult{ValidationResult: result, Score: verifier.ScoreResult(result)}
+	}
+	job.completeStage(StageScore)
+
+	// Segment
+	job.startStage(StageSegment, len(rows))
+	job.StatusCounts = make(map[string]int)
+	job.SegmentCounts = make(map[string]int)
+	for _, row := range rows {
+		row.Segment = verifier.SegmentFor(row.Status)
+		job.StatusCounts[string(row.Status)]++
+		job.SegmentCounts[row.Segment]++
+	}
+	job.completeStage(StageSegment)
+	s.jobs.Save(ctx, job)
+
+	// Export
+	exportStage := job.startStage(StageExport, len(rows))
+	for start := 0; start < len(rows); start += workflowVerifyChunk {
+		end := start + workflowVerifyChunk
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batch := make([]interface{}, 0, end-start)
+		for _, row := range rows[start:end] {
+			batch = append(batch, row)
+		}
+		if err := s.jobs.AppendResults(ctx, job.ID, batch); err != nil {
+			fail(err)
+			return
+		}
+		exportStage.Processed = end
+	}
+	job.completeStage(StageExport)
+
+	completed := time.Now()
+	job.Status = JobCompleted
+	job.CompletedAt = &completed
+	s.jobs.Save(ctx, job)
+}
+
+// readEmailList accepts either a JSON body ({"emails": [...]}) or a CSV
+// upload. For CSV, an "email" header column is used when present, otherwise
+// the first column; tags and metadata come from tag= and meta.<key>= query
+// parameters.
+func readEmailList(r *http.Request) (*CleanListRequest, error) {
+	var req CleanListRequest
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, errors.New("invalid JSON body")
+		}
+		return &req, nil
+	}
+	req.ClientMetadata = metadataFromQuery(r.URL.Query())
+	req.OnDuplicate = r.URL.Query().Get("on_duplicate")
+
+	reader := newEmailReader(r.Body)
+	for {
+		email, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid CSV upload")
+		}
+		req.Emails = append(req.Emails, email)
+	}
+	return &req, nil
+}
+
+// emailReader reads the address column of a CSV list one row at a time
+type emailReader struct {
+	csv    *csv.Reader
+	column int
+	line   int
+}
+
+func newEmailReader(r io.Reader) *emailReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	return &emailReader{csv: reader}
+}
+
+// Next returns the next address, or io.EOF after the last row
+func (er *emailReader) Next() (string, error) {
+	for {
+		record, err := er.csv.Read()
+		if err != nil {
+			return "", err
+		}
+
+		if er.line++; er.line == 1 {
+			header := false
+			for i, field := range record {
+				if strings.EqualFold(strings.TrimSpace(field), "email") {
+					er.column, header = i, true
+					break
+				}
+			}
+			if header {
+				continue
+			}
+		}
+
+		if er.column < len(record) {
+			return record[er.column], nil
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package verifier
+
+// ============================================================================
+// ACCOUNT TYPE
+// ============================================================================
+
+// Google and Microsoft host both free consumer mailboxes and paid business
+// ones behind the same MX hosts, and B2B users need to tell them apart.
+// Google's MX hosts say nothing either way, so a Google domain on the free
+// provider list (gmail.com, googlemail.com) is consumer and any other is a
+// Workspace customer. Microsoft splits its hosts: Outlook.com/Hotmail mail
+// arrives at olc.protection.outlook.com, Microsoft 365 tenants at
+// mail.protection.outlook.com. The platform comes from the probed MX host,
+// or the first MX record for checks that stop at DNS. Domains answered by a
+// directory connector are Workspace or Microsoft 365 tenants by definition.
+
+// Account types
+const (
+	AccountConsumer = "consumer"
+	AccountBusiness = "business"
+)
+
+// accountType returns the account type of a result at Google or Microsoft,
+// or "" elsewhere. IsFreeProvider must already be set.
+func (v *SMTPVerifier) accountType(r *ValidationResult) string {
+	switch r.DirectoryAttributes["source"] {
+	case DirectoryGoogleWorkspace, DirectoryMicrosoftGraph:
+		return AccountBusiness
+	}
+	provider := r.Provider
+	if provider == "" && len(r.MXRecords) > 0 && !isNullMX(r.MXRecords) {
+		if hint := v.providerFor(r.MXRecords[0].Exchange); hint != nil {
+			provider = hint.Name
+		}
+	}
+	switch provider {
+	case "google":
+		if r.IsFreeProvider {
+			return AccountConsumer
+		}
+		return AccountBusiness
+	case "microsoft_consumer":
+		return AccountConsumer
+	case "microsoft365":
+		return AccountBusiness
+	}
+	return ""
+}
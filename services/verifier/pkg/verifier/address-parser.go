@@ -0,0 +1,256 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// ============================================================================
+// ADDRESS PARSER
+// ============================================================================
+
+// parseAddress implements the mailbox grammar of RFC 5321 section 4.1.2 (as
+// extended for UTF-8 by RFC 6531/6532):
+//
+//	Mailbox    = Local-part "@" Domain
+//	Local-part = Dot-string / Quoted-string    ; at most 64 octets
+//	Dot-string = Atom *("." Atom)
+//	Domain     = sub-domain *("." sub-domain)  ; at most 255 octets
+//
+// Comments, folding whitespace and obsolete forms from RFC 5322 are not
+// accepted; they never appear in an address someone means to mail. Address
+// literals ([192.0.2.1]) are recognized but refused, since no MX routes to
+// them. A failure is an *AddressError whose Code ends up in the result
+// reason as "syntax_error: <code>".
+
+const (
+	maxLocalPartLength = 64  // Octets, RFC 5321 4.5.3.1.1
+	maxDomainLength    = 255 // Octets, RFC 5321 4.5.3.1.2
+	maxLabelLength     = 63
+)
+
+// Address syntax error codes
+const (
+	AddrMissingAt           = "missing_at"
+	AddrEmptyLocalPart      = "empty_local_part"
+	AddrLocalPartTooLong    = "local_part_too_long"
+	AddrLeadingDot          = "leading_dot"
+	AddrTrailingDot         = "trailing_dot"
+	AddrConsecutiveDots     = "consecutive_dots"
+	AddrInvalidCharacter    = "invalid_character"
+	AddrInvalidUTF8         = "invalid_utf8"
+	AddrUnterminatedQuote   = "unterminated_quote"
+	AddrEmptyDomain         = "empty_domain"
+	AddrDomainTooLong       = "domain_too_long"
+	AddrAddressLiteral      = "address_literal"
+	AddrInvalidIDN          = "invalid_idn"
+	AddrEmptyLabel          = "empty_label"
+	AddrLabelTooLong        = "label_too_long"
+	AddrInvalidDomainChar   = "invalid_domain_character"
+	AddrHyphenAtLabelEdge   = "hyphen_at_label_edge"
+	AddrSingleLabelDomain   = "single_label_domain"
+	AddrInvalidTopLevelName = "invalid_tld"
+)
+
+var addressErrorMessages = map[string]string{
+	AddrMissingAt:           "no @ separating the local part from the domain",
+	AddrEmptyLocalPart:      "nothing before the @",
+	AddrLocalPartTooLong:    "local part is longer than 64 octets",
+	AddrLeadingDot:          "local part starts with a dot",
+	AddrTrailingDot:         "local part ends with a dot",
+	AddrConsecutiveDots:     "local part has two dots in a row",
+	AddrInvalidCharacter:    "character not allowed in an unquoted local part",
+	AddrInvalidUTF8:         "address is not valid UTF-8",
+	AddrUnterminatedQuote:   "quoted local part is not closed, or text follows the closing quote",
+	AddrEmptyDomain:         "nothing after the @",
+	AddrDomainTooLong:       "domain is longer than 255 octets",
+	AddrAddressLiteral:      "domain is an address literal, which has no MX to route through",
+	AddrInvalidIDN:          "internationalized domain cannot be converted to an A-label",
+	AddrEmptyLabel:          "domain has an empty label (leading, trailing or doubled dot)",
+	AddrLabelTooLong:        "domain label is longer than 63 octets",
+	AddrInvalidDomainChar:   "character not allowed in a domain name",
+	AddrHyphenAtLabelEdge:   "domain label starts or ends with a hyphen",
+	AddrSingleLabelDomain:   "domain has no top-level domain",
+	AddrInvalidTopLevelName: "top-level domain must be letters or an xn-- label",
+}
+
+// AddressError says where and why an address failed to parse
+type AddressError struct {
+	Code string // One of the Addr* codes
+	Pos  int    // Byte offset in the address
+}
+
+func (e *AddressError) Error() string {
+	return fmt.Sprintf("%s (offset %d)", addressErrorMessages[e.Code], e.Pos)
+}
+
+func addrErr(code string, pos int) *AddressError {
+	return &AddressError{Code: code, Pos: pos}
+}
+
+// parseAddress checks email's syntax and returns its domain as a lowercase
+// ASCII A-label
+func parseAddress(email string) (string, *AddressError) {
+	if !utf8.ValidString(email) {
+		return "", addrErr(AddrInvalidUTF8, 0)
+	}
+
+	var at int
+	var err *AddressError
+	if strings.HasPrefix(email, `"`) {
+		at, err = scanQuotedString(email)
+	} else {
+		at, err = scanDotString(email)
+	}
+	if err != nil {
+		return "", err
+	}
+	if at == 0 {
+		return "", addrErr(AddrEmptyLocalPart, 0)
+	}
+	if at > maxLocalPartLength {
+		return "", addrErr(AddrLocalPartTooLong, maxLocalPartLength)
+	}
+
+	return parseDomain(email[at+1:], at+1)
+}
+
+// scanDotString reads Atom *("." Atom) and returns the offset of the @
+func scanDotString(email string) (int, *AddressError) {
+	prevDot := true // A dot at offset 0 is a leading dot
+	for i, r := range email {
+		switch {
+		case r == '@':
+			if i > 0 && prevDot {
+				return 0, addrErr(AddrTrailingDot, i-1)
+			}
+			return i, nil
+		case r == '.':
+			if i == 0 {
+				return 0, addrErr(AddrLeadingDot, i)
+			}
+			if prevDot {
+				return 0, addrErr(AddrConsecutiveDots, i)
+			}
+			prevDot = true
+		case isAtext(r):
+			prevDot = false
+		default:
+			return 0, addrErr(AddrInvalidCharacter, i)
+		}
+	}
+	return 0, addrErr(AddrMissingAt, len(email))
+}
+
+// scanQuotedString reads DQUOTE *QcontentSMTP DQUOTE and returns the offset
+// of the @ that must follow it
+func scanQuotedString(email string) (int, *AddressError) {
+	escaped := false
+	for i, r := range email {
+		if i == 0 {
+			continue
+		}
+		switch {
+		case escaped:
+			// quoted-pairSMTP: backslash followed by any printable ASCII or space
+			if r < ' ' || r > '~' {
+				return 0, addrErr(AddrInvalidCharacter, i)
+			}
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			if i+1 >= len(email) || email[i+1] != '@' {
+				return 0, addrErr(AddrUnterminatedQuote, i)
+			}
+			return i + 1, nil
+		case !isQtext(r):
+			return 0, addrErr(AddrInvalidCharacter, i)
+		}
+	}
+	return 0, addrErr(AddrUnterminatedQuote, len(email))
+}
+
+// isAtext reports an atext character: ASCII letters, digits and
+// !#$%&'*+-/=?^_`{|}~, or printable non-ASCII (RFC 6532)
+func isAtext(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r < utf8.RuneSelf:
+		return strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r)
+	}
+	return unicode.IsPrint(r) && !unicode.IsSpace(r)
+}
+
+// isQtext reports a character allowed unescaped inside quotes: printable
+// ASCII and space except " and \, or printable non-ASCII
+func isQtext(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r >= ' ' && r <= '~' && r != '"' && r != '\\'
+	}
+	return unicode.IsPrint(r)
+}
+
+// parseDomain validates domain, which starts at offset in the address, and
+// returns it as a lowercase A-label
+func parseDomain(domain string, offset int) (string, *AddressError) {
+	if domain == "" {
+		return "", addrErr(AddrEmptyDomain, offset)
+	}
+	if strings.HasPrefix(domain, "[") {
+		return "", addrErr(AddrAddressLiteral, offset)
+	}
+	if !isASCII(domain) {
+		ascii, err := idna.Lookup.ToASCII(domain)
+		if err != nil {
+			return "", addrErr(AddrInvalidIDN, offset)
+		}
+		domain = ascii
+	}
+	domain = strings.ToLower(domain)
+	if len(domain) > maxDomainLength {
+		return "", addrErr(AddrDomainTooLong, offset+maxDomainLength)
+	}
+
+	labels := strings.Split(domain, ".")
+	pos := offset
+	for _, label := range labels {
+		if err := checkLabel(label, pos); err != nil {
+			return "", err
+		}
+		pos += len(label) + 1
+	}
+	if len(labels) < 2 {
+		return "", addrErr(AddrSingleLabelDomain, offset+len(domain))
+	}
+	tld := labels[len(labels)-1]
+	if !strings.HasPrefix(tld, "xn--") && (len(tld) < 2 || strings.IndexFunc(tld, func(r rune) bool { return r < 'a' || r > 'z' }) >= 0) {
+		return "", addrErr(AddrInvalidTopLevelName, pos-len(tld)-1)
+	}
+	return domain, nil
+}
+
+// checkLabel enforces letters, digits and hyphens (RFC 1035 2.3.1, relaxed
+// by RFC 1123 to allow a leading digit)
+func checkLabel(label string, pos int) *AddressError {
+	switch {
+	case label == "":
+		return addrErr(AddrEmptyLabel, pos)
+	case len(label) > maxLabelLength:
+		return addrErr(AddrLabelTooLong, pos+maxLabelLength)
+	case label[0] == '-' || label[len(label)-1] == '-':
+		return addrErr(AddrHyphenAtLabelEdge, pos)
+	}
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return addrErr(AddrInvalidDomainChar, pos+i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// BATCH WORKER POOL
+// ============================================================================
+
+// domainLane holds the pending batch positions for one domain
+type domainLane struct {
+	domain  string
+	mx      *mxGroup
+	indexes []int
+	active  int
+}
+
+// mxGroup counts in-flight checks against one mail provider across the
+// domains it hosts
+type mxGroup struct {
+	host   string
+	active int
+}
+
+// batchScheduler hands work to a fixed pool of workers, rotating across
+// domains and capping how many workers one domain, and one MX host, may
+// occupy. A slow MX can therefore only stall its own lanes, never the whole
+// batch. Lanes are ordered by MX host so the rotation works through one
+// provider's domains back to back, keeping its pooled SMTP sessions warm,
+// and moves on once that provider is at its cap.
+type batchScheduler struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	lanes     []*domainLane
+	next      int
+	remaining int
+	perDomain int
+	perMX     int
+}
+
+// newBatchScheduler buckets emails by domain and the domains by MX host.
+// mxHost returns a domain's primary MX, or "" when it is not known yet; such
+// domains form a group of their own. perMX <= 0 leaves MX hosts uncapped.
+func newBatchScheduler(emails []string, perDomain, perMX int, mxHost func(domain string) string) *batchScheduler {
+	if perDomain <= 0 {
+		perDomain = 1
+	}
+
+	s := &batchScheduler{remaining: len(emails), perDomain: perDomain, perMX: perMX}
+	s.cond = sync.NewCond(&s.mu)
+
+	byDomain := make(map[string]*domainLane)
+	groups := make(map[string]*mxGroup)
+	for i, email := range emails {
+		domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+		lane, ok := byDomain[domain]
+		if !ok {
+			host := mxHost(domain)
+			if host == "" {
+				host = domain
+			}
+			group, ok := groups[host]
+			if !ok {
+				group = &mxGroup{host: host}
+				groups[host] = group
+			}
+			lane = &domainLane{domain: domain, mx: group}
+			byDomain[domain] = lane
+			s.lanes = append(s.lanes, lane)
+		}
+		lane.indexes = append(lane.indexes, i)
+	}
+
+	sort.SliceStable(s.lanes, func(i, j int) bool {
+		if s.lanes[i].mx.host != s.lanes[j].mx.host {
+			return s.lanes[i].mx.host < s.lanes[j].mx.host
+		}
+		return s.lanes[i].domain < s.lanes[j].domain
+	})
+	return s
+}
+
+// take blocks until a batch position is available on a lane with spare
+// capacity. It returns false once every position has been handed out.
+func (s *batchScheduler) take() (*domainLane, int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.remaining > 0 {
+		for n := 0; n < len(s.lanes); n++ {
+			i := (s.next + n) % len(s.lanes)
+			lane := s.lanes[i]
+			if len(lane.indexes) == 0 || lane.active >= s.perDomain {
+				continue
+			}
+			if s.perMX > 0 && lane.mx.active >= s.perMX {
+				continue
+			}
+
+			idx := lane.indexes[0]
+			lane.indexes = lane.indexes[1:]
+			lane.active++
+			lane.mx.active++
+			s.remaining--
+			s.next = (i + 1) % len(s.lanes)
+			return lane, idx, true
+		}
+		s.cond.Wait()
+	}
+
+	return nil, 0, false
+}
+
+func (s *batchScheduler) done(lane *domainLane) {
+	s.mu.Lock()
+	lane.active--
+	lane.mx.active--
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// VerifyBatch verifies emails concurrently using BatchConcurrency workers,
+// at most MaxConcurrentPerDomain in-flight checks per domain and
+// MaxConcurrentPerMX per MX host. Results keep the input order; failed
+// verifications become StatusUnknown results.
+func (v *SMTPVerifier) VerifyBatch(ctx context.Context, emails []string) []*ValidationResult {
+	results := make([]*ValidationResult, len(emails))
+	if len(emails) == 0 {
+		return results
+	}
+
+	workers := v.cfg().BatchConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(emails) {
+		workers = len(emails)
+	}
+
+	scheduler := newBatchScheduler(emails, v.cfg().MaxConcurrentPerDomain, v.cfg().MaxConcurrentPerMX, func(domain string) string {
+		return v.primaryMXHost(ctx, domain)
+	})
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				lane, i, ok := scheduler.take()
+				if !ok {
+					return
+				}
+				results[i] = v.verifyBatchEntry(ctx, emails[i])
+				scheduler.done(lane)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// primaryMXHost returns the domain's most preferred MX host from cache, or
+// "" on a miss; batches do not wait on DNS just to be ordered
+func (v *SMTPVerifier) primaryMXHost(ctx context.Context, domain string) string {
+	records, err := v.getCachedMXRecords(ctx, domain)
+	if err != nil || len(records) == 0 || isNullMX(records) {
+		return ""
+	}
+	best := records[0]
+	for _, mx := range records[1:] {
+		if mx.Priority < best.Priority {
+			best = mx
+		}
+	}
+	return strings.ToLower(strings.TrimSuffix(best.Exchange, "."))
+}
+
+func (v *SMTPVerifier) verifyBatchEntry(ctx context.Context, email string) *ValidationResult {
+	result, err := v.Verify(ctx, email)
+	if err != nil {
+		slog.WarnContext(ctx, "batch verification failed", "email_hash", hashEmail(email), "error", err)
+		return &ValidationResult{
+			Email:      email,
+			Status:     StatusUnknown,
+			Reason:     fmt.Sprintf("Verification error: %v", err),
+			Confidence: 0.0,
+			CheckedAt:  time.Now(),
+		}
+	}
+	return result
+}
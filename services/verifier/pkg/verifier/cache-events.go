@@ -0,0 +1,156 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// IN-MEMORY CACHE TIER AND COHERENCE EVENTS
+// ============================================================================
+
+// Domain metadata (including the catch-all verdict) is read on every
+// verification, so each replica keeps a short-lived copy in memory in front
+// of Redis. Whenever a replica changes the record it publishes a cache event;
+// every other replica drops its copy for that domain at once. The local TTL
+// bounds staleness if an event is missed (e.g. while the subscription
+// reconnects).
+
+const cacheEventsChannel = "cache:events"
+
+// Cache event kinds
+const (
+	CacheEventDomainUpdated     = "domain_updated"
+	CacheEventDomainInvalidated = "domain_invalidated"
+)
+
+type cacheEvent struct {
+	Origin string `json:"origin"` // instance ID of the publisher
+	Kind   string `json:"kind"`
+	Domain string `json:"domain"`
+}
+
+type localEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// localCache is a TTL map with a size cap; when full, expired entries are
+// swept and, failing that, the insert is skipped
+type localCache struct {
+	mu         sync.RWMutex
+	entries    map[string]localEntry
+	ttl        time.Duration
+	maxEntries int
+}
+
+func newLocalCache(ttl time.Duration, maxEntries int) *localCache {
+	return &localCache{entries: make(map[string]localEntry), ttl: ttl, maxEntries: maxEntries}
+}
+
+func (c *localCache) get(key string) (interface{}, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *localCache) set(key string, value interface{}) {
+	if c.ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.maxEntries {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			return
+		}
+	}
+	c.entries[key] = localEntry{value: value, expires: now.Add(c.ttl)}
+}
+
+// dropDomain removes every entry held for domain
+func (c *localCache) dropDomain(domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range domainCacheKeys(domain) {
+		delete(c.entries, key)
+	}
+}
+
+func (c *localCache) clear() {
+	c.mu.Lock()
+	c.entries = make(map[string]localEntry)
+	c.mu.Unlock()
+}
+
+// domainCacheKeys lists the Redis keys whose copies live in the local tier
+func domainCacheKeys(domain string) []string {
+	return []string{domainMetaKey(domain)}
+}
+
+// publishCacheEvent drops the local copy and tells the other replicas to do
+// the same
+func (v *SMTPVerifier) publishCacheEvent(ctx context.Context, kind, domain string) {
+	v.local.dropDomain(domain)
+	data, _ := json.Marshal(cacheEvent{Origin: v.instanceID, Kind: kind, Domain: domain})
+	if err := v.redis.Publish(ctx, cacheEventsChannel, data).Err(); err != nil {
+		slog.WarnContext(ctx, "cache event publish failed", "domain", domain, "error", err)
+	}
+}
+
+// InvalidateDomain deletes the shared domain records, including a cached
+// no-MX answer, and every replica's copy
+func (v *SMTPVerifier) InvalidateDomain(ctx context.Context, domain string) error {
+	keys := append(domainCacheKeys(domain), mxNegativeKey(domain), domainAuthKey(domain))
+	if err := v.redis.Del(ctx, keys...).Err(); err != nil {
+		return err
+	}
+	v.publishCacheEvent(ctx, CacheEventDomainInvalidated, domain)
+	return nil
+}
+
+// RunCacheEvents applies other replicas' events until ctx is cancelled
+func (v *SMTPVerifier) RunCacheEvents(ctx context.Context) {
+	for ctx.Err() == nil {
+		sub := v.redis.Subscribe(ctx, cacheEventsChannel)
+		if _, err := sub.Receive(ctx); err != nil {
+			sub.Close()
+			if ctx.Err() == nil {
+				slog.Warn("cache events subscribe failed", "error", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		// Events may have been missed while unsubscribed
+		v.local.clear()
+
+		for {
+			msg, err := sub.ReceiveMessage(ctx)
+			if err != nil {
+				break
+			}
+			var event cacheEvent
+			if json.Unmarshal([]byte(msg.Payload), &event) != nil || event.Origin == v.instanceID {
+				continue
+			}
+			v.local.dropDomain(event.Domain)
+		}
+		sub.Close()
+	}
+}
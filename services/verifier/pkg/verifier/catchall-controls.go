@@ -0,0 +1,217 @@
+package verifier
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// CATCH-ALL PROBE CONTROLS
+// ============================================================================
+
+// Catch-all probes send RCPT TO for made-up addresses, which some providers
+// report as abuse. Operators can stop probing globally or per domain, and cap
+// probes per domain per day. Switches set through the API live in Redis, so
+// every replica honors them on its next probe without a redeploy; config
+// values apply on top. Known verdicts in the domain record are still used.
+
+const (
+	catchAllKillSwitchKey      = "catchall:kill_switch"      // value: reason
+	catchAllDisabledDomainsKey = "catchall:disabled_domains" // hash: domain -> reason
+	catchAllBudgetsKey         = "catchall:budgets"          // hash: domain -> daily budget
+	catchAllProbeCountTTL      = 48 * time.Hour
+)
+
+// Reasons a catch-all probe was not run (ValidationResult.CatchAllSkipped)
+const (
+	CatchAllSkippedKillSwitch     = "kill_switch"
+	CatchAllSkippedDomainDisabled = "domain_disabled"
+	CatchAllSkippedBudget         = "budget_exhausted"
+	CatchAllSkippedUnavailable    = "controls_unavailable" // Redis error; fail closed
+)
+
+// CatchAllControls is the effective state of the switches and budgets
+type CatchAllControls struct {
+	KillSwitch       bool              `json:"kill_switch"`
+	KillSwitchReason string            `json:"kill_switch_reason,omitempty"`
+	DisabledDomains  map[string]string `json:"disabled_domains"` // domain -> reason
+	DailyProbeBudget int               `json:"daily_probe_budget"`
+	ProbeBudgets     map[string]int    `json:"probe_budgets"` // per-domain overrides
+}
+
+// CatchAllDomainControl is the per-domain state, with today's probe count
+type CatchAllDomainControl struct {
+	Domain           string `json:"domain"`
+	Disabled         bool   `json:"disabled"`
+	Reason           string `json:"reason,omitempty"`
+	DailyProbeBudget int    `json:"daily_probe_budget"` // 0 = unlimited
+	ProbesToday      int64  `json:"probes_today"`
+}
+
+func catchAllProbeCountKey(domain string, day time.Time) string {
+	return "catchall:probes:" + domain + ":" + day.UTC().Format("20060102")
+}
+
+func (v *SMTPVerifier) configDisablesCatchAll(domain string) bool {
+	for _, d := range v.cfg().CatchAllDisabledDomains {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// dailyProbeBudget resolves the budget for domain: API override, then config
+// override, then the default
+func (v *SMTPVerifier) dailyProbeBudget(override, domain string) int {
+	if n, err := strconv.Atoi(override); err == nil && n >= 0 {
+		return n
+	}
+	if n, ok := v.cfg().CatchAllProbeBudgets[domain]; ok {
+		return n
+	}
+	return v.cfg().CatchAllDailyProbeBudget
+}
+
+// reserveCatchAllProbes checks the switches and takes n probes from today's
+// budget. It returns "" when probing may go ahead, otherwise the skip reason.
+func (v *SMTPVerifier) reserveCatchAllProbes(ctx context.Context, domain string, n int) string {
+	if v.cfg().CatchAllKillSwitch {
+		return CatchAllSkippedKillSwitch
+	}
+	if v.configDisablesCatchAll(domain) {
+		return CatchAllSkippedDomainDisabled
+	}
+
+	pipe := v.redis.Pipeline()
+	killSwitch := pipe.Exists(ctx, catchAllKillSwitchKey)
+	disabled := pipe.HExists(ctx, catchAllDisabledDomainsKey, domain)
+	override := pipe.HGet(ctx, catchAllBudgetsKey, domain)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return CatchAllSkippedUnavailable
+	}
+	if killSwitch.Val() > 0 {
+		return CatchAllSkippedKillSwitch
+	}
+	if disabled.Val() {
+		return CatchAllSkippedDomainDisabled
+	}
+
+	budget := v.dailyProbeBudget(override.Val(), domain)
+	if budget == 0 {
+		return ""
+	}
+	key := catchAllProbeCountKey(domain, time.Now())
+	pipe = v.redis.TxPipeline()
+	used := pipe.IncrBy(ctx, key, int64(n))
+	pipe.Expire(ctx, key, catchAllProbeCountTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return CatchAllSkippedUnavailable
+	}
+	if used.Val() > int64(budget) {
+		v.redis.DecrBy(ctx, key, int64(n)) // Not spent
+		return CatchAllSkippedBudget
+	}
+	return ""
+}
+
+// CatchAllControls returns the switches and budgets from config and the API
+func (v *SMTPVerifier) CatchAllControls(ctx context.Context) (*CatchAllControls, error) {
+	pipe := v.redis.Pipeline()
+	killSwitch := pipe.Get(ctx, catchAllKillSwitchKey)
+	disabled := pipe.HGetAll(ctx, catchAllDisabledDomainsKey)
+	budgets := pipe.HGetAll(ctx, catchAllBudgetsKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	controls := &CatchAllControls{
+		DisabledDomains:  make(map[string]string),
+		DailyProbeBudget: v.cfg().CatchAllDailyProbeBudget,
+		ProbeBudgets:     make(map[string]int),
+	}
+	if v.cfg().CatchAllKillSwitch {
+		controls.KillSwitch = true
+		controls.KillSwitchReason = "config"
+	} else if reason, err := killSwitch.Result(); err == nil {
+		controls.KillSwitch = true
+		controls.KillSwitchReason = reason
+	}
+	for _, domain := range v.cfg().CatchAllDisabledDomains {
+		controls.DisabledDomains[domain] = "config"
+	}
+	for domain, reason := range disabled.Val() {
+		controls.DisabledDomains[domain] = reason
+	}
+	for domain, n := range v.cfg().CatchAllProbeBudgets {
+		controls.ProbeBudgets[domain] = n
+	}
+	for domain, raw := range budgets.Val() {
+		if n, err := strconv.Atoi(raw); err == nil {
+			controls.ProbeBudgets[domain] = n
+		}
+	}
+	return controls, nil
+}
+
+// SetCatchAllKillSwitch stops (or resumes) catch-all probing on every replica
+func (v *SMTPVerifier) SetCatchAllKillSwitch(ctx context.Context, on bool, reason string) error {
+	if !on {
+		return v.redis.Del(ctx, catchAllKillSwitchKey).Err()
+	}
+	return v.redis.Set(ctx, catchAllKillSwitchKey, reason, 0).Err()
+}
+
+// CatchAllDomainControl returns the effective state for one domain
+func (v *SMTPVerifier) CatchAllDomainControl(ctx context.Context, domain string) (*CatchAllDomainControl, error) {
+	pipe := v.redis.Pipeline()
+	reason := pipe.HGet(ctx, catchAllDisabledDomainsKey, domain)
+	override := pipe.HGet(ctx, catchAllBudgetsKey, domain)
+	probes := pipe.Get(ctx, catchAllProbeCountKey(domain, time.Now()))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	control := &CatchAllDomainControl{
+		Domain:           domain,
+		DailyProbeBudget: v.dailyProbeBudget(override.Val(), domain),
+	}
+	control.ProbesToday, _ = probes.Int64()
+	if r, err := reason.Result(); err == nil {
+		control.Disabled = true
+		control.Reason = r
+	} else if v.configDisablesCatchAll(domain) {
+		control.Disabled = true
+		control.Reason = "config"
+	}
+	return control, nil
+}
+
+// SetCatchAllDomainControl disables or re-enables probing for domain and,
+// when budget is non-nil, overrides its daily budget
+func (v *SMTPVerifier) SetCatchAllDomainControl(ctx context.Context, domain string, disabled bool, reason string, budget *int) error {
+	pipe := v.redis.TxPipeline()
+	if disabled {
+		pipe.HSet(ctx, catchAllDisabledDomainsKey, domain, reason)
+	} else {
+		pipe.HDel(ctx, catchAllDisabledDomainsKey, domain)
+	}
+	if budget != nil {
+		pipe.HSet(ctx, catchAllBudgetsKey, domain, *budget)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ClearCatchAllDomainControl removes the API switch and budget for domain;
+// config values still apply
+func (v *SMTPVerifier) ClearCatchAllDomainControl(ctx context.Context, domain string) error {
+	pipe := v.redis.TxPipeline()
+	pipe.HDel(ctx, catchAllDisabledDomainsKey, domain)
+	pipe.HDel(ctx, catchAllBudgetsKey, domain)
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,101 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// CATCH-ALL PROBE LOCK
+// ============================================================================
+
+// Many workers hitting a new domain at once would each run their own
+// catch-all probes. A lock per domain lets one replica probe while the rest
+// poll the domain record for its verdict. The lock expires on its own if the
+// holder dies; a waiter then takes it over.
+
+const (
+	catchAllLockPrefix = "lock:catchall:"
+	catchAllLockPoll   = 250 * time.Millisecond
+	catchAllProbeGap   = 500 * time.Millisecond // Pause between probe handshakes
+)
+
+// releaseLock deletes a lock only if we still hold it.
+// ARGV: token
+var releaseLock = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// catchAllLockTTL covers a full probe sequence
+func (v *SMTPVerifier) catchAllLockTTL(domain string) time.Duration {
+	return time.Duration(v.cfg().CatchAllProbeCount+1) * (v.sessionLease(domain) + catchAllProbeGap)
+}
+
+// awaitCatchAllProbe returns a release function once the caller holds the
+// domain's probe lock, or the verdict another holder recorded meanwhile
+// (found). If Redis is unreachable the caller probes without the lock.
+// beforeWait, when set, runs once before the first wait for another holder,
+// so the caller can let go of what it must not hold while waiting.
+func (v *SMTPVerifier) awaitCatchAllProbe(ctx context.Context, domain string, beforeWait func()) (release func(), isCatchAll, found bool, err error) {
+	key := catchAllLockPrefix + domain
+	token := NewID()
+	for {
+		locked, err := v.redis.SetNX(ctx, key, token, v.catchAllLockTTL(domain)).Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, false, false, ctx.Err()
+			}
+			slog.WarnContext(ctx, "catch-all lock unavailable", "domain", domain, "error", err)
+			return func() {}, false, false, nil
+		}
+
+		// Also checked after locking: a holder may have finished just before
+		isCatchAll, found := v.freshCatchAllVerdict(ctx, domain)
+		if locked {
+			release := func() {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				releaseLock.Run(ctx, v.redis, []string{key}, token)
+			}
+			if found {
+				release()
+				return nil, isCatchAll, true, nil
+			}
+			return release, false, false, nil
+		}
+		if found {
+			return nil, isCatchAll, true, nil
+		}
+
+		if beforeWait != nil {
+			beforeWait()
+			beforeWait = nil
+		}
+		select {
+		case <-time.After(catchAllLockPoll):
+		case <-ctx.Done():
+			return nil, false, false, ctx.Err()
+		}
+	}
+}
+
+// freshCatchAllVerdict reads the verdict from Redis, skipping the local tier,
+// which may not have heard about another replica's probe yet
+func (v *SMTPVerifier) freshCatchAllVerdict(ctx context.Context, domain string) (bool, bool) {
+	val, err := v.redis.Get(ctx, domainMetaKey(domain)).Result()
+	if err != nil {
+		return false, false
+	}
+	var meta DomainMetadata
+	if json.Unmarshal([]byte(val), &meta) != nil {
+		return false, false
+	}
+	return meta.catchAllVerdict(v.cfg().ResultCacheTTL)
+}
@@ -0,0 +1,178 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// PER-CHECK BREAKDOWN (v2 results)
+// ============================================================================
+
+// The v2 API reports each check on its own rather than only the verdict.
+// Like explanations (see explain.go), the breakdown is rebuilt from the
+// result; durations are measured as the checks run and are not cached, so a
+// cached result reports 0 for each.
+//
+// pass means the check found nothing against the address: for catch_all,
+// disposable, role and free_provider, fail means the address is one. A check
+// that did not run, because the depth stopped short of it or an earlier
+// check decided the verdict, is skipped.
+
+// Check results
+const (
+	CheckPass    = "pass"
+	CheckFail    = "fail"
+	CheckUnknown = "unknown" // Ran without an answer, e.g. the mail server timed out
+	CheckSkipped = "skipped"
+)
+
+type Check struct {
+	Result     string `json:"result"`
+	DurationMs int64  `json:"duration_ms"`
+	Evidence   string `json:"evidence,omitempty"`
+}
+
+type Checks struct {
+	Syntax       Check `json:"syntax"`
+	MX           Check `json:"mx"`
+	SMTP         Check `json:"smtp"`
+	CatchAll     Check `json:"catch_all"`
+	Disposable   Check `json:"disposable"`
+	Role         Check `json:"role"`
+	FreeProvider Check `json:"free_provider"`
+}
+
+// checkTimings are recorded by runChecks; catchAll is part of the SMTP step
+// and is subtracted from smtp once that step returns
+type checkTimings struct {
+	syntax, mx, smtp, catchAll, disposable time.Duration
+}
+
+// BuildChecks breaks a result down into its checks
+func BuildChecks(r *ValidationResult) Checks {
+	skipped := Check{Result: CheckSkipped}
+	c := Checks{
+		Syntax:       skipped,
+		MX:           skipped,
+		SMTP:         skipped,
+		CatchAll:     skipped,
+		Disposable:   skipped,
+		Role:         skipped,
+		FreeProvider: skipped,
+	}
+	t := r.timings
+	deep := r.Depth == "" || r.Depth == DepthDeep
+
+	// Syntax
+	if strings.HasPrefix(r.Reason, "syntax_error") {
+		c.Syntax = Check{Result: CheckFail, DurationMs: t.syntax.Milliseconds(), Evidence: strings.TrimPrefix(r.Reason, "syntax_error: ")}
+		return c
+	}
+	c.Syntax = Check{Result: CheckPass, DurationMs: t.syntax.Milliseconds(), Evidence: "Valid RFC 5321 mailbox"}
+
+	// Free providers, a set lookup on the domain
+	if r.Depth != DepthSyntax {
+		c.FreeProvider = Check{Result: CheckPass}
+		if r.IsFreeProvider {
+			c.FreeProvider = Check{Result: CheckFail, Evidence: r.Domain + " is a free consumer provider"}
+		}
+	}
+
+	// Role needs only the local part
+	if deep {
+		c.Role = Check{Result: CheckPass}
+		if r.IsRole {
+			c.Role = Check{Result: CheckFail, Evidence: "Role mailbox " + strings.SplitN(r.Email, "@", 2)[0]}
+		}
+	}
+
+	// Domain lists, domain policy verdicts and directories answer before any other check runs
+	switch r.Reason {
+	case "domain_policy", ReasonAllowlisted, ReasonBlocklisted, ReasonNeverProbe:
+		return c
+	}
+	if strings.HasPrefix(r.Reason, "ldap_") || strings.HasPrefix(r.Reason, "directory_") {
+		c.SMTP = Check{Result: checkResultFor(r.Status), DurationMs: t.smtp.Milliseconds(), Evidence: "Answered by the directory for " + r.Domain}
+		return c
+	}
+
+	// Disposable list, checked before DNS
+	if deep {
+		c.Disposable = Check{Result: CheckPass, DurationMs: t.disposable.Milliseconds()}
+		if r.IsDisposable {
+			c.Disposable = Check{Result: CheckFail, DurationMs: t.disposable.Milliseconds(), Evidence: r.Domain + " is on the disposable domain list"}
+			if len(r.MXRecords) == 0 {
+				return c
+			}
+		}
+	}
+	if r.Reason == "do_not_verify" {
+		return c
+	}
+
+	// DNS
+	switch {
+	case r.Reason == "no_mx_records":
+		c.MX = Check{Result: CheckFail, DurationMs: t.mx.Milliseconds(), Evidence: "No MX or A/AAAA records for " + r.Domain}
+		return c
+	case r.Reason == "null_mx":
+		c.MX = Check{Result: CheckFail, DurationMs: t.mx.Milliseconds(), Evidence: "Null MX (MX 0 .)"}
+		return c
+	case r.ImplicitMX:
+		c.MX = Check{Result: CheckPass, DurationMs: t.mx.Milliseconds(), Evidence: "Implicit MX: " + r.Domain}
+	default:
+		hosts := make([]string, len(r.MXRecords))
+		for i, mx := range r.MXRecords {
+			hosts[i] = mx.Exchange
+		}
+		c.MX = Check{Result: CheckPass, DurationMs: t.mx.Milliseconds(), Evidence: "MX: " + strings.Join(hosts, ", ")}
+	}
+	if r.Reason == "mx_found" {
+		return c
+	}
+
+	// SMTP
+	c.SMTP = Check{Result: checkResultFor(r.Status), DurationMs: t.smtp.Milliseconds(), Evidence: r.Reason}
+	if r.SMTPCode != 0 {
+		c.SMTP.Evidence = fmt.Sprintf("%s answered %d %s", r.MXHost, r.SMTPCode, strings.TrimSpace(r.SMTPResponse))
+	}
+	if r.Status == StatusCatchAll {
+		// The mailbox was accepted; the catch-all check qualifies that
+		c.SMTP.Result = CheckPass
+	}
+
+	// Catch-all, only after the mailbox was accepted
+	switch {
+	case r.IsCatchAll:
+		c.CatchAll = Check{Result: CheckFail, DurationMs: t.catchAll.Milliseconds(), Evidence: "Domain also accepted random addresses"}
+	case r.CatchAllSkipped != "":
+		c.CatchAll.Evidence = r.CatchAllSkipped
+	case hasDegraded(r, DegradedCatchAll):
+		c.CatchAll.Evidence = "Skipped under load"
+	case deep && r.Status == StatusValid:
+		c.CatchAll = Check{Result: CheckPass, DurationMs: t.catchAll.Milliseconds()}
+	}
+	return c
+}
+
+// checkResultFor maps a verdict onto the check that decided it
+func checkResultFor(status ValidationStatus) string {
+	switch status {
+	case StatusValid:
+		return CheckPass
+	case StatusInvalid:
+		return CheckFail
+	}
+	return CheckUnknown
+}
+
+func hasDegraded(r *ValidationResult, check string) bool {
+	for _, d := range r.DegradedChecks {
+		if d == check {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package verifier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// CLASSIFIER VERSION
+// ============================================================================
+
+// Every result is stamped with the classifier that produced it: a code
+// revision plus a digest of the response rules and provider hints in effect,
+// so new rules or hints count as an upgrade without a code change. A cached
+// result from another version is brought up to date when it is read. When it
+// holds the RCPT TO reply, that reply is classified again with today's
+// logic and the result rewritten in place, keeping its TTL. Anything else is
+// treated as a cache miss and checked afresh.
+
+// classifierRevision is bumped with any change to classifyReply,
+// classifySMTPResponse, ProviderHint.apply or how catch-all probes affect the
+// verdict
+const classifierRevision = 2
+
+// ClassifierStats counts cached results brought up to date
+type ClassifierStats struct {
+	Version     string `json:"version"`
+	Rescored    int64  `json:"rescored"`    // Reclassified from the stored reply
+	Invalidated int64  `json:"invalidated"` // Served as a miss instead
+}
+
+func classifierVersion(rules responseRules, hints *ProviderHints) string {
+	h := sha256.New()
+	for _, r := range rules {
+		fmt.Fprintf(h, "%s\x00%s\x00%v\x00%s\x00%s\x00%g\n", r.Name, r.Pattern, r.Codes, r.Status, r.Reason, r.Confidence)
+	}
+	if data, err := yaml.Marshal(hints); err == nil {
+		h.Write(data)
+	}
+	return fmt.Sprintf("%d-%s", classifierRevision, hex.EncodeToString(h.Sum(nil))[:12])
+}
+
+// ClassifierStats reports the current version and what was upgraded
+func (v *SMTPVerifier) ClassifierStats() ClassifierStats {
+	return ClassifierStats{
+		Version:     v.classifier,
+		Rescored:    v.rescored.Load(),
+		Invalidated: v.invalidated.Load(),
+	}
+}
+
+// upgradeCached brings a cached result up to the current classifier. It
+// reports false when the result must be checked again instead; the boolean
+// changed tells the caller to write the result back.
+func (v *SMTPVerifier) upgradeCached(r *ValidationResult) (ok, changed bool) {
+	if r.ClassifierVersion == v.classifier {
+		return true, false
+	}
+	if !v.reclassify(r) {
+		v.invalidated.Add(1)
+		return false, false
+	}
+	v.rescored.Add(1)
+	return true, true
+}
+
+// reclassify repeats the verdict steps of verifySMTPWithMX on the stored
+// reply. It fails for results not decided by a reply, and when the new
+// verdict would call for a catch-all probe that never ran.
+func (v *SMTPVerifier) reclassify(r *ValidationResult) bool {
+	if r.SMTPCode == 0 || r.Reason == "probe_blocked" {
+		return false
+	}
+
+	provider := v.providerFor(r.MXHost)
+	status, reason, confidence, rule := v.classifyReply(r.SMTPCode, r.SMTPResponse, provider)
+	probeCatchAll := true
+	if provider != nil {
+		status, reason, confidence, probeCatchAll = provider.apply(r.SMTPCode, r.SMTPResponse, status, reason, confidence)
+	}
+
+	probed := r.Status == StatusValid || r.Status == StatusCatchAll
+	if status == StatusValid && probeCatchAll && v.cfg().EnableCatchAllDetection {
+		if !probed {
+			return false
+		}
+		if r.IsCatchAll {
+			status, reason, confidence = StatusCatchAll, "catch_all_domain", 0.5
+		}
+	} else {
+		r.IsCatchAll = false
+	}
+
+	r.Status, r.Reason, r.Confidence = status, reason, confidence
+	r.ResponseRule = ""
+	if rule != nil && reason == rule.Reason {
+		r.ResponseRule = rule.Name
+	}
+	if provider != nil {
+		r.Provider = provider.Name
+	}
+	r.ClassifierVersion = v.classifier
+	return true
+}
@@ -0,0 +1,140 @@
+package verifier
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// PER-CLIENT API RATE LIMITS
+// ============================================================================
+
+// API callers are limited by a token bucket per client (API key, or source
+// IP without one) in the home Redis, so every replica draws from the same
+// bucket. A ClientRateLimit holds Burst tokens and refills Requests per Per.
+// ClientRouteRateLimits give routes such as /v1/validate/batch a bucket of
+// their own; every other route shares the client's default bucket. With
+// Redis unreachable the buckets are kept in-process, like the SMTP limits
+// (see redis-degraded.go).
+//
+//	ratelimit:client:{route or "default"}:{client}  hash, expires once full
+
+const clientRateLimitDefaultScope = "default"
+
+// ClientRateLimit is a token bucket for API requests; 0 Requests is unlimited
+type ClientRateLimit struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"` // Defaults to Requests
+}
+
+// interval is the refill time of one token
+func (l ClientRateLimit) interval() time.Duration {
+	per := l.Per
+	if per <= 0 {
+		per = time.Second
+	}
+	return per / time.Duration(l.Requests)
+}
+
+func (l ClientRateLimit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// ClientRateLimitDecision is the outcome of one request against its bucket
+type ClientRateLimitDecision struct {
+	Allowed    bool
+	Limit      int           // Bucket size
+	Remaining  int           // Tokens left after this request
+	Reset      time.Time     // When the bucket is full again
+	RetryAfter time.Duration // Until the next token, when refused
+}
+
+// clientRateLimitTake takes one token from the bucket in KEYS[1].
+// ARGV: now (ms), interval (ms), burst. Returns {allowed, tokens left, ms
+// until the next token when refused, ms until the bucket is full}.
+var clientRateLimitTake = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local t = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+t = math.min(burst, t + math.max(0, now - ts) / interval)
+local allowed = 0
+local wait = 0
+if t >= 1 then
+	t = t - 1
+	allowed = 1
+	redis.call('HSET', KEYS[1], 'tokens', tostring(t), 'ts', now)
+	redis.call('PEXPIRE', KEYS[1], math.ceil((burst - t) * interval) + 1000)
+else
+	wait = math.ceil((1 - t) * interval)
+end
+return {allowed, math.floor(t), wait, math.ceil((burst - t) * interval)}
+`)
+
+// ClientRateLimitFor returns the limit on route (a route template such as
+// /v1/validate) and the scope of its bucket
+func (v *SMTPVerifier) ClientRateLimitFor(route string) (ClientRateLimit, string) {
+	if limit, ok := v.cfg().ClientRouteRateLimits[route]; ok {
+		return limit, route
+	}
+	return v.cfg().ClientRateLimit, clientRateLimitDefaultScope
+}
+
+// TakeClientRateLimit takes a token for client from the bucket of scope
+func (v *SMTPVerifier) TakeClientRateLimit(ctx context.Context, scope, client string, limit ClientRateLimit) *ClientRateLimitDecision {
+	bucket := rateLimitBucket{"ratelimit:client:" + scope + ":" + client, limit.interval(), limit.burst()}
+	if v.redisDegraded(v.redis) {
+		return v.takeLocalClientRateLimit(bucket)
+	}
+
+	now := time.Now()
+	vals, err := clientRateLimitTake.Run(ctx, v.redis, []string{bucket.key},
+		now.UnixMilli(), bucket.interval.Milliseconds(), bucket.burst).Int64Slice()
+	if err != nil || len(vals) != 4 {
+		slog.WarnContext(ctx, "client rate limit unavailable, limiting in-process", "bucket", bucket.key, "error", err)
+		return v.takeLocalClientRateLimit(bucket)
+	}
+	return &ClientRateLimitDecision{
+		Allowed:    vals[0] == 1,
+		Limit:      bucket.burst,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Millisecond,
+		Reset:      now.Add(time.Duration(vals[3]) * time.Millisecond),
+	}
+}
+
+func (v *SMTPVerifier) takeLocalClientRateLimit(bucket rateLimitBucket) *ClientRateLimitDecision {
+	v.localRateLimits.Add(1)
+	now := time.Now()
+	wait, tokens := v.localLimits.takeOne(bucket)
+	return &ClientRateLimitDecision{
+		Allowed:    wait == 0,
+		Limit:      bucket.burst,
+		Remaining:  int(math.Floor(tokens)),
+		RetryAfter: wait,
+		Reset:      now.Add(time.Duration((float64(bucket.burst) - tokens) * float64(bucket.interval))),
+	}
+}
+
+// takeOne is take for a single bucket that also reports the tokens left
+func (l *localRateLimiter) takeOne(b rateLimitBucket) (time.Duration, float64) {
+	if wait := l.take([]rateLimitBucket{b}); wait > 0 {
+		return wait, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if state, ok := l.buckets[b.key]; ok {
+		return 0, state.tokens
+	}
+	return 0, 0
+}
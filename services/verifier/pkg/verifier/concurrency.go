@@ -0,0 +1,114 @@
+package verifier
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// DISTRIBUTED CONCURRENCY LIMITS
+// ============================================================================
+
+// MaxConcurrentPerDomain and MaxConcurrentPerMX cap open SMTP sessions across
+// every replica. Each capped target has a sorted set of session tokens scored
+// by lease expiry, so a replica that dies mid-session frees its slot when the
+// lease runs out.
+
+const (
+	concurrencyDomainPrefix = "concurrency:domain:"
+	concurrencyMXPrefix     = "concurrency:mx:"
+	concurrencyMinWait      = 25 * time.Millisecond
+	concurrencyMaxWait      = 500 * time.Millisecond
+	concurrencyLeaseSlack   = 5 * time.Second
+)
+
+// concurrencyAcquire takes a slot in every key or in none.
+// ARGV: now (ms), lease expiry (ms), lease TTL (ms), token, then one limit
+// per key. Returns 1 when the slots were taken.
+var concurrencyAcquire = redis.NewScript(`
+for i, key in ipairs(KEYS) do
+	redis.call('ZREMRANGEBYSCORE', key, '-inf', ARGV[1])
+	if redis.call('ZCARD', key) >= tonumber(ARGV[4 + i]) then
+		return 0
+	end
+end
+for _, key in ipairs(KEYS) do
+	redis.call('ZADD', key, ARGV[2], ARGV[4])
+	redis.call('PEXPIRE', key, ARGV[3])
+end
+return 1
+`)
+
+// sessionLease bounds one SMTP session to domain: connect, then the whole
+// conversation runs under a single read deadline
+func (v *SMTPVerifier) sessionLease(domain string) time.Duration {
+	connect, read := v.smtpTimeouts(domain)
+	return connect + read + concurrencyLeaseSlack
+}
+
+// acquireTargetSlots waits until the domain and MX host both have a free
+// session slot and returns the release function. If Redis is unreachable the
+// session goes ahead unlimited rather than failing the verification.
+func (v *SMTPVerifier) acquireTargetSlots(ctx context.Context, domain, mxHost string) (func(), error) {
+	var keys []string
+	var limits []interface{}
+	if v.cfg().MaxConcurrentPerDomain > 0 && domain != "" {
+		keys = append(keys, concurrencyDomainPrefix+domain)
+		limits = append(limits, v.cfg().MaxConcurrentPerDomain)
+	}
+	if v.cfg().MaxConcurrentPerMX > 0 {
+		keys = append(keys, concurrencyMXPrefix+strings.ToLower(mxHost))
+		limits = append(limits, v.cfg().MaxConcurrentPerMX)
+	}
+	if len(keys) == 0 {
+		return func() {}, nil
+	}
+
+	token := NewID()
+	lease := v.sessionLease(domain)
+	wait := concurrencyMinWait
+	for {
+		now := time.Now()
+		args := append([]interface{}{
+			now.UnixMilli(),
+			now.Add(lease).UnixMilli(),
+			lease.Milliseconds(),
+			token,
+		}, limits...)
+		acquired, err := concurrencyAcquire.Run(ctx, v.redis, keys, args...).Int()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			slog.WarnContext(ctx, "concurrency limit unavailable", "mx_host", mxHost, "error", err)
+			return func() {}, nil
+		}
+		if acquired == 1 {
+			break
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if wait *= 2; wait > concurrencyMaxWait {
+			wait = concurrencyMaxWait
+		}
+	}
+
+	return func() {
+		// The caller's context may already be done; the slot must still go
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		pipe := v.redis.Pipeline()
+		for _, key := range keys {
+			pipe.ZRem(ctx, key, token)
+		}
+		pipe.Exec(ctx)
+	}, nil
+}
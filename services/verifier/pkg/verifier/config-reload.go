@@ -0,0 +1,176 @@
+package verifier
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ============================================================================
+// CONFIGURATION RELOAD
+// ============================================================================
+
+// Settings the verifier reads per verification can change without a
+// restart: Reload swaps in a copy of the running Config with those fields
+// taken from the new one, so verifications in progress pick the new values
+// up at their next step. Pools, workers, listeners and connections are sized
+// or opened once; their settings wait for the next restart and are reported
+// as such.
+
+// reloadableFields are the Config fields Reload applies
+var reloadableFields = map[string]bool{
+	// SMTP probing
+	"SMTPConnectTimeout":   true,
+	"SMTPReadTimeout":      true,
+	"EHLOHostname":         true,
+	"MailFrom":             true,
+	"NormalizeAddresses":   true,
+	"SMTPPoolIdleTimeout":  true,
+	"MaxRcptPerConnection": true,
+	"ImplicitMXFallback":   true,
+	"MaxRetries":           true,
+	"RetryBackoff":         true,
+	"RetryBackoffFactor":   true,
+	"ProbeBlockCooldown":   true,
+	"ProbeRetryDelay":      true,
+	"GreylistRetryDelay":   true,
+	"GreylistMaxAttempts":  true,
+
+	// Throttling
+	"MaxConcurrentPerDomain": true,
+	"MaxConcurrentPerMX":     true,
+	"DomainRateLimit":        true,
+	"DomainRateBurst":        true,
+	"MXRateLimit":            true,
+	"MXRateBurst":            true,
+	"ClientRateLimit":        true,
+	"ClientRouteRateLimits":  true,
+	"APIKeyHashes":           true,
+	"APIKeyRequired":         true,
+	"AdminKeyHashes":         true,
+	"QuotaDaily":             true,
+	"QuotaMonthly":           true,
+	"TenantQuotas":           true,
+	"QuotaFailClosed":        true,
+
+	// Catch-all detection
+	"EnableCatchAllDetection":  true,
+	"CatchAllProbeCount":       true,
+	"CatchAllKillSwitch":       true,
+	"CatchAllDisabledDomains":  true,
+	"CatchAllDailyProbeBudget": true,
+	"CatchAllProbeBudgets":     true,
+
+	// Cache TTLs and retention
+	"MXCacheTTL":           true,
+	"MXNegativeCacheTTL":   true,
+	"ResultCacheTTL":       true,
+	"DomainMetaCacheTTL":   true,
+	"DMARCReportRetention": true,
+	"ProbeAuditRetention":  true,
+	"ResultFeedMaxLen":     true,
+
+	// Webhooks
+	"WebhookTimeout":      true,
+	"WebhookMaxAttempts":  true,
+	"WebhookRetryBackoff": true,
+	"WebhookRetention":    true,
+
+	// Tenants and domains
+	"Tenants":           true,
+	"DomainPolicies":    true,
+	"AllowlistDomains":  true,
+	"BlocklistDomains":  true,
+	"NeverProbeDomains": true,
+
+	// Domain security checks (findings already cached keep their TTL)
+	"DomainSecurityChecks":  true,
+	"DomainSecurityTimeout": true,
+	"DNSBLZones":            true,
+
+	// Risky TLDs and parked domains (cached verdicts keep their TTL)
+	"RiskyTLDs":           true,
+	"ParkingMXHosts":      true,
+	"ParkedWildcardCheck": true,
+
+	// Spamtrap heuristics
+	"TrapPatterns":        true,
+	"TrapOperatorDomains": true,
+	"TrapHistory":         true,
+	"TrapRDAPURL":         true,
+
+	// Applied by the server process (see setupLogging)
+	"LogLevel":  true,
+	"LogFormat": true,
+}
+
+// ConfigChange is one setting that differs between two configs
+type ConfigChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+func (c ConfigChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, c.Old, c.New)
+}
+
+// ConfigReload reports what a Reload did
+type ConfigReload struct {
+	Applied         []ConfigChange // Now in effect
+	RestartRequired []ConfigChange // Differ, but only take effect on restart
+}
+
+// cfg returns the config in effect
+func (v *SMTPVerifier) cfg() *Config {
+	return v.config.Load()
+}
+
+// Config returns the config in effect. It must not be modified.
+func (v *SMTPVerifier) Config() *Config {
+	return v.cfg()
+}
+
+// Reload applies next's reloadable settings. next should have passed
+// Validate.
+func (v *SMTPVerifier) Reload(next *Config) ConfigReload {
+	current := v.cfg()
+	updated := *current
+	var report ConfigReload
+
+	cur, nxt, upd := reflect.ValueOf(current).Elem(), reflect.ValueOf(next).Elem(), reflect.ValueOf(&updated).Elem()
+	t := cur.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if reflect.DeepEqual(cur.Field(i).Interface(), nxt.Field(i).Interface()) {
+			continue
+		}
+		change := ConfigChange{Field: name, Old: configValue(name, cur.Field(i)), New: configValue(name, nxt.Field(i))}
+		if reloadableFields[name] {
+			upd.Field(i).Set(nxt.Field(i))
+			report.Applied = append(report.Applied, change)
+		} else {
+			report.RestartRequired = append(report.RestartRequired, change)
+		}
+	}
+	if len(report.Applied) > 0 {
+		v.config.Store(&updated)
+	}
+	return report
+}
+
+// secretFields are never printed in change reports
+var secretFields = map[string]bool{
+	"WebhookSigningSecret":  true,
+	"StorageCredentialsKey": true,
+	"MQURL":                 true,
+	"Regions":               true,
+	"LDAPDirectories":       true,
+	"DirectoryConnectors":   true,
+}
+
+func configValue(name string, v reflect.Value) string {
+	if secretFields[name] {
+		return "(redacted)"
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
@@ -0,0 +1,301 @@
+package verifier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// CONFIGURATION VALIDATION
+// ============================================================================
+
+// Validate checks every setting at once, so a bad deploy fails at startup
+// with the whole list rather than one problem per restart. Problems name
+// the YAML key (see config/config.yaml) of the offending setting.
+
+// ConfigError lists everything wrong with a Config
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%d invalid setting(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+type configCheck struct {
+	problems []string
+}
+
+func (c *configCheck) fail(key, format string, args ...interface{}) {
+	c.problems = append(c.problems, key+": "+fmt.Sprintf(format, args...))
+}
+
+func (c *configCheck) positive(key string, d time.Duration) {
+	if d <= 0 {
+		c.fail(key, "must be positive (got %s)", d)
+	}
+}
+
+func (c *configCheck) nonNegative(key string, d time.Duration) {
+	if d < 0 {
+		c.fail(key, "must not be negative (got %s)", d)
+	}
+}
+
+func (c *configCheck) atLeast(key string, n, min int64) {
+	if n < min {
+		c.fail(key, "must be at least %d (got %d)", min, n)
+	}
+}
+
+func (c *configCheck) fraction(key string, f float64) {
+	if f < 0 || f > 1 {
+		c.fail(key, "must be between 0 and 1 (got %g)", f)
+	}
+}
+
+func (c *configCheck) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	c.fail(key, "must be one of %s (got %q)", strings.Join(allowed, ", "), value)
+}
+
+func (c *configCheck) address(key, value string) {
+	if _, err := mail.ParseAddress(value); err != nil {
+		c.fail(key, "must be an email address (got %q)", value)
+	}
+}
+
+// Validate returns a *ConfigError listing every invalid setting, or nil
+func (c *Config) Validate() error {
+	var check configCheck
+
+	// SMTP
+	check.positive("smtp.connect_timeout", c.SMTPConnectTimeout)
+	check.positive("smtp.read_timeout", c.SMTPReadTimeout)
+	check.positive("smtp.write_timeout", c.SMTPWriteTimeout)
+	if c.EHLOHostname == "" || strings.ContainsAny(c.EHLOHostname, " \t@") {
+		check.fail("smtp.ehlo_hostname", "must be a hostname (got %q)", c.EHLOHostname)
+	}
+	check.address("smtp.mail_from", c.MailFrom)
+	check.atLeast("smtp.pool_max_idle", int64(c.SMTPPoolMaxIdle), 0)
+	check.atLeast("smtp.max_rcpt_per_connection", int64(c.MaxRcptPerConnection), 0)
+	check.atLeast("smtp.max_retries", int64(c.MaxRetries), 0)
+	check.nonNegative("smtp.retry_backoff", c.RetryBackoff)
+	if c.RetryBackoffFactor < 1 {
+		check.fail("smtp.retry_backoff_factor", "must be at least 1 (got %g)", c.RetryBackoffFactor)
+	}
+	if c.EnableCatchAllDetection {
+		check.atLeast("smtp.catch_all_probe_count", int64(c.CatchAllProbeCount), 1)
+	}
+	check.atLeast("smtp.catch_all_daily_probe_budget", int64(c.CatchAllDailyProbeBudget), 0)
+	for domain, budget := range c.CatchAllProbeBudgets {
+		check.atLeast("smtp.catch_all_probe_budgets."+domain, int64(budget), 0)
+	}
+	check.nonNegative("smtp.probe_block_cooldown", c.ProbeBlockCooldown)
+	check.nonNegative("smtp.probe_retry_delay", c.ProbeRetryDelay)
+	check.nonNegative("smtp.greylist_retry_delay", c.GreylistRetryDelay)
+	check.atLeast("smtp.greylist_max_attempts", int64(c.GreylistMaxAttempts), 0)
+	check.positive("smtp.dns_timeout", c.DNSTimeout)
+	for _, server := range c.DNSServers {
+		host := server
+		if h, _, err := net.SplitHostPort(server); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) == nil {
+			check.fail("smtp.dns_servers", "%q is not an IP address or IP:port", server)
+		}
+	}
+	for _, ip := range c.EgressIPs {
+		if net.ParseIP(ip) == nil {
+			check.fail("security.egress_ips", "%q is not an IP address", ip)
+		}
+	}
+
+	// Workers and rate limits
+	check.atLeast("workers.max_inflight_verifications", int64(c.MaxInFlightVerifications), 1)
+	check.atLeast("workers.max_smtp_sessions", int64(c.MaxSMTPSessions), 0)
+	check.atLeast("workers.batch_concurrency", int64(c.BatchConcurrency), 1)
+	check.atLeast("workers.max_concurrent_per_domain", int64(c.MaxConcurrentPerDomain), 1)
+	check.atLeast("workers.max_concurrent_per_mx", int64(c.MaxConcurrentPerMX), 1)
+	check.fraction("workers.degrade_catch_all_at", c.DegradeCatchAllAt)
+	check.fraction("workers.degrade_enrichment_at", c.DegradeEnrichmentAt)
+	check.nonNegative("workers.overload_queue_timeout", c.OverloadQueueTimeout)
+	check.nonNegative("workers.domain_rate_limit", c.DomainRateLimit)
+	check.atLeast("workers.domain_rate_burst", int64(c.DomainRateBurst), 0)
+	check.nonNegative("workers.mx_rate_limit", c.MXRateLimit)
+	check.atLeast("workers.mx_rate_burst", int64(c.MXRateBurst), 0)
+
+	// Caches
+	check.positive("redis.mx_cache_ttl", c.MXCacheTTL)
+	check.positive("redis.result_cache_ttl", c.ResultCacheTTL)
+	check.nonNegative("redis.mx_negative_cache_ttl", c.MXNegativeCacheTTL)
+	check.positive("redis.domain_meta_cache_ttl", c.DomainMetaCacheTTL)
+	check.nonNegative("redis.local_cache_ttl", c.LocalCacheTTL)
+	check.atLeast("redis.local_cache_max_entries", int64(c.LocalCacheMaxEntries), 1)
+	check.positive("redis.recovery_interval", c.RedisRecoveryInterval)
+	check.atLeast("redis.result_feed_max_len", c.ResultFeedMaxLen, 1)
+	check.positive("domain_security.timeout", c.DomainSecurityTimeout)
+	check.nonNegative("domain_security.cache_ttl", c.DomainSecurityCacheTTL)
+	check.nonNegative("dnsbl.cache_ttl", c.DNSBLCacheTTL)
+	check.nonNegative("risky_domains.parked_cache_ttl", c.ParkedCacheTTL)
+	for _, zone := range c.DNSBLZones {
+		if !validDomainPattern(zone) || strings.HasPrefix(zone, "*.") {
+			check.fail("dnsbl.zones", "%q is not a DNS zone", zone)
+		}
+	}
+	for _, tld := range c.RiskyTLDs {
+		if name := strings.TrimPrefix(tld, "."); name == "" || strings.Contains(name, ".") {
+			check.fail("risky_domains.tlds", "%q is not a TLD", tld)
+		}
+	}
+	for _, pattern := range c.ParkingMXHosts {
+		if !validDomainPattern(pattern) {
+			check.fail("risky_domains.parking_mx_hosts", "%q is not a domain pattern", pattern)
+		}
+	}
+	for _, pattern := range c.TrapPatterns {
+		if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+			check.fail("spamtraps.patterns", "%q: %v", pattern, err)
+		}
+	}
+	for _, pattern := range c.TrapOperatorDomains {
+		if !validDomainPattern(pattern) {
+			check.fail("spamtraps.operator_domains", "%q is not a domain pattern", pattern)
+		}
+	}
+	check.positive("spamtraps.history", c.TrapHistory)
+	if c.TrapRDAPURL != "" {
+		if u, err := url.Parse(c.TrapRDAPURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			check.fail("spamtraps.rdap_url", "%q is not an http(s) URL", c.TrapRDAPURL)
+		}
+	}
+
+	// Queue and jobs
+	check.atLeast("queue.consumer_count", int64(c.QueueConsumers), 1)
+	check.atLeast("queue.batch_size", c.QueueReadCount, 1)
+	check.positive("queue.block_time", c.QueueBlockTime)
+	check.oneOf("jobs.duplicate_action", c.DuplicateJobAction, DuplicateWarn, DuplicateReuse)
+	check.nonNegative("jobs.duplicate_window", c.DuplicateJobWindow)
+	check.positive("retention.completed_jobs_retention_days", c.JobRetention)
+	check.nonNegative("retention.probe_audit", c.ProbeAuditRetention)
+	check.positive("webhooks.timeout", c.WebhookTimeout)
+	check.atLeast("webhooks.max_attempts", int64(c.WebhookMaxAttempts), 1)
+	check.nonNegative("webhooks.retry_backoff", c.WebhookRetryBackoff)
+
+	// Integrations
+	check.oneOf("smtp_proxy.mode", c.SMTPProxyMode, GateModeReject, GateModeFlag)
+	check.oneOf("milter.mode", c.MilterMode, GateModeReject, GateModeFlag)
+	check.oneOf("message_queue.driver", c.MQDriver, "", "nats", "amqp")
+	if c.MQDriver != "" && c.MQURL == "" {
+		check.fail("message_queue.url", "is required with driver %s", c.MQDriver)
+	}
+	for _, addr := range []struct{ key, value string }{
+		{"smtp_proxy.listen_addr", c.SMTPProxyAddr},
+		{"milter.listen_addr", c.MilterAddr},
+		{"policy_service.listen_addr", c.PolicyAddr},
+	} {
+		if addr.value == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr.value); err != nil {
+			check.fail(addr.key, "must be host:port (got %q)", addr.value)
+		}
+	}
+
+	// API
+	for _, hash := range c.APIKeyHashes {
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			check.fail("auth.api_key_hashes", "%q is not a SHA-256 hex digest", hash)
+		}
+	}
+	for _, hash := range c.AdminKeyHashes {
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			check.fail("auth.admin_key_hashes", "%q is not a SHA-256 hex digest", hash)
+		}
+	}
+	check.atLeast("quotas.daily", c.QuotaDaily, 0)
+	check.atLeast("quotas.monthly", c.QuotaMonthly, 0)
+	for tenant, quota := range c.TenantQuotas {
+		check.atLeast("quotas.tenants."+tenant+".daily", quota.Daily, 0)
+		check.atLeast("quotas.tenants."+tenant+".monthly", quota.Monthly, 0)
+	}
+	check.clientRateLimit("api.rate_limits.default", c.ClientRateLimit)
+	for route, limit := range c.ClientRouteRateLimits {
+		if !strings.HasPrefix(route, "/") {
+			check.fail("api.rate_limits.routes", "%q must be a route template such as /v1/validate", route)
+		}
+		check.clientRateLimit("api.rate_limits.routes."+route, limit)
+	}
+	check.oneOf("server.tls.client_auth", c.TLSClientAuth, "", "require", "optional")
+	for tenant, tc := range c.Tenants {
+		key := "tenants." + tenant
+		if tc.MailFrom != "" {
+			check.address(key+".mail_from", tc.MailFrom)
+		}
+		check.nonNegative(key+".result_cache_ttl", tc.ResultCacheTTL)
+		check.nonNegative(key+".domain_rate_limit", tc.DomainRateLimit)
+		check.nonNegative(key+".mx_rate_limit", tc.MXRateLimit)
+	}
+	for key, list := range map[string][]string{
+		"domain_lists.allowlist":   c.AllowlistDomains,
+		"domain_lists.blocklist":   c.BlocklistDomains,
+		"domain_lists.never_probe": c.NeverProbeDomains,
+	} {
+		for _, pattern := range list {
+			if !validDomainPattern(pattern) {
+				check.fail(key, "%q must be a lower-case domain or *.domain pattern", pattern)
+			}
+		}
+	}
+	for pattern, p := range c.DomainPolicies {
+		key := "domains." + pattern
+		if !validDomainPattern(pattern) {
+			check.fail("domains", "%q must be a lower-case domain or *.domain pattern", pattern)
+		}
+		check.nonNegative(key+".connect_timeout", p.ConnectTimeout)
+		check.nonNegative(key+".read_timeout", p.ReadTimeout)
+		check.nonNegative(key+".rate_limit", p.RateLimit)
+		check.atLeast(key+".rate_burst", int64(p.RateBurst), 0)
+		if p.Depth != "" {
+			check.oneOf(key+".depth", p.Depth, DepthSyntax, DepthDNS, DepthSMTP, DepthDeep)
+		}
+		if p.Status != "" {
+			check.oneOf(key+".status", string(p.Status), string(StatusValid), string(StatusInvalid), string(StatusCatchAll), string(StatusUnknown), string(StatusRisky))
+		}
+	}
+
+	// Observability
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		check.fail("logging.level", "must be debug, info, warn or error (got %q)", c.LogLevel)
+	}
+	check.oneOf("logging.format", c.LogFormat, "json", "text")
+	check.fraction("tracing.sample_rate", c.TracingSampleRatio)
+
+	if err := CheckResidency(c); err != nil {
+		check.fail("residency", "%v", err)
+	}
+
+	if len(check.problems) > 0 {
+		return &ConfigError{Problems: check.problems}
+	}
+	return nil
+}
+
+func (c *configCheck) clientRateLimit(key string, limit ClientRateLimit) {
+	c.atLeast(key+".requests", int64(limit.Requests), 0)
+	c.nonNegative(key+".per", limit.Per)
+	c.atLeast(key+".burst", int64(limit.Burst), 0)
+}
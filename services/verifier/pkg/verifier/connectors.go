@@ -0,0 +1,94 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// NO-CODE CONNECTOR ENDPOINTS
+// ============================================================================
+
+// These endpoints are deliberately flat and stable for Zapier/Make style
+// connectors. Do not add nested objects here; extend the main API instead.
+
+const resultFeedKey = "validation:feed"
+
+// SimpleResult is the flat verdict shape returned to connectors
+type SimpleResult struct {
+	ID           string `json:"id,omitempty"`
+	Email        string `json:"email"`
+	Verdict      string `json:"verdict"`
+	Reason       string `json:"reason"`
+	Score        int    `json:"score"`
+	IsCatchAll   bool   `json:"is_catch_all"`
+	IsDisposable bool   `json:"is_disposable"`
+	CheckedAt    string `json:"checked_at"`
+}
+
+type SimpleFeedResponse struct {
+	Results    []SimpleResult `json:"results"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+func ToSimpleResult(result *ValidationResult) SimpleResult {
+	return SimpleResult{
+		Email:        result.Email,
+		Verdict:      SegmentFor(result.Status),
+		Reason:       result.Reason,
+		Score:        ScoreResult(result),
+		IsCatchAll:   result.IsCatchAll,
+		IsDisposable: result.IsDisposable,
+		CheckedAt:    result.CheckedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// publishResult appends a fresh verification to the capped polling feed
+func (v *SMTPVerifier) publishResult(ctx context.Context, result *ValidationResult) error {
+	data, err := json.Marshal(ToSimpleResult(result))
+	if err != nil {
+		return err
+	}
+
+	return v.TenantStore(ctx).XAdd(ctx, &redis.XAddArgs{
+		Stream: resultFeedKey,
+		MaxLen: v.cfg().ResultFeedMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"result": data},
+	}).Err()
+}
+
+// ResultFeed returns feed entries newer than cursor, oldest first. Without a
+// cursor it returns the most recent entries so a new connector can sample.
+func (v *SMTPVerifier) ResultFeed(ctx context.Context, cursor string, limit int64) (*SimpleFeedResponse, error) {
+	var msgs []redis.XMessage
+	var err error
+	if cursor == "" {
+		msgs, err = v.TenantStore(ctx).XRevRangeN(ctx, resultFeedKey, "+", "-", limit).Result()
+		for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+			msgs[i], msgs[j] = msgs[j], msgs[i]
+		}
+	} else {
+		msgs, err = v.TenantStore(ctx).XRangeN(ctx, resultFeedKey, "("+cursor, "+", limit).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SimpleFeedResponse{Results: []SimpleResult{}, NextCursor: cursor}
+	for _, msg := range msgs {
+		raw, _ := msg.Values["result"].(string)
+		var result SimpleResult
+		if json.Unmarshal([]byte(raw), &result) != nil {
+			continue
+		}
+		result.ID = msg.ID
+		resp.Results = append(resp.Results, result)
+		resp.NextCursor = msg.ID
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,89 @@
+package verifier
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// VERIFICATION DEPTH
+// ============================================================================
+
+// Callers choose how far a check goes (VerifyOptions.Depth):
+//
+//	syntax  parse only; no network                   unknown/syntax_valid
+//	dns     + MX lookup (null MX and no MX fail)     unknown/mx_found
+//	smtp    + directories, RCPT TO; no catch-all     the SMTP verdict
+//	deep    + catch-all probe, disposable and role   the full verdict (default)
+//
+// Only deep checks are cached, shared with identical checks in flight and
+// counted in domain records. A cached deep result answers any depth but
+// syntax, which never touches Redis.
+
+// Verification depths
+const (
+	DepthSyntax = "syntax"
+	DepthDNS    = "dns"
+	DepthSMTP   = "smtp"
+	DepthDeep   = "deep"
+)
+
+// ValidDepth reports a known depth; "" means DepthDeep
+func ValidDepth(depth string) bool {
+	switch depth {
+	case "", DepthSyntax, DepthDNS, DepthSMTP, DepthDeep:
+		return true
+	}
+	return false
+}
+
+// verifyAtDepth runs a check short of DepthDeep. Its result is kept for
+// explanations but never cached, since a deep check would be answered from
+// it.
+func (v *SMTPVerifier) verifyAtDepth(ctx context.Context, email, emailHash, depth string, startTime time.Time) (*ValidationResult, error) {
+	if depth != DepthSyntax {
+		release, err := v.limiter.acquireVerification(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	result, _ := v.runChecks(ctx, email, emailHash, depth, startTime)
+	result.Depth = depth
+	setQuality(result)
+	if depth != DepthSyntax {
+		result.IsFreeProvider = result.Domain != "" && v.isFreeProvider(ctx, result.Domain)
+		result.AccountType = v.accountType(result)
+		v.rememberResult(ctx, emailHash, result)
+	}
+	v.recordUsage(ctx, result, false)
+	return result, nil
+}
+
+// roleLocalParts are mailboxes that reach a function or a team rather than a
+// person (RFC 2142 and common practice)
+var roleLocalParts = map[string]bool{
+	"abuse": true, "accounts": true, "admin": true, "administrator": true,
+	"billing": true, "careers": true, "contact": true, "customerservice": true,
+	"dev": true, "enquiries": true, "feedback": true, "hello": true,
+	"help": true, "helpdesk": true, "hostmaster": true, "hr": true,
+	"info": true, "inquiries": true, "it": true, "jobs": true,
+	"mail": true, "marketing": true, "media": true, "news": true,
+	"newsletter": true, "no-reply": true, "noc": true, "noreply": true,
+	"office": true, "orders": true, "postmaster": true, "press": true,
+	"privacy": true, "recruitment": true, "root": true, "sales": true,
+	"security": true, "service": true, "staff": true, "support": true,
+	"team": true, "webmaster": true,
+}
+
+// isRoleAccount reports a role mailbox, ignoring any "+tag"
+func isRoleAccount(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return false
+	}
+	local, _, _ := strings.Cut(email[:at], subaddressSeparator)
+	return roleLocalParts[local]
+}
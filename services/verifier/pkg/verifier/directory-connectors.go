@@ -0,0 +1,261 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// CLOUD DIRECTORY CONNECTORS (GOOGLE WORKSPACE / MICROSOFT GRAPH)
+// ============================================================================
+
+// Corporate Workspace and Microsoft 365 domains are usually catch-all to the
+// outside world, so SMTP probing says nothing. When the tenant grants us
+// directory read access, addresses are answered exactly from the directory.
+
+const (
+	DirectoryGoogleWorkspace = "google_workspace"
+	DirectoryMicrosoftGraph  = "microsoft_graph"
+
+	googleDirectoryAPI = "https://admin.googleapis.com/admin/directory/v1"
+	googleTokenURL     = "https://oauth2.googleapis.com/token"
+	graphAPI           = "https://graph.microsoft.com/v1.0"
+	graphTokenURL      = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+)
+
+// DirectoryConnector configures one tenant directory. Secrets are read from
+// the named environment variables, never from the config file.
+type DirectoryConnector struct {
+	Provider        string        `yaml:"provider"` // google_workspace or microsoft_graph
+	Domains         []string      `yaml:"domains"`
+	TenantID        string        `yaml:"tenant_id"` // Microsoft Entra tenant
+	ClientID        string        `yaml:"client_id"`
+	ClientSecretEnv string        `yaml:"client_secret_env"`
+	RefreshTokenEnv string        `yaml:"refresh_token_env"` // Google admin consent refresh token
+	Timeout         time.Duration `yaml:"timeout"`
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// directoryMatch is what a directory knows about one address
+type directoryMatch struct {
+	Found    bool
+	Disabled bool
+	Kind     string // user or group
+	Name     string
+}
+
+// directoryConnectorFor returns the connector authoritative for domain, if any
+func (v *SMTPVerifier) directoryConnectorFor(domain string) *DirectoryConnector {
+	for _, conn := range v.cfg().DirectoryConnectors {
+		for _, d := range conn.Domains {
+			if strings.EqualFold(d, domain) {
+				return conn
+			}
+		}
+	}
+	return nil
+}
+
+// verifyDirectory answers from the tenant directory. The boolean reports
+// whether the result is cacheable, as for runChecks.
+func (v *SMTPVerifier) verifyDirectory(ctx context.Context, conn *DirectoryConnector, email, emailHash, domain string, startTime time.Time) (*ValidationResult, bool) {
+	timeout := conn.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var match *directoryMatch
+	var err error
+	switch conn.Provider {
+	case DirectoryGoogleWorkspace:
+		match, err = conn.lookupGoogle(ctx, email)
+	case DirectoryMicrosoftGraph:
+		match, err = conn.lookupGraph(ctx, email)
+	default:
+		err = fmt.Errorf("unsupported directory provider %q", conn.Provider)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "directory lookup failed", "provider", conn.Provider, "domain", domain, "error", err)
+		return v.createResult(email, emailHash, domain, StatusUnknown, "directory_error", 0.2, 0, "", "", nil, startTime), false
+	}
+
+	status, reason := StatusInvalid, "directory_mailbox_not_found"
+	switch {
+	case match.Found && match.Disabled:
+		reason = "directory_account_disabled"
+	case match.Found:
+		status, reason = StatusValid, "directory_mailbox_found"
+	}
+
+	result := v.createResult(email, emailHash, domain, status, reason, 1.0, 0, "", "", nil, startTime)
+	if match.Found {
+		result.DirectoryAttributes = map[string]string{"source": conn.Provider, "kind": match.Kind}
+		if match.Name != "" {
+			result.DirectoryAttributes["name"] = match.Name
+		}
+	}
+	return result, true
+}
+
+// lookupGoogle checks users (including aliases), then groups
+func (conn *DirectoryConnector) lookupGoogle(ctx context.Context, email string) (*directoryMatch, error) {
+	var user struct {
+		Suspended bool `json:"suspended"`
+		Archived  bool `json:"archived"`
+		Name      struct {
+			FullName string `json:"fullName"`
+		} `json:"name"`
+	}
+	found, err := conn.getJSON(ctx, googleDirectoryAPI+"/users/"+url.PathEscape(email), nil, &user)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return &directoryMatch{Found: true, Disabled: user.Suspended || user.Archived, Kind: "user", Name: user.Name.FullName}, nil
+	}
+
+	var group struct {
+		Name string `json:"name"`
+	}
+	found, err = conn.getJSON(ctx, googleDirectoryAPI+"/groups/"+url.PathEscape(email), nil, &group)
+	if err != nil || !found {
+		return &directoryMatch{}, err
+	}
+	return &directoryMatch{Found: true, Kind: "group", Name: group.Name}, nil
+}
+
+// lookupGraph matches primary mail or any SMTP proxy address on users, then
+// mail-enabled groups
+func (conn *DirectoryConnector) lookupGraph(ctx context.Context, email string) (*directoryMatch, error) {
+	quoted := strings.ReplaceAll(email, "'", "''")
+	filter := fmt.Sprintf("mail eq '%s' or proxyAddresses/any(p:p eq 'smtp:%s')", quoted, quoted)
+	headers := map[string]string{"ConsistencyLevel": "eventual"}
+
+	var users struct {
+		Value []struct {
+			DisplayName    string `json:"displayName"`
+			AccountEnabled bool   `json:"accountEnabled"`
+		} `json:"value"`
+	}
+	query := url.Values{"$filter": {filter}, "$select": {"displayName,accountEnabled"}, "$count": {"true"}}
+	if _, err := conn.getJSON(ctx, graphAPI+"/users?"+query.Encode(), headers, &users); err != nil {
+		return nil, err
+	}
+	if len(users.Value) > 0 {
+		u := users.Value[0]
+		return &directoryMatch{Found: true, Disabled: !u.AccountEnabled, Kind: "user", Name: u.DisplayName}, nil
+	}
+
+	var groups struct {
+		Value []struct {
+			DisplayName string `json:"displayName"`
+		} `json:"value"`
+	}
+	query.Set("$select", "displayName")
+	if _, err := conn.getJSON(ctx, graphAPI+"/groups?"+query.Encode(), headers, &groups); err != nil {
+		return nil, err
+	}
+	if len(groups.Value) > 0 {
+		return &directoryMatch{Found: true, Kind: "group", Name: groups.Value[0].DisplayName}, nil
+	}
+	return &directoryMatch{}, nil
+}
+
+// getJSON performs an authenticated GET. It returns false without error when
+// the directory answers 404.
+func (conn *DirectoryConnector) getJSON(ctx context.Context, endpoint string, headers map[string]string, out interface{}) (bool, error) {
+	token, err := conn.token(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return true, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// token returns a cached access token, fetching a new one when it is about to
+// expire. Google uses the admin's refresh token; Graph uses client credentials.
+func (conn *DirectoryConnector) token(ctx context.Context) (string, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.accessToken != "" && time.Now().Add(time.Minute).Before(conn.expiresAt) {
+		return conn.accessToken, nil
+	}
+
+	form := url.Values{
+		"client_id":     {conn.ClientID},
+		"client_secret": {os.Getenv(conn.ClientSecretEnv)},
+	}
+	tokenURL := googleTokenURL
+	if conn.Provider == DirectoryMicrosoftGraph {
+		tokenURL = fmt.Sprintf(graphTokenURL, url.PathEscape(conn.TenantID))
+		form.Set("grant_type", "client_credentials")
+		form.Set("scope", "https://graph.microsoft.com/.default")
+	} else {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", os.Getenv(conn.RefreshTokenEnv))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("token request: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	conn.accessToken = token.AccessToken
+	conn.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return conn.accessToken, nil
+}
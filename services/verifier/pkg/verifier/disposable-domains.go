@@ -0,0 +1,71 @@
+package verifier
+
+// bundledDisposableDomains ships with the binary so detection works before the
+// first external refresh. Keep it sorted; operators extend it through
+// disposable_domains.custom_disposable_domains or external_list_url.
+var bundledDisposableDomains = []string{
+	"0-mail.com",
+	"10minutemail.co.uk",
+	"10minutemail.com",
+	"10minutemail.net",
+	"20minutemail.com",
+	"33mail.com",
+	"anonbox.net",
+	"anonymbox.com",
+	"burnermail.io",
+	"discard.email",
+	"discardmail.com",
+	"dispostable.com",
+	"dropmail.me",
+	"emailondeck.com",
+	"fakeinbox.com",
+	"fakemail.net",
+	"getairmail.com",
+	"getnada.com",
+	"guerrillamail.biz",
+	"guerrillamail.com",
+	"guerrillamail.de",
+	"guerrillamail.info",
+	"guerrillamail.net",
+	"guerrillamail.org",
+	"guerrillamailblock.com",
+	"harakirimail.com",
+	"incognitomail.org",
+	"jetable.org",
+	"mail-temp.com",
+	"mailcatch.com",
+	"maildrop.cc",
+	"mailinator.com",
+	"mailinator.net",
+	"mailinator2.com",
+	"mailnesia.com",
+	"mailnull.com",
+	"mintemail.com",
+	"mohmal.com",
+	"moakt.com",
+	"mytemp.email",
+	"mytrashmail.com",
+	"nada.email",
+	"sharklasers.com",
+	"spam4.me",
+	"spambox.us",
+	"spamgourmet.com",
+	"spamherelots.com",
+	"tempail.com",
+	"temp-mail.io",
+	"temp-mail.org",
+	"tempinbox.com",
+	"tempmail.com",
+	"tempmail.net",
+	"tempmailo.com",
+	"tempr.email",
+	"throwawaymail.com",
+	"trash-mail.com",
+	"trashmail.com",
+	"trashmail.de",
+	"trashmail.net",
+	"wegwerfmail.de",
+	"yopmail.com",
+	"yopmail.fr",
+	"yopmail.net",
+}
@@ -0,0 +1,140 @@
+package verifier
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// DISPOSABLE DOMAIN LIST
+// ============================================================================
+
+const (
+	disposableDomainsKey = "disposable:domains"
+	maxDisposableListLen = 1 << 20
+)
+
+// DisposableList maintains the shared Redis set of disposable domains from the
+// bundled list, configured additions and an optional external list
+type DisposableList struct {
+	redis  *redis.Client
+	config *Config
+	http   *http.Client
+}
+
+func NewDisposableList(redisClient *redis.Client, config *Config) *DisposableList {
+	return &DisposableList{
+		redis:  redisClient,
+		config: config,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Refresh rebuilds the set and swaps it in atomically. The scheduler runs it
+// on one replica per disposable_refresh occurrence.
+func (d *DisposableList) Refresh(ctx context.Context) (int, error) {
+	var domains []string
+	if d.config.DisposableBuiltin {
+		domains = append(domains, bundledDisposableDomains...)
+	}
+	domains = append(domains, d.config.DisposableCustomDomains...)
+	return refreshDomainSet(ctx, d.redis, d.http, disposableDomainsKey, domains, d.config.DisposableListURL)
+}
+
+// refreshDomainSet rebuilds the set at key from local entries and the list
+// at url. Downloaded entries are kept in key:external, replaced only by a
+// successful fetch, so a failing list server leaves its last good copy in
+// place; the local entries are stored either way and the fetch error is
+// still returned for the task's last run.
+func refreshDomainSet(ctx context.Context, redisClient *redis.Client, client *http.Client, key string, local []string, url string) (int, error) {
+	external := key + ":external"
+	var fetchErr error
+	if url == "" {
+		if err := redisClient.Del(ctx, external).Err(); err != nil {
+			return 0, err
+		}
+	} else if domains, err := fetchDomainList(ctx, client, url); err != nil {
+		fetchErr = fmt.Errorf("external list not refreshed, keeping the last copy: %w", err)
+	} else if _, err := swapDomainSet(ctx, redisClient, external, domains); err != nil {
+		return 0, err
+	}
+
+	n, err := swapDomainSet(ctx, redisClient, key, local, external)
+	if err != nil {
+		return 0, err
+	}
+	return n, fetchErr
+}
+
+// swapDomainSet replaces the set at key with domains plus the members of
+// the include sets. The set is built under key:staging and renamed over
+// key, so readers see the old set or the new one.
+func swapDomainSet(ctx context.Context, redisClient *redis.Client, key string, domains []string, include ...string) (int, error) {
+	members := make([]interface{}, 0, len(domains))
+	for _, domain := range domains {
+		if domain = normalizeDomain(domain); domain != "" {
+			members = append(members, domain)
+		}
+	}
+
+	staging := key + ":staging"
+	pipe := redisClient.TxPipeline()
+	pipe.Del(ctx, staging)
+	if len(members) > 0 {
+		pipe.SAdd(ctx, staging, members...)
+	}
+	if len(include) > 0 {
+		pipe.SUnionStore(ctx, staging, append([]string{staging}, include...)...)
+	}
+	card := pipe.SCard(ctx, staging)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	// RENAME fails on a missing key, and an empty set does not exist
+	if card.Val() == 0 {
+		return 0, redisClient.Del(ctx, key).Err()
+	}
+	return int(card.Val()), redisClient.Rename(ctx, staging, key).Err()
+}
+
+// fetchDomainList downloads a plain-text list: one domain per line, #
+// comments allowed
+func fetchDomainList(ctx context.Context, client *http.Client, url string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+
+	var domains []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			domains = append(domains, line)
+		}
+		if len(domains) > maxDisposableListLen {
+			return nil, fmt.Errorf("external list exceeds %d entries", maxDisposableListLen)
+		}
+	}
+	return domains, scanner.Err()
+}
+
+// isDisposableDomain checks the shared set
+func (v *SMTPVerifier) isDisposableDomain(ctx context.Context, domain string) bool {
+	found, err := v.redis.SIsMember(ctx, disposableDomainsKey, domain).Result()
+	return err == nil && found
+}
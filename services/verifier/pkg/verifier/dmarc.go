@@ -0,0 +1,277 @@
+package verifier
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// DMARC AGGREGATE (RUA) REPORTS
+// ============================================================================
+
+const (
+	MaxDMARCReportSize = 10 << 20
+	dmarcTopSources    = 20
+)
+
+var ErrDuplicateDMARCReport = errors.New("dmarc report already ingested")
+
+// DMARCFeedback mirrors the aggregate report schema in RFC 7489 Appendix C
+type DMARCFeedback struct {
+	XMLName  xml.Name `xml:"feedback"`
+	Metadata struct {
+		OrgName   string `xml:"org_name"`
+		Email     string `xml:"email"`
+		ReportID  string `xml:"report_id"`
+		DateRange struct {
+			Begin int64 `xml:"begin"`
+			End   int64 `xml:"end"`
+		} `xml:"date_range"`
+	} `xml:"report_metadata"`
+	Policy struct {
+		Domain string `xml:"domain"`
+		ADKIM  string `xml:"adkim"`
+		ASPF   string `xml:"aspf"`
+		P      string `xml:"p"`
+		SP     string `xml:"sp"`
+		Pct    int    `xml:"pct"`
+	} `xml:"policy_published"`
+	Records []DMARCRecord `xml:"record"`
+}
+
+type DMARCRecord struct {
+	Row struct {
+		SourceIP        string `xml:"source_ip"`
+		Count           int64  `xml:"count"`
+		PolicyEvaluated struct {
+			Disposition string `xml:"disposition"`
+			DKIM        string `xml:"dkim"`
+			SPF         string `xml:"spf"`
+		} `xml:"policy_evaluated"`
+	} `xml:"row"`
+	Identifiers struct {
+		HeaderFrom string `xml:"header_from"`
+	} `xml:"identifiers"`
+}
+
+type DMARCIngestResponse struct {
+	Domain     string    `json:"domain"`
+	OrgName    string    `json:"org_name"`
+	ReportID   string    `json:"report_id"`
+	Records    int       `json:"records"`
+	Messages   int64     `json:"messages"`
+	BeginDate  time.Time `json:"begin_date"`
+	EndDate    time.Time `json:"end_date"`
+	Duplicated bool      `json:"duplicated"`
+}
+
+type DMARCDaySummary struct {
+	Date         string  `json:"date"`
+	Messages     int64   `json:"messages"`
+	DKIMAligned  int64   `json:"dkim_aligned"`
+	SPFAligned   int64   `json:"spf_aligned"`
+	DMARCPass    int64   `json:"dmarc_pass"`
+	Quarantined  int64   `json:"quarantined"`
+	Rejected     int64   `json:"rejected"`
+	PassRate     float64 `json:"pass_rate"`
+	ReportsCount int64   `json:"reports"`
+}
+
+type DMARCSource struct {
+	SourceIP string `json:"source_ip"`
+	Messages int64  `json:"messages"`
+}
+
+type DMARCSummary struct {
+	Domain     string             `json:"domain"`
+	Days       []*DMARCDaySummary `json:"days"`
+	TopSources []DMARCSource      `json:"top_sources"`
+}
+
+// ParseDMARCReport accepts raw XML or the gzip/zip wrappers receivers send
+func ParseDMARCReport(data []byte) (*DMARCFeedback, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip report: %w", err)
+		}
+		defer gz.Close()
+		if data, err = io.ReadAll(io.LimitReader(gz, MaxDMARCReportSize)); err != nil {
+			return nil, fmt.Errorf("invalid gzip report: %w", err)
+		}
+
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zip report: %w", err)
+		}
+		if len(zr.File) == 0 {
+			return nil, errors.New("empty zip report")
+		}
+		f, err := zr.File[0].Open()
+		if err != nil {
+			return nil, fmt.Errorf("invalid zip report: %w", err)
+		}
+		defer f.Close()
+		if data, err = io.ReadAll(io.LimitReader(f, MaxDMARCReportSize)); err != nil {
+			return nil, fmt.Errorf("invalid zip report: %w", err)
+		}
+	}
+
+	var feedback DMARCFeedback
+	if err := xml.Unmarshal(data, &feedback); err != nil {
+		return nil, fmt.Errorf("invalid report xml: %w", err)
+	}
+	if feedback.Policy.Domain == "" || feedback.Metadata.ReportID == "" {
+		return nil, errors.New("report is missing policy domain or report id")
+	}
+
+	return &feedback, nil
+}
+
+// IngestDMARCReport stores a parsed report and folds it into per-day,
+// per-domain aggregates. Re-submitted reports are detected and skipped.
+func (v *SMTPVerifier) IngestDMARCReport(ctx context.Context, feedback *DMARCFeedback) (*DMARCIngestResponse, error) {
+	domain := normalizeDomain(feedback.Policy.Domain)
+	begin := time.Unix(feedback.Metadata.DateRange.Begin, 0).UTC()
+	resp := &DMARCIngestResponse{
+		Domain:    domain,
+		OrgName:   feedback.Metadata.OrgName,
+		ReportID:  feedback.Metadata.ReportID,
+		Records:   len(feedback.Records),
+		BeginDate: begin,
+		EndDate:   time.Unix(feedback.Metadata.DateRange.End, 0).UTC(),
+	}
+	for _, rec := range feedback.Records {
+		resp.Messages += rec.Row.Count
+	}
+
+	retention := v.cfg().DMARCReportRetention
+	reportKey := fmt.Sprintf("dmarc:report:%s:%s", feedback.Metadata.OrgName, feedback.Metadata.ReportID)
+	data, err := json.Marshal(feedback)
+	if err != nil {
+		return nil, err
+	}
+	created, err := v.redis.SetNX(ctx, reportKey, data, retention).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		resp.Duplicated = true
+		return resp, ErrDuplicateDMARCReport
+	}
+
+	date := begin.Format("2006-01-02")
+	statsKey := "dmarc:stats:" + domain + ":" + date
+	sourcesKey := "dmarc:sources:" + domain + ":" + date
+
+	pipe := v.redis.TxPipeline()
+	pipe.HIncrBy(ctx, statsKey, "reports", 1)
+	for _, rec := range feedback.Records {
+		count := rec.Row.Count
+		eval := rec.Row.PolicyEvaluated
+		pipe.HIncrBy(ctx, statsKey, "messages", count)
+		if eval.DKIM == "pass" {
+			pipe.HIncrBy(ctx, statsKey, "dkim_aligned", count)
+		}
+		if eval.SPF == "pass" {
+			pipe.HIncrBy(ctx, statsKey, "spf_aligned", count)
+		}
+		if eval.DKIM == "pass" || eval.SPF == "pass" {
+			pipe.HIncrBy(ctx, statsKey, "dmarc_pass", count)
+		}
+		switch eval.Disposition {
+		case "quarantine":
+			pipe.HIncrBy(ctx, statsKey, "quarantined", count)
+		case "reject":
+			pipe.HIncrBy(ctx, statsKey, "rejected", count)
+		}
+		if rec.Row.SourceIP != "" {
+			pipe.ZIncrBy(ctx, sourcesKey, float64(count), rec.Row.SourceIP)
+		}
+	}
+	pipe.Expire(ctx, statsKey, retention)
+	pipe.Expire(ctx, sourcesKey, retention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// DMARCSummary returns daily alignment/volume figures for the last n days
+func (v *SMTPVerifier) DMARCSummary(ctx context.Context, domain string, days int) (*DMARCSummary, error) {
+	domain = normalizeDomain(domain)
+	summary := &DMARCSummary{Domain: domain, Days: []*DMARCDaySummary{}}
+	today := time.Now().UTC()
+
+	pipe := v.redis.Pipeline()
+	statsCmds := make([]*redis.MapStringStringCmd, days)
+	sourceCmds := make([]*redis.ZSliceCmd, days)
+	dates := make([]string, days)
+	for i := 0; i < days; i++ {
+		dates[i] = today.AddDate(0, 0, -i).Format("2006-01-02")
+		statsCmds[i] = pipe.HGetAll(ctx, "dmarc:stats:"+domain+":"+dates[i])
+		sourceCmds[i] = pipe.ZRevRangeWithScores(ctx, "dmarc:sources:"+domain+":"+dates[i], 0, dmarcTopSources-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	sources := make(map[string]int64)
+	for i := range dates {
+		fields, _ := statsCmds[i].Result()
+		if len(fields) > 0 {
+			day := &DMARCDaySummary{
+				Date:         dates[i],
+				Messages:     parseCounter(fields["messages"]),
+				DKIMAligned:  parseCounter(fields["dkim_aligned"]),
+				SPFAligned:   parseCounter(fields["spf_aligned"]),
+				DMARCPass:    parseCounter(fields["dmarc_pass"]),
+				Quarantined:  parseCounter(fields["quarantined"]),
+				Rejected:     parseCounter(fields["rejected"]),
+				ReportsCount: parseCounter(fields["reports"]),
+			}
+			if day.Messages > 0 {
+				day.PassRate = float64(day.DMARCPass) / float64(day.Messages)
+			}
+			summary.Days = append(summary.Days, day)
+		}
+
+		members, _ := sourceCmds[i].Result()
+		for _, z := range members {
+			sources[fmt.Sprint(z.Member)] += int64(z.Score)
+		}
+	}
+
+	for ip, count := range sources {
+		summary.TopSources = append(summary.TopSources, DMARCSource{SourceIP: ip, Messages: count})
+	}
+	sort.Slice(summary.TopSources, func(i, j int) bool {
+		return summary.TopSources[i].Messages > summary.TopSources[j].Messages
+	})
+	if len(summary.TopSources) > dmarcTopSources {
+		summary.TopSources = summary.TopSources[:dmarcTopSources]
+	}
+
+	return summary, nil
+}
+
+func parseCounter(val string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+	return n
+}
@@ -0,0 +1,328 @@
+package verifier
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ============================================================================
+// DNS RESOLVER
+// ============================================================================
+
+// With DNSServers set, lookups go to those servers in order instead of the
+// system resolver (/etc/resolv.conf), so containers can use internal
+// resolvers. Each server gets DNSTimeout per query; a timeout, refusal or
+// other transport failure moves the query to the next one. An authoritative
+// "no such domain" or "no records" answer is final and does not fail over.
+//
+// Record types the Go resolver cannot ask for (TLSA) are queried directly
+// with golang.org/x/net/dns/dnsmessage, over UDP with a TCP retry when the
+// answer is truncated, against the same servers; the system resolver's are
+// read from /etc/resolv.conf.
+
+const (
+	defaultDNSPort = "53"
+	resolvConfPath = "/etc/resolv.conf"
+	dnsUDPSize     = 4096
+	dnsTypeTLSA    = dnsmessage.Type(52)
+)
+
+type dnsUpstream struct {
+	server   string // host:port; "" for the system resolver
+	resolver *net.Resolver
+}
+
+type dnsResolver struct {
+	upstreams []dnsUpstream
+	timeout   time.Duration // per query and server; 0 = caller's deadline only
+}
+
+func newDNSResolver(servers []string, timeout time.Duration) *dnsResolver {
+	r := &dnsResolver{timeout: timeout}
+	for _, server := range servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, defaultDNSPort)
+		}
+		r.upstreams = append(r.upstreams, dnsUpstream{server: server, resolver: upstreamResolver(server)})
+	}
+	if len(r.upstreams) == 0 {
+		r.upstreams = []dnsUpstream{{resolver: net.DefaultResolver}}
+	}
+	return r
+}
+
+// upstreamResolver sends every query to server, whatever resolv.conf lists
+func upstreamResolver(server string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+func (r *dnsResolver) LookupMX(ctx context.Context, domain string) ([]*net.MX, error) {
+	var mxs []*net.MX
+	err := r.query(ctx, domain, func(ctx context.Context, res *net.Resolver) (err error) {
+		mxs, err = res.LookupMX(ctx, domain)
+		return err
+	})
+	return mxs, err
+}
+
+func (r *dnsResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	var txts []string
+	err := r.query(ctx, name, func(ctx context.Context, res *net.Resolver) (err error) {
+		txts, err = res.LookupTXT(ctx, name)
+		return err
+	})
+	return txts, err
+}
+
+func (r *dnsResolver) LookupHost(ctx context.Context, domain string) ([]string, error) {
+	var addrs []string
+	err := r.query(ctx, domain, func(ctx context.Context, res *net.Resolver) (err error) {
+		addrs, err = res.LookupHost(ctx, domain)
+		return err
+	})
+	return addrs, err
+}
+
+// query runs lookup against each upstream until one answers
+func (r *dnsResolver) query(ctx context.Context, domain string, lookup func(context.Context, *net.Resolver) error) error {
+	var err error
+	for i, upstream := range r.upstreams {
+		queryCtx, cancel := ctx, context.CancelFunc(func() {})
+		if r.timeout > 0 {
+			queryCtx, cancel = context.WithTimeout(ctx, r.timeout)
+		}
+		err = lookup(queryCtx, upstream.resolver)
+		cancel()
+
+		var dnsErr *net.DNSError
+		if err == nil || (errors.As(err, &dnsErr) && dnsErr.IsNotFound) || ctx.Err() != nil {
+			return err
+		}
+		if i < len(r.upstreams)-1 {
+			slog.WarnContext(ctx, "DNS server failed, trying next", "server", upstream.server, "domain", domain, "error", err)
+		}
+	}
+	return err
+}
+
+// TLSARecord is one TLSA record (RFC 6698)
+type TLSARecord struct {
+	Usage        uint8  `json:"usage"`         // 0-3; 3 (DANE-EE) and 2 (DANE-TA) are used for SMTP
+	Selector     uint8  `json:"selector"`      // 0 full certificate, 1 public key
+	MatchingType uint8  `json:"matching_type"` // 0 exact, 1 SHA-256, 2 SHA-512
+	Data         string `json:"data"`          // Hex
+}
+
+// LookupTLSA returns the TLSA records at name and whether the resolver
+// validated them with DNSSEC (the AD bit). No such name is no records.
+func (r *dnsResolver) LookupTLSA(ctx context.Context, name string) (records []TLSARecord, authenticated bool, err error) {
+	query, id, err := dnsQuery(name, dnsTypeTLSA)
+	if err != nil {
+		return nil, false, err
+	}
+	servers := r.rawServers()
+	for i, server := range servers {
+		queryCtx, cancel := ctx, context.CancelFunc(func() {})
+		if r.timeout > 0 {
+			queryCtx, cancel = context.WithTimeout(ctx, r.timeout)
+		}
+		var answer []byte
+		answer, err = dnsExchange(queryCtx, server, query)
+		cancel()
+		if err == nil {
+			records, authenticated, err = parseTLSA(answer, id)
+		}
+		if err == nil || ctx.Err() != nil {
+			return records, authenticated, err
+		}
+		if i < len(servers)-1 {
+			slog.WarnContext(ctx, "DNS server failed, trying next", "server", server, "domain", name, "error", err)
+		}
+	}
+	return nil, false, err
+}
+
+// rawServers returns the servers to send raw queries to
+func (r *dnsResolver) rawServers() []string {
+	var servers []string
+	for _, upstream := range r.upstreams {
+		if upstream.server != "" {
+			servers = append(servers, upstream.server)
+		} else {
+			servers = append(servers, systemNameservers()...)
+		}
+	}
+	return servers
+}
+
+// systemNameservers lists the nameservers in resolv.conf, or the local one
+func systemNameservers() []string {
+	var servers []string
+	if f, err := os.Open(resolvConfPath); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				servers = append(servers, net.JoinHostPort(fields[1], defaultDNSPort))
+			}
+		}
+	}
+	if len(servers) == 0 {
+		servers = []string{net.JoinHostPort("127.0.0.1", defaultDNSPort)}
+	}
+	return servers
+}
+
+// dnsQuery builds a recursive query for name and qtype asking for DNSSEC
+// validation
+func dnsQuery(name string, qtype dnsmessage.Type) ([]byte, uint16, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	var idBytes [2]byte
+	rand.Read(idBytes[:])
+	id := binary.BigEndian.Uint16(idBytes[:])
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true, AuthenticData: true})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, 0, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, 0, err
+	}
+	if err := b.StartAdditionals(); err != nil {
+		return nil, 0, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(dnsUDPSize, dnsmessage.RCodeSuccess, true); err != nil {
+		return nil, 0, err
+	}
+	if err := b.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, 0, err
+	}
+	msg, err := b.Finish()
+	return msg, id, err
+}
+
+// dnsExchange sends query to server over UDP, and again over TCP when the
+// answer comes back truncated
+func dnsExchange(ctx context.Context, server string, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	answer := make([]byte, dnsUDPSize)
+	n, err := conn.Read(answer)
+	if err != nil {
+		return nil, err
+	}
+	answer = answer[:n]
+	var p dnsmessage.Parser
+	if h, err := p.Start(answer); err != nil || !h.Truncated {
+		return answer, err
+	}
+
+	tcp, err := d.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer tcp.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		tcp.SetDeadline(deadline)
+	}
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := tcp.Write(append(framed, query...)); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(tcp, length[:]); err != nil {
+		return nil, err
+	}
+	answer = make([]byte, binary.BigEndian.Uint16(length[:]))
+	_, err = io.ReadFull(tcp, answer)
+	return answer, err
+}
+
+// parseTLSA reads the TLSA answers of the reply to query id
+func parseTLSA(answer []byte, id uint16) ([]TLSARecord, bool, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(answer)
+	if err != nil {
+		return nil, false, err
+	}
+	if h.ID != id {
+		return nil, false, errors.New("DNS reply ID mismatch")
+	}
+	switch h.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, h.AuthenticData, nil
+	default:
+		return nil, false, fmt.Errorf("DNS query failed: %s", h.RCode)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, false, err
+	}
+	var records []TLSARecord
+	for {
+		rh, err := p.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if rh.Type != dnsTypeTLSA {
+			if err := p.SkipAnswer(); err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+		res, err := p.UnknownResource()
+		if err != nil {
+			return nil, false, err
+		}
+		if len(res.Data) < 4 {
+			continue
+		}
+		records = append(records, TLSARecord{
+			Usage:        res.Data[0],
+			Selector:     res.Data[1],
+			MatchingType: res.Data[2],
+			Data:         fmt.Sprintf("%x", res.Data[3:]),
+		})
+	}
+	return records, h.AuthenticData, nil
+}
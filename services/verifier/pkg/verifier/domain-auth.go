@@ -0,0 +1,361 @@
+package verifier
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+// ============================================================================
+// SENDER AUTHENTICATION RECORDS (SPF, DMARC, DKIM)
+// ============================================================================
+
+// DomainAuth reads what a domain publishes to authenticate its mail, for
+// qualifying sender domains: the SPF record with its mechanisms and final
+// "all", the DMARC policy with its report addresses, and the DKIM keys under
+// commonly used selectors. DKIM selectors cannot be listed, so a domain
+// signing with another selector shows none. Only the domain's own records
+// are read: includes and redirects are named, not followed, and a missing
+// _dmarc record is not looked for at the organizational domain.
+//
+// Answers are cached in Redis for MXCacheTTL, like MX records; a lookup that
+// failed rather than finding nothing is not cached.
+
+// ErrInvalidDomain is returned for a name that is not a domain
+var ErrInvalidDomain = errors.New("invalid domain name")
+
+// dkimSelectors are tried in order; those of the large mail platforms and
+// ESPs, then generic names
+var dkimSelectors = []string{
+	"google", "selector1", "selector2", "k1", "k2", "k3", "s1", "s2",
+	"fm1", "fm2", "fm3", "zoho", "protonmail", "mandrill", "mxvault",
+	"default", "dkim", "mail", "smtp", "key1", "key2", "sig1",
+}
+
+// DomainAuth is a domain's SPF, DMARC and DKIM records
+type DomainAuth struct {
+	Domain    string       `json:"domain"`
+	SPF       *SPFRecord   `json:"spf,omitempty"`
+	DMARC     *DMARCPolicy `json:"dmarc,omitempty"`
+	DKIM      []DKIMKey    `json:"dkim"`
+	Errors    []string     `json:"errors,omitempty"` // Lookups that failed; the answer is incomplete
+	CheckedAt time.Time    `json:"checked_at"`
+}
+
+// SPFRecord is a parsed SPF record (RFC 7208)
+type SPFRecord struct {
+	Raw        string         `json:"raw"`
+	Mechanisms []SPFMechanism `json:"mechanisms"`
+	All        string         `json:"all,omitempty"` // -all, ~all, ?all or +all
+	Includes   []string       `json:"includes,omitempty"`
+	Redirect   string         `json:"redirect,omitempty"`
+	Lookups    int            `json:"dns_lookups"`     // Terms needing DNS here; RFC 7208 allows 10 in total
+	Error      string         `json:"error,omitempty"` // Why receivers would treat it as a permerror
+}
+
+// SPFMechanism is one mechanism of an SPF record
+type SPFMechanism struct {
+	Qualifier string `json:"qualifier"` // + pass, - fail, ~ softfail, ? neutral
+	Type      string `json:"type"`      // all, include, a, mx, ptr, ip4, ip6, exists
+	Value     string `json:"value,omitempty"`
+}
+
+// DMARCPolicy is a parsed DMARC record (RFC 7489)
+type DMARCPolicy struct {
+	Raw             string   `json:"raw"`
+	Policy          string   `json:"policy"` // none, quarantine or reject
+	SubdomainPolicy string   `json:"subdomain_policy,omitempty"`
+	Pct             int      `json:"pct"`
+	RUA             []string `json:"rua,omitempty"` // Aggregate report addresses
+	RUF             []string `json:"ruf,omitempty"` // Failure report addresses
+	ADKIM           string   `json:"adkim"`         // r relaxed or s strict
+	ASPF            string   `json:"aspf"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// DKIMKey is the key published under one selector
+type DKIMKey struct {
+	Selector string `json:"selector"`
+	KeyType  string `json:"key_type"`       // rsa or ed25519
+	Bits     int    `json:"bits,omitempty"` // Key size; 0 when unreadable
+	Revoked  bool   `json:"revoked,omitempty"`
+	Testing  bool   `json:"testing,omitempty"` // t=y
+}
+
+func domainAuthKey(domain string) string {
+	return "domain:auth:" + domain
+}
+
+// canonicalDomain returns the ASCII form of a domain given to the API, or
+// ErrInvalidDomain
+func canonicalDomain(domain string) (string, error) {
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), "."))
+	if err != nil || strings.HasPrefix(domain, "*.") || !strings.Contains(domain, ".") || !validDomainPattern(domain) {
+		return "", ErrInvalidDomain
+	}
+	return domain, nil
+}
+
+// DomainAuth returns the SPF, DMARC and DKIM records of domain
+func (v *SMTPVerifier) DomainAuth(ctx context.Context, domain string) (*DomainAuth, error) {
+	domain, err := canonicalDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	if !v.skipCache(v.redis) {
+		if data, err := v.redis.Get(ctx, domainAuthKey(domain)).Bytes(); err == nil {
+			var auth DomainAuth
+			if json.Unmarshal(data, &auth) == nil {
+				return &auth, nil
+			}
+		}
+	}
+
+	auth := &DomainAuth{Domain: domain, DKIM: []DKIMKey{}, CheckedAt: time.Now().UTC()}
+	var mu sync.Mutex
+	failed := func(what string, err error) {
+		mu.Lock()
+		auth.Errors = append(auth.Errors, what+": "+err.Error())
+		mu.Unlock()
+	}
+	var wg sync.WaitGroup
+	wg.Add(2 + len(dkimSelectors))
+	go func() {
+		defer wg.Done()
+		txts, err := v.authTXT(ctx, domain)
+		if err != nil {
+			failed("spf", err)
+		}
+		auth.SPF = parseSPF(txts)
+	}()
+	go func() {
+		defer wg.Done()
+		txts, err := v.authTXT(ctx, "_dmarc."+domain)
+		if err != nil {
+			failed("dmarc", err)
+		}
+		auth.DMARC = parseDMARC(txts)
+	}()
+	keys := make([]*DKIMKey, len(dkimSelectors))
+	for i, selector := range dkimSelectors {
+		go func(i int, selector string) {
+			defer wg.Done()
+			txts, err := v.authTXT(ctx, selector+"._domainkey."+domain)
+			if err != nil {
+				failed("dkim "+selector, err)
+			}
+			keys[i] = parseDKIM(selector, txts)
+		}(i, selector)
+	}
+	wg.Wait()
+	for _, key := range keys {
+		if key != nil {
+			auth.DKIM = append(auth.DKIM, *key)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if len(auth.Errors) == 0 && !v.skipCache(v.redis) {
+		if data, err := json.Marshal(auth); err == nil {
+			v.redis.Set(ctx, domainAuthKey(domain), data, v.cfg().MXCacheTTL)
+		}
+	}
+	return auth, nil
+}
+
+// authTXT returns the TXT records at name; a name without any is no error
+func (v *SMTPVerifier) authTXT(ctx context.Context, name string) ([]string, error) {
+	txts, err := v.dns.LookupTXT(ctx, name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, nil
+	}
+	return txts, err
+}
+
+// parseSPF parses the domain's SPF record, or returns nil without one
+func parseSPF(txts []string) *SPFRecord {
+	var records []string
+	for _, txt := range txts {
+		lower := strings.ToLower(txt)
+		if lower == "v=spf1" || strings.HasPrefix(lower, "v=spf1 ") {
+			records = append(records, txt)
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	spf := &SPFRecord{Raw: records[0], Mechanisms: []SPFMechanism{}}
+	if len(records) > 1 {
+		spf.Error = "more than one SPF record"
+	}
+
+	for _, term := range strings.Fields(records[0])[1:] {
+		if name, value, ok := strings.Cut(term, "="); ok && !strings.ContainsAny(name, ":/") {
+			if strings.EqualFold(name, "redirect") {
+				spf.Redirect = value
+				spf.Lookups++
+			}
+			continue
+		}
+		m := SPFMechanism{Qualifier: "+"}
+		if strings.ContainsRune("+-~?", rune(term[0])) {
+			m.Qualifier, term = term[:1], term[1:]
+		}
+		m.Type = term
+		if i := strings.IndexAny(term, ":/"); i >= 0 {
+			m.Type, m.Value = term[:i], strings.TrimPrefix(term[i:], ":")
+		}
+		m.Type = strings.ToLower(m.Type)
+		switch m.Type {
+		case "include":
+			spf.Includes = append(spf.Includes, m.Value)
+			spf.Lookups++
+		case "a", "mx", "ptr", "exists":
+			spf.Lookups++
+		case "all":
+			spf.All = m.Qualifier + "all"
+		case "ip4", "ip6":
+		default:
+			if spf.Error == "" {
+				spf.Error = "unknown mechanism " + m.Type
+			}
+		}
+		spf.Mechanisms = append(spf.Mechanisms, m)
+	}
+	if spf.Lookups > 10 && spf.Error == "" {
+		spf.Error = "more than 10 DNS lookups"
+	}
+	return spf
+}
+
+// parseDMARC parses the domain's DMARC record, or returns nil without one
+func parseDMARC(txts []string) *DMARCPolicy {
+	var raw string
+	for _, txt := range txts {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(txt)), "v=dmarc1") {
+			raw = txt
+			break
+		}
+	}
+	if raw == "" {
+		return nil
+	}
+	dmarc := &DMARCPolicy{Raw: raw, Pct: 100, ADKIM: "r", ASPF: "r"}
+	for _, tag := range strings.Split(raw, ";") {
+		name, value, ok := strings.Cut(tag, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "p":
+			dmarc.Policy = strings.ToLower(value)
+		case "sp":
+			dmarc.SubdomainPolicy = strings.ToLower(value)
+		case "pct":
+			if pct, err := strconv.Atoi(value); err == nil {
+				dmarc.Pct = pct
+			}
+		case "rua":
+			dmarc.RUA = dmarcAddresses(value)
+		case "ruf":
+			dmarc.RUF = dmarcAddresses(value)
+		case "adkim":
+			dmarc.ADKIM = strings.ToLower(value)
+		case "aspf":
+			dmarc.ASPF = strings.ToLower(value)
+		}
+	}
+	switch dmarc.Policy {
+	case "none", "quarantine", "reject":
+	case "":
+		dmarc.Error = "no p= policy"
+	default:
+		dmarc.Error = "unknown policy " + dmarc.Policy
+	}
+	return dmarc
+}
+
+// dmarcAddresses lists the addresses of a rua or ruf tag, without mailto:
+// and size limits
+func dmarcAddresses(value string) []string {
+	var addrs []string
+	for _, uri := range strings.Split(value, ",") {
+		uri = strings.TrimSpace(uri)
+		if i := strings.LastIndexByte(uri, '!'); i > 0 {
+			uri = uri[:i]
+		}
+		if len(uri) > len("mailto:") && strings.EqualFold(uri[:len("mailto:")], "mailto:") {
+			uri = uri[len("mailto:"):]
+		}
+		if uri != "" {
+			addrs = append(addrs, uri)
+		}
+	}
+	return addrs
+}
+
+// parseDKIM reads the key published under selector, or returns nil
+func parseDKIM(selector string, txts []string) *DKIMKey {
+	for _, txt := range txts {
+		tags := make(map[string]string)
+		for _, tag := range strings.Split(txt, ";") {
+			if name, value, ok := strings.Cut(tag, "="); ok {
+				tags[strings.ToLower(strings.TrimSpace(name))] = strings.Join(strings.Fields(value), "")
+			}
+		}
+		p, hasKey := tags["p"]
+		if v, ok := tags["v"]; (ok && v != "DKIM1") || !hasKey {
+			continue
+		}
+		key := &DKIMKey{Selector: selector, KeyType: strings.ToLower(tags["k"]), Revoked: p == ""}
+		if key.KeyType == "" {
+			key.KeyType = "rsa"
+		}
+		for _, flag := range strings.Split(tags["t"], ":") {
+			if flag == "y" {
+				key.Testing = true
+			}
+		}
+		if der, err := base64.StdEncoding.DecodeString(p); err == nil && len(der) > 0 {
+			key.Bits = dkimKeyBits(key.KeyType, der)
+		}
+		return key
+	}
+	return nil
+}
+
+// dkimKeyBits returns the size of a DKIM public key, or 0
+func dkimKeyBits(keyType string, der []byte) int {
+	if keyType == "ed25519" {
+		if len(der) == ed25519.PublicKeySize {
+			return 256
+		}
+		return 0
+	}
+	if pub, err := x509.ParsePKIXPublicKey(der); err == nil {
+		if rsaKey, ok := pub.(*rsa.PublicKey); ok {
+			return rsaKey.N.BitLen()
+		}
+		return 0
+	}
+	if rsaKey, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return rsaKey.N.BitLen()
+	}
+	return 0
+}
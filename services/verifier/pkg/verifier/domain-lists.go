@@ -0,0 +1,79 @@
+package verifier
+
+import "slices"
+
+// ============================================================================
+// DOMAIN ALLOWLIST AND BLOCKLISTS
+// ============================================================================
+
+// Three configured lists answer for whole domains before the MX lookup:
+//
+//	allowlist    internal domains, always valid          valid/allowlisted_domain
+//	blocklist    known spam farms, always invalid        invalid/blocklisted_domain
+//	never_probe  partners who asked us to stop probing   unknown/never_probe_domain
+//
+// Entries are patterns as in domain-policy.go ("example.com" or
+// "*.example.com"). A domain on several lists takes the first in the order
+// above. Allowlist and blocklist verdicts hold at every depth but syntax and
+// bypass the cache; never_probe only stops SMTP, so depth dns still looks up
+// MX records. The matching entry is reported in domain_policy.
+
+// Domain list reasons
+const (
+	ReasonAllowlisted = "allowlisted_domain"
+	ReasonBlocklisted = "blocklisted_domain"
+	ReasonNeverProbe  = "never_probe_domain"
+)
+
+// domainListKeys are the config keys (under domain_lists) of each reason's list
+var domainListKeys = map[string]string{
+	ReasonAllowlisted: "allowlist",
+	ReasonBlocklisted: "blocklist",
+	ReasonNeverProbe:  "never_probe",
+}
+
+// domainListing is the list a domain is on
+type domainListing struct {
+	status     ValidationStatus
+	reason     string
+	confidence float64
+	pattern    string
+	probing    bool // Applies only when the check would probe
+}
+
+// listedDomain returns the first list domain is on, or nil
+func (v *SMTPVerifier) listedDomain(domain string) *domainListing {
+	cfg := v.cfg()
+	for _, list := range []struct {
+		patterns []string
+		listing  domainListing
+	}{
+		{cfg.AllowlistDomains, domainListing{status: StatusValid, reason: ReasonAllowlisted, confidence: 1.0}},
+		{cfg.BlocklistDomains, domainListing{status: StatusInvalid, reason: ReasonBlocklisted, confidence: 1.0}},
+		{cfg.NeverProbeDomains, domainListing{status: StatusUnknown, reason: ReasonNeverProbe, confidence: 0.1, probing: true}},
+	} {
+		if len(list.patterns) == 0 {
+			continue
+		}
+		pattern := matchDomainPattern(domain, func(pattern string) bool {
+			return slices.Contains(list.patterns, pattern)
+		})
+		if pattern != "" {
+			listing := list.listing
+			listing.pattern = pattern
+			return &listing
+		}
+	}
+	return nil
+}
+
+// addressListed reports whether email's domain has an allowlist or
+// blocklist verdict
+func (v *SMTPVerifier) addressListed(email string) bool {
+	domain, err := parseAddress(email)
+	if err != nil {
+		return false
+	}
+	listing := v.listedDomain(domain)
+	return listing != nil && !listing.probing
+}
@@ -0,0 +1,157 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// DOMAIN METADATA WRITER
+// ============================================================================
+
+// domain:meta:{domain} is the single record of what we know about a domain:
+// disposable flag, catch-all verdict, MX snapshot and validation stats. Every
+// fresh verification folds into it, catch-all probes and DNS lookups record
+// their outcome in it, and readers (catch-all detection, pre-flight) use
+// nothing else.
+
+// domainMetaMerge merges a JSON patch into the record in one step, so
+// concurrent verifications of a busy domain never drop each other's updates.
+// ARGV: TTL in ms, patch, status to count ("" for none), time of that
+// verification. Returns 1 when a patched field actually changed; the stats
+// never count as a change. cjson encodes an empty array as {}, which Go
+// cannot read into a slice, so empty arrays are stored as absent fields
+// (every slice field is omitempty); records already holding {} are repaired
+// on their next merge.
+var domainMetaMerge = redis.NewScript(`
+local function empty(value)
+	return type(value) == 'table' and next(value) == nil
+end
+
+local meta = {}
+local raw = redis.call('GET', KEYS[1])
+if raw then
+	local ok, decoded = pcall(cjson.decode, raw)
+	if ok and type(decoded) == 'table' then meta = decoded end
+end
+for field, value in pairs(meta) do
+	if empty(value) then meta[field] = nil end
+end
+
+local changed = 0
+for field, value in pairs(cjson.decode(ARGV[2])) do
+	if empty(value) then
+		if meta[field] ~= nil then changed = 1 end
+		meta[field] = nil
+	else
+		if meta[field] == nil or cjson.encode(meta[field]) ~= cjson.encode(value) then
+			changed = 1
+		end
+		meta[field] = value
+	end
+end
+
+if ARGV[3] ~= '' then
+	meta.last_validation = ARGV[4]
+	meta.validation_count = (meta.validation_count or 0) + 1
+	local counts = meta.status_counts or {}
+	counts[ARGV[3]] = (counts[ARGV[3]] or 0) + 1
+	meta.status_counts = counts
+end
+
+redis.call('SET', KEYS[1], cjson.encode(meta), 'PX', ARGV[1])
+return changed
+`)
+
+// catchAllInconclusiveTTL is how long a probe sequence that got no definite
+// answer (timeouts, 4xx) stands in for a verdict; long enough that the rest of
+// a batch does not probe again, short enough to retry soon after
+const catchAllInconclusiveTTL = 15 * time.Minute
+
+func domainMetaKey(domain string) string {
+	return "domain:meta:" + domain
+}
+
+// catchAllVerdict returns the recorded catch-all verdict if it is younger
+// than maxAge
+func (m *DomainMetadata) catchAllVerdict(maxAge time.Duration) (bool, bool) {
+	if m == nil || m.IsCatchAll == nil || m.CatchAllChecked == nil {
+		return false, false
+	}
+	if !m.catchAllProbesAnswered() {
+		maxAge = min(maxAge, catchAllInconclusiveTTL)
+	}
+	if time.Since(*m.CatchAllChecked) > maxAge {
+		return false, false
+	}
+	return *m.IsCatchAll, true
+}
+
+// catchAllProbesAnswered reports whether any probe got a definite accept or
+// reject; verdicts recorded before probe codes were kept count as answered
+func (m *DomainMetadata) catchAllProbesAnswered() bool {
+	if m.CatchAllProbes == nil {
+		return true
+	}
+	for _, code := range m.CatchAllProbes {
+		if code >= 200 && code < 300 || code >= 500 {
+			return true
+		}
+	}
+	return false
+}
+
+// updateDomainMetadata merges patch (JSON field names) into the record and,
+// when status is set, counts a verification made at checkedAt. Local copies are only dropped when a
+// patched field changed, so busy domains do not flood cache:events; stats in
+// a local copy may lag by up to the local TTL.
+func (v *SMTPVerifier) updateDomainMetadata(ctx context.Context, domain string, patch map[string]interface{}, status ValidationStatus, checkedAt time.Time) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	ttl := v.cfg().DomainMetaCacheTTL.Milliseconds()
+	changed, err := domainMetaMerge.Run(ctx, v.redis, []string{domainMetaKey(domain)}, ttl, data, string(status), checkedAt.Format(time.RFC3339Nano)).Int()
+	if err != nil {
+		return err
+	}
+	if changed == 1 {
+		v.publishCacheEvent(ctx, CacheEventDomainUpdated, domain)
+	}
+	return nil
+}
+
+// recordVerification folds a fresh result into its domain's record
+func (v *SMTPVerifier) recordVerification(ctx context.Context, result *ValidationResult) {
+	if result.Domain == "" {
+		return
+	}
+	patch := map[string]interface{}{"is_disposable": result.IsDisposable}
+	if err := v.updateDomainMetadata(ctx, result.Domain, patch, result.Status, result.CheckedAt); err != nil {
+		slog.WarnContext(ctx, "domain metadata update failed", "domain", result.Domain, "error", err)
+	}
+}
+
+// recordCatchAll stores the outcome of a catch-all probe
+func (v *SMTPVerifier) recordCatchAll(ctx context.Context, domain string, isCatchAll bool, probeCodes []int) {
+	patch := map[string]interface{}{
+		"is_catch_all":          isCatchAll,
+		"catch_all_checked_at":  time.Now(),
+		"catch_all_probe_codes": probeCodes,
+	}
+	if err := v.updateDomainMetadata(ctx, domain, patch, "", time.Time{}); err != nil {
+		slog.WarnContext(ctx, "domain metadata update failed", "domain", domain, "error", err)
+	}
+}
+
+// recordMXSnapshot stores the MX set from a fresh DNS lookup
+func (v *SMTPVerifier) recordMXSnapshot(ctx context.Context, domain string, records []MXRecord) {
+	patch := map[string]interface{}{"mx_records": records}
+	if err := v.updateDomainMetadata(ctx, domain, patch, "", time.Time{}); err != nil {
+		slog.WarnContext(ctx, "domain metadata update failed", "domain", domain, "error", err)
+	}
+}
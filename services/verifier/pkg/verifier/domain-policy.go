@@ -0,0 +1,138 @@
+package verifier
+
+import (
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// PER-DOMAIN POLICIES
+// ============================================================================
+
+// Some receiving domains need handling of their own: slow MX hosts want
+// longer timeouts, strict ones a slower rate, and domains that always
+// tempfail us are better left unprobed. A DomainPolicies entry overrides
+// Config for addresses on matching domains:
+//
+//   - connect_timeout and read_timeout: the SMTP session timeouts.
+//   - rate_limit and rate_burst: the domain's token bucket, replacing
+//     workers.domain_rate_limit and domain_rate_burst for it.
+//   - depth: the deepest check run (see depth.go); "dns" never opens an
+//     SMTP session. Requests for deeper checks are answered at this depth.
+//   - status: the verdict, given without DNS or SMTP (reason domain_policy,
+//     confidence 1). Cached results are bypassed and such results are never
+//     cached, so removing the entry takes effect at once.
+//
+// Patterns are a domain ("example.com", that domain only) or a wildcard
+// ("*.example.com", any subdomain). An exact entry wins over wildcards and
+// the longest wildcard wins among them. Results a policy applied to name the
+// pattern in domain_policy.
+
+// DomainPolicy overrides Config for one domain pattern; zero fields keep
+// the global value
+type DomainPolicy struct {
+	ConnectTimeout time.Duration    `yaml:"connect_timeout"`
+	ReadTimeout    time.Duration    `yaml:"read_timeout"`
+	RateLimit      time.Duration    `yaml:"rate_limit"`
+	RateBurst      int              `yaml:"rate_burst"`
+	Depth          string           `yaml:"depth"`
+	Status         ValidationStatus `yaml:"status"`
+}
+
+// depthOrder ranks depths from shallowest to deepest
+var depthOrder = map[string]int{DepthSyntax: 0, DepthDNS: 1, DepthSMTP: 2, DepthDeep: 3}
+
+// domainPolicy returns the policy for domain and the pattern it matched,
+// or nil
+func (v *SMTPVerifier) domainPolicy(domain string) (*DomainPolicy, string) {
+	policies := v.cfg().DomainPolicies
+	if len(policies) == 0 {
+		return nil, ""
+	}
+	pattern := matchDomainPattern(domain, func(pattern string) bool {
+		_, ok := policies[pattern]
+		return ok
+	})
+	if pattern == "" {
+		return nil, ""
+	}
+	p := policies[pattern]
+	return &p, pattern
+}
+
+// matchDomainPattern returns the most specific pattern has reports for
+// domain: the domain itself, then *.parent from the longest parent up, or ""
+func matchDomainPattern(domain string, has func(pattern string) bool) string {
+	if domain == "" {
+		return ""
+	}
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if has(domain) {
+		return domain
+	}
+	for rest := domain; ; {
+		dot := strings.IndexByte(rest, '.')
+		if dot < 0 {
+			return ""
+		}
+		rest = rest[dot+1:]
+		if has("*." + rest) {
+			return "*." + rest
+		}
+	}
+}
+
+// addressPolicy returns the policy for email's domain, or nil
+func (v *SMTPVerifier) addressPolicy(email string) *DomainPolicy {
+	domain, err := parseAddress(email)
+	if err != nil {
+		return nil
+	}
+	p, _ := v.domainPolicy(domain)
+	return p
+}
+
+// policyDepth caps depth at the policy's
+func (p *DomainPolicy) policyDepth(depth string) string {
+	if p != nil && p.Depth != "" && depthOrder[p.Depth] < depthOrder[depth] {
+		return p.Depth
+	}
+	return depth
+}
+
+// smtpTimeouts returns the connect and read timeouts for domain
+func (v *SMTPVerifier) smtpTimeouts(domain string) (connect, read time.Duration) {
+	connect, read = v.cfg().SMTPConnectTimeout, v.cfg().SMTPReadTimeout
+	if p, _ := v.domainPolicy(domain); p != nil {
+		if p.ConnectTimeout > 0 {
+			connect = p.ConnectTimeout
+		}
+		if p.ReadTimeout > 0 {
+			read = p.ReadTimeout
+		}
+	}
+	return connect, read
+}
+
+// domainRateLimit returns the domain's token bucket interval and burst; a
+// zero interval is unlimited
+func (v *SMTPVerifier) domainRateLimit(domain string) (time.Duration, int) {
+	if p, _ := v.domainPolicy(domain); p != nil && p.RateLimit > 0 {
+		return p.RateLimit, max(p.RateBurst, 1)
+	}
+	return v.cfg().DomainRateLimit, max(v.cfg().DomainRateBurst, 1)
+}
+
+// validDomainPattern reports a domain or *.domain pattern in lower case
+func validDomainPattern(pattern string) bool {
+	name := strings.TrimPrefix(pattern, "*.")
+	if name == "" || name != strings.ToLower(name) || strings.ContainsAny(name, "*@ \t/:") {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,186 @@
+package verifier
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"log"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// DOMAIN REPORTS
+// ============================================================================
+
+// DomainReport gathers what we know about a receiving domain in one place:
+// its MX records, the catch-all verdict and disposable and free-provider
+// flags, the statuses of our own past verifications there, a risk score for
+// its TLD, and a composite risk score from 0 (no concern) to 100 (mail will
+// not reach anyone). The composite adds up:
+//
+//	tld_risk       30% of the TLD's score (data/tld-risk.yaml)
+//	catch_all      15 when the domain accepts any address
+//	invalid_ratio  up to 50, the share of invalid results once there are
+//	               domainReportMinSamples of them
+//	mx_blocklisted 25 when an MX host address is on a configured DNSBL
+//
+// and is raised to 90 for disposable domains and to 100 for domains that
+// accept no mail. Each part that applied is named in risk_factors. The
+// report reads cached data and DNS only; it never opens an SMTP session.
+
+//go:embed data/tld-risk.yaml
+var bundledTLDRisk []byte
+
+const domainReportMinSamples = 20
+
+// Risk factors named in domain reports
+const (
+	RiskTLD           = "tld_risk"
+	RiskCatchAll      = "catch_all"
+	RiskInvalidRatio  = "invalid_ratio"
+	RiskMXBlocklisted = "mx_blocklisted"
+	RiskDisposable    = "disposable"
+	RiskNoMX          = "no_mx"
+)
+
+// tldRiskTable scores TLDs 0-100
+type tldRiskTable struct {
+	Default int            `yaml:"default"`
+	TLDs    map[string]int `yaml:"tlds"`
+}
+
+var tldRisk = mustLoadTLDRisk()
+
+func mustLoadTLDRisk() *tldRiskTable {
+	var table tldRiskTable
+	if err := yaml.Unmarshal(bundledTLDRisk, &table); err != nil {
+		log.Fatalf("Invalid bundled TLD risk table: %v", err)
+	}
+	return &table
+}
+
+// score returns the risk score of domain's TLD and the TLD
+func (t *tldRiskTable) score(domain string) (int, string) {
+	tld := domain[strings.LastIndexByte(domain, '.')+1:]
+	if score, ok := t.TLDs[tld]; ok {
+		return score, tld
+	}
+	return t.Default, tld
+}
+
+// DomainReport is the aggregated report on one domain
+type DomainReport struct {
+	Domain          string        `json:"domain"`
+	MXRecords       []MXRecord    `json:"mx_records"`
+	AcceptsMail     bool          `json:"accepts_mail"`           // Has MX records other than a null MX
+	IsCatchAll      *bool         `json:"is_catch_all,omitempty"` // Absent until probed
+	CatchAllChecked *time.Time    `json:"catch_all_checked_at,omitempty"`
+	IsDisposable    bool          `json:"is_disposable"`
+	IsFreeProvider  bool          `json:"is_free_provider"`
+	MXBlocklists    []string      `json:"mx_blocklists,omitempty"` // When DNSBL zones are configured
+	History         DomainHistory `json:"history"`
+	TLD             string        `json:"tld"`
+	TLDRisk         int           `json:"tld_risk"`
+	RiskScore       int           `json:"risk_score"`
+	RiskFactors     []string      `json:"risk_factors,omitempty"`
+	GeneratedAt     time.Time     `json:"generated_at"`
+}
+
+// DomainHistory summarizes our verifications of addresses on the domain
+type DomainHistory struct {
+	Validations    int64                      `json:"validations"`
+	StatusCounts   map[ValidationStatus]int64 `json:"status_counts,omitempty"`
+	ValidRatio     float64                    `json:"valid_ratio"`
+	InvalidRatio   float64                    `json:"invalid_ratio"`
+	LastValidation *time.Time                 `json:"last_validation,omitempty"`
+}
+
+// DomainReport returns the aggregated report on domain
+func (v *SMTPVerifier) DomainReport(ctx context.Context, domain string) (*DomainReport, error) {
+	domain, err := canonicalDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	report := &DomainReport{Domain: domain, MXRecords: []MXRecord{}, GeneratedAt: time.Now().UTC()}
+
+	records, err := v.getMXRecords(ctx, domain)
+	var dnsErr *net.DNSError
+	switch {
+	case err == nil:
+		if !isNullMX(records) {
+			report.MXRecords, report.AcceptsMail = records, len(records) > 0
+		}
+	case errors.Is(err, errNoMXCached), errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+	default:
+		return nil, err
+	}
+
+	meta, _ := v.getDomainMetadata(ctx, domain)
+	if meta != nil {
+		report.IsCatchAll, report.CatchAllChecked = meta.IsCatchAll, meta.CatchAllChecked
+	}
+	report.IsDisposable = v.isDisposableDomain(ctx, domain)
+	report.IsFreeProvider = v.isFreeProvider(ctx, domain)
+	if report.AcceptsMail {
+		report.MXBlocklists = v.mxBlocklists(ctx, report.MXRecords)
+	}
+	report.History = domainHistory(meta)
+	report.TLDRisk, report.TLD = tldRisk.score(domain)
+	report.RiskScore, report.RiskFactors = report.riskScore()
+	return report, nil
+}
+
+func domainHistory(meta *DomainMetadata) DomainHistory {
+	var h DomainHistory
+	if meta == nil {
+		return h
+	}
+	h.StatusCounts = meta.StatusCounts
+	for _, n := range meta.StatusCounts {
+		h.Validations += n
+	}
+	if h.Validations > 0 {
+		h.ValidRatio = float64(meta.StatusCounts[StatusValid]) / float64(h.Validations)
+		h.InvalidRatio = float64(meta.StatusCounts[StatusInvalid]) / float64(h.Validations)
+	}
+	if !meta.LastValidation.IsZero() {
+		last := meta.LastValidation
+		h.LastValidation = &last
+	}
+	return h
+}
+
+// riskScore combines the report into a 0-100 score and names what counted
+func (r *DomainReport) riskScore() (int, []string) {
+	var factors []string
+	score := 0.3 * float64(r.TLDRisk)
+	if r.TLDRisk > 0 {
+		factors = append(factors, RiskTLD)
+	}
+	if r.IsCatchAll != nil && *r.IsCatchAll {
+		score += 15
+		factors = append(factors, RiskCatchAll)
+	}
+	if r.History.Validations >= domainReportMinSamples && r.History.InvalidRatio > 0 {
+		score += 50 * r.History.InvalidRatio
+		factors = append(factors, RiskInvalidRatio)
+	}
+	if len(r.MXBlocklists) > 0 {
+		score += 25
+		factors = append(factors, RiskMXBlocklisted)
+	}
+	if r.IsDisposable {
+		score = math.Max(score, 90)
+		factors = append(factors, RiskDisposable)
+	}
+	if !r.AcceptsMail {
+		score = 100
+		factors = append(factors, RiskNoMX)
+	}
+	return int(math.Round(math.Min(score, 100))), factors
+}
@@ -0,0 +1,223 @@
+package verifier
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// DOMAIN SECURITY (MTA-STS AND DANE)
+// ============================================================================
+
+// With DomainSecurityChecks on, deep results report how the recipient domain
+// asks senders to protect mail in transit, for deliverability engineers:
+//
+//   - MTA-STS (RFC 8461): the _mta-sts TXT record announces a policy, read
+//     from https://mta-sts.<domain>/.well-known/mta-sts.txt. Its mode is
+//     enforce, testing or none; MX hosts the policy does not cover are
+//     listed, as senders enforcing it would refuse to deliver to them.
+//   - DANE (RFC 7672): TLSA records at _25._tcp.<mx host>. They only count
+//     when DNSSEC-validated, which we learn from the resolver's AD bit, so
+//     a resolver that does not validate reports dnssec false throughout.
+//
+// Nothing here affects the verdict. Findings are kept per domain in memory
+// for DomainSecurityCacheTTL and skipped, like other enrichment, under load.
+
+// MTA-STS modes
+const (
+	MTASTSEnforce = "enforce"
+	MTASTSTesting = "testing"
+	MTASTSNone    = "none"
+)
+
+const (
+	mtaSTSMaxPolicy          = 64 << 10 // Policy body read at most
+	domainSecurityMaxEntries = 10000
+)
+
+// mtaSTSClient fetches policies; RFC 8461 forbids following redirects
+var mtaSTSClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// DomainSecurity is what the recipient domain publishes for TLS in transit
+type DomainSecurity struct {
+	MTASTS MTASTSPolicy `json:"mta_sts"`
+	DANE   DANEStatus   `json:"dane"`
+}
+
+// MTASTSPolicy is the domain's MTA-STS policy
+type MTASTSPolicy struct {
+	Mode       string   `json:"mode"` // enforce, testing or none
+	ID         string   `json:"id,omitempty"`
+	MX         []string `json:"mx,omitempty"`          // Patterns MX hosts must match
+	MaxAge     int64    `json:"max_age,omitempty"`     // Seconds senders may cache the policy
+	MXMismatch []string `json:"mx_mismatch,omitempty"` // MX hosts the policy does not cover
+	Error      string   `json:"error,omitempty"`       // Announced but not readable
+}
+
+// DANEStatus is what the domain's MX hosts publish for DANE
+type DANEStatus struct {
+	Enabled bool       `json:"enabled"` // Every MX host has DNSSEC-validated TLSA records
+	Hosts   []DANEHost `json:"hosts,omitempty"`
+}
+
+// DANEHost holds the TLSA records of one MX host
+type DANEHost struct {
+	MXHost  string       `json:"mx_host"`
+	DNSSEC  bool         `json:"dnssec"` // The resolver validated the answer
+	Records []TLSARecord `json:"records,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// domainSecurity returns the findings for domain, fetching them when not
+// cached
+func (v *SMTPVerifier) domainSecurity(ctx context.Context, domain string, mxRecords []MXRecord) *DomainSecurity {
+	if cached, ok := v.security.get(domain); ok {
+		return cached.(*DomainSecurity)
+	}
+	ctx, cancel := context.WithTimeout(ctx, v.cfg().DomainSecurityTimeout)
+	defer cancel()
+
+	security := &DomainSecurity{}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		security.MTASTS = v.mtaSTSPolicy(ctx, domain, mxRecords)
+	}()
+	go func() {
+		defer wg.Done()
+		security.DANE = v.daneStatus(ctx, mxRecords)
+	}()
+	wg.Wait()
+
+	if ctx.Err() == nil {
+		v.security.set(domain, security)
+	}
+	return security
+}
+
+// mtaSTSPolicy looks up and fetches the domain's MTA-STS policy
+func (v *SMTPVerifier) mtaSTSPolicy(ctx context.Context, domain string, mxRecords []MXRecord) MTASTSPolicy {
+	policy := MTASTSPolicy{Mode: MTASTSNone}
+	txts, err := v.dns.LookupTXT(ctx, "_mta-sts."+domain)
+	if err != nil {
+		return policy
+	}
+	for _, txt := range txts {
+		if !strings.HasPrefix(txt, "v=STSv1") {
+			continue
+		}
+		for _, field := range strings.Split(txt, ";") {
+			if k, val, ok := strings.Cut(strings.TrimSpace(field), "="); ok && k == "id" {
+				policy.ID = val
+			}
+		}
+	}
+	if policy.ID == "" {
+		return policy
+	}
+
+	fetched, err := fetchMTASTSPolicy(ctx, domain)
+	if err != nil {
+		policy.Error = err.Error()
+		return policy
+	}
+	policy.Mode, policy.MX, policy.MaxAge = fetched.Mode, fetched.MX, fetched.MaxAge
+	if policy.Mode != MTASTSNone {
+		for _, mx := range mxRecords {
+			if !mtaSTSMatch(policy.MX, mx.Exchange) {
+				policy.MXMismatch = append(policy.MXMismatch, mx.Exchange)
+			}
+		}
+	}
+	return policy
+}
+
+// fetchMTASTSPolicy reads the policy file over HTTPS
+func fetchMTASTSPolicy(ctx context.Context, domain string) (*MTASTSPolicy, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://mta-sts."+domain+"/.well-known/mta-sts.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := mtaSTSClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("policy fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("policy fetch returned %s", resp.Status)
+	}
+
+	policy := &MTASTSPolicy{}
+	version := ""
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, mtaSTSMaxPolicy))
+	for scanner.Scan() {
+		key, val, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch strings.TrimSpace(key) {
+		case "version":
+			version = val
+		case "mode":
+			policy.Mode = val
+		case "mx":
+			policy.MX = append(policy.MX, strings.ToLower(val))
+		case "max_age":
+			policy.MaxAge, _ = strconv.ParseInt(val, 10, 64)
+		}
+	}
+	if version != "STSv1" {
+		return nil, errors.New("policy has no version STSv1")
+	}
+	switch policy.Mode {
+	case MTASTSEnforce, MTASTSTesting, MTASTSNone:
+	default:
+		return nil, fmt.Errorf("policy has unknown mode %q", policy.Mode)
+	}
+	return policy, nil
+}
+
+// mtaSTSMatch reports whether mxHost matches a policy mx pattern; a leading
+// "*." stands for exactly one label
+func mtaSTSMatch(patterns []string, mxHost string) bool {
+	host := strings.ToLower(strings.TrimSuffix(mxHost, "."))
+	for _, pattern := range patterns {
+		if rest, ok := strings.CutPrefix(pattern, "*."); ok {
+			if dot := strings.IndexByte(host, '.'); dot > 0 && host[dot+1:] == rest {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// daneStatus looks up the TLSA records of every MX host
+func (v *SMTPVerifier) daneStatus(ctx context.Context, mxRecords []MXRecord) DANEStatus {
+	status := DANEStatus{Enabled: len(mxRecords) > 0}
+	for _, mx := range mxRecords {
+		host := DANEHost{MXHost: mx.Exchange}
+		records, authenticated, err := v.dns.LookupTLSA(ctx, "_25._tcp."+strings.TrimSuffix(mx.Exchange, "."))
+		if err != nil {
+			host.Error = err.Error()
+		}
+		host.Records, host.DNSSEC = records, authenticated
+		if len(records) == 0 || !authenticated {
+			status.Enabled = false
+		}
+		status.Hosts = append(status.Hosts, host)
+	}
+	return status
+}
@@ -0,0 +1,325 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// RESULT EXPLANATIONS
+// ============================================================================
+
+// Explanations are rebuilt from the stored result: the reason identifies the
+// rule that decided, and the recorded observations (MX hosts, SMTP reply,
+// provider, degraded checks) show what each step saw on the way there.
+
+// recentResultTTL keeps uncacheable results around long enough to explain them
+const recentResultTTL = 24 * time.Hour
+
+type ExplainStep struct {
+	Check    string `json:"check"`
+	Outcome  string `json:"outcome"` // passed, failed, decided, skipped
+	Observed string `json:"observed"`
+}
+
+type Explanation struct {
+	EmailHash  string           `json:"email_hash"`
+	Email      string           `json:"email"`
+	Status     ValidationStatus `json:"status"`
+	Reason     string           `json:"reason"`
+	Confidence float64          `json:"confidence"`
+	CheckedAt  time.Time        `json:"checked_at"`
+	Steps      []ExplainStep    `json:"steps"`
+	Rule       string           `json:"rule"`
+	NextSteps  []string         `json:"next_steps,omitempty"`
+}
+
+// ruleDescriptions say why each reason leads to its status
+var ruleDescriptions = map[string]string{
+	"ldap_mailbox_found":          "Internal directory has an entry for this address",
+	"ldap_mailbox_not_found":      "Internal directory is authoritative for the domain and has no entry for this address",
+	"ldap_account_disabled":       "Directory entry exists but the Active Directory account is disabled",
+	"ldap_error":                  "Internal directory could not be queried, so no verdict was possible",
+	"directory_mailbox_found":     "Tenant directory has a user or group with this address",
+	"directory_mailbox_not_found": "Tenant directory is authoritative for the domain and has no user or group with this address",
+	"directory_account_disabled":  "Tenant directory user exists but is suspended or disabled",
+	"directory_error":             "Tenant directory could not be queried, so no verdict was possible",
+	"disposable_domain":           "Domain is on the disposable domain list; mailboxes there are short-lived",
+	"no_mx_records":               "Domain publishes no MX records, so mail cannot be routed to it",
+	"null_mx":                     "Domain publishes a null MX (RFC 7505), declaring that it accepts no mail",
+	"mailbox_exists":              "Mail server accepted RCPT TO (250/251) for this address",
+	"mailbox_not_found":           "Mail server rejected RCPT TO with 550/551/553, or its reply says the user is unknown",
+	"mailbox_disabled":            "Mail server reply says the mailbox exists but is disabled or suspended",
+	"mailbox_full":                "Mail server reply says the mailbox is over quota; it exists but may not accept mail",
+	"probe_blocked":               "Mail server blocked our probe IP (blocklist, reputation or 5xx at connect) on every egress path; it said nothing about the mailbox",
+	"relay_denied":                "Mail server refused to relay for the domain, so it is not the domain's real mail host",
+	"sender_policy_rejected":      "Reply rejects our sender identity (SPF, DMARC, HELO or reverse DNS), not the mailbox",
+	"temporary_failure":           "Mail server answered with a temporary 45x code",
+	"rate_limited":                "Mail server answered 421 and closed the session",
+	"unknown_response":            "Mail server reply could not be classified",
+	"catch_all_domain":            "Mail server also accepted a random address on the domain, so acceptance proves nothing",
+	"all_mx_failed":               "No MX host produced a usable answer",
+	"provider_blocked":            "Provider refused the probe itself; the reply says nothing about the mailbox",
+	"provider_accepts_all":        "Provider accepts every RCPT TO and bounces later, so acceptance proves nothing",
+	"greylisted":                  "Provider greylists first contact with a temporary failure",
+	"smtputf8_unsupported":        "Address has a non-ASCII local part and the mail server does not offer SMTPUTF8, so it can never accept mail for it",
+	"syntax_valid":                "Only the syntax was checked (depth syntax); the address is well formed",
+	"mx_found":                    "Checked as far as DNS (depth dns); the domain has mail hosts but no mailbox was asked about",
+	"do_not_verify":               "Domain is on the do-not-verify list after an abuse complaint, so it was not probed",
+	"domain_policy":               "Status is set by the domain's policy in the service configuration; nothing was probed",
+	ReasonAllowlisted:             "Domain is on the configured allowlist of internal domains, so every address on it is valid",
+	ReasonBlocklisted:             "Domain is on the configured blocklist of known bad domains, so every address on it is invalid",
+	ReasonNeverProbe:              "Domain is on the configured never-probe list, so no mailbox was asked about",
+	ReasonRiskyTLD:                "Domain is on a configured high-abuse TLD, so it was flagged instead of probed",
+	ReasonParkedDomain:            "Domain looks parked (parking-service MX or wildcard DNS serving its mail), so it was flagged instead of probed",
+}
+
+// rememberResult stores a result that is not cached so it can still be explained
+func (v *SMTPVerifier) rememberResult(ctx context.Context, emailHash string, result *ValidationResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return v.TenantStore(ctx).Set(ctx, v.tenantKey(ctx, "validation:recent:"+emailHash), data, recentResultTTL).Err()
+}
+
+// storedResult returns the cached result, falling back to the recent one
+func (v *SMTPVerifier) storedResult(ctx context.Context, emailHash string) (*ValidationResult, error) {
+	if result, err := v.getCachedResult(ctx, emailHash); err == nil && result != nil {
+		return result, nil
+	}
+	data, err := v.TenantStore(ctx).Get(ctx, v.tenantKey(ctx, "validation:recent:"+emailHash)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var result ValidationResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Explain builds the decision trace for a stored result
+func (v *SMTPVerifier) Explain(ctx context.Context, emailHash string) (*Explanation, error) {
+	result, err := v.storedResult(ctx, emailHash)
+	if err != nil {
+		return nil, err
+	}
+	return explainResult(result), nil
+}
+
+func explainResult(r *ValidationResult) *Explanation {
+	e := &Explanation{
+		EmailHash:  r.EmailHash,
+		Email:      r.Email,
+		Status:     r.Status,
+		Reason:     r.Reason,
+		Confidence: r.Confidence,
+		CheckedAt:  r.CheckedAt,
+	}
+	add := func(check, outcome, observed string) {
+		e.Steps = append(e.Steps, ExplainStep{Check: check, Outcome: outcome, Observed: observed})
+	}
+	degraded := func(check string) bool {
+		for _, d := range r.DegradedChecks {
+			if d == check {
+				return true
+			}
+		}
+		return false
+	}
+
+	e.Rule = ruleDescriptions[r.Reason]
+	if e.Rule == "" {
+		switch {
+		case strings.HasPrefix(r.Reason, "smtp_error_"):
+			e.Rule = "Mail server answered with a permanent 5xx code other than the usual unknown-user codes"
+		case strings.HasPrefix(r.Reason, "smtp_error:"):
+			e.Rule = "SMTP conversation failed before a reply was classified"
+		case strings.HasPrefix(r.Reason, "syntax_error"):
+			e.Rule = "Address is not valid RFC 5321 mailbox syntax, so it cannot be delivered"
+		default:
+			e.Rule = "No description for this reason"
+		}
+	}
+
+	// Syntax
+	if strings.HasPrefix(r.Reason, "syntax_error") {
+		observed := fmt.Sprintf("%q is not a valid address", r.Email)
+		if _, err := parseAddress(r.Email); err != nil {
+			observed += ": " + err.Error()
+		}
+		add("syntax", "failed", observed)
+		e.NextSteps = append(e.NextSteps, "Correct the address; no further checks ran")
+		return e
+	}
+	add("syntax", "passed", "Address is well formed")
+	if r.Reason == "syntax_valid" {
+		e.NextSteps = append(e.NextSteps, "Verify at depth dns, smtp or deep for a deliverability verdict")
+		return e
+	}
+
+	// Domain lists and policies set the verdict before any lookup
+	switch r.Reason {
+	case ReasonAllowlisted, ReasonBlocklisted, ReasonNeverProbe:
+		list := domainListKeys[r.Reason]
+		add("domain_list", "decided", fmt.Sprintf("%s matches %s on domain_lists.%s", r.Domain, r.DomainPolicy, list))
+		e.NextSteps = append(e.NextSteps, "Remove the entry from domain_lists."+list+" to have the address verified")
+		return e
+	}
+	if r.Reason == "domain_policy" {
+		add("domain_policy", "decided", fmt.Sprintf("%s matches domains entry %s, which sets status %s", r.Domain, r.DomainPolicy, r.Status))
+		e.NextSteps = append(e.NextSteps, "Remove the entry's status to have the address verified")
+		return e
+	}
+
+	// Authoritative directories
+	switch {
+	case strings.HasPrefix(r.Reason, "ldap_"), strings.HasPrefix(r.Reason, "directory_"):
+		observed := "Directory lookup for " + r.Domain
+		if len(r.DirectoryAttributes) > 0 {
+			attrs := make([]string, 0, len(r.DirectoryAttributes))
+			for k, val := range r.DirectoryAttributes {
+				attrs = append(attrs, k+"="+val)
+			}
+			observed += " returned " + strings.Join(attrs, ", ")
+		}
+		add("directory", "decided", observed)
+		if r.Reason == "ldap_error" || r.Reason == "directory_error" {
+			e.NextSteps = append(e.NextSteps, "Check directory connectivity and credentials, then verify again with skip_cache")
+		}
+		return e
+	}
+
+	// Disposable list, checked before DNS
+	if r.IsDisposable && len(r.MXRecords) == 0 {
+		add("disposable", "decided", r.Domain+" is on the disposable domain list")
+		return e
+	}
+
+	// Do-not-verify list, checked before DNS
+	if r.Reason == "do_not_verify" {
+		add("do_not_verify", "decided", r.Domain+" is on the do-not-verify list")
+		e.NextSteps = append(e.NextSteps, "Lift the entry once the abuse complaint is resolved, then verify again")
+		return e
+	}
+
+	// DNS
+	if r.Reason == "no_mx_records" {
+		add("dns_mx", "failed", "No MX records found for "+r.Domain)
+		if r.DidYouMean != "" {
+			e.NextSteps = append(e.NextSteps, "Did you mean "+r.DidYouMean+"?")
+		}
+		e.NextSteps = append(e.NextSteps, "Verify again once the domain publishes MX records")
+		return e
+	}
+	if r.Reason == "null_mx" {
+		add("dns_mx", "failed", r.Domain+" publishes a null MX (MX 0 .) and accepts no mail")
+		return e
+	}
+	if r.ImplicitMX {
+		add("dns_mx", "passed", "No MX records; probed "+r.Domain+" itself, which has an A/AAAA record (implicit MX)")
+	} else {
+		hosts := make([]string, len(r.MXRecords))
+		for i, mx := range r.MXRecords {
+			hosts[i] = fmt.Sprintf("%s (%d)", mx.Exchange, mx.Priority)
+		}
+		add("dns_mx", "passed", "MX: "+strings.Join(hosts, ", "))
+	}
+	if r.Reason == "mx_found" {
+		e.NextSteps = append(e.NextSteps, "Verify at depth smtp or deep to ask the mail server about the mailbox")
+		return e
+	}
+
+	// Risky TLDs and parked domains, checked before SMTP
+	switch r.Reason {
+	case ReasonRiskyTLD:
+		add("risky_domain", "decided", r.Domain+" is on a TLD listed in risky_domains.tlds")
+		return e
+	case ReasonParkedDomain:
+		add("risky_domain", "decided", r.Domain+" matches risky_domains.parking_mx_hosts or has a wildcard A record serving its MX hosts")
+		return e
+	}
+
+	// Domain metadata
+	if degraded(DegradedEnrichment) {
+		add("domain_metadata", "skipped", "Skipped under load")
+	} else if r.IsDisposable {
+		add("domain_metadata", "decided", "Domain metadata marks "+r.Domain+" as disposable")
+		return e
+	} else {
+		add("domain_metadata", "passed", "Loaded the domain record for "+r.Domain+" (known catch-all verdict, stats)")
+	}
+
+	// SMTP
+	switch {
+	case r.Reason == "probe_blocked":
+		if r.SMTPCode != 0 {
+			add("smtp", "failed", fmt.Sprintf("%s blocked our probe: %d %s", r.MXHost, r.SMTPCode, strings.TrimSpace(r.SMTPResponse)))
+		} else {
+			add("smtp", "skipped", "Every egress IP is benched for "+r.MXHost+" after earlier blocks")
+		}
+		e.NextSteps = append(e.NextSteps, "A retry is scheduled once the blocked egress IPs have cooled down; no action needed")
+		return e
+	case r.Reason == "smtputf8_unsupported":
+		add("smtp", "failed", r.MXHost+" does not advertise SMTPUTF8 in its EHLO reply; RCPT TO was not sent")
+		return e
+	case r.SMTPCode != 0:
+		add("smtp", "passed", fmt.Sprintf("RCPT TO on %s answered %d %s", r.MXHost, r.SMTPCode, strings.TrimSpace(r.SMTPResponse)))
+	case r.Reason == "all_mx_failed" || strings.HasPrefix(r.Reason, "smtp_error:"):
+		add("smtp", "failed", "No MX host completed the SMTP conversation")
+		e.NextSteps = append(e.NextSteps, "Retry later; the mail servers were unreachable or timed out")
+		return e
+	}
+
+	// Response text rules
+	if r.ResponseRule != "" {
+		add("response_rules", "decided", "Reply text matched rule "+r.ResponseRule)
+	}
+
+	// Provider hints
+	if r.Provider != "" {
+		outcome := "passed"
+		if isProviderVerdict(r.Reason) {
+			outcome = "decided"
+		}
+		add("provider_hints", outcome, "MX belongs to "+r.Provider)
+	}
+
+	// Catch-all probe
+	switch {
+	case r.IsCatchAll:
+		add("catch_all", "decided", "A random address on "+r.Domain+" was also accepted")
+	case degraded(DegradedCatchAll):
+		add("catch_all", "skipped", "Skipped under load")
+		e.NextSteps = append(e.NextSteps, "Verify again with skip_cache when load is lower to run the catch-all probe")
+	case r.CatchAllSkipped != "":
+		add("catch_all", "skipped", "Probe not run: "+r.CatchAllSkipped)
+		if r.CatchAllSkipped == CatchAllSkippedBudget {
+			e.NextSteps = append(e.NextSteps, "Today's probe budget for "+r.Domain+" is spent; verify again tomorrow")
+		}
+	case r.Status == StatusValid:
+		add("catch_all", "passed", "A random address on "+r.Domain+" was rejected")
+	}
+
+	switch r.Reason {
+	case "greylisted", "temporary_failure":
+		if r.RetryAt != nil {
+			e.NextSteps = append(e.NextSteps, "A retry is scheduled for "+r.RetryAt.UTC().Format(time.RFC3339)+"; no action needed")
+		} else {
+			e.NextSteps = append(e.NextSteps, "Retry after the greylist window (usually 5-15 minutes)")
+		}
+	case "rate_limited":
+		e.NextSteps = append(e.NextSteps, "Retry later; the server is throttling our probes")
+	case "sender_policy_rejected":
+		e.NextSteps = append(e.NextSteps, "Check the probe sender's SPF, HELO and reverse DNS, then verify again")
+	case "provider_blocked":
+		e.NextSteps = append(e.NextSteps, "Probe from a different source IP or confirm by sending a message")
+	case "provider_accepts_all", "catch_all_domain":
+		e.NextSteps = append(e.NextSteps, "Only a sent message (and its bounce, if any) can confirm this mailbox")
+	}
+	return e
+}
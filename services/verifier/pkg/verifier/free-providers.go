@@ -0,0 +1,125 @@
+package verifier
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// FREE EMAIL PROVIDERS
+// ============================================================================
+
+// Consumer mailbox providers anyone can sign up to, so B2B pipelines can tell
+// personal addresses from company ones. Kept like the disposable list (see
+// disposable.go): the bundled list, configured additions and an optional
+// external list are swapped into one Redis set by the free_provider_refresh
+// task. is_free_provider is set on every result past depth syntax.
+
+const freeProviderDomainsKey = "free_providers:domains"
+
+// FreeProviderList maintains the shared Redis set of free provider domains
+type FreeProviderList struct {
+	redis  *redis.Client
+	config *Config
+	http   *http.Client
+}
+
+func NewFreeProviderList(redisClient *redis.Client, config *Config) *FreeProviderList {
+	return &FreeProviderList{
+		redis:  redisClient,
+		config: config,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Refresh rebuilds the set and swaps it in atomically
+func (f *FreeProviderList) Refresh(ctx context.Context) (int, error) {
+	var domains []string
+	if f.config.FreeProviderBuiltin {
+		domains = append(domains, bundledFreeProviderDomains...)
+	}
+	domains = append(domains, f.config.FreeProviderCustomDomains...)
+	return refreshDomainSet(ctx, f.redis, f.http, freeProviderDomainsKey, domains, f.config.FreeProviderListURL)
+}
+
+// isFreeProvider checks the shared set
+func (v *SMTPVerifier) isFreeProvider(ctx context.Context, domain string) bool {
+	found, err := v.redis.SIsMember(ctx, freeProviderDomainsKey, domain).Result()
+	return err == nil && found
+}
+
+// bundledFreeProviderDomains ships with the binary. Keep it sorted; operators
+// extend it through free_providers.custom_free_providers or
+// external_list_url.
+var bundledFreeProviderDomains = []string{
+	"aim.com",
+	"aol.com",
+	"bk.ru",
+	"btinternet.com",
+	"comcast.net",
+	"cox.net",
+	"free.fr",
+	"freenet.de",
+	"gmail.com",
+	"gmx.at",
+	"gmx.com",
+	"gmx.de",
+	"gmx.net",
+	"googlemail.com",
+	"hey.com",
+	"hotmail.co.uk",
+	"hotmail.com",
+	"hotmail.de",
+	"hotmail.fr",
+	"hotmail.it",
+	"icloud.com",
+	"inbox.ru",
+	"laposte.net",
+	"libero.it",
+	"list.ru",
+	"live.co.uk",
+	"live.com",
+	"live.fr",
+	"mac.com",
+	"mail.com",
+	"mail.ru",
+	"me.com",
+	"msn.com",
+	"naver.com",
+	"orange.fr",
+	"outlook.com",
+	"outlook.de",
+	"outlook.fr",
+	"pm.me",
+	"proton.me",
+	"protonmail.com",
+	"qq.com",
+	"rambler.ru",
+	"rediffmail.com",
+	"rocketmail.com",
+	"sbcglobal.net",
+	"seznam.cz",
+	"sky.com",
+	"t-online.de",
+	"tutanota.com",
+	"ukr.net",
+	"verizon.net",
+	"web.de",
+	"yahoo.ca",
+	"yahoo.co.in",
+	"yahoo.co.jp",
+	"yahoo.co.uk",
+	"yahoo.com",
+	"yahoo.com.br",
+	"yahoo.de",
+	"yahoo.es",
+	"yahoo.fr",
+	"yahoo.it",
+	"yandex.com",
+	"yandex.ru",
+	"ymail.com",
+	"zoho.com",
+}
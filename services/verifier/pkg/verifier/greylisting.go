@@ -0,0 +1,206 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// GREYLISTING AND DEFERRED RETRIES
+// ============================================================================
+
+// Greylisting servers answer 450/451 to a first contact and accept the same
+// sender and recipient a few minutes later. Such answers become
+// unknown/greylisted: from provider hints, or from the reply text on any
+// server. A deep check that ends greylisted is not cached; the address goes
+// to a deferred queue and is verified again after GreylistRetryDelay (or the
+// provider's greylist_retry_after), growing with each attempt, up to
+// GreylistMaxAttempts. The result carries retry_at while a retry is pending.
+// A callback URL given with the request receives the final result as a
+// verification.completed webhook (see webhooks.go).
+//
+//	greylist:deferred     sorted set: email -> due unix time
+//	greylist:state:{hash} JSON deferredState, removed with the final result
+
+const (
+	greylistDeferredKey = "greylist:deferred"
+	greylistStatePrefix = "greylist:state:"
+	greylistRetryBatch  = 100
+)
+
+// greylistPatterns are reply fragments greylisting implementations use
+// (Postgrey, Exim, Rspamd, milter-greylist and common hosted MTAs)
+var greylistPatterns = []string{
+	"greylist", "graylist", "grey-list", "gray-list",
+	"try again later", "please retry later", "temporarily rejected",
+}
+
+type deferredState struct {
+	Attempts  int       `json:"attempts"`
+	WebhookID string    `json:"webhook_id,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// looksGreylisted reports a temporary reply whose text names greylisting
+func looksGreylisted(code int, response string) bool {
+	if code != 450 && code != 451 {
+		return false
+	}
+	response = strings.ToLower(response)
+	for _, pattern := range greylistPatterns {
+		if strings.Contains(response, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// greylistDelay is the wait before the given attempt (1-based) at mxHost
+func (v *SMTPVerifier) greylistDelay(mxHost string, attempt int) time.Duration {
+	delay := v.cfg().GreylistRetryDelay
+	if hint := v.providerFor(mxHost); hint != nil && hint.GreylistRetryAfter > 0 {
+		delay = hint.GreylistRetryAfter
+	}
+	return delay * time.Duration(attempt)
+}
+
+func greylistStateKey(email string) string {
+	return greylistStatePrefix + hashEmail(email)
+}
+
+func (v *SMTPVerifier) loadDeferredState(ctx context.Context, email string) (*deferredState, error) {
+	data, err := v.TenantStore(ctx).Get(ctx, greylistStateKey(email)).Bytes()
+	if err == redis.Nil {
+		return &deferredState{FirstSeen: time.Now()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state deferredState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (v *SMTPVerifier) saveDeferredState(ctx context.Context, email string, state *deferredState, due time.Time) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	// Outlives the last retry so a late retry still finds its callback
+	ttl := time.Until(due) + v.greylistDelay("", v.cfg().GreylistMaxAttempts)
+	return v.TenantStore(ctx).Set(ctx, greylistStateKey(email), data, ttl).Err()
+}
+
+// deferGreylisted queues a greylisted address for another attempt and
+// stamps result with its time; it does nothing once the attempts are used up
+func (v *SMTPVerifier) deferGreylisted(ctx context.Context, email string, result *ValidationResult) {
+	if v.cfg().GreylistMaxAttempts <= 0 {
+		return
+	}
+	state, err := v.loadDeferredState(ctx, email)
+	if err != nil {
+		slog.WarnContext(ctx, "greylist retry not scheduled", "email_hash", hashEmail(email), "error", err)
+		return
+	}
+	if state.Attempts >= v.cfg().GreylistMaxAttempts {
+		return
+	}
+	state.Attempts++
+	due := time.Now().Add(v.greylistDelay(result.MXHost, state.Attempts))
+
+	store := v.TenantStore(ctx)
+	if err := v.saveDeferredState(ctx, email, state, due); err == nil {
+		err = store.ZAdd(ctx, greylistDeferredKey, redis.Z{Score: float64(due.Unix()), Member: email}).Err()
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "greylist retry not scheduled", "email_hash", hashEmail(email), "error", err)
+		return
+	}
+	result.RetryAt = &due
+}
+
+// SetDeferredCallback has the final result for a deferred result's address
+// POSTed to url, and returns the webhook that will carry it
+func (v *SMTPVerifier) SetDeferredCallback(ctx context.Context, result *ValidationResult, url string) (*WebhookDelivery, error) {
+	email := result.Email
+	if result.NormalizedEmail != "" {
+		email = result.NormalizedEmail
+	}
+	state, err := v.loadDeferredState(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	due, err := v.TenantStore(ctx).ZScore(ctx, greylistDeferredKey, email).Result()
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := v.CreateWebhook(ctx, url, WebhookDeferredCompleted)
+	if err != nil {
+		return nil, err
+	}
+	state.WebhookID = delivery.ID
+	return delivery, v.saveDeferredState(ctx, email, state, time.Unix(int64(due), 0))
+}
+
+// RetryGreylisted re-verifies a batch of due deferred addresses, at home and
+// in each region. Like RetryBlockedProbes, entries are claimed with ZREM.
+func (v *SMTPVerifier) RetryGreylisted(ctx context.Context) error {
+	err := v.retryGreylisted(WithRegion(ctx, ""))
+	for _, region := range v.cfg().Regions {
+		if regionErr := v.retryGreylisted(WithRegion(ctx, region.Name)); regionErr != nil && err == nil {
+			err = fmt.Errorf("region %s: %w", region.Name, regionErr)
+		}
+	}
+	return err
+}
+
+func (v *SMTPVerifier) retryGreylisted(ctx context.Context) error {
+	store := v.TenantStore(ctx)
+	due, err := store.ZRangeByScore(ctx, greylistDeferredKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().Unix(), 10),
+		Count: greylistRetryBatch,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	for _, email := range due {
+		if claimed, _ := store.ZRem(ctx, greylistDeferredKey, email).Result(); claimed == 0 {
+			continue
+		}
+		result, err := v.VerifyWithOptions(ctx, email, VerifyOptions{SkipCache: true})
+		if err != nil {
+			slog.WarnContext(ctx, "greylist retry failed", "email_hash", hashEmail(email), "error", err)
+			continue
+		}
+		if result.RetryAt == nil {
+			v.finishDeferred(ctx, email, result)
+		}
+	}
+	return nil
+}
+
+// finishDeferred delivers the final result of a deferred address
+func (v *SMTPVerifier) finishDeferred(ctx context.Context, email string, result *ValidationResult) {
+	state, err := v.loadDeferredState(ctx, email)
+	v.TenantStore(ctx).Del(ctx, greylistStateKey(email))
+	if err != nil || state.WebhookID == "" {
+		return
+	}
+
+	delivery, err := v.loadWebhook(ctx, state.WebhookID)
+	if err != nil {
+		slog.WarnContext(ctx, "deferred result webhook lost", "email_hash", result.EmailHash, "webhook_id", state.WebhookID, "error", err)
+		return
+	}
+	v.SendWebhook(WithTenant(ctx, delivery.Tenant), delivery, result)
+}
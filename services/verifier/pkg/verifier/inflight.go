@@ -0,0 +1,102 @@
+package verifier
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ============================================================================
+// IN-FLIGHT DEDUPLICATION
+// ============================================================================
+
+// A double-submitted form sends the same address twice at once. Within a
+// process, identical verifications share one check (singleflight on the
+// email hash); across replicas, a verify:inflight:{hash} marker makes later
+// arrivals wait for the first replica's result instead of probing again.
+
+const (
+	inflightKeyPrefix = "verify:inflight:"
+	inflightTTL       = 2 * time.Minute // Longest a waiter defers to another replica
+	inflightPoll      = 200 * time.Millisecond
+)
+
+// verifyShared runs one fresh check per email hash at a time. The check is
+// detached from the caller's cancellation so a caller that gives up does
+// not fail the others; each caller still returns as soon as its own ctx
+// ends. Callers that joined get their own copy of the result, counted as a
+// cache hit.
+func (v *SMTPVerifier) verifyShared(ctx context.Context, email, emailHash string, startTime time.Time) (*ValidationResult, error) {
+	ran := false
+	ch := v.inflight.DoChan(v.TenantRegion(ctx)+"|"+v.tenantKey(ctx, emailHash), func() (interface{}, error) {
+		ran = true
+		return v.verifyFresh(context.WithoutCancel(ctx), email, emailHash, startTime)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		result := res.Val.(*ValidationResult)
+		if ran {
+			return result, nil
+		}
+		copied := *result
+		v.recordUsage(ctx, &copied, true)
+		return &copied, nil
+	}
+}
+
+// claimInflight marks emailHash as being verified by this replica, first
+// waiting out another replica's check. It returns that replica's result when
+// one turns up, or the release function for our own marker. If Redis is
+// unreachable the check simply goes ahead.
+func (v *SMTPVerifier) claimInflight(ctx context.Context, emailHash string) (*ValidationResult, func()) {
+	key := v.tenantKey(ctx, inflightKeyPrefix+emailHash)
+	store := v.TenantStore(ctx)
+	token := NewID()
+	waitStart := time.Now()
+	deadline := waitStart.Add(inflightTTL)
+	for {
+		claimed, err := store.SetNX(ctx, key, token, inflightTTL).Result()
+		if err != nil {
+			slog.WarnContext(ctx, "in-flight marker unavailable", "email_hash", emailHash, "error", err)
+			return nil, func() {}
+		}
+		if claimed {
+			return nil, func() {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				releaseLock.Run(ctx, store, []string{key}, token)
+			}
+		}
+
+		// Another replica is on it; its result lands in the cache, or in
+		// the recent results when it is not cacheable
+		select {
+		case <-time.After(inflightPoll):
+		case <-ctx.Done():
+			return nil, func() {}
+		}
+		if result := v.inflightResult(ctx, emailHash, waitStart); result != nil {
+			return result, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, func() {}
+		}
+	}
+}
+
+// inflightResult returns a deep result stored since we started waiting, so
+// an earlier or shallower answer is never mistaken for the one being waited
+// on
+func (v *SMTPVerifier) inflightResult(ctx context.Context, emailHash string, since time.Time) *ValidationResult {
+	result, err := v.storedResult(ctx, emailHash)
+	if err != nil || result == nil || result.CheckedAt.Before(since) || (result.Depth != "" && result.Depth != DepthDeep) {
+		return nil
+	}
+	return result
+}
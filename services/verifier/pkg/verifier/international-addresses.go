@@ -0,0 +1,53 @@
+package verifier
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// ============================================================================
+// INTERNATIONALIZED ADDRESSES (EAI)
+// ============================================================================
+
+// The parser (address-parser.go) accepts UTF-8 in local parts and domains.
+// Domains may be given in Unicode (例え.jp); they are converted to their
+// punycode A-label (xn--r8jz45g.jp) for DNS, rate limits and RCPT TO, so an
+// ASCII local part on an IDN domain needs nothing special on the wire. A
+// non-ASCII local part (用户@...) can only be sent with SMTPUTF8 (RFC 6531):
+// MAIL FROM carries the SMTPUTF8 parameter, and an MX that does not offer
+// the extension could never take delivery, so the address is invalid there.
+
+// errNoSMTPUTF8 is returned when a non-ASCII local part meets an MX without
+// the SMTPUTF8 extension
+var errNoSMTPUTF8 = errors.New("server does not offer SMTPUTF8")
+
+// smtpAddress returns email as sent in RCPT TO, with an A-label domain, and
+// whether it needs SMTPUTF8
+func smtpAddress(email string) (string, bool) {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email, false
+	}
+	local, domain := email[:at], email[at+1:]
+	if ascii, err := idna.Lookup.ToASCII(domain); err == nil {
+		domain = ascii
+	}
+	return local + "@" + domain, !isASCII(local)
+}
+
+// needsSMTPUTF8 reports a non-ASCII local part
+func needsSMTPUTF8(email string) bool {
+	return !isASCII(email[:max(strings.LastIndex(email, "@"), 0)])
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package verifier
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ============================================================================
+// LDAP / ACTIVE DIRECTORY BACKEND
+// ============================================================================
+
+// For internal domains the directory is authoritative: addresses are looked up
+// in LDAP instead of probing our own mail servers over SMTP.
+
+const (
+	defaultLDAPFilter  = "(|(mail={email})(proxyAddresses=smtp:{email}))"
+	defaultLDAPTimeout = 5 * time.Second
+
+	// userAccountControl ACCOUNTDISABLE flag
+	adAccountDisabled = 0x2
+)
+
+// LDAPDirectory configures one directory and the domains it is authoritative for
+type LDAPDirectory struct {
+	Domains          []string      `yaml:"domains"`
+	URL              string        `yaml:"url"` // ldap:// or ldaps://
+	StartTLS         bool          `yaml:"start_tls"`
+	BindDN           string        `yaml:"bind_dn"`
+	BindPasswordEnv  string        `yaml:"bind_password_env"` // Env var holding the bind password
+	BaseDN           string        `yaml:"base_dn"`
+	Filter           string        `yaml:"filter"`           // {email} is replaced by the escaped address
+	ActiveDirectory  bool          `yaml:"active_directory"` // Treat disabled AD accounts as invalid
+	ReturnAttributes []string      `yaml:"return_attributes"`
+	Timeout          time.Duration `yaml:"timeout"`
+}
+
+// ldapDirectoryFor returns the directory authoritative for domain, if any
+func (v *SMTPVerifier) ldapDirectoryFor(domain string) *LDAPDirectory {
+	for i := range v.cfg().LDAPDirectories {
+		dir := &v.cfg().LDAPDirectories[i]
+		for _, d := range dir.Domains {
+			if strings.EqualFold(d, domain) {
+				return dir
+			}
+		}
+	}
+	return nil
+}
+
+// verifyLDAP answers from the directory. The boolean reports whether the
+// result is cacheable, as for runChecks.
+func (v *SMTPVerifier) verifyLDAP(ctx context.Context, dir *LDAPDirectory, email, emailHash, domain string, startTime time.Time) (*ValidationResult, bool) {
+	entry, err := dir.lookup(email)
+	if err != nil {
+		slog.WarnContext(ctx, "LDAP lookup failed", "url", dir.URL, "domain", domain, "error", err)
+		return v.createResult(email, emailHash, domain, StatusUnknown, "ldap_error", 0.2, 0, "", "", nil, startTime), false
+	}
+	if entry == nil {
+		return v.createResult(email, emailHash, domain, StatusInvalid, "ldap_mailbox_not_found", 1.0, 0, "", "", nil, startTime), true
+	}
+
+	status, reason := StatusValid, "ldap_mailbox_found"
+	if dir.ActiveDirectory {
+		uac, _ := strconv.Atoi(entry.GetAttributeValue("userAccountControl"))
+		if uac&adAccountDisabled != 0 {
+			status, reason = StatusInvalid, "ldap_account_disabled"
+		}
+	}
+
+	result := v.createResult(email, emailHash, domain, status, reason, 1.0, 0, "", "", nil, startTime)
+	if len(dir.ReturnAttributes) > 0 {
+		result.DirectoryAttributes = make(map[string]string)
+		for _, attr := range dir.ReturnAttributes {
+			if val := entry.GetAttributeValue(attr); val != "" {
+				result.DirectoryAttributes[attr] = val
+			}
+		}
+	}
+	return result, true
+}
+
+// lookup returns the matching entry, or nil if the address is unknown
+func (dir *LDAPDirectory) lookup(email string) (*ldap.Entry, error) {
+	timeout := dir.Timeout
+	if timeout <= 0 {
+		timeout = defaultLDAPTimeout
+	}
+
+	conn, err := ldap.DialURL(dir.URL, ldap.DialWithDialer(&net.Dialer{Timeout: timeout}))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetTimeout(timeout)
+
+	if dir.StartTLS {
+		host := dir.URL
+		if u, err := url.Parse(dir.URL); err == nil {
+			host = u.Hostname()
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return nil, err
+		}
+	}
+	if dir.BindDN != "" {
+		if err := conn.Bind(dir.BindDN, os.Getenv(dir.BindPasswordEnv)); err != nil {
+			return nil, err
+		}
+	}
+
+	filter := dir.Filter
+	if filter == "" {
+		filter = defaultLDAPFilter
+	}
+	filter = strings.ReplaceAll(filter, "{email}", ldap.EscapeFilter(email))
+
+	attrs := append([]string{"mail"}, dir.ReturnAttributes...)
+	if dir.ActiveDirectory {
+		attrs = append(attrs, "userAccountControl")
+	}
+
+	res, err := conn.Search(ldap.NewSearchRequest(
+		dir.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		1, int(timeout.Seconds()), false, filter, attrs, nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, err
+	}
+	if res == nil || len(res.Entries) == 0 {
+		return nil, nil
+	}
+	return res.Entries[0], nil
+}
@@ -0,0 +1,151 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// LOAD LIMITING AND GRACEFUL DEGRADATION
+// ============================================================================
+
+// ErrOverloaded is returned when no verification slot frees up within the
+// configured queue timeout.
+var ErrOverloaded = errors.New("verifier overloaded: no verification slot available")
+
+// LoadLevel is a rung on the degradation ladder. Each level keeps the
+// behaviour of the levels below it.
+type LoadLevel int
+
+const (
+	LoadNormal         LoadLevel = iota
+	LoadShedCatchAll             // catch-all probes are skipped
+	LoadShedEnrichment           // domain metadata enrichment is skipped
+	LoadQueueing                 // new verifications wait for a free slot
+)
+
+func (l LoadLevel) String() string {
+	switch l {
+	case LoadShedCatchAll:
+		return "shed_catch_all"
+	case LoadShedEnrichment:
+		return "shed_enrichment"
+	case LoadQueueing:
+		return "queueing"
+	default:
+		return "normal"
+	}
+}
+
+// Names of checks recorded in ValidationResult.DegradedChecks
+const (
+	DegradedCatchAll   = "catch_all"
+	DegradedEnrichment = "enrichment"
+)
+
+type LoadStats struct {
+	Level                    string `json:"level"`
+	InFlightVerifications    int    `json:"in_flight_verifications"`
+	MaxInFlightVerifications int    `json:"max_in_flight_verifications"`
+	SMTPSessions             int    `json:"smtp_sessions"`
+	MaxSMTPSessions          int    `json:"max_smtp_sessions"`
+	Queued                   int64  `json:"queued"`
+}
+
+// loadLimiter caps concurrent verifications and SMTP sessions so bursts
+// degrade result richness instead of exhausting memory and sockets.
+type loadLimiter struct {
+	verifications chan struct{}
+	sessions      chan struct{}
+	queued        int64
+
+	catchAllAt   float64
+	enrichmentAt float64
+	queueTimeout time.Duration
+}
+
+func newLoadLimiter(config *Config) *loadLimiter {
+	maxVerifications := config.MaxInFlightVerifications
+	if maxVerifications <= 0 {
+		maxVerifications = DefaultConfig().MaxInFlightVerifications
+	}
+	maxSessions := config.MaxSMTPSessions
+	if maxSessions <= 0 {
+		maxSessions = DefaultConfig().MaxSMTPSessions
+	}
+
+	return &loadLimiter{
+		verifications: make(chan struct{}, maxVerifications),
+		sessions:      make(chan struct{}, maxSessions),
+		catchAllAt:    config.DegradeCatchAllAt,
+		enrichmentAt:  config.DegradeEnrichmentAt,
+		queueTimeout:  config.OverloadQueueTimeout,
+	}
+}
+
+// acquireVerification reserves a verification slot. When every slot is taken
+// the caller queues for up to queueTimeout before ErrOverloaded is returned.
+func (l *loadLimiter) acquireVerification(ctx context.Context) (func(), error) {
+	release := func() { <-l.verifications }
+
+	select {
+	case l.verifications <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	atomic.AddInt64(&l.queued, 1)
+	defer atomic.AddInt64(&l.queued, -1)
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.verifications <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, ErrOverloaded
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// acquireSession reserves one of the global SMTP session slots
+func (l *loadLimiter) acquireSession(ctx context.Context) (func(), error) {
+	select {
+	case l.sessions <- struct{}{}:
+		return func() { <-l.sessions }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// level derives the current degradation rung from verification slot usage
+func (l *loadLimiter) level() LoadLevel {
+	if atomic.LoadInt64(&l.queued) > 0 {
+		return LoadQueueing
+	}
+
+	utilization := float64(len(l.verifications)) / float64(cap(l.verifications))
+	switch {
+	case utilization >= l.enrichmentAt:
+		return LoadShedEnrichment
+	case utilization >= l.catchAllAt:
+		return LoadShedCatchAll
+	default:
+		return LoadNormal
+	}
+}
+
+func (l *loadLimiter) stats() LoadStats {
+	return LoadStats{
+		Level:                    l.level().String(),
+		InFlightVerifications:    len(l.verifications),
+		MaxInFlightVerifications: cap(l.verifications),
+		SMTPSessions:             len(l.sessions),
+		MaxSMTPSessions:          cap(l.sessions),
+		Queued:                   atomic.LoadInt64(&l.queued),
+	}
+}
@@ -0,0 +1,142 @@
+package verifier
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ============================================================================
+// LOCAL PART QUALITY
+// ============================================================================
+
+// Signup abuse runs on addresses like xk29fj2qq@gmail.com: deliverable, but
+// nobody's. quality_score rates from 0 to 100 how much an address looks like
+// one a person uses, apart from whether it can receive mail; the status is
+// never changed. The local part is read for three patterns, each named in
+// quality_signals:
+//
+//	consonant_run  qualityConsonantRun or more consonants in a row, digits
+//	               skipped (xk29fj2qq reads xkfjqq)
+//	digit_ratio    at least a third digits, and not only a trailing number
+//	               (john1987 passes, a8k3m9x2 does not)
+//	keyboard_walk  four or more neighbouring keys of a letter row (asdf,
+//	               qwerty), either way
+//
+// Disposable, role and possible trap addresses lose points too. The score is
+// recomputed for cached results, so it follows the current rules.
+
+// Quality signals
+const (
+	QualityConsonantRun = "consonant_run"
+	QualityDigitRatio   = "digit_ratio"
+	QualityKeyboardWalk = "keyboard_walk"
+)
+
+const (
+	qualityConsonantRun = 6
+	qualityKeyboardWalk = 4
+)
+
+// qualityPenalties are the points each signal or flag costs
+var qualityPenalties = map[string]int{
+	QualityConsonantRun: 30,
+	QualityDigitRatio:   30,
+	QualityKeyboardWalk: 25,
+	"disposable":        30,
+	"possible_trap":     40,
+	"role":              10,
+}
+
+// keyboardRows are the letter rows of a QWERTY keyboard
+var keyboardRows = []string{"qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// setQuality scores the result's address; syntax failures score 0
+func setQuality(result *ValidationResult) {
+	at := strings.LastIndexByte(result.Email, '@')
+	if result.Domain == "" || at < 0 {
+		result.QualityScore, result.QualitySignals = 0, nil
+		return
+	}
+	signals := localPartSignals(result.Email[:at])
+	score := 100
+	for _, signal := range signals {
+		score -= qualityPenalties[signal]
+	}
+	for flag, set := range map[string]bool{"disposable": result.IsDisposable, "possible_trap": result.IsPossibleTrap, "role": result.IsRole} {
+		if set {
+			score -= qualityPenalties[flag]
+		}
+	}
+	result.QualityScore, result.QualitySignals = max(score, 0), signals
+}
+
+// localPartSignals returns the patterns found in local; a "+tag" is ignored
+func localPartSignals(local string) []string {
+	if plus := strings.IndexByte(local, '+'); plus > 0 {
+		local = local[:plus]
+	}
+	local = strings.ToLower(local)
+
+	var signals []string
+	if longestConsonantRun(local) >= qualityConsonantRun {
+		signals = append(signals, QualityConsonantRun)
+	}
+	if digitHeavy(local) {
+		signals = append(signals, QualityDigitRatio)
+	}
+	if keyboardWalk(local) {
+		signals = append(signals, QualityKeyboardWalk)
+	}
+	return signals
+}
+
+// longestConsonantRun counts consonants in a row within each part between
+// separators, skipping digits; y counts as a vowel
+func longestConsonantRun(local string) int {
+	longest, run := 0, 0
+	for _, r := range local {
+		switch {
+		case unicode.IsDigit(r):
+		case r >= 'a' && r <= 'z' && !strings.ContainsRune("aeiouy", r):
+			run++
+			longest = max(longest, run)
+		default:
+			run = 0
+		}
+	}
+	return longest
+}
+
+// digitHeavy reports a local part at least a third digits whose digits are
+// not all one trailing number
+func digitHeavy(local string) bool {
+	digits := 0
+	for _, r := range local {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	if digits == 0 || digits*3 < len(local) {
+		return false
+	}
+	trailing := len(local) - len(strings.TrimRightFunc(local, unicode.IsDigit))
+	return trailing < digits
+}
+
+// keyboardWalk reports qualityKeyboardWalk neighbouring keys of one row
+func keyboardWalk(local string) bool {
+	for _, row := range keyboardRows {
+		reversed := []byte(row)
+		for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+			reversed[i], reversed[j] = reversed[j], reversed[i]
+		}
+		for _, keys := range []string{row, string(reversed)} {
+			for i := 0; i+qualityKeyboardWalk <= len(keys); i++ {
+				if strings.Contains(local, keys[i:i+qualityKeyboardWalk]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package verifier
+
+import "fmt"
+
+// ============================================================================
+// CLIENT METADATA
+// ============================================================================
+
+// Integrators attach their own identifiers (campaign ID, CRM list ID, tags)
+// to jobs and single requests. We store them untouched and echo them on every
+// result.
+
+const (
+	maxMetadataKeys     = 20
+	maxMetadataKeyLen   = 40
+	maxMetadataValueLen = 500
+	maxTags             = 20
+	maxTagLen           = 64
+)
+
+// ClientMetadata is embedded in requests, jobs and results
+type ClientMetadata struct {
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+}
+
+// Validate enforces the size limits on keys, values and tags
+func (m ClientMetadata) Validate() error {
+	if len(m.Metadata) > maxMetadataKeys {
+		return fmt.Errorf("at most %d metadata keys are allowed", maxMetadataKeys)
+	}
+	for k, v := range m.Metadata {
+		if k == "" || len(k) > maxMetadataKeyLen {
+			return fmt.Errorf("metadata keys must be 1-%d characters", maxMetadataKeyLen)
+		}
+		if len(v) > maxMetadataValueLen {
+			return fmt.Errorf("metadata value for %q exceeds %d characters", k, maxMetadataValueLen)
+		}
+	}
+	if len(m.Tags) > maxTags {
+		return fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+	for _, tag := range m.Tags {
+		if tag == "" || len(tag) > maxTagLen {
+			return fmt.Errorf("tags must be 1-%d characters", maxTagLen)
+		}
+	}
+	return nil
+}
+
+// Empty reports whether no metadata or tags were given
+func (m ClientMetadata) Empty() bool {
+	return len(m.Metadata) == 0 && len(m.Tags) == 0
+}
+
+// Attach echoes the metadata on a result. Results are cached without it, so
+// this must only be called on the copy returned to the client.
+func (m ClientMetadata) Attach(result *ValidationResult) {
+	if result != nil && !m.Empty() {
+		result.ClientMetadata = m
+	}
+}
+
+// Matches reports whether m carries every tag and metadata pair of filter
+func (m ClientMetadata) Matches(filter ClientMetadata) bool {
+	for k, v := range filter.Metadata {
+		if m.Metadata[k] != v {
+			return false
+		}
+	}
+	for _, want := range filter.Tags {
+		found := false
+		for _, tag := range m.Tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
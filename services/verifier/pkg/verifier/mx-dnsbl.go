@@ -0,0 +1,123 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// MX BLOCKLIST (DNSBL) CHECKS
+// ============================================================================
+
+// A mailbox that does not answer is not always a dead one: the domain's
+// mail servers may be compromised or misconfigured. With DNSBLZones set,
+// deep results check the IPv4 addresses of the domain's MX hosts against
+// those lists (zen.spamhaus.org, b.barracudacentral.org, ...) and report
+// mx_blocklisted with the zones that listed any of them. The verdict is
+// unchanged.
+//
+// A listing is an A record in 127.0.0.0/8 for the reversed address under
+// the zone. Spamhaus answers 127.255.255.x to queries it refuses (public or
+// unregistered resolvers); those count as errors, not listings, so point
+// smtp.dns_servers at a resolver the lists accept. Answers are kept per
+// address and zone in memory for DNSBLCacheTTL.
+
+const (
+	dnsblMaxHosts   = 5 // MX hosts checked per domain
+	dnsblMaxAddrs   = 4 // Addresses checked per MX host
+	dnsblMaxEntries = 50000
+)
+
+// mxBlocklists returns the zones listing any address of the MX hosts
+func (v *SMTPVerifier) mxBlocklists(ctx context.Context, mxRecords []MXRecord) []string {
+	zones := v.cfg().DNSBLZones
+	if len(zones) == 0 {
+		return nil
+	}
+	var addrs []string
+	for i, mx := range mxRecords {
+		if i == dnsblMaxHosts {
+			break
+		}
+		hostAddrs, err := v.dns.LookupHost(ctx, mx.Exchange)
+		if err != nil {
+			continue
+		}
+		n := 0
+		for _, addr := range hostAddrs {
+			if ip := net.ParseIP(addr).To4(); ip != nil && n < dnsblMaxAddrs {
+				addrs = append(addrs, ip.String())
+				n++
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	hits := make(map[string]bool)
+	for _, addr := range addrs {
+		for _, zone := range zones {
+			wg.Add(1)
+			go func(addr, zone string) {
+				defer wg.Done()
+				if v.dnsblListed(ctx, addr, zone) {
+					mu.Lock()
+					hits[zone] = true
+					mu.Unlock()
+				}
+			}(addr, zone)
+		}
+	}
+	wg.Wait()
+
+	var listed []string
+	for zone := range hits {
+		listed = append(listed, zone)
+	}
+	sort.Strings(listed)
+	return listed
+}
+
+// dnsblListed reports whether zone lists the IPv4 address addr
+func (v *SMTPVerifier) dnsblListed(ctx context.Context, addr, zone string) bool {
+	key := addr + "|" + zone
+	if listed, ok := v.dnsbl.get(key); ok {
+		return listed.(bool)
+	}
+	listed, err := v.queryDNSBL(ctx, addr, zone)
+	if err != nil {
+		return false
+	}
+	v.dnsbl.set(key, listed)
+	return listed
+}
+
+// queryDNSBL asks zone about addr
+func (v *SMTPVerifier) queryDNSBL(ctx context.Context, addr, zone string) (bool, error) {
+	octets := strings.Split(addr, ".")
+	name := fmt.Sprintf("%s.%s.%s.%s.%s", octets[3], octets[2], octets[1], octets[0], zone)
+	answers, err := v.dns.LookupHost(ctx, name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, answer := range answers {
+		if strings.HasPrefix(answer, "127.255.255.") {
+			return false, fmt.Errorf("%s refused the query (%s)", zone, answer)
+		}
+	}
+	for _, answer := range answers {
+		if strings.HasPrefix(answer, "127.") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
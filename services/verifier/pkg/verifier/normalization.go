@@ -0,0 +1,56 @@
+package verifier
+
+import (
+	"strings"
+)
+
+// ============================================================================
+// ADDRESS NORMALIZATION
+// ============================================================================
+
+// Opt-in (NormalizeAddresses, or VerifyOptions.Normalize per call): a
+// "+tag" suffix is dropped on any domain, and Gmail's own rules apply
+// (dots in the local part are ignored; googlemail.com is gmail.com). The
+// normalized address is what gets checked and cached, so jane+news@ and
+// j.ane@ share one SMTP check. Results keep the caller's address in email
+// and carry the checked one in normalized_email; email_hash is the cache key,
+// the hash of normalized_email.
+
+const subaddressSeparator = "+"
+
+// gmailDomains answer for the same mailboxes
+var gmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+}
+
+// normalizeAddress returns the canonical form of a lowercased address.
+// Quoted local parts and addresses without an @ come back unchanged.
+func normalizeAddress(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || strings.HasPrefix(email, `"`) {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+
+	// A leading "+" is the whole name, not a tag
+	if i := strings.Index(local, subaddressSeparator); i > 0 {
+		local = local[:i]
+	}
+	if gmailDomains[domain] {
+		domain = "gmail.com"
+		if undotted := strings.ReplaceAll(local, ".", ""); undotted != "" {
+			local = undotted
+		}
+	}
+	return local + "@" + domain
+}
+
+// withNormalized returns a copy of result answering for original, the
+// address the caller asked about, leaving shared and cached results alone
+func withNormalized(result *ValidationResult, original string) *ValidationResult {
+	copied := *result
+	copied.NormalizedEmail = copied.Email
+	copied.Email = original
+	return &copied
+}
@@ -0,0 +1,350 @@
+package verifier
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// OBJECT STORAGE (S3 / GCS)
+// ============================================================================
+
+// List jobs can read their input from, and write their output to, the
+// tenant's own bucket instead of going through HTTP uploads. Objects are
+// named s3://bucket/key or gs://bucket/key. Both speak the S3 XML API
+// signed with SigV4: GCS through its interoperability endpoint with HMAC
+// keys. S3-compatible stores (MinIO, R2) work through an s3 endpoint.
+//
+// Each tenant registers one set of keys per provider. They are tenant data
+// (kept in the tenant's residency region), sealed with AES-256-GCM under
+// STORAGE_CREDENTIALS_KEY, and never returned over the API:
+//
+//	tenant:storage:{tenant}:{provider}  sealed JSON StorageCredentials
+
+// Object storage providers
+const (
+	StorageS3  = "s3"
+	StorageGCS = "gcs"
+)
+
+const (
+	gcsEndpoint      = "storage.googleapis.com"
+	defaultS3Region  = "us-east-1"
+	sigV4Algorithm   = "AWS4-HMAC-SHA256"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	storageErrMaxLen = 512
+)
+
+var (
+	ErrStorageDisabled      = errors.New("STORAGE_CREDENTIALS_KEY is not configured")
+	ErrNoStorageCredentials = errors.New("no storage credentials registered for this provider")
+)
+
+var storageClient = &http.Client{}
+
+// StorageCredentials are a tenant's keys for one provider
+type StorageCredentials struct {
+	Provider        string    `json:"provider"`
+	AccessKeyID     string    `json:"access_key_id"`
+	SecretAccessKey string    `json:"secret_access_key,omitempty"` // Cleared before the credentials are shown
+	Region          string    `json:"region,omitempty"`            // S3 only; defaults to us-east-1
+	Endpoint        string    `json:"endpoint,omitempty"`          // S3-compatible host instead of AWS
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ObjectURL is a parsed s3:// or gs:// object name
+type ObjectURL struct {
+	Provider string
+	Bucket   string
+	Key      string
+}
+
+// ParseObjectURL accepts s3://bucket/key and gs://bucket/key
+func ParseObjectURL(raw string) (*ObjectURL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	obj := &ObjectURL{Bucket: u.Host, Key: strings.TrimPrefix(u.Path, "/")}
+	switch u.Scheme {
+	case "s3":
+		obj.Provider = StorageS3
+	case "gs":
+		obj.Provider = StorageGCS
+	default:
+		return nil, fmt.Errorf("%q is not an s3:// or gs:// URL", raw)
+	}
+	if obj.Bucket == "" {
+		return nil, fmt.Errorf("%q names no bucket", raw)
+	}
+	return obj, nil
+}
+
+func (o *ObjectURL) String() string {
+	scheme := "s3"
+	if o.Provider == StorageGCS {
+		scheme = "gs"
+	}
+	return scheme + "://" + o.Bucket + "/" + o.Key
+}
+
+// Join names an object under o, which is treated as a prefix
+func (o *ObjectURL) Join(name string) *ObjectURL {
+	key := strings.TrimSuffix(o.Key, "/")
+	if key != "" {
+		key += "/"
+	}
+	return &ObjectURL{Provider: o.Provider, Bucket: o.Bucket, Key: key + name}
+}
+
+// Validate checks credentials before they are stored
+func (c *StorageCredentials) Validate() error {
+	if c.Provider != StorageS3 && c.Provider != StorageGCS {
+		return fmt.Errorf("provider must be %q or %q", StorageS3, StorageGCS)
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return errors.New("access_key_id and secret_access_key are required")
+	}
+	if c.Endpoint != "" {
+		if c.Provider != StorageS3 {
+			return errors.New("endpoint applies to s3 only")
+		}
+		if strings.Contains(c.Endpoint, "/") {
+			return errors.New("endpoint is a host name, e.g. minio.example.com:9000")
+		}
+	}
+	return nil
+}
+
+func storageCredentialsKey(tenant, provider string) string {
+	return "tenant:storage:" + tenant + ":" + provider
+}
+
+// storageGCM returns the cipher for sealed credentials
+func (v *SMTPVerifier) storageGCM() (cipher.AEAD, error) {
+	key, err := hex.DecodeString(v.cfg().StorageCredentialsKey)
+	if err != nil || len(key) != 32 {
+		return nil, ErrStorageDisabled
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SetStorageCredentials registers creds for the tenant in ctx, replacing
+// any earlier keys for the provider
+func (v *SMTPVerifier) SetStorageCredentials(ctx context.Context, creds *StorageCredentials) error {
+	tenant := TenantFrom(ctx)
+	if !keyedTenant(tenant) {
+		return ErrNoTenant
+	}
+	gcm, err := v.storageGCM()
+	if err != nil {
+		return err
+	}
+	creds.UpdatedAt = time.Now().UTC()
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	key := storageCredentialsKey(tenant, creds.Provider)
+	sealed := gcm.Seal(nonce, nonce, plaintext, []byte(key))
+	return v.TenantStore(ctx).Set(ctx, key, sealed, 0).Err()
+}
+
+// StorageCredentials returns the tenant's keys for provider, or
+// ErrNoStorageCredentials
+func (v *SMTPVerifier) StorageCredentials(ctx context.Context, provider string) (*StorageCredentials, error) {
+	tenant := TenantFrom(ctx)
+	if !keyedTenant(tenant) {
+		return nil, ErrNoStorageCredentials
+	}
+	gcm, err := v.storageGCM()
+	if err != nil {
+		return nil, err
+	}
+	key := storageCredentialsKey(tenant, provider)
+	sealed, err := v.TenantStore(ctx).Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNoStorageCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("corrupt storage credentials")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt storage credentials: %w", err)
+	}
+	var creds StorageCredentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// DeleteStorageCredentials forgets the tenant's keys for provider
+func (v *SMTPVerifier) DeleteStorageCredentials(ctx context.Context, provider string) error {
+	tenant := TenantFrom(ctx)
+	if !keyedTenant(tenant) {
+		return ErrNoTenant
+	}
+	return v.TenantStore(ctx).Del(ctx, storageCredentialsKey(tenant, provider)).Err()
+}
+
+// GetObject opens obj with the tenant's credentials; the caller closes it
+func (v *SMTPVerifier) GetObject(ctx context.Context, obj *ObjectURL) (io.ReadCloser, error) {
+	resp, err := v.storageRequest(ctx, http.MethodGet, obj, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// PutObject uploads size bytes from body to obj
+func (v *SMTPVerifier) PutObject(ctx context.Context, obj *ObjectURL, body io.Reader, size int64, contentType string) error {
+	resp, err := v.storageRequest(ctx, http.MethodPut, obj, body, size, contentType)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil
+}
+
+// CheckStorageAccess reports whether the tenant in ctx has credentials for
+// obj, so jobs can be refused before they start
+func (v *SMTPVerifier) CheckStorageAccess(ctx context.Context, obj *ObjectURL) error {
+	_, err := v.StorageCredentials(ctx, obj.Provider)
+	return err
+}
+
+func (v *SMTPVerifier) storageRequest(ctx context.Context, method string, obj *ObjectURL, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	creds, err := v.StorageCredentials(ctx, obj.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	// Virtual-hosted names on AWS; path style on GCS and custom endpoints
+	host, path, region := "", "/"+obj.Bucket+"/"+obj.Key, creds.Region
+	switch {
+	case obj.Provider == StorageGCS:
+		host, region = gcsEndpoint, "auto"
+	case creds.Endpoint != "":
+		host = creds.Endpoint
+	default:
+		if region == "" {
+			region = defaultS3Region
+		}
+		host, path = obj.Bucket+".s3."+region+".amazonaws.com", "/"+obj.Key
+	}
+	if region == "" {
+		region = defaultS3Region
+	}
+	escaped := uriEncode(path)
+
+	req, err := http.NewRequestWithContext(ctx, method, "https://"+host+escaped, body)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawPath = escaped
+	if body != nil {
+		req.ContentLength = size
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	signV4(req, creds, region, escaped, time.Now())
+
+	resp, err := storageClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, storageErrMaxLen))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: HTTP %d %s", method, obj, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return resp, nil
+}
+
+// signV4 adds an AWS Signature Version 4 Authorization header. The payload
+// is not hashed, so uploads can stream.
+func signV4(req *http.Request, creds *StorageCredentials, region, escapedPath string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		escapedPath,
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + unsignedPayload,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+	hashed := sha256.Sum256([]byte(canonical))
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	toSign := sigV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode escapes an object path the way SigV4 canonical requests
+// expect: everything but unreserved characters and "/"
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// DOMAIN PRE-FLIGHT
+// ============================================================================
+
+const preflightResolveWorkers = 20
+
+type PreflightResponse struct {
+	Domains []*DomainPreflight `json:"domains"`
+}
+
+// DomainPreflight is what we already know about a domain. Pointer fields are
+// nil when the value is not known.
+type DomainPreflight struct {
+	Domain       string     `json:"domain"`
+	Known        bool       `json:"known"`
+	MXValid      *bool      `json:"mx_valid,omitempty"`
+	MXRecords    []MXRecord `json:"mx_records,omitempty"`
+	IsDisposable bool       `json:"is_disposable"`
+	IsCatchAll   *bool      `json:"is_catch_all,omitempty"`
+	// Our verifications of the domain's addresses, as in the domain report;
+	// omitted before the first one
+	Reputation *DomainHistory `json:"reputation,omitempty"`
+}
+
+// PreflightDomains returns cached metadata for each domain in a single Redis
+// round trip, optionally falling back to DNS for uncached MX records.
+func (v *SMTPVerifier) PreflightDomains(ctx context.Context, domains []string, resolveMX bool) ([]*DomainPreflight, error) {
+	results := make([]*DomainPreflight, len(domains))
+
+	pipe := v.redis.Pipeline()
+	mxCmds := make([]*redis.StringCmd, len(domains))
+	metaCmds := make([]*redis.StringCmd, len(domains))
+	disposableCmds := make([]*redis.BoolCmd, len(domains))
+	for i, domain := range domains {
+		domain = normalizeDomain(domain)
+		results[i] = &DomainPreflight{Domain: domain}
+		mxCmds[i] = pipe.Get(ctx, "mx:records:"+domain)
+		metaCmds[i] = pipe.Get(ctx, domainMetaKey(domain))
+		disposableCmds[i] = pipe.SIsMember(ctx, disposableDomainsKey, domain)
+	}
+	// With Redis unreachable every domain is unknown and falls back to DNS,
+	// like any cache miss in soft-fail mode
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil && !isRedisConnErr(err) {
+		return nil, err
+	}
+
+	var unresolved []*DomainPreflight
+	for i, result := range results {
+		if val, err := mxCmds[i].Result(); err == nil {
+			var records []MXRecord
+			if json.Unmarshal([]byte(val), &records) == nil && len(records) > 0 {
+				mxValid := !isNullMX(records)
+				result.MXValid = &mxValid
+				result.MXRecords = records
+				result.Known = true
+			}
+		}
+
+		if val, err := metaCmds[i].Result(); err == nil {
+			var meta DomainMetadata
+			if json.Unmarshal([]byte(val), &meta) == nil {
+				result.IsDisposable = meta.IsDisposable
+				result.IsCatchAll = meta.IsCatchAll
+				result.Known = true
+				if history := domainHistory(&meta); history.Validations > 0 {
+					result.Reputation = &history
+				}
+				if result.MXValid == nil && len(meta.MXRecords) > 0 {
+					mxValid := !isNullMX(meta.MXRecords)
+					result.MXValid = &mxValid
+					result.MXRecords = meta.MXRecords
+				}
+			}
+		}
+
+		if disposableCmds[i].Val() {
+			result.IsDisposable = true
+			result.Known = true
+		}
+
+		if result.MXValid == nil && result.Domain != "" {
+			unresolved = append(unresolved, result)
+		}
+	}
+
+	if resolveMX && len(unresolved) > 0 {
+		v.resolvePreflightMX(ctx, unresolved)
+	}
+
+	return results, nil
+}
+
+// resolvePreflightMX fills in MX data over DNS with bounded parallelism
+func (v *SMTPVerifier) resolvePreflightMX(ctx context.Context, pending []*DomainPreflight) {
+	jobs := make(chan *DomainPreflight)
+	var wg sync.WaitGroup
+
+	for i := 0; i < preflightResolveWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for result := range jobs {
+				records, err := v.getMXRecords(ctx, result.Domain)
+				mxValid := err == nil && len(records) > 0 && !isNullMX(records)
+				result.MXValid = &mxValid
+				result.MXRecords = records
+				result.Known = true
+			}
+		}()
+	}
+
+	for _, result := range pending {
+		select {
+		case jobs <- result:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if at := strings.LastIndex(domain, "@"); at >= 0 {
+		domain = domain[at+1:]
+	}
+	return strings.TrimSuffix(domain, ".")
+}
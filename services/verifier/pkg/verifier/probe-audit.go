@@ -0,0 +1,256 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// PROBE AUDIT TRAIL AND ABUSE REPORTS
+// ============================================================================
+
+// Every RCPT TO we send is appended to a Redis stream with the MX host,
+// egress IP and MAIL FROM used, so a provider's abuse complaint ("show all
+// probes to our servers last week") can be answered from the record. Filing
+// the complaint through the API puts the domains it covers on do-not-verify:
+// their addresses come back unknown/do_not_verify without DNS or SMTP until
+// the entry is lifted. Recipients are kept as hashes only.
+
+const (
+	probeAuditKey   = "audit:probes"        // stream, one entry per RCPT TO
+	abuseReportsKey = "abuse:reports"       // hash: report ID -> JSON
+	doNotVerifyKey  = "abuse:do_not_verify" // hash: domain -> report ID or reason
+	probeAuditPage  = 1000
+
+	// MaxProbeAuditResults caps one probe search or report listing
+	MaxProbeAuditResults = 10000
+)
+
+// Probe kinds
+const (
+	ProbeKindVerify   = "verify"
+	ProbeKindCatchAll = "catch_all"
+)
+
+// ProbeRecord is one RCPT TO we sent
+type ProbeRecord struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Domain   string    `json:"domain"`
+	RcptHash string    `json:"rcpt_hash"` // Hash of the recipient, as in email_hash
+	MXHost   string    `json:"mx_host"`
+	EgressIP string    `json:"egress_ip"`
+	MailFrom string    `json:"mail_from"`
+	SMTPCode int       `json:"smtp_code"` // 0 = no reply
+}
+
+// ProbeQuery selects audit records; empty filters match everything
+type ProbeQuery struct {
+	From, To time.Time
+	Domain   string
+	MXSuffix string // MX host or a parent domain of it, e.g. "outlook.com"
+	EgressIP string
+	Limit    int
+}
+
+func (q ProbeQuery) matches(p *ProbeRecord) bool {
+	if q.Domain != "" && p.Domain != q.Domain {
+		return false
+	}
+	if q.MXSuffix != "" && p.MXHost != q.MXSuffix && !strings.HasSuffix(p.MXHost, "."+q.MXSuffix) {
+		return false
+	}
+	return q.EgressIP == "" || p.EgressIP == q.EgressIP
+}
+
+type probeKindKey struct{}
+
+// withProbeKind marks the RCPT TOs sent under ctx, e.g. as catch-all probes
+func withProbeKind(ctx context.Context, kind string) context.Context {
+	return context.WithValue(ctx, probeKindKey{}, kind)
+}
+
+func probeKindFrom(ctx context.Context) string {
+	if kind, ok := ctx.Value(probeKindKey{}).(string); ok {
+		return kind
+	}
+	return ProbeKindVerify
+}
+
+// recordProbe appends one RCPT TO to the audit stream, trimming entries
+// older than ProbeAuditRetention
+func (v *SMTPVerifier) recordProbe(ctx context.Context, rcpt, mxHost, egress string, code int) {
+	if v.cfg().ProbeAuditRetention <= 0 || v.redisDegraded(v.redis) {
+		return
+	}
+	if egress == "" {
+		egress = defaultEgress
+	}
+	now := time.Now()
+	data, _ := json.Marshal(ProbeRecord{
+		Time:     now.UTC(),
+		Kind:     probeKindFrom(ctx),
+		Domain:   rcpt[strings.LastIndex(rcpt, "@")+1:],
+		RcptHash: hashEmail(rcpt),
+		MXHost:   strings.ToLower(mxHost),
+		EgressIP: egress,
+		MailFrom: v.mailFrom(ctx),
+		SMTPCode: code,
+	})
+	err := v.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: probeAuditKey,
+		MinID:  strconv.FormatInt(now.Add(-v.cfg().ProbeAuditRetention).UnixMilli(), 10),
+		Approx: true,
+		Values: map[string]interface{}{"p": data},
+	}).Err()
+	if err != nil {
+		slog.WarnContext(ctx, "probe audit write failed", "mx_host", mxHost, "error", err)
+	}
+}
+
+// SearchProbes returns the audited probes matching q, oldest first. The
+// boolean reports that more matched than q.Limit (at most
+// MaxProbeAuditResults).
+func (v *SMTPVerifier) SearchProbes(ctx context.Context, q ProbeQuery) ([]ProbeRecord, bool, error) {
+	if q.Limit <= 0 || q.Limit > MaxProbeAuditResults {
+		q.Limit = MaxProbeAuditResults
+	}
+	probes := []ProbeRecord{}
+	start := strconv.FormatInt(q.From.UnixMilli(), 10)
+	end := strconv.FormatInt(q.To.UnixMilli(), 10)
+	for {
+		page, err := v.redis.XRangeN(ctx, probeAuditKey, start, end, probeAuditPage).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		for _, msg := range page {
+			raw, _ := msg.Values["p"].(string)
+			var p ProbeRecord
+			if json.Unmarshal([]byte(raw), &p) != nil || !q.matches(&p) {
+				continue
+			}
+			if len(probes) == q.Limit {
+				return probes, true, nil
+			}
+			probes = append(probes, p)
+		}
+		if len(page) < probeAuditPage {
+			return probes, false, nil
+		}
+		start = "(" + page[len(page)-1].ID
+	}
+}
+
+// AbuseReport is a complaint about our probes and what was done about it
+type AbuseReport struct {
+	ID          string        `json:"id"`
+	ReceivedAt  time.Time     `json:"received_at"`
+	Reporter    string        `json:"reporter,omitempty"`
+	Notes       string        `json:"notes,omitempty"`
+	Domain      string        `json:"domain,omitempty"`
+	MXSuffix    string        `json:"mx,omitempty"`
+	EgressIP    string        `json:"egress_ip,omitempty"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	DoNotVerify []string      `json:"do_not_verify"` // Domains put on do-not-verify for this report
+	ProbeCount  int           `json:"probe_count"`
+	Probes      []ProbeRecord `json:"probes,omitempty"`
+	Truncated   bool          `json:"truncated,omitempty"` // More than MaxProbeAuditResults probes matched
+}
+
+func (r *AbuseReport) query() ProbeQuery {
+	return ProbeQuery{From: r.From, To: r.To, Domain: r.Domain, MXSuffix: r.MXSuffix, EgressIP: r.EgressIP}
+}
+
+// FileAbuseReport records a complaint, lists the probes it covers and, with
+// doNotVerify, stops verifying the reported domain and every domain probed
+// on the reported MX hosts in the window
+func (v *SMTPVerifier) FileAbuseReport(ctx context.Context, report *AbuseReport, doNotVerify bool) error {
+	report.ID = NewID()
+	report.ReceivedAt = time.Now().UTC()
+	probes, truncated, err := v.SearchProbes(ctx, report.query())
+	if err != nil {
+		return err
+	}
+	report.Probes, report.Truncated, report.ProbeCount = probes, truncated, len(probes)
+
+	report.DoNotVerify = []string{}
+	if doNotVerify {
+		seen := make(map[string]bool)
+		if report.Domain != "" {
+			seen[report.Domain] = true
+			report.DoNotVerify = append(report.DoNotVerify, report.Domain)
+		}
+		for _, p := range probes {
+			if !seen[p.Domain] {
+				seen[p.Domain] = true
+				report.DoNotVerify = append(report.DoNotVerify, p.Domain)
+			}
+		}
+	}
+
+	stored := *report
+	stored.Probes = nil
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+	pipe := v.redis.TxPipeline()
+	pipe.HSet(ctx, abuseReportsKey, report.ID, data)
+	for _, domain := range report.DoNotVerify {
+		pipe.HSet(ctx, doNotVerifyKey, domain, report.ID)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetAbuseReport returns a filed report with its probes listed again; probes
+// older than ProbeAuditRetention are gone by then
+func (v *SMTPVerifier) GetAbuseReport(ctx context.Context, id string) (*AbuseReport, error) {
+	data, err := v.redis.HGet(ctx, abuseReportsKey, id).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var report AbuseReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	report.Probes, report.Truncated, err = v.SearchProbes(ctx, report.query())
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// DoNotVerifyDomains returns domain -> the report ID or reason that put it
+// on do-not-verify
+func (v *SMTPVerifier) DoNotVerifyDomains(ctx context.Context) (map[string]string, error) {
+	return v.redis.HGetAll(ctx, doNotVerifyKey).Result()
+}
+
+// SetDoNotVerify stops verifying domain without a report, e.g. on a
+// complaint by phone
+func (v *SMTPVerifier) SetDoNotVerify(ctx context.Context, domain, reason string) error {
+	return v.redis.HSet(ctx, doNotVerifyKey, domain, reason).Err()
+}
+
+// LiftDoNotVerify lets domain be verified again
+func (v *SMTPVerifier) LiftDoNotVerify(ctx context.Context, domain string) error {
+	return v.redis.HDel(ctx, doNotVerifyKey, domain).Err()
+}
+
+// isDoNotVerify reports a domain we must not probe. A failed lookup lets the
+// verification go ahead.
+func (v *SMTPVerifier) isDoNotVerify(ctx context.Context, domain string) bool {
+	if v.redisDegraded(v.redis) {
+		return false
+	}
+	listed, err := v.redis.HExists(ctx, doNotVerifyKey, domain).Result()
+	return err == nil && listed
+}
@@ -0,0 +1,205 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// BLOCKED PROBE DETECTION AND SELF-HEALING
+// ============================================================================
+
+// A receiving server that blocks our probe IP (DNSBL listing, reputation,
+// 554 at connect) says nothing about the mailbox. Such answers become
+// unknown/probe_blocked; the egress IP is benched for that MX, the probe moves
+// to the next egress IP, and if every path is blocked the address is queued
+// for a later retry.
+
+const (
+	probeBlockKeyPrefix = "probe:blocked:" // probe:blocked:{egress}:{mx} -> reply
+	probeRetryKey       = "probe:retry"    // sorted set: tenant|email -> due unix time
+	probeRetryBatch     = 100
+	defaultEgress       = "default" // Key name for the system-chosen source IP
+)
+
+// errRejectedAtConnect marks a 5xx greeting: the server refused us before we
+// named any recipient
+var errRejectedAtConnect = errors.New("rejected at connect")
+
+// ProbeBlock is one benched egress IP / MX pair
+type ProbeBlock struct {
+	EgressIP  string    `json:"egress_ip"`
+	MXHost    string    `json:"mx_host"`
+	Response  string    `json:"response"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func probeBlockKey(egress, mxHost string) string {
+	if egress == "" {
+		egress = defaultEgress
+	}
+	return probeBlockKeyPrefix + egress + ":" + strings.ToLower(mxHost)
+}
+
+// egressPaths lists source IPs for probes in ctx's region; "" lets the OS
+// choose
+func (v *SMTPVerifier) egressPaths(ctx context.Context) []string {
+	if region := v.regionConfig(ctx); region != nil && len(region.EgressIPs) > 0 {
+		return region.EgressIPs
+	}
+	if len(v.cfg().EgressIPs) == 0 {
+		return []string{""}
+	}
+	return v.cfg().EgressIPs
+}
+
+// pickEgress returns the next egress IP (round-robin) not benched for
+// mxHost. It reports false when every path is blocked.
+func (v *SMTPVerifier) pickEgress(ctx context.Context, mxHost string) (string, bool) {
+	paths := v.egressPaths(ctx)
+	start := int(v.egressNext.Add(1))
+
+	pipe := v.redis.Pipeline()
+	blocked := make([]*redis.IntCmd, len(paths))
+	for i, egress := range paths {
+		blocked[i] = pipe.Exists(ctx, probeBlockKey(egress, mxHost))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		// Unknown block state; probing is still better than failing
+		return paths[start%len(paths)], true
+	}
+	for i := range paths {
+		n := (start + i) % len(paths)
+		if blocked[n].Val() == 0 {
+			return paths[n], true
+		}
+	}
+	return "", false
+}
+
+// egressDialer binds outgoing SMTP connections to egress
+func (v *SMTPVerifier) egressDialer(egress string) *net.Dialer {
+	d := &net.Dialer{Timeout: v.cfg().SMTPConnectTimeout}
+	if ip := net.ParseIP(egress); ip != nil {
+		d.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	return d
+}
+
+// markProbeBlocked benches egress for mxHost and alerts operators
+func (v *SMTPVerifier) markProbeBlocked(ctx context.Context, egress, mxHost string, code int, response string) {
+	if egress == "" {
+		egress = defaultEgress
+	}
+	slog.ErrorContext(ctx, "probe blocked",
+		"alert", "probe_blocked",
+		"egress_ip", egress,
+		"mx_host", mxHost,
+		"smtp_code", code,
+		"smtp_response", response,
+	)
+	if err := v.redis.Set(ctx, probeBlockKey(egress, mxHost), response, v.cfg().ProbeBlockCooldown).Err(); err != nil {
+		slog.WarnContext(ctx, "probe block not recorded", "egress_ip", egress, "mx_host", mxHost, "error", err)
+	}
+}
+
+// probeRetryMember names a retry entry; the tenant comes first and never
+// contains the separator, so the retry is verified, metered and reported
+// for the key that asked
+func probeRetryMember(tenant, email string) string {
+	return tenant + usageFieldSep + email
+}
+
+// parseProbeRetryMember reverses probeRetryMember; entries queued before
+// tenants were recorded belong to DefaultTenant
+func parseProbeRetryMember(member string) (tenant, email string) {
+	tenant, email, ok := strings.Cut(member, usageFieldSep)
+	if !ok {
+		return DefaultTenant, member
+	}
+	return tenant, email
+}
+
+// scheduleProbeRetry queues email for a fresh verification once blocks
+// have had time to clear
+func (v *SMTPVerifier) scheduleProbeRetry(ctx context.Context, email string) {
+	due := time.Now().Add(v.cfg().ProbeRetryDelay)
+	member := probeRetryMember(TenantFrom(ctx), email)
+	err := v.TenantStore(ctx).ZAddNX(ctx, probeRetryKey, redis.Z{Score: float64(due.Unix()), Member: member}).Err()
+	if err != nil {
+		slog.WarnContext(ctx, "probe retry not scheduled", "email_hash", hashEmail(email), "error", err)
+	}
+}
+
+// RetryBlockedProbes re-verifies a batch of addresses whose probes were
+// blocked and are now due, at home and in each region. Each due entry is
+// claimed with ZREM, so any number of replicas can run it at once.
+func (v *SMTPVerifier) RetryBlockedProbes(ctx context.Context) error {
+	err := v.retryBlockedProbes(WithRegion(ctx, ""))
+	for _, region := range v.cfg().Regions {
+		if regionErr := v.retryBlockedProbes(WithRegion(ctx, region.Name)); regionErr != nil && err == nil {
+			err = fmt.Errorf("region %s: %w", region.Name, regionErr)
+		}
+	}
+	return err
+}
+
+func (v *SMTPVerifier) retryBlockedProbes(ctx context.Context) error {
+	store := v.TenantStore(ctx)
+	due, err := store.ZRangeByScore(ctx, probeRetryKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().Unix(), 10),
+		Count: probeRetryBatch,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	for _, member := range due {
+		if claimed, _ := store.ZRem(ctx, probeRetryKey, member).Result(); claimed == 0 {
+			continue
+		}
+		tenant, email := parseProbeRetryMember(member)
+		if _, err := v.VerifyWithOptions(WithTenant(ctx, tenant), email, VerifyOptions{SkipCache: true}); err != nil {
+			slog.WarnContext(ctx, "probe retry failed", "email_hash", hashEmail(email), "error", err)
+		}
+	}
+	return nil
+}
+
+// ProbeBlocks lists the currently benched egress IP / MX pairs
+func (v *SMTPVerifier) ProbeBlocks(ctx context.Context) ([]ProbeBlock, error) {
+	blocks := []ProbeBlock{}
+	iter := v.redis.Scan(ctx, 0, probeBlockKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		// IPv6 egress IPs contain colons; MX hosts never do
+		pair := strings.TrimPrefix(key, probeBlockKeyPrefix)
+		i := strings.LastIndex(pair, ":")
+		if i < 0 {
+			continue
+		}
+		egress, mxHost := pair[:i], pair[i+1:]
+		pipe := v.redis.Pipeline()
+		response := pipe.Get(ctx, key)
+		ttl := pipe.PTTL(ctx, key)
+		if _, err := pipe.Exec(ctx); err != nil {
+			continue // Expired between SCAN and GET
+		}
+		blocks = append(blocks, ProbeBlock{EgressIP: egress, MXHost: mxHost, Response: response.Val(), ExpiresAt: time.Now().Add(ttl.Val())})
+	}
+	return blocks, iter.Err()
+}
+
+// ClearProbeBlock puts egress back into rotation for mxHost, e.g. after a
+// delisting
+func (v *SMTPVerifier) ClearProbeBlock(ctx context.Context, egress, mxHost string) error {
+	return v.redis.Del(ctx, probeBlockKey(egress, mxHost)).Err()
+}
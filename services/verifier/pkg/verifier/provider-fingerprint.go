@@ -0,0 +1,93 @@
+package verifier
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// PROVIDER FINGERPRINTING
+// ============================================================================
+
+// Results name the mail platform behind the MX host (provider), so contacts
+// can be grouped by it. The MX hostname decides first (mx_suffixes in the
+// hints dataset). Domains often point a vanity MX name at a hosted platform,
+// so the 220 greeting is read next: hints with banner_patterns match it, and
+// then their strategy applies as if the MX name had matched. Failing both,
+// a recognized MTA in the greeting gives "self-hosted <mta>". Greetings are
+// kept per MX host in memory; the first probe of a host learns it.
+
+const (
+	mxBannerTTL        = 24 * time.Hour
+	mxBannerMaxEntries = 50000
+	mxBannerMaxBytes   = 2048 // Greeting text kept per host
+)
+
+// selfHostedMTAs recognize server software in a greeting
+var selfHostedMTAs = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"postfix", regexp.MustCompile(`(?i)\bpostfix\b`)},
+	{"exim", regexp.MustCompile(`(?i)\bexim\b`)},
+	{"sendmail", regexp.MustCompile(`(?i)\bsendmail\b`)},
+	{"exchange", regexp.MustCompile(`(?i)microsoft esmtp mail service`)},
+	{"qmail", regexp.MustCompile(`(?i)\bqmail\b`)},
+	{"opensmtpd", regexp.MustCompile(`(?i)\bopensmtpd\b`)},
+	{"haraka", regexp.MustCompile(`(?i)\bharaka\b`)},
+	{"zimbra", regexp.MustCompile(`(?i)\bzimbra\b`)},
+	{"mdaemon", regexp.MustCompile(`(?i)\bmdaemon\b`)},
+	{"kerio", regexp.MustCompile(`(?i)\bkerio\b`)},
+	{"communigate", regexp.MustCompile(`(?i)\bcommunigate\b`)},
+	{"icewarp", regexp.MustCompile(`(?i)\bicewarp\b`)},
+}
+
+// ForBanner returns the provider whose longest banner pattern appears in
+// the greeting
+func (h *ProviderHints) ForBanner(banner string) *ProviderHint {
+	if banner == "" {
+		return nil
+	}
+	banner = strings.ToLower(banner)
+	var best *ProviderHint
+	bestLen := 0
+	for _, p := range h.Providers {
+		for _, pattern := range p.BannerPatterns {
+			if strings.Contains(banner, strings.ToLower(pattern)) && len(pattern) > bestLen {
+				best, bestLen = p, len(pattern)
+			}
+		}
+	}
+	return best
+}
+
+// mxBanner returns the last greeting seen from mxHost, or ""
+func (v *SMTPVerifier) mxBanner(mxHost string) string {
+	if banner, ok := v.banners.get(strings.ToLower(mxHost)); ok {
+		return banner.(string)
+	}
+	return ""
+}
+
+// providerFor returns the provider hint for mxHost by name, then by greeting
+func (v *SMTPVerifier) providerFor(mxHost string) *ProviderHint {
+	if hint := v.hints.ForMX(mxHost); hint != nil {
+		return hint
+	}
+	return v.hints.ForBanner(v.mxBanner(mxHost))
+}
+
+// mailPlatform names the platform behind mxHost for results, or ""
+func (v *SMTPVerifier) mailPlatform(mxHost string) string {
+	if hint := v.providerFor(mxHost); hint != nil {
+		return hint.Name
+	}
+	banner := v.mxBanner(mxHost)
+	for _, mta := range selfHostedMTAs {
+		if mta.pattern.MatchString(banner) {
+			return "self-hosted " + mta.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// PROVIDER HEALTH
+// ============================================================================
+
+// Every MX probe is counted per minute and mailbox provider (see
+// provider-hints.go; unknown providers count as "other") by how it ended:
+// answered, deferred (4xx, greylisting, 421), blocked (our probe was
+// refused) or failed (no SMTP conversation). The last providerHealthWindow
+// minutes make up the public status, so callers can tell a bad list from
+// a provider throttling us.
+
+const (
+	providerHealthPrefix = "stats:provider_health:"
+	providerHealthTTL    = time.Hour
+	providerHealthWindow = 15 * time.Minute
+	providerHealthSep    = "|"
+	providerOther        = "other"
+
+	// Fewer probes than this in the window say nothing about a provider
+	providerHealthMinProbes = 20
+)
+
+// Probe outcomes
+const (
+	probeAnswered = "answered"
+	probeDeferred = "deferred"
+	probeBlocked  = "blocked"
+	probeFailed   = "failed"
+)
+
+// Provider health states
+const (
+	HealthNormal            = "normal"
+	HealthNoData            = "no_data"
+	HealthElevatedDeferrals = "elevated_deferrals"
+	HealthConnectionErrors  = "connection_errors"
+	HealthBlocking          = "blocking"
+)
+
+// Rates of the window's probes at which a provider leaves HealthNormal
+var providerHealthThresholds = []struct {
+	state  string
+	metric func(p *ProviderHealth) float64
+	rate   float64
+}{
+	{HealthBlocking, func(p *ProviderHealth) float64 { return p.BlockRate }, 0.10},
+	{HealthConnectionErrors, func(p *ProviderHealth) float64 { return p.ErrorRate }, 0.20},
+	{HealthElevatedDeferrals, func(p *ProviderHealth) float64 { return p.DeferralRate }, 0.20},
+}
+
+type ProviderHealth struct {
+	Provider     string  `json:"provider"`
+	Status       string  `json:"status"`
+	Summary      string  `json:"summary"` // e.g. "microsoft_consumer: elevated deferrals"
+	Probes       int64   `json:"probes"`
+	DeferralRate float64 `json:"deferral_rate"`
+	BlockRate    float64 `json:"block_rate"`
+	ErrorRate    float64 `json:"error_rate"`
+}
+
+type ServiceStatus struct {
+	Status        string           `json:"status"` // normal, or degraded while any provider is not
+	WindowMinutes int              `json:"window_minutes"`
+	Providers     []ProviderHealth `json:"providers"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+func providerHealthKey(t time.Time) string {
+	return providerHealthPrefix + t.UTC().Format("200601021504")
+}
+
+// probeOutcome says how a probe of one MX ended; "" for ones that say
+// nothing about the provider, such as our own shutdown or rate limits
+func probeOutcome(ctx context.Context, result *ValidationResult, err error) string {
+	if result == nil {
+		if err == nil || ctx.Err() != nil || errors.Is(err, ErrOverloaded) {
+			return ""
+		}
+		return probeFailed
+	}
+	switch {
+	case result.Reason == "probe_blocked" || result.Reason == "provider_blocked":
+		return probeBlocked
+	case result.Reason == "greylisted" || result.Reason == "rate_limited" || result.Reason == "temporary_failure",
+		result.SMTPCode >= 400 && result.SMTPCode < 500:
+		return probeDeferred
+	}
+	return probeAnswered
+}
+
+// recordProviderHealth counts one probe of mxHost
+func (v *SMTPVerifier) recordProviderHealth(ctx context.Context, mxHost string, result *ValidationResult, err error) {
+	outcome := probeOutcome(ctx, result, err)
+	if outcome == "" {
+		return
+	}
+	provider := providerOther
+	if hint := v.providerFor(mxHost); hint != nil {
+		provider = hint.Name
+	}
+
+	key := providerHealthKey(time.Now())
+	ctx = context.WithoutCancel(ctx)
+	pipe := v.redis.Pipeline()
+	pipe.HIncrBy(ctx, key, provider+providerHealthSep+outcome, 1)
+	pipe.Expire(ctx, key, providerHealthTTL)
+	pipe.Exec(ctx)
+}
+
+// Status summarizes provider health over the last providerHealthWindow.
+// Every provider in the hints dataset is listed, with HealthNoData when it
+// was hardly probed.
+func (v *SMTPVerifier) Status(ctx context.Context) (*ServiceStatus, error) {
+	now := time.Now()
+	minutes := int(providerHealthWindow / time.Minute)
+	pipe := v.redis.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, 0, minutes)
+	for i := 0; i < minutes; i++ {
+		cmds = append(cmds, pipe.HGetAll(ctx, providerHealthKey(now.Add(-time.Duration(i)*time.Minute))))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]map[string]int64)
+	for _, hint := range v.hints.Providers {
+		counts[hint.Name] = make(map[string]int64)
+	}
+	for _, cmd := range cmds {
+		for field, raw := range cmd.Val() {
+			provider, outcome, ok := strings.Cut(field, providerHealthSep)
+			n, err := strconv.ParseInt(raw, 10, 64)
+			if !ok || err != nil {
+				continue
+			}
+			if counts[provider] == nil {
+				counts[provider] = make(map[string]int64)
+			}
+			counts[provider][outcome] += n
+		}
+	}
+
+	status := &ServiceStatus{Status: HealthNormal, WindowMinutes: minutes, Providers: []ProviderHealth{}, UpdatedAt: now}
+	for provider, c := range counts {
+		health := ProviderHealth{Provider: provider, Status: HealthNormal}
+		for _, n := range c {
+			health.Probes += n
+		}
+		if health.Probes < providerHealthMinProbes {
+			health.Status = HealthNoData
+		} else {
+			total := float64(health.Probes)
+			health.DeferralRate = float64(c[probeDeferred]) / total
+			health.BlockRate = float64(c[probeBlocked]) / total
+			health.ErrorRate = float64(c[probeFailed]) / total
+			for _, t := range providerHealthThresholds {
+				if t.metric(&health) >= t.rate {
+					health.Status = t.state
+					status.Status = "degraded"
+					break
+				}
+			}
+		}
+		health.Summary = fmt.Sprintf("%s: %s", provider, strings.ReplaceAll(health.Status, "_", " "))
+		status.Providers = append(status.Providers, health)
+	}
+	sort.Slice(status.Providers, func(a, b int) bool { return status.Providers[a].Provider < status.Providers[b].Provider })
+	return status, nil
+}
@@ -0,0 +1,193 @@
+package verifier
+
+import (
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// MAILBOX PROVIDER HINTS
+// ============================================================================
+
+// Large providers answer probes in their own ways: Gmail's 550 5.1.1 is as
+// good as a bounce, Microsoft 365 rejects unknown users with wording the
+// shared rules read as a block, Yahoo accepts everyone and defers probers.
+// The provider recognized from the MX host brings its own strategy:
+//
+//   - rules: response text rules checked before the shared ones, so the
+//     provider's wording gets its own verdict
+//   - confidence: per-reason confidence replacing the generic value when
+//     the verdict came from the reply code or a shared rule
+//   - apply: block patterns, accept-all and greylisting, after classifying
+//
+// Replies from unrecognized hosts get the generic classification.
+
+//go:embed data/provider-hints.yaml
+var bundledProviderHints []byte
+
+// ProviderHint describes how one mailbox provider behaves towards probes
+type ProviderHint struct {
+	Name                 string             `yaml:"name"`
+	MXSuffixes           []string           `yaml:"mx_suffixes"`
+	BannerPatterns       []string           `yaml:"banner_patterns"` // Greeting substrings for MX hosts with their own names
+	RCPTRevealsExistence bool               `yaml:"rcpt_reveals_existence"`
+	BlockPatterns        []string           `yaml:"block_patterns"`
+	Greylisting          bool               `yaml:"greylisting"`
+	GreylistRetryAfter   time.Duration      `yaml:"greylist_retry_after"`
+	Rules                []*ResponseRule    `yaml:"rules"`      // Checked before the shared response rules
+	Confidence           map[string]float64 `yaml:"confidence"` // Reason -> confidence replacing the generic one
+	Notes                string             `yaml:"notes"`
+}
+
+type ProviderHints struct {
+	Version   string          `yaml:"version"`
+	Updated   string          `yaml:"updated"`
+	Providers []*ProviderHint `yaml:"providers"`
+}
+
+// loadProviderHints parses the bundled dataset and merges the operator
+// override file on top: entries with the same name replace bundled ones,
+// new names are added.
+func loadProviderHints(overridePath string) (*ProviderHints, error) {
+	var hints ProviderHints
+	if err := yaml.Unmarshal(bundledProviderHints, &hints); err != nil {
+		return nil, fmt.Errorf("bundled provider hints: %w", err)
+	}
+	if overridePath == "" {
+		return &hints, nil
+	}
+
+	data, err := os.ReadFile(overridePath)
+	if err != nil {
+		return &hints, err
+	}
+	var override ProviderHints
+	if err := yaml.Unmarshal(data, &override); err != nil {
+		return &hints, fmt.Errorf("%s: %w", overridePath, err)
+	}
+
+	for _, o := range override.Providers {
+		replaced := false
+		for i, h := range hints.Providers {
+			if h.Name == o.Name {
+				hints.Providers[i] = o
+				replaced = true
+			}
+		}
+		if !replaced {
+			hints.Providers = append(hints.Providers, o)
+		}
+	}
+	if override.Version != "" {
+		hints.Version += "+" + override.Version
+	}
+	return &hints, nil
+}
+
+// mustLoadProviderHints falls back to the bundled dataset on override errors
+func mustLoadProviderHints(overridePath string) *ProviderHints {
+	hints, err := loadProviderHints(overridePath)
+	if err != nil {
+		slog.Warn("provider hints override not applied", "path", overridePath, "error", err)
+	}
+	if hints == nil {
+		hints = &ProviderHints{}
+	}
+	for _, p := range hints.Providers {
+		for _, err := range p.compileRules() {
+			slog.Warn("provider rule not applied", "provider", p.Name, "error", err)
+		}
+	}
+	return hints
+}
+
+// compileRules drops and reports invalid rules and confidences
+func (p *ProviderHint) compileRules() []error {
+	var errs []error
+	rules := p.Rules[:0]
+	for _, r := range p.Rules {
+		if err := r.compile(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+			continue
+		}
+		rules = append(rules, r)
+	}
+	p.Rules = rules
+	for reason, c := range p.Confidence {
+		if c < 0 || c > 1 {
+			errs = append(errs, fmt.Errorf("%s: confidence for %s must be between 0 and 1", p.Name, reason))
+			delete(p.Confidence, reason)
+		}
+	}
+	return errs
+}
+
+// ForMX returns the provider whose longest MX suffix matches host
+func (h *ProviderHints) ForMX(host string) *ProviderHint {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	var best *ProviderHint
+	bestLen := 0
+	for _, p := range h.Providers {
+		for _, suffix := range p.MXSuffixes {
+			if (host == suffix || strings.HasSuffix(host, "."+suffix)) && len(suffix) > bestLen {
+				best, bestLen = p, len(suffix)
+			}
+		}
+	}
+	return best
+}
+
+// classifyReply classifies an RCPT TO reply with provider's strategy (nil
+// for none): its rules, then the shared rules, then the reply code. Returns
+// the rule that decided, if any.
+func (v *SMTPVerifier) classifyReply(code int, response string, provider *ProviderHint) (ValidationStatus, string, float64, *ResponseRule) {
+	if provider != nil {
+		if rule := responseRules(provider.Rules).match(code, response); rule != nil {
+			return rule.Status, rule.Reason, rule.Confidence, rule
+		}
+	}
+
+	status, reason, confidence := classifySMTPResponse(code, response)
+	if looksGreylisted(code, response) {
+		reason = "greylisted"
+	}
+	rule := v.rules.match(code, response)
+	if rule != nil {
+		status, reason, confidence = rule.Status, rule.Reason, rule.Confidence
+	}
+	if provider != nil {
+		if c, ok := provider.Confidence[reason]; ok {
+			confidence = c
+		}
+	}
+	return status, reason, confidence, rule
+}
+
+// isProviderVerdict reports whether reason came from a provider hint
+func isProviderVerdict(reason string) bool {
+	return reason == "provider_blocked" || reason == "provider_accepts_all" || reason == "greylisted"
+}
+
+// apply adjusts a classified SMTP answer using what is known about the
+// provider. It also reports whether catch-all probing is still meaningful.
+func (p *ProviderHint) apply(code int, response string, status ValidationStatus, reason string, confidence float64) (ValidationStatus, string, float64, bool) {
+	for _, pattern := range p.BlockPatterns {
+		if strings.Contains(response, pattern) {
+			return StatusUnknown, "provider_blocked", 0.2, false
+		}
+	}
+
+	switch {
+	case status == StatusValid && !p.RCPTRevealsExistence:
+		return StatusUnknown, "provider_accepts_all", 0.4, false
+	case code >= 450 && code < 500 && p.Greylisting:
+		return StatusUnknown, "greylisted", 0.3, false
+	}
+	return status, reason, confidence, true
+}
@@ -0,0 +1,212 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// API KEY QUOTAS AND METERING
+// ============================================================================
+
+// Every verification served to an API key, fresh or cached, is counted per
+// UTC day and month in the tenant's region. Quotas (QuotaDaily,
+// QuotaMonthly, or a TenantQuotas entry) are checked before work starts: a
+// request whose addresses would take the key past either limit is refused
+// until the period resets. Checks and counts are separate, so concurrent
+// requests can overshoot by what they hold between them. A check that gets
+// an error from Redis refuses the request; only an unreachable Redis lets it
+// through, and not with QuotaFailClosed. Keyless API requests are metered
+// as AnonymousTenant; internal work (queues, schedules, MTA integrations)
+// is neither metered nor limited.
+//
+//	usage:{tenant}:day:{YYYYMMDD}  counter, kept for usageDayTTL
+//	usage:{tenant}:month:{YYYYMM}  counter, kept for usageMonthTTL
+
+const (
+	usageDayTTL   = 35 * 24 * time.Hour  // The month's daily history stays readable
+	usageMonthTTL = 400 * 24 * time.Hour // A year back for billing disputes
+)
+
+// Quota periods
+const (
+	QuotaDay   = "day"
+	QuotaMonth = "month"
+)
+
+// Quota limits one API key's verifications; 0 is unlimited
+type Quota struct {
+	Daily   int64 `yaml:"daily" json:"daily"`
+	Monthly int64 `yaml:"monthly" json:"monthly"`
+}
+
+// QuotaExceededError refuses a request that would go over a quota
+type QuotaExceededError struct {
+	Period   string // QuotaDay or QuotaMonth
+	Limit    int64
+	Used     int64
+	ResetsAt time.Time
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota of %d verifications exceeded (%d used); resets at %s",
+		e.Period, e.Limit, e.Used, e.ResetsAt.Format(time.RFC3339))
+}
+
+// RetryAfter is the wait until the quota resets
+func (e *QuotaExceededError) RetryAfter() time.Duration {
+	return time.Until(e.ResetsAt)
+}
+
+// UsagePeriod is a key's consumption in one quota period
+type UsagePeriod struct {
+	Start     time.Time `json:"start"`
+	ResetsAt  time.Time `json:"resets_at"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`               // 0 = unlimited
+	Remaining *int64    `json:"remaining,omitempty"` // Unset when unlimited
+}
+
+// DailyUsage is one day's count in the month so far
+type DailyUsage struct {
+	Date          string `json:"date"` // YYYY-MM-DD (UTC)
+	Verifications int64  `json:"verifications"`
+}
+
+// TenantUsage answers GET /v1/usage
+type TenantUsage struct {
+	Tenant string       `json:"tenant"`
+	Day    UsagePeriod  `json:"day"`
+	Month  UsagePeriod  `json:"month"`
+	Daily  []DailyUsage `json:"daily"`
+}
+
+func usageDayKey(tenant string, t time.Time) string {
+	return "usage:" + tenant + ":day:" + t.UTC().Format("20060102")
+}
+
+func usageMonthKey(tenant string, t time.Time) string {
+	return "usage:" + tenant + ":month:" + t.UTC().Format("200601")
+}
+
+// quotaPeriods returns the start and end of the UTC day and month around t
+func quotaPeriods(t time.Time) (dayStart, dayEnd, monthStart, monthEnd time.Time) {
+	t = t.UTC()
+	dayStart = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return dayStart, dayStart.AddDate(0, 0, 1), monthStart, monthStart.AddDate(0, 1, 0)
+}
+
+// QuotaFor returns the quota that applies to tenant
+func (v *SMTPVerifier) QuotaFor(tenant string) Quota {
+	if quota, ok := v.cfg().TenantQuotas[tenant]; ok {
+		return quota
+	}
+	return Quota{Daily: v.cfg().QuotaDaily, Monthly: v.cfg().QuotaMonthly}
+}
+
+// meterUsage counts n verifications against the API key in ctx
+func (v *SMTPVerifier) meterUsage(ctx context.Context, pipe redis.Pipeliner, n int64) {
+	tenant := TenantFrom(ctx)
+	if tenant == DefaultTenant {
+		return
+	}
+	now := time.Now()
+	dayKey, monthKey := usageDayKey(tenant, now), usageMonthKey(tenant, now)
+	pipe.IncrBy(ctx, dayKey, n)
+	pipe.Expire(ctx, dayKey, usageDayTTL)
+	pipe.IncrBy(ctx, monthKey, n)
+	pipe.Expire(ctx, monthKey, usageMonthTTL)
+}
+
+// CheckQuota returns a *QuotaExceededError when n more verifications would
+// take the API key in ctx past its daily or monthly quota
+func (v *SMTPVerifier) CheckQuota(ctx context.Context, n int) error {
+	tenant := TenantFrom(ctx)
+	quota := v.QuotaFor(tenant)
+	if tenant == DefaultTenant || (quota.Daily <= 0 && quota.Monthly <= 0) {
+		return nil
+	}
+
+	now := time.Now()
+	_, dayEnd, _, monthEnd := quotaPeriods(now)
+	store := v.TenantStore(ctx)
+	pipe := store.Pipeline()
+	day := pipe.Get(ctx, usageDayKey(tenant, now))
+	month := pipe.Get(ctx, usageMonthKey(tenant, now))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		if isRedisConnErr(err) && !v.cfg().QuotaFailClosed {
+			slog.WarnContext(ctx, "quota check skipped, Redis unreachable", "error", err)
+			return nil
+		}
+		return fmt.Errorf("quota check: %w", err)
+	}
+
+	// The month is reported first: waiting out the day would not help
+	monthUsed, _ := month.Int64()
+	if quota.Monthly > 0 && monthUsed+int64(n) > quota.Monthly {
+		return &QuotaExceededError{Period: QuotaMonth, Limit: quota.Monthly, Used: monthUsed, ResetsAt: monthEnd}
+	}
+	dayUsed, _ := day.Int64()
+	if quota.Daily > 0 && dayUsed+int64(n) > quota.Daily {
+		return &QuotaExceededError{Period: QuotaDay, Limit: quota.Daily, Used: dayUsed, ResetsAt: dayEnd}
+	}
+	return nil
+}
+
+// Usage returns the consumption of the API key in ctx: today, this month,
+// and each day of the month so far
+func (v *SMTPVerifier) Usage(ctx context.Context) (*TenantUsage, error) {
+	tenant := TenantFrom(ctx)
+	if tenant == DefaultTenant {
+		return nil, ErrNoTenant
+	}
+	now := time.Now().UTC()
+	dayStart, dayEnd, monthStart, monthEnd := quotaPeriods(now)
+
+	keys := []string{usageMonthKey(tenant, now)}
+	for d := monthStart; !d.After(dayStart); d = d.AddDate(0, 0, 1) {
+		keys = append(keys, usageDayKey(tenant, d))
+	}
+	vals, err := v.TenantStore(ctx).MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	count := func(val interface{}) int64 {
+		s, _ := val.(string)
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+
+	quota := v.QuotaFor(tenant)
+	usage := &TenantUsage{
+		Tenant: tenant,
+		Month:  usagePeriod(monthStart, monthEnd, count(vals[0]), quota.Monthly),
+		Daily:  make([]DailyUsage, 0, len(vals)-1),
+	}
+	for i, val := range vals[1:] {
+		usage.Daily = append(usage.Daily, DailyUsage{
+			Date:          monthStart.AddDate(0, 0, i).Format("2006-01-02"),
+			Verifications: count(val),
+		})
+	}
+	usage.Day = usagePeriod(dayStart, dayEnd, usage.Daily[len(usage.Daily)-1].Verifications, quota.Daily)
+	return usage, nil
+}
+
+func usagePeriod(start, end time.Time, used, limit int64) UsagePeriod {
+	period := UsagePeriod{Start: start, ResetsAt: end, Used: used, Limit: limit}
+	if limit > 0 {
+		remaining := limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		period.Remaining = &remaining
+	}
+	return period
+}
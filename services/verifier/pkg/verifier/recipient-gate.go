@@ -0,0 +1,150 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// RECIPIENT GATING
+// ============================================================================
+
+// Shared by the MTA-facing integrations (SMTP proxy, milter, policy) that decide
+// whether a recipient may be mailed.
+
+// suppressionKey is a Redis set of normalized addresses that must never be
+// mailed (hard bounces, complaints, unsubscribes), maintained by operators.
+const suppressionKey = "suppression:emails"
+
+// RecipientHeader carries flag-mode verdicts on relayed messages
+const RecipientHeader = "X-Recipient-Verification"
+
+// Gate modes: refuse bad recipients outright, or accept and flag them
+const (
+	GateModeReject = "reject"
+	GateModeFlag   = "flag"
+)
+
+type RecipientVerdict string
+
+const (
+	RecipientOK         RecipientVerdict = ""
+	RecipientInvalid    RecipientVerdict = "invalid"
+	RecipientSuppressed RecipientVerdict = "suppressed"
+	RecipientUnverified RecipientVerdict = "unverified" // Lookup failed, timed out or was inconclusive
+)
+
+// Blocked reports whether the recipient must not be mailed
+func (rv RecipientVerdict) Blocked() bool {
+	return rv == RecipientInvalid || rv == RecipientSuppressed
+}
+
+// IsSuppressed reports whether the address is on the suppression list
+func (v *SMTPVerifier) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	return v.redis.SIsMember(ctx, suppressionKey, strings.ToLower(strings.TrimSpace(email))).Result()
+}
+
+// CheckRecipient returns a verdict and reason for a recipient. Lookups that
+// fail or exceed timeout yield RecipientUnverified, which callers should let
+// through so mail flow never blocks on our own outages.
+func (v *SMTPVerifier) CheckRecipient(ctx context.Context, addr string, timeout time.Duration) (RecipientVerdict, string) {
+	ctx, cancel := context.WithTimeout(EnsureRequestID(ctx), timeout)
+	defer cancel()
+
+	if suppressed, err := v.IsSuppressed(ctx, addr); err == nil && suppressed {
+		return RecipientSuppressed, "suppressed"
+	}
+
+	result, err := v.Verify(ctx, addr)
+	switch {
+	case err != nil:
+		return RecipientUnverified, err.Error()
+	case result.Status == StatusInvalid:
+		return RecipientInvalid, result.Reason
+	case result.Status == StatusUnknown:
+		return RecipientUnverified, result.Reason
+	default:
+		return RecipientOK, ""
+	}
+}
+
+// CachedRecipient is a verdict reached without a fresh check
+type CachedRecipient struct {
+	Email   string
+	Verdict RecipientVerdict
+	Reason  string
+	Result  *ValidationResult // The cached result; nil on a miss or when suppressed
+}
+
+// CheckRecipientsCached judges addrs from the suppression list and the
+// result cache alone, in one round trip to each store, for callers in a
+// sending path that cannot wait for SMTP. A cache miss is RecipientUnverified
+// with reason "not_cached"; a failed lookup is RecipientUnverified too.
+func (v *SMTPVerifier) CheckRecipientsCached(ctx context.Context, addrs []string) []CachedRecipient {
+	recipients := make([]CachedRecipient, len(addrs))
+	members := make([]interface{}, len(addrs))
+	keys := make([]string, len(addrs))
+	for i, addr := range addrs {
+		email := strings.ToLower(strings.TrimSpace(addr))
+		recipients[i] = CachedRecipient{Email: email, Verdict: RecipientUnverified, Reason: "not_cached"}
+		members[i] = email
+		if v.cfg().NormalizeAddresses {
+			email = normalizeAddress(email)
+		}
+		keys[i] = v.tenantKey(ctx, "validation:result:"+hashEmail(email))
+	}
+	if len(addrs) == 0 {
+		return recipients
+	}
+
+	suppressed, err := v.redis.SMIsMember(ctx, suppressionKey, members...).Result()
+	if err != nil {
+		suppressed = make([]bool, len(addrs))
+		for i := range recipients {
+			recipients[i].Reason = "lookup_failed"
+		}
+	}
+	var cached []interface{}
+	if store := v.TenantStore(ctx); !v.skipCache(store) {
+		cached, _ = store.MGet(ctx, keys...).Result()
+	}
+
+	for i := range recipients {
+		if suppressed[i] {
+			recipients[i].Verdict, recipients[i].Reason = RecipientSuppressed, "suppressed"
+			continue
+		}
+		if i >= len(cached) {
+			continue
+		}
+		raw, ok := cached[i].(string)
+		var result ValidationResult
+		if !ok || json.Unmarshal([]byte(raw), &result) != nil {
+			continue
+		}
+		// Rescored in memory only; the next Verify writes it back
+		if current, _ := v.upgradeCached(&result); !current {
+			continue
+		}
+		recipients[i].Result = &result
+		switch result.Status {
+		case StatusInvalid:
+			recipients[i].Verdict, recipients[i].Reason = RecipientInvalid, result.Reason
+		case StatusUnknown:
+			recipients[i].Verdict, recipients[i].Reason = RecipientUnverified, result.Reason
+		default:
+			recipients[i].Verdict, recipients[i].Reason = RecipientOK, ""
+		}
+	}
+	return recipients
+}
+
+// RejectReply is the SMTP reply used when a recipient is refused
+func RejectReply(addr string, verdict RecipientVerdict, reason string) string {
+	if verdict == RecipientSuppressed {
+		return "550 5.7.1 <" + addr + ">: Recipient is suppressed"
+	}
+	return "550 5.1.1 <" + addr + ">: Recipient address rejected: " + reason
+}
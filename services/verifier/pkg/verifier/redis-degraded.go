@@ -0,0 +1,218 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// REDIS SOFT-FAIL MODE
+// ============================================================================
+
+// When a command to a Redis store fails at the connection level, the store
+// is marked degraded until a background ping succeeds again, every
+// RedisRecoveryInterval. While degraded, cache reads are treated as misses and
+// cache writes are dropped without touching Redis, and the domain and MX rate
+// limits fall back to in-process token buckets. Those are per replica, so the
+// fleet's effective rate is the configured one times the replica count until
+// Redis returns. Reply errors from a reachable server (NOSCRIPT, WRONGTYPE)
+// do not count.
+
+const (
+	homeStoreName            = "home"
+	localRateLimitPruneEvery = time.Minute
+)
+
+// RedisStats reports soft-fail mode for /health and /metrics
+type RedisStats struct {
+	Degraded        []string `json:"degraded,omitempty"` // "home" or region names
+	CacheSkips      int64    `json:"cache_skips"`        // Cache reads and writes skipped while degraded
+	LocalRateLimits int64    `json:"local_rate_limits"`  // Rate limit checks served in-process
+}
+
+// redisHealth tracks one store; it is also a go-redis hook on that store's
+// client so any command can notice the outage
+type redisHealth struct {
+	name      string
+	client    *redis.Client
+	interval  time.Duration
+	degraded  atomic.Bool
+	onRecover func()
+}
+
+func newRedisHealth(name string, client *redis.Client, interval time.Duration, onRecover func()) *redisHealth {
+	h := &redisHealth{name: name, client: client, interval: interval, onRecover: onRecover}
+	client.AddHook(h)
+	return h
+}
+
+// isRedisConnErr reports failures to reach Redis, as opposed to misses,
+// server replies and the caller giving up
+func isRedisConnErr(err error) bool {
+	if err == nil || err == redis.Nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var reply redis.Error
+	return !errors.As(err, &reply)
+}
+
+func (h *redisHealth) observe(err error) {
+	if !isRedisConnErr(err) || !h.degraded.CompareAndSwap(false, true) {
+		return
+	}
+	slog.Warn("Redis unavailable, entering degraded mode", "store", h.name, "error", err)
+	go h.awaitRecovery()
+}
+
+// awaitRecovery pings until the store answers, then leaves degraded mode
+func (h *redisHealth) awaitRecovery() {
+	start := time.Now()
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), h.interval)
+		err := h.client.Ping(ctx).Err()
+		cancel()
+		if err == nil {
+			break
+		}
+	}
+	if h.onRecover != nil {
+		h.onRecover()
+	}
+	h.degraded.Store(false)
+	slog.Info("Redis recovered, leaving degraded mode", "store", h.name, "degraded_for", time.Since(start).Round(time.Second))
+}
+
+func (h *redisHealth) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *redisHealth) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		h.observe(err)
+		return err
+	}
+}
+
+func (h *redisHealth) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		h.observe(err)
+		return err
+	}
+}
+
+func (v *SMTPVerifier) watchRedis() {
+	v.health = map[*redis.Client]*redisHealth{
+		v.redis: newRedisHealth(homeStoreName, v.redis, v.cfg().RedisRecoveryInterval, v.localLimits.reset),
+	}
+	for name, store := range v.regionStores {
+		v.health[store] = newRedisHealth(name, store, v.cfg().RedisRecoveryInterval, nil)
+	}
+}
+
+func (v *SMTPVerifier) redisDegraded(store *redis.Client) bool {
+	h := v.health[store]
+	return h != nil && h.degraded.Load()
+}
+
+// skipCache reports that store is degraded and counts the skipped access
+func (v *SMTPVerifier) skipCache(store *redis.Client) bool {
+	if !v.redisDegraded(store) {
+		return false
+	}
+	v.cacheSkips.Add(1)
+	return true
+}
+
+// RedisStats reports which stores are degraded and what was skipped
+func (v *SMTPVerifier) RedisStats() RedisStats {
+	stats := RedisStats{
+		CacheSkips:      v.cacheSkips.Load(),
+		LocalRateLimits: v.localRateLimits.Load(),
+	}
+	for _, h := range v.health {
+		if h.degraded.Load() {
+			stats.Degraded = append(stats.Degraded, h.name)
+		}
+	}
+	return stats
+}
+
+// ----------------------------------------------------------------------------
+// In-process rate limits
+// ----------------------------------------------------------------------------
+
+// localRateLimiter is rateLimitTake for a single process
+type localRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*localBucket
+	pruned  time.Time
+}
+
+type localBucket struct {
+	tokens float64
+	ts     time.Time
+}
+
+func newLocalRateLimiter() *localRateLimiter {
+	return &localRateLimiter{buckets: make(map[string]*localBucket)}
+}
+
+// take takes a token from every bucket or from none, and returns how long to
+// wait before trying again when a bucket is empty
+func (l *localRateLimiter) take(buckets []rateLimitBucket) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.pruned) > localRateLimitPruneEvery {
+		l.prune(now)
+	}
+
+	var wait time.Duration
+	tokens := make([]float64, len(buckets))
+	for i, b := range buckets {
+		t := float64(b.burst)
+		if state, ok := l.buckets[b.key]; ok {
+			t = min(float64(b.burst), state.tokens+float64(now.Sub(state.ts))/float64(b.interval))
+		}
+		if t < 1 {
+			wait = max(wait, time.Duration((1-t)*float64(b.interval)))
+		}
+		tokens[i] = t
+	}
+	if wait > 0 {
+		return wait
+	}
+	for i, b := range buckets {
+		l.buckets[b.key] = &localBucket{tokens: tokens[i] - 1, ts: now}
+	}
+	return 0
+}
+
+// prune drops buckets left untouched as long as Redis keeps them; they have
+// refilled and would start full anyway
+func (l *localRateLimiter) prune(now time.Time) {
+	for key, state := range l.buckets {
+		if now.Sub(state.ts) > rateLimitMinTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.pruned = now
+}
+
+// reset forgets every bucket once Redis is back in charge
+func (l *localRateLimiter) reset() {
+	l.mu.Lock()
+	l.buckets = make(map[string]*localBucket)
+	l.mu.Unlock()
+}
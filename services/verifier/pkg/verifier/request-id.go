@@ -0,0 +1,48 @@
+package verifier
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// ============================================================================
+// REQUEST IDS
+// ============================================================================
+
+// The request ID travels in the context through every verification so log
+// lines from any replica can be tied back to the request that caused them.
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request ID in ctx, or ""
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// EnsureRequestID gives work that did not come through HTTP (milter, policy
+// service, SMTP proxy) its own ID
+func EnsureRequestID(ctx context.Context) context.Context {
+	if RequestIDFrom(ctx) != "" {
+		return ctx
+	}
+	return WithRequestID(ctx, NewID())
+}
+
+// NewID returns a random RFC 4122 version 4 UUID
+func NewID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
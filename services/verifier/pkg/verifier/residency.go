@@ -0,0 +1,104 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// DATA RESIDENCY
+// ============================================================================
+
+// A tenant pinned to a region keeps its data there. That covers cached and
+// recent results, the result feed, usage stats, job history and blocked-probe
+// retries, all in the region's Redis. Its probes leave from the region's
+// egress IPs. Other tenants use the home Redis and security.egress_ips.
+// Domain-level operational data holds no tenant data and stays home: MX
+// cache, domain metadata, rate limits and probe blocks.
+
+// Region is a storage backend and egress pool, tagged by name
+type Region struct {
+	Name               string   `yaml:"name"`
+	RedisAddr          string   `yaml:"redis_addr"`
+	RedisPassword      string   `yaml:"redis_password"`
+	RedisDB            int      `yaml:"redis_db"`
+	EgressIPs          []string `yaml:"egress_ips"`           // Empty = home egress IPs
+	WarehouseExportDir string   `yaml:"warehouse_export_dir"` // Usage export for the region's tenants; empty = none
+}
+
+type regionKey struct{}
+
+// WithRegion returns ctx routed to region regardless of its tenant; work
+// that runs per region (exports, retries) uses it
+func WithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+// CheckResidency reports regions without a Redis address, duplicate names
+// and tenants pinned to a region that does not exist
+func CheckResidency(config *Config) error {
+	names := make(map[string]bool)
+	for _, region := range config.Regions {
+		if region.Name == "" || region.RedisAddr == "" {
+			return fmt.Errorf("residency region %q needs a name and redis_addr", region.Name)
+		}
+		if names[region.Name] {
+			return fmt.Errorf("residency region %q is defined twice", region.Name)
+		}
+		names[region.Name] = true
+	}
+	for tenant, region := range config.TenantRegions {
+		if !names[region] {
+			return fmt.Errorf("tenant %s is pinned to unknown region %q", tenant, region)
+		}
+	}
+	return nil
+}
+
+func newRegionStores(regions []Region) map[string]*redis.Client {
+	stores := make(map[string]*redis.Client, len(regions))
+	for _, region := range regions {
+		stores[region.Name] = redis.NewClient(&redis.Options{
+			Addr:     region.RedisAddr,
+			Password: region.RedisPassword,
+			DB:       region.RedisDB,
+		})
+	}
+	return stores
+}
+
+// TenantRegion returns the region ctx is routed to, or "" for home
+func (v *SMTPVerifier) TenantRegion(ctx context.Context) string {
+	if region, ok := ctx.Value(regionKey{}).(string); ok {
+		return region
+	}
+	return v.cfg().TenantRegions[TenantFrom(ctx)]
+}
+
+// TenantStore returns the Redis holding tenant data for ctx
+func (v *SMTPVerifier) TenantStore(ctx context.Context) *redis.Client {
+	if store, ok := v.regionStores[v.TenantRegion(ctx)]; ok {
+		return store
+	}
+	return v.redis
+}
+
+// Regions lists the configured regions
+func (v *SMTPVerifier) Regions() []Region {
+	return v.cfg().Regions
+}
+
+func (v *SMTPVerifier) regionConfig(ctx context.Context) *Region {
+	name := v.TenantRegion(ctx)
+	if name == "" {
+		return nil
+	}
+	for i := range v.cfg().Regions {
+		if v.cfg().Regions[i].Name == name {
+			return &v.cfg().Regions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package verifier
+
+import (
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// SMTP RESPONSE TEXT RULES
+// ============================================================================
+
+// A 550 can mean "no such user" or "your IP is on Spamhaus"; only the reply
+// text tells them apart. Rules map that text to precise reasons.
+
+//go:embed data/response-rules.yaml
+var bundledResponseRules []byte
+
+// ResponseRule maps SMTP reply text to a classification
+type ResponseRule struct {
+	Name       string           `yaml:"name"`
+	Pattern    string           `yaml:"pattern"`
+	Codes      []int            `yaml:"codes"` // Empty = any 4xx/5xx
+	Status     ValidationStatus `yaml:"status"`
+	Reason     string           `yaml:"reason"`
+	Confidence float64          `yaml:"confidence"`
+
+	re *regexp.Regexp
+}
+
+type responseRules []*ResponseRule
+
+func (r *ResponseRule) compile() error {
+	if r.Name == "" || r.Reason == "" {
+		return fmt.Errorf("rule %q: name and reason are required", r.Name)
+	}
+	switch r.Status {
+	case StatusValid, StatusInvalid, StatusUnknown, StatusRisky:
+	default:
+		return fmt.Errorf("rule %q: unsupported status %q", r.Name, r.Status)
+	}
+	if r.Confidence < 0 || r.Confidence > 1 {
+		return fmt.Errorf("rule %q: confidence must be between 0 and 1", r.Name)
+	}
+	re, err := regexp.Compile("(?i)" + r.Pattern)
+	if err != nil {
+		return fmt.Errorf("rule %q: %w", r.Name, err)
+	}
+	r.re = re
+	return nil
+}
+
+// loadResponseRules returns the operator rules followed by the bundled ones.
+// Operator rules replace bundled rules of the same name; invalid rules are
+// dropped and reported.
+func loadResponseRules(custom []ResponseRule) (responseRules, []error) {
+	var bundled struct {
+		Rules []*ResponseRule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(bundledResponseRules, &bundled); err != nil {
+		return nil, []error{fmt.Errorf("bundled response rules: %w", err)}
+	}
+
+	var rules responseRules
+	var errs []error
+	seen := make(map[string]bool)
+	add := func(r *ResponseRule) {
+		if seen[r.Name] {
+			return
+		}
+		seen[r.Name] = true
+		if err := r.compile(); err != nil {
+			errs = append(errs, err)
+			return
+		}
+		rules = append(rules, r)
+	}
+	for i := range custom {
+		r := custom[i]
+		add(&r)
+	}
+	for _, r := range bundled.Rules {
+		add(r)
+	}
+	return rules, errs
+}
+
+func mustLoadResponseRules(custom []ResponseRule) responseRules {
+	rules, errs := loadResponseRules(custom)
+	for _, err := range errs {
+		slog.Warn("response rule not applied", "error", err)
+	}
+	return rules
+}
+
+// match returns the first rule matching a rejected or deferred reply
+func (rules responseRules) match(code int, response string) *ResponseRule {
+	if code < 400 || response == "" {
+		return nil
+	}
+	for _, r := range rules {
+		if len(r.Codes) > 0 && !containsCode(r.Codes, code) {
+			continue
+		}
+		if r.re.MatchString(response) {
+			return r
+		}
+	}
+	return nil
+}
+
+func containsCode(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
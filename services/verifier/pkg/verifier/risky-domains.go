@@ -0,0 +1,110 @@
+package verifier
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+)
+
+// ============================================================================
+// RISKY TLDS AND PARKED DOMAINS
+// ============================================================================
+
+// Some domains are not worth an SMTP probe: whatever the mailbox says, mail
+// there is a poor bet. At depth smtp and deep, once MX records are known,
+// two kinds are answered as risky without one:
+//
+//	risky_tld      the TLD is in RiskyTLDs (.xyz, .top, .click, ...)
+//	parked_domain  an MX host matches ParkingMXHosts, or the domain
+//	               answers any name with A records (a wildcard) and its
+//	               MX hosts resolve to nothing but those addresses
+//
+// The second parked test catches parking services that run web and mail on
+// one catch-all box without us having to know them. Parked verdicts are
+// kept per domain in memory for ParkedCacheTTL. Configured domain lists and
+// policies come first, so an allowlisted .xyz domain is still probed.
+
+// Risky domain reasons
+const (
+	ReasonRiskyTLD     = "risky_tld"
+	ReasonParkedDomain = "parked_domain"
+)
+
+const parkedMaxEntries = 50000
+
+// riskyDomain returns the reason domain should not be probed, or ""
+func (v *SMTPVerifier) riskyDomain(ctx context.Context, domain string, mxRecords []MXRecord) string {
+	if v.riskyTLD(domain) {
+		return ReasonRiskyTLD
+	}
+	if v.parkedDomain(ctx, domain, mxRecords) {
+		return ReasonParkedDomain
+	}
+	return ""
+}
+
+// riskyTLD reports whether domain's TLD is in RiskyTLDs
+func (v *SMTPVerifier) riskyTLD(domain string) bool {
+	tld := strings.ToLower(domain[strings.LastIndexByte(domain, '.')+1:])
+	return slices.ContainsFunc(v.cfg().RiskyTLDs, func(risky string) bool {
+		return strings.TrimPrefix(strings.ToLower(risky), ".") == tld
+	})
+}
+
+// parkedDomain reports whether domain looks parked
+func (v *SMTPVerifier) parkedDomain(ctx context.Context, domain string, mxRecords []MXRecord) bool {
+	if cached, ok := v.parked.get(domain); ok {
+		return cached.(bool)
+	}
+	cfg := v.cfg()
+	for _, mx := range mxRecords {
+		matched := matchDomainPattern(mx.Exchange, func(pattern string) bool {
+			return slices.Contains(cfg.ParkingMXHosts, pattern)
+		})
+		if matched != "" {
+			v.parked.set(domain, true)
+			return true
+		}
+	}
+	if !cfg.ParkedWildcardCheck {
+		return false
+	}
+
+	parked, err := v.wildcardMX(ctx, domain, mxRecords)
+	if err != nil {
+		return false
+	}
+	v.parked.set(domain, parked)
+	return parked
+}
+
+// wildcardMX reports whether domain has a wildcard A record and every MX
+// host resolves only to its addresses
+func (v *SMTPVerifier) wildcardMX(ctx context.Context, domain string, mxRecords []MXRecord) (bool, error) {
+	label := make([]byte, 8)
+	rand.Read(label)
+	wildcard, err := v.dns.LookupHost(ctx, "wc-"+hex.EncodeToString(label)+"."+domain)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, mx := range mxRecords {
+		addrs, err := v.dns.LookupHost(ctx, mx.Exchange)
+		if err != nil {
+			return false, err
+		}
+		for _, addr := range addrs {
+			if !slices.Contains(wildcard, addr) {
+				return false, nil
+			}
+		}
+	}
+	return len(mxRecords) > 0, nil
+}
@@ -0,0 +1,168 @@
+package verifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// SAMPLED VERIFICATION
+// ============================================================================
+
+type SampleValidateResponse struct {
+	TotalEmails         int               `json:"total_emails"`
+	SampledEmails       int               `json:"sampled_emails"`
+	Confidence          float64           `json:"confidence"`
+	ProjectedBounceRate float64           `json:"projected_bounce_rate"`
+	ProjectedBounces    int               `json:"projected_bounces"`
+	Domains             []*DomainEstimate `json:"domains"`
+}
+
+// DomainEstimate extrapolates list quality for one domain from its sample
+type DomainEstimate struct {
+	Domain           string                   `json:"domain"`
+	Total            int                      `json:"total"`
+	Sampled          int                      `json:"sampled"`
+	StatusCounts     map[ValidationStatus]int `json:"status_counts"`
+	BounceRate       float64                  `json:"bounce_rate"`
+	BounceRateLower  float64                  `json:"bounce_rate_lower"`
+	BounceRateUpper  float64                  `json:"bounce_rate_upper"`
+	ProjectedBounces int                      `json:"projected_bounces"`
+}
+
+// zScores for the supported two-sided confidence levels
+var zScores = map[float64]float64{
+	0.90: 1.645,
+	0.95: 1.96,
+	0.99: 2.576,
+}
+
+// SampleCount is the number of addresses VerifySample verifies for emails:
+// each domain's distinct addresses, up to sampleSize
+func SampleCount(emails []string, sampleSize int) int {
+	byDomain := make(map[string]int)
+	seen := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		at := strings.LastIndex(email, "@")
+		if at < 0 || seen[email] {
+			continue
+		}
+		seen[email] = true
+		byDomain[email[at+1:]]++
+	}
+	n := 0
+	for _, count := range byDomain {
+		n += min(count, sampleSize)
+	}
+	return n
+}
+
+// VerifySample fully verifies a deterministic per-domain sample of emails and
+// projects domain-level bounce rates for the whole list.
+func (v *SMTPVerifier) VerifySample(ctx context.Context, emails []string, sampleSize int, seed string, confidence float64) *SampleValidateResponse {
+	z, ok := zScores[confidence]
+	if !ok {
+		confidence, z = 0.95, zScores[0.95]
+	}
+
+	type candidate struct {
+		email string
+		key   uint64
+	}
+
+	byDomain := make(map[string][]candidate)
+	seen := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		at := strings.LastIndex(email, "@")
+		if at < 0 || seen[email] {
+			continue
+		}
+		seen[email] = true
+		domain := email[at+1:]
+		byDomain[domain] = append(byDomain[domain], candidate{email: email, key: sampleKey(seed, email)})
+	}
+
+	// Bottom-k sampling on a seeded hash: uniform per domain and reproducible
+	var sample []string
+	estimates := make(map[string]*DomainEstimate, len(byDomain))
+	for domain, members := range byDomain {
+		sort.Slice(members, func(i, j int) bool { return members[i].key < members[j].key })
+		n := sampleSize
+		if n > len(members) {
+			n = len(members)
+		}
+		for _, c := range members[:n] {
+			sample = append(sample, c.email)
+		}
+		estimates[domain] = &DomainEstimate{
+			Domain:       domain,
+			Total:        len(members),
+			Sampled:      n,
+			StatusCounts: make(map[ValidationStatus]int),
+		}
+	}
+
+	results := v.VerifyBatch(ctx, sample)
+
+	for i, email := range sample {
+		domain := email[strings.LastIndex(email, "@")+1:]
+		estimates[domain].StatusCounts[results[i].Status]++
+	}
+
+	resp := &SampleValidateResponse{
+		SampledEmails: len(sample),
+		Confidence:    confidence,
+	}
+	for _, est := range estimates {
+		bounces := est.StatusCounts[StatusInvalid]
+		est.BounceRate = float64(bounces) / float64(est.Sampled)
+		if est.Sampled == est.Total {
+			// The whole domain was verified, so there is nothing to estimate
+			est.BounceRateLower, est.BounceRateUpper = est.BounceRate, est.BounceRate
+		} else {
+			est.BounceRateLower, est.BounceRateUpper = wilsonInterval(bounces, est.Sampled, z)
+		}
+		est.ProjectedBounces = int(math.Round(est.BounceRate * float64(est.Total)))
+
+		resp.TotalEmails += est.Total
+		resp.ProjectedBounces += est.ProjectedBounces
+		resp.Domains = append(resp.Domains, est)
+	}
+	if resp.TotalEmails > 0 {
+		resp.ProjectedBounceRate = float64(resp.ProjectedBounces) / float64(resp.TotalEmails)
+	}
+
+	// Largest domains first, since they dominate the projection
+	sort.Slice(resp.Domains, func(i, j int) bool {
+		if resp.Domains[i].Total != resp.Domains[j].Total {
+			return resp.Domains[i].Total > resp.Domains[j].Total
+		}
+		return resp.Domains[i].Domain < resp.Domains[j].Domain
+	})
+
+	return resp
+}
+
+func sampleKey(seed, email string) uint64 {
+	sum := sha256.Sum256([]byte(seed + "\x00" + email))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// wilsonInterval returns the Wilson score interval for k successes in n trials
+func wilsonInterval(k, n int, z float64) (float64, float64) {
+	if n == 0 {
+		return 0, 1
+	}
+	p := float64(k) / float64(n)
+	nf := float64(n)
+	denom := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / denom
+	margin := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / denom
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
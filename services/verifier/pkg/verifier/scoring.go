@@ -0,0 +1,48 @@
+package verifier
+
+import "math"
+
+// ============================================================================
+// SCORING AND SEGMENTS
+// ============================================================================
+
+// List segments used by the cleaning workflow and connector feeds
+const (
+	SegmentDeliverable   = "deliverable"
+	SegmentRisky         = "risky"
+	SegmentUnknown       = "unknown"
+	SegmentUndeliverable = "undeliverable"
+)
+
+// ScoreResult maps status and confidence onto 0-100. Low confidence pulls the
+// score towards the neutral midpoint.
+func ScoreResult(result *ValidationResult) int {
+	weight := 0.5
+	switch result.Status {
+	case StatusValid:
+		weight = 1.0
+	case StatusCatchAll:
+		weight = 0.6
+	case StatusUnknown:
+		weight = 0.4
+	case StatusRisky:
+		weight = 0.2
+	case StatusInvalid:
+		weight = 0.0
+	}
+	return int(math.Round(50 + (weight*100-50)*result.Confidence))
+}
+
+// SegmentFor buckets a status into a list segment
+func SegmentFor(status ValidationStatus) string {
+	switch status {
+	case StatusValid:
+		return SegmentDeliverable
+	case StatusCatchAll, StatusRisky:
+		return SegmentRisky
+	case StatusInvalid:
+		return SegmentUndeliverable
+	default:
+		return SegmentUnknown
+	}
+}
@@ -0,0 +1,330 @@
+package verifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// SMTP CONNECTION POOL
+// ============================================================================
+
+// Sessions are kept open per MX host and egress IP and reused for further
+// MAIL FROM/RCPT TO transactions, so a batch against one provider pays for
+// TCP, EHLO and STARTTLS once per connection rather than once per address.
+// A connection is retired after MaxRcptPerConnection recipients, on any
+// transport error or 421, and after SMTPPoolIdleTimeout unused. Idle
+// connections hold no concurrency slot; SMTPPoolMaxIdle bounds them.
+
+const smtpPoolReapInterval = 5 * time.Second
+
+// smtpSession is one open, greeted connection
+type smtpSession struct {
+	conn      *transcriptConn // Above TLS once it is up (see smtp-transcript.go)
+	client    *smtp.Client
+	ehlo      string // Name it greeted with; sessions are pooled per name
+	rcpts     int    // RCPT TO commands sent on this connection
+	idleSince time.Time
+}
+
+// close says QUIT when the connection is still usable, then drops it
+func (s *smtpSession) close() {
+	s.conn.SetDeadline(time.Now().Add(time.Second))
+	s.client.Quit()
+	s.client.Close()
+}
+
+type smtpPool struct {
+	mu   sync.Mutex
+	idle map[string][]*smtpSession // egress|mx|ehlo -> most recently used last
+}
+
+func newSMTPPool() *smtpPool {
+	return &smtpPool{idle: make(map[string][]*smtpSession)}
+}
+
+func smtpPoolKey(egress, mxHost, ehlo string) string {
+	return egress + "|" + strings.ToLower(mxHost) + "|" + ehlo
+}
+
+// get takes the most recently used session that has not idled out
+func (p *smtpPool) get(key string, idleTimeout time.Duration) *smtpSession {
+	p.mu.Lock()
+	var session *smtpSession
+	var expired []*smtpSession
+	sessions := p.idle[key]
+	for len(sessions) > 0 && session == nil {
+		last := sessions[len(sessions)-1]
+		sessions = sessions[:len(sessions)-1]
+		if time.Since(last.idleSince) < idleTimeout {
+			session = last
+		} else {
+			expired = append(expired, last)
+		}
+	}
+	p.setIdle(key, sessions)
+	p.mu.Unlock()
+
+	for _, s := range expired {
+		s.close()
+	}
+	return session
+}
+
+// put returns a session for reuse, or closes it when maxIdle are waiting
+func (p *smtpPool) put(key string, s *smtpSession, maxIdle int) {
+	s.idleSince = time.Now()
+	p.mu.Lock()
+	if len(p.idle[key]) >= maxIdle {
+		p.mu.Unlock()
+		s.close()
+		return
+	}
+	p.idle[key] = append(p.idle[key], s)
+	p.mu.Unlock()
+}
+
+// reap closes sessions idle for idleTimeout or longer; 0 closes all
+func (p *smtpPool) reap(idleTimeout time.Duration) {
+	var expired []*smtpSession
+	p.mu.Lock()
+	for key, sessions := range p.idle {
+		kept := sessions[:0]
+		for _, s := range sessions {
+			if time.Since(s.idleSince) < idleTimeout {
+				kept = append(kept, s)
+			} else {
+				expired = append(expired, s)
+			}
+		}
+		p.setIdle(key, kept)
+	}
+	p.mu.Unlock()
+
+	for _, s := range expired {
+		s.close()
+	}
+}
+
+func (p *smtpPool) setIdle(key string, sessions []*smtpSession) {
+	if len(sessions) == 0 {
+		delete(p.idle, key)
+		return
+	}
+	p.idle[key] = sessions
+}
+
+// openSMTPSession reuses an idle session to mxHost from egress, or dials,
+// greets and upgrades a new one, with domain's timeouts. Reused sessions are
+// RSET first, which also weeds out connections the server has since dropped.
+// The session records into ctx's transcript, if any, until released.
+func (v *SMTPVerifier) openSMTPSession(ctx context.Context, domain, mxHost, egress string) (*smtpSession, error) {
+	ehlo := v.ehloHostname(ctx)
+	connectTimeout, readTimeout := v.smtpTimeouts(domain)
+	transcript := transcriptFrom(ctx)
+	if v.cfg().SMTPPoolMaxIdle > 0 {
+		key := smtpPoolKey(egress, mxHost, ehlo)
+		for {
+			session := v.pool.get(key, v.cfg().SMTPPoolIdleTimeout)
+			if session == nil {
+				break
+			}
+			session.conn.attach(transcript)
+			session.conn.note("reusing pooled connection from %s after %d recipient(s)", egressName(egress), session.rcpts)
+			session.conn.SetDeadline(time.Now().Add(readTimeout))
+			if err := session.client.Reset(); err == nil {
+				return session, nil
+			}
+			session.client.Close()
+		}
+	}
+
+	// Connect with timeout
+	d := v.egressDialer(egress)
+	d.Timeout = connectTimeout
+
+	raw, err := d.DialContext(ctx, "tcp", net.JoinHostPort(mxHost, "25"))
+	if err != nil {
+		if transcript != nil {
+			transcript.add(mxHost, TranscriptNote, fmt.Sprintf("connection from %s failed: %v", egressName(egress), err))
+		}
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	conn := &transcriptConn{Conn: raw, mxHost: mxHost}
+	conn.attach(transcript)
+	conn.note("connected to %s from %s", raw.RemoteAddr(), egressName(egress))
+
+	// Set deadlines
+	conn.SetDeadline(time.Now().Add(readTimeout))
+
+	// Read the greeting, keeping it for fingerprinting
+	text := textproto.NewConn(conn)
+	_, greeting, err := text.ReadResponse(220)
+	if greeting != "" {
+		if len(greeting) > mxBannerMaxBytes {
+			greeting = greeting[:mxBannerMaxBytes]
+		}
+		v.banners.set(strings.ToLower(mxHost), strings.ReplaceAll(greeting, "\n", " "))
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp client creation failed: %w", err)
+	}
+
+	// Upgrade with STARTTLS when offered. It is negotiated here rather than
+	// by net/smtp so the connection net/smtp writes to, and the transcript,
+	// sit above TLS; net/smtp then greets again, which RFC 5321 allows on a
+	// plain connection too.
+	var details *TLSDetails
+	if offersSTARTTLS(text, ehlo) {
+		tlsConn := tls.Client(raw, &tls.Config{
+			ServerName:         mxHost,
+			InsecureSkipVerify: true, // For verification purposes only
+		})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.note("TLS handshake failed: %v", err)
+			raw.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+		details = tlsDetails(tlsConn.ConnectionState(), mxHost)
+		upgraded := &transcriptConn{Conn: tlsConn, mxHost: mxHost}
+		upgraded.attach(transcript)
+		upgraded.note("TLS established (%s, %s, certificate %q)", details.Version, details.CipherSuite, details.Subject)
+		conn = upgraded
+	}
+	v.rememberTLS(mxHost, details)
+
+	// Create SMTP client on the greeting already read
+	client, err := smtp.NewClient(&replayConn{Conn: conn, pending: []byte("220 " + mxHost + "\r\n")}, mxHost)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp client creation failed: %w", err)
+	}
+
+	// EHLO/HELO
+	if err := client.Hello(ehlo); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("EHLO failed: %w", err)
+	}
+
+	return &smtpSession{conn: conn, client: client, ehlo: ehlo}, nil
+}
+
+// offersSTARTTLS sends EHLO on a plain connection and, when the server
+// offers STARTTLS, starts it. It reports whether the caller should now
+// perform the TLS handshake.
+func offersSTARTTLS(text *textproto.Conn, ehlo string) bool {
+	if err := text.PrintfLine("EHLO %s", ehlo); err != nil {
+		return false
+	}
+	_, msg, err := text.ReadResponse(250)
+	if err != nil {
+		return false
+	}
+	offered := false
+	for _, ext := range strings.Split(msg, "\n") {
+		if strings.EqualFold(strings.TrimSpace(ext), "STARTTLS") {
+			offered = true
+		}
+	}
+	if !offered {
+		return false
+	}
+	if err := text.PrintfLine("STARTTLS"); err != nil {
+		return false
+	}
+	_, _, err = text.ReadResponse(220)
+	return err == nil
+}
+
+// releaseSMTPSession pools the session after a transaction that ended with
+// code, or retires it
+func (v *SMTPVerifier) releaseSMTPSession(mxHost, egress string, session *smtpSession, code int) {
+	reusable := v.cfg().SMTPPoolMaxIdle > 0 &&
+		code != 0 && code != 421 &&
+		(v.cfg().MaxRcptPerConnection <= 0 || session.rcpts < v.cfg().MaxRcptPerConnection)
+	if !reusable {
+		session.close()
+		return
+	}
+	session.conn.attach(nil)
+	v.pool.put(smtpPoolKey(egress, mxHost, session.ehlo), session, v.cfg().SMTPPoolMaxIdle)
+}
+
+// RunSMTPPool closes idle pooled connections as they time out, and all of
+// them once ctx is cancelled
+func (v *SMTPVerifier) RunSMTPPool(ctx context.Context) {
+	ticker := time.NewTicker(smtpPoolReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			v.pool.reap(0)
+			return
+		case <-ticker.C:
+			v.pool.reap(v.cfg().SMTPPoolIdleTimeout)
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Held transactions
+// ----------------------------------------------------------------------------
+
+// heldTransaction is an open MAIL FROM transaction whose recipient was
+// accepted, kept along with its session and slots so catch-all probes can be
+// sent as further RCPT TOs instead of fresh handshakes. Probes stay within
+// the session's original read deadline; a server that has gone quiet by
+// then costs a fresh handshake. It is only kept while its holder runs the
+// probes itself: waiting on another replica's probe, or opening a fresh
+// handshake, finishes it first so its slots never outlive their leases.
+type heldTransaction struct {
+	session        *smtpSession
+	mxHost, egress string
+	lastCode       int // Reply to the latest RCPT TO; 0 after a transport error
+	release        func()
+	finished       bool
+}
+
+// rcpt asks about one more recipient in the transaction and returns the
+// reply code, or false when there is no usable transaction (a nil or
+// finished t included)
+func (t *heldTransaction) rcpt(v *SMTPVerifier, email string) (int, bool) {
+	if t == nil || t.finished || t.lastCode == 0 {
+		return 0, false
+	}
+	if v.cfg().MaxRcptPerConnection > 0 && t.session.rcpts >= v.cfg().MaxRcptPerConnection {
+		return 0, false
+	}
+	err := t.session.client.Rcpt(email)
+	t.session.rcpts++
+	if err == nil {
+		t.lastCode = 250
+		return 250, true
+	}
+	code, _ := parseSMTPError(err)
+	t.lastCode = code
+	if code == 0 || code == 421 {
+		t.lastCode = 0 // The session is gone; see releaseSMTPSession
+		return 0, false
+	}
+	return code, true
+}
+
+// finishTransaction pools or retires the held session and frees its slots;
+// finishing it again does nothing
+func (v *SMTPVerifier) finishTransaction(t *heldTransaction) {
+	if t == nil || t.finished {
+		return
+	}
+	t.finished = true
+	v.releaseSMTPSession(t.mxHost, t.egress, t.session, t.lastCode)
+	t.release()
+}
@@ -0,0 +1,62 @@
+package verifier
+
+import (
+	"crypto/tls"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// SMTP TLS DETAILS
+// ============================================================================
+
+// Probes upgrade with STARTTLS whenever the MX host offers it, without
+// verifying the certificate: a verdict must not depend on how well a mail
+// server is configured. What the handshake showed is still worth having
+// for deliverability audits, so results carry a tls object: the protocol
+// version and cipher suite, the leaf certificate's subject, issuer and
+// expiry, and whether the certificate names the MX host. Like greetings
+// (see provider-fingerprint.go), TLS details are kept per MX host in
+// memory and refreshed by every new connection; hosts last seen without
+// STARTTLS have none, and results from cached verdicts keep what was seen
+// at the time.
+
+// TLSDetails describes the TLS session of the probe's connection
+type TLSDetails struct {
+	Version       string    `json:"version"`      // e.g. TLS 1.3
+	CipherSuite   string    `json:"cipher_suite"` // e.g. TLS_AES_128_GCM_SHA256
+	Subject       string    `json:"subject,omitempty"`
+	Issuer        string    `json:"issuer,omitempty"`
+	NotAfter      time.Time `json:"not_after,omitempty"`
+	HostnameMatch bool      `json:"hostname_match"` // Certificate is valid for the MX hostname
+}
+
+// tlsDetails summarizes state for a session with mxHost
+func tlsDetails(state tls.ConnectionState, mxHost string) *TLSDetails {
+	d := &TLSDetails{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+	}
+	if len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		d.Subject = leaf.Subject.String()
+		d.Issuer = leaf.Issuer.String()
+		d.NotAfter = leaf.NotAfter
+		d.HostnameMatch = leaf.VerifyHostname(strings.TrimSuffix(mxHost, ".")) == nil
+	}
+	return d
+}
+
+// rememberTLS records the TLS details of a new connection to mxHost; nil
+// records a plain connection
+func (v *SMTPVerifier) rememberTLS(mxHost string, d *TLSDetails) {
+	v.mxTLS.set(strings.ToLower(mxHost), d)
+}
+
+// tlsFor returns the TLS details last seen from mxHost, or nil
+func (v *SMTPVerifier) tlsFor(mxHost string) *TLSDetails {
+	if d, ok := v.mxTLS.get(strings.ToLower(mxHost)); ok {
+		return d.(*TLSDetails)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// SMTP TRANSCRIPTS
+// ============================================================================
+
+// A verification with VerifyOptions.Debug records its whole SMTP dialog:
+// the greeting, EHLO replies, every command and reply with its time, on
+// every MX host and connection it used, including catch-all probes. The
+// dialog is read above TLS (see openSMTPSession), so it stays legible after
+// STARTTLS. Addresses are redacted to their first character and domain. The
+// transcript is returned in the result and logged at debug level.
+//
+// Debug checks are always fresh and run on their own rather than joining an
+// identical check in flight; one already running on another replica still
+// answers without a dialog of ours.
+
+// Transcript line directions
+const (
+	TranscriptClient = "C" // Sent by us
+	TranscriptServer = "S" // Sent by the mail server
+	TranscriptNote   = "*" // What the verifier did (connect, TLS, reuse)
+)
+
+// TranscriptLine is one line of an SMTP dialog
+type TranscriptLine struct {
+	At     time.Time `json:"at"`
+	MXHost string    `json:"mx_host"`
+	Dir    string    `json:"dir"` // C, S or *
+	Text   string    `json:"text"`
+}
+
+// smtpTranscript collects the dialog of one verification
+type smtpTranscript struct {
+	mu    sync.Mutex
+	lines []TranscriptLine
+}
+
+type transcriptKey struct{}
+
+// withTranscript returns a context whose SMTP sessions record into t
+func withTranscript(ctx context.Context, t *smtpTranscript) context.Context {
+	return context.WithValue(ctx, transcriptKey{}, t)
+}
+
+// transcriptFrom returns the transcript ctx records into, or nil
+func transcriptFrom(ctx context.Context) *smtpTranscript {
+	t, _ := ctx.Value(transcriptKey{}).(*smtpTranscript)
+	return t
+}
+
+func (t *smtpTranscript) add(mxHost, dir, text string) {
+	t.mu.Lock()
+	t.lines = append(t.lines, TranscriptLine{At: time.Now(), MXHost: mxHost, Dir: dir, Text: redactAddresses(text)})
+	t.mu.Unlock()
+}
+
+// Lines returns a copy of the lines recorded so far
+func (t *smtpTranscript) Lines() []TranscriptLine {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TranscriptLine(nil), t.lines...)
+}
+
+// String renders the transcript for logs
+func (t *smtpTranscript) String() string {
+	var b strings.Builder
+	for _, line := range t.Lines() {
+		fmt.Fprintf(&b, "%s %s %s %s\n", line.At.Format("15:04:05.000"), line.MXHost, line.Dir, line.Text)
+	}
+	return b.String()
+}
+
+// egressName names a source IP in notes
+func egressName(egress string) string {
+	if egress == "" {
+		return defaultEgress
+	}
+	return egress
+}
+
+// transcriptAddress matches the local part of an address
+var transcriptAddress = regexp.MustCompile(`([A-Za-z0-9])[A-Za-z0-9._%+'=-]*@([A-Za-z0-9-]+\.)`)
+
+// redactAddresses keeps the first character of each local part
+func redactAddresses(text string) string {
+	return transcriptAddress.ReplaceAllString(text, "$1***@$2")
+}
+
+// transcriptConn records the lines read and written through it while a
+// transcript is attached. Pooled sessions move between verifications, so
+// the transcript is swapped as the session is handed out and returned.
+type transcriptConn struct {
+	net.Conn
+	mxHost  string
+	t       atomic.Pointer[smtpTranscript]
+	in, out []byte // Partial lines
+}
+
+func (c *transcriptConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if t := c.t.Load(); t != nil && n > 0 {
+		c.in = c.record(t, TranscriptServer, append(c.in, p[:n]...))
+	}
+	return n, err
+}
+
+func (c *transcriptConn) Write(p []byte) (int, error) {
+	if t := c.t.Load(); t != nil {
+		c.out = c.record(t, TranscriptClient, append(c.out, p...))
+	}
+	return c.Conn.Write(p)
+}
+
+// record adds the complete lines in buf and returns the rest
+func (c *transcriptConn) record(t *smtpTranscript, dir string, buf []byte) []byte {
+	for {
+		i := strings.IndexByte(string(buf), '\n')
+		if i < 0 {
+			return buf
+		}
+		t.add(c.mxHost, dir, strings.TrimRight(string(buf[:i]), "\r"))
+		buf = buf[i+1:]
+	}
+}
+
+// note records what the verifier did, when recording
+func (c *transcriptConn) note(format string, args ...interface{}) {
+	if t := c.t.Load(); t != nil {
+		t.add(c.mxHost, TranscriptNote, fmt.Sprintf(format, args...))
+	}
+}
+
+// attach starts recording into t (nil stops), dropping partial lines
+func (c *transcriptConn) attach(t *smtpTranscript) {
+	c.t.Store(t)
+	c.in, c.out = nil, nil
+}
+
+// replayConn returns pending before reading from the connection, so
+// net/smtp can be handed a session whose greeting was already read
+type replayConn struct {
+	net.Conn
+	pending []byte
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	if len(c.pending) > 0 {
+		n := copy(p, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
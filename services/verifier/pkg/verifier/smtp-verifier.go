@@ -0,0 +1,1621 @@
+package verifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+// ============================================================================
+// TYPES AND CONSTANTS
+// ============================================================================
+
+type ValidationStatus string
+
+const (
+	StatusValid    ValidationStatus = "valid"
+	StatusInvalid  ValidationStatus = "invalid"
+	StatusCatchAll ValidationStatus = "catch-all"
+	StatusUnknown  ValidationStatus = "unknown"
+	StatusRisky    ValidationStatus = "risky"
+)
+
+type ValidationResult struct {
+	Email               string            `json:"email"`
+	EmailHash           string            `json:"email_hash"`
+	Domain              string            `json:"domain"`
+	Status              ValidationStatus  `json:"status"`
+	Reason              string            `json:"reason"`
+	Confidence          float64           `json:"confidence"`
+	SMTPCode            int               `json:"smtp_code,omitempty"`
+	SMTPResponse        string            `json:"smtp_response,omitempty"`
+	MXHost              string            `json:"mx_host,omitempty"`
+	MXRecords           []MXRecord        `json:"mx_records,omitempty"`
+	ImplicitMX          bool              `json:"implicit_mx,omitempty"`      // No MX records; probed the domain's A/AAAA host
+	EAI                 bool              `json:"eai,omitempty"`              // Non-ASCII local part; probed with SMTPUTF8
+	NormalizedEmail     string            `json:"normalized_email,omitempty"` // Address checked when normalization is on
+	IsCatchAll          bool              `json:"is_catch_all"`
+	IsDisposable        bool              `json:"is_disposable"`
+	IsRole              bool              `json:"is_role"`          // Role mailbox such as info@ or support@; deep checks only
+	IsFreeProvider      bool              `json:"is_free_provider"` // Consumer provider such as gmail.com; not at depth syntax
+	IsPossibleTrap      bool              `json:"is_possible_trap"` // Spamtrap heuristics matched; deep checks only (see spamtraps.go)
+	TrapSignals         []string          `json:"trap_signals,omitempty"`
+	QualityScore        int               `json:"quality_score"` // 0-100, how much the address looks like a person's (see local-part-quality.go)
+	QualitySignals      []string          `json:"quality_signals,omitempty"`
+	Provider            string            `json:"provider,omitempty"`
+	TLS                 *TLSDetails       `json:"tls,omitempty"`             // STARTTLS session with the MX host (see smtp-tls.go)
+	DomainSecurity      *DomainSecurity   `json:"domain_security,omitempty"` // MTA-STS and DANE (see domain-security.go)
+	MXBlocklisted       bool              `json:"mx_blocklisted,omitempty"`  // An MX host address is on a DNSBL (see mx-dnsbl.go)
+	MXBlocklists        []string          `json:"mx_blocklists,omitempty"`   // Zones that listed it
+	AccountType         string            `json:"account_type,omitempty"`    // consumer or business, at Google and Microsoft (see account-type.go)
+	ResponseRule        string            `json:"response_rule,omitempty"`   // Response text rule that set the reason
+	DomainPolicy        string            `json:"domain_policy,omitempty"`   // Config domains or domain list pattern applied to the check
+	DirectoryAttributes map[string]string `json:"directory_attributes,omitempty"`
+	DidYouMean          string            `json:"did_you_mean,omitempty"`
+	CatchAllSkipped     string            `json:"catch_all_skipped,omitempty"` // Why the catch-all probe was not run
+	RetryAt             *time.Time        `json:"retry_at,omitempty"`          // Greylisted; verified again then (see greylisting.go)
+	ClientMetadata
+	DegradedChecks    []string  `json:"degraded_checks,omitempty"`
+	Depth             string    `json:"depth,omitempty"`              // How far the check went (see depth.go)
+	ClassifierVersion string    `json:"classifier_version,omitempty"` // Rules that produced the verdict (see classifier-version.go)
+	ValidationTimeMs  int64     `json:"validation_duration_ms"`
+	CheckedAt         time.Time `json:"checked_at"`
+
+	Transcript []TranscriptLine `json:"transcript,omitempty"` // SMTP dialog of a debug check (see smtp-transcript.go)
+
+	timings checkTimings // Per-check durations of a fresh check (see checks.go)
+}
+
+type MXRecord struct {
+	Exchange string `json:"exchange"`
+	Priority uint16 `json:"priority"`
+}
+
+// nullMXHost is the exchange of an RFC 7505 null MX ("MX 0 .")
+const nullMXHost = "."
+
+// DomainMetadata is the per-domain record kept up to date after every
+// verification (see domain-metadata.go)
+type DomainMetadata struct {
+	IsCatchAll      *bool                      `json:"is_catch_all,omitempty"`
+	CatchAllChecked *time.Time                 `json:"catch_all_checked_at,omitempty"`
+	CatchAllProbes  []int                      `json:"catch_all_probe_codes,omitempty"` // RCPT reply per probe; 0 = no reply
+	IsDisposable    bool                       `json:"is_disposable"`
+	MXRecords       []MXRecord                 `json:"mx_records,omitempty"`
+	LastValidation  time.Time                  `json:"last_validation,omitempty"`
+	ValidationCount int64                      `json:"validation_count"`
+	StatusCounts    map[ValidationStatus]int64 `json:"status_counts,omitempty"`
+}
+
+// Batch queue priority classes, keys of QueueStreams and QueueWeights
+const (
+	PriorityExpress  = "express"
+	PriorityStandard = "standard"
+	PriorityBulk     = "bulk"
+)
+
+// DuplicateJobAction values
+const (
+	DuplicateWarn  = "warn"  // Run a new job and point at the earlier one
+	DuplicateReuse = "reuse" // Return the earlier job instead of running again
+)
+
+// Configuration
+type Config struct {
+	// SMTP Timeouts
+	SMTPConnectTimeout time.Duration
+	SMTPReadTimeout    time.Duration
+	SMTPWriteTimeout   time.Duration
+
+	// SMTP Identity
+	EHLOHostname string
+	MailFrom     string
+
+	// Check and cache plus-tagged and Gmail dotted addresses under their
+	// canonical form (see normalization.go)
+	NormalizeAddresses bool
+
+	// SMTP Connection Pool (see smtp-pool.go)
+	SMTPPoolMaxIdle      int           // Idle connections kept per MX host and egress IP; 0 disables pooling
+	SMTPPoolIdleTimeout  time.Duration // Idle connections older than this are closed
+	MaxRcptPerConnection int           // Recipients tried on one connection before it is retired; 0 = no limit
+
+	// Egress and Blocked Probes (see probe-blocks.go)
+	EgressIPs          []string      // Source IPs probes rotate through; empty = OS default
+	ProbeBlockCooldown time.Duration // How long a blocked egress IP sits out for that MX
+	ProbeRetryDelay    time.Duration // When addresses with every path blocked are retried
+
+	// Greylisting (see greylisting.go)
+	GreylistRetryDelay  time.Duration // Wait before the first retry, multiplied by the attempt number
+	GreylistMaxAttempts int           // Retries of a greylisted address; 0 disables the deferred queue
+
+	// Webhook Callbacks (see webhooks.go)
+	WebhookSigningSecret string        // HMAC-SHA256 key for X-Webhook-Signature; empty = unsigned
+	WebhookTimeout       time.Duration // Per attempt
+	WebhookMaxAttempts   int
+	WebhookRetryBackoff  time.Duration // Before the first retry; doubles each time
+	WebhookRetention     time.Duration // How long delivery records stay queryable
+
+	// DNS (see dns-resolver.go)
+	DNSServers         []string      // Upstream resolvers (host or host:port) tried in order; empty = system resolver
+	DNSTimeout         time.Duration // Per query and server before failing over
+	ImplicitMXFallback bool          // With no MX records, probe the domain's own A/AAAA host (RFC 5321 section 5.1)
+
+	// Batch Processing
+	BatchConcurrency int // Worker pool size for batch verification
+
+	// Rate Limiting
+	MaxConcurrentPerDomain int           // Open SMTP sessions per domain across all replicas
+	MaxConcurrentPerMX     int           // Open SMTP sessions per MX host across all replicas
+	DomainRateLimit        time.Duration // Token refill interval per domain (steady rate: one per interval)
+	DomainRateBurst        int           // Requests allowed back to back before the interval applies
+	MXRateLimit            time.Duration // Same per MX host; 0 disables
+	MXRateBurst            int
+
+	// Retry Policy
+	MaxRetries         int
+	RetryBackoff       time.Duration
+	RetryBackoffFactor float64
+
+	// Catch-all Detection
+	EnableCatchAllDetection bool
+	CatchAllProbeCount      int
+	// Probe controls for honoring abuse complaints; the API adds to these at
+	// runtime (see catchall-controls.go)
+	CatchAllKillSwitch       bool           // Stop all probing; known verdicts are still used
+	CatchAllDisabledDomains  []string       // Never probe these domains
+	CatchAllDailyProbeBudget int            // Probe handshakes per domain per UTC day; 0 = unlimited
+	CatchAllProbeBudgets     map[string]int // Per-domain overrides of the daily budget
+
+	// Redis Soft-Fail (see redis-degraded.go)
+	RedisRecoveryInterval time.Duration // How often a degraded store is pinged
+
+	// Cache TTLs
+	MXCacheTTL         time.Duration
+	MXNegativeCacheTTL time.Duration // NXDOMAIN and no-MX answers; 0 disables
+	ResultCacheTTL     time.Duration
+	DomainMetaCacheTTL time.Duration
+
+	// In-memory tier in front of Redis for domain records; kept coherent
+	// across replicas by cache events. 0 TTL disables it.
+	LocalCacheTTL        time.Duration
+	LocalCacheMaxEntries int
+
+	// Load Limits and Degradation
+	MaxInFlightVerifications int
+	MaxSMTPSessions          int
+	DegradeCatchAllAt        float64       // Slot utilization at which catch-all probes are skipped
+	DegradeEnrichmentAt      float64       // Slot utilization at which domain enrichment is skipped
+	OverloadQueueTimeout     time.Duration // Max wait for a slot before rejecting
+
+	// DMARC Aggregate Reports
+	DMARCReportRetention time.Duration
+
+	// Background Jobs
+	JobRetention time.Duration
+
+	// Shutdown
+	ShutdownGracePeriod time.Duration // How long a stopping process drains before cancelling work
+
+	// API Server TLS (see cmd/verifier/tls.go); no cert and no autocert
+	// domains serves plain HTTP
+	TLSCertFile         string   // PEM certificate chain, reloaded when the file changes
+	TLSKeyFile          string   // PEM private key
+	TLSAutocertDomains  []string // Obtain certificates from Let's Encrypt for these hosts
+	TLSAutocertCacheDir string   // Where issued certificates are kept across restarts
+	TLSAutocertEmail    string   // ACME account contact
+	TLSClientCAFile     string   // PEM CAs that client certificates must chain to
+	TLSClientAuth       string   // "require" (the default with a CA file) or "optional"
+
+	// Probe Audit Trail (see probe-audit.go)
+	ProbeAuditRetention time.Duration // How long every RCPT TO is kept for abuse reports; 0 disables
+
+	// Connector Result Feed
+	ResultFeedMaxLen int64 // Approximate cap on the polling feed stream
+
+	// SMTP Proxy (pre-send validation)
+	SMTPProxyAddr           string        // Listen address; empty disables the proxy
+	SMTPProxyUpstream       string        // Real MTA (host:port) that accepted mail is relayed to
+	SMTPProxyMode           string        // "reject" bad recipients at RCPT, or "flag" them in a header
+	SMTPProxyVerifyTimeout  time.Duration // Recipients not verified in time are accepted
+	SMTPProxyMaxMessageSize int64
+
+	// Priority Queues (batch validation)
+	QueueStreams       map[string]string // Priority class -> Redis stream
+	QueueWeights       map[string]int    // Relative share of reads per class
+	QueueConsumerGroup string
+	QueueConsumers     int
+	QueueReadCount     int64
+	QueueBlockTime     time.Duration
+
+	// Disposable Domains
+	DisposableBuiltin         bool
+	DisposableListURL         string // Optional plain-text list, one domain per line
+	DisposableRefreshInterval time.Duration
+	DisposableCustomDomains   []string
+
+	// Free Email Providers (see free-providers.go)
+	FreeProviderBuiltin         bool
+	FreeProviderListURL         string // Optional plain-text list, one domain per line
+	FreeProviderRefreshInterval time.Duration
+	FreeProviderCustomDomains   []string
+
+	// Domain Security Checks (see domain-security.go)
+	DomainSecurityChecks   bool          // MTA-STS and DANE findings on deep results
+	DomainSecurityTimeout  time.Duration // For one domain's lookups and policy fetch together
+	DomainSecurityCacheTTL time.Duration // Findings kept per domain in memory
+
+	// MX Blocklists (see mx-dnsbl.go)
+	DNSBLZones    []string      // e.g. zen.spamhaus.org; empty disables
+	DNSBLCacheTTL time.Duration // Answers kept per address and zone in memory
+
+	// Risky TLDs and Parked Domains (see risky-domains.go)
+	RiskyTLDs           []string      // Answered risky/risky_tld without a probe
+	ParkingMXHosts      []string      // MX host patterns of parking services
+	ParkedWildcardCheck bool          // Also detect wildcard A records shared with the MX hosts
+	ParkedCacheTTL      time.Duration // Verdicts kept per domain in memory
+
+	// Spamtrap Heuristics (see spamtraps.go)
+	TrapPatterns        []string      // Case-insensitive regexps on the local part
+	TrapOperatorDomains []string      // Domain patterns whose role addresses are flagged
+	TrapHistory         time.Duration // Hard bounces and first checks of a domain are remembered this long
+	TrapRDAPURL         string        // e.g. https://rdap.org/domain/; empty disables recycled_domain
+
+	// LDAP Directories (authoritative for internal domains)
+	LDAPDirectories []LDAPDirectory
+
+	// Mailbox Provider Hints
+	ProviderHintsFile string // Operator overrides merged over the bundled dataset
+
+	// SMTP Response Text Rules (checked before the bundled rules)
+	ResponseRules []ResponseRule
+
+	// Cloud Directory Connectors (Workspace / Microsoft 365 tenants)
+	DirectoryConnectors []*DirectoryConnector
+
+	// Milter (MTA recipient gating)
+	MilterAddr          string // host:port or unix socket path; empty disables
+	MilterMode          string // "reject" or "flag", as for the SMTP proxy
+	MilterVerifyTimeout time.Duration
+
+	// Postfix Policy Delegation
+	PolicyAddr            string // host:port or unix socket path; empty disables
+	PolicyVerifyTimeout   time.Duration
+	PolicyDeferUnverified bool // DEFER recipients we could not verify instead of DUNNO
+
+	// Logging
+	LogLevel  string // debug, info, warn, error
+	LogFormat string // json or text
+
+	// List Jobs
+	DuplicateJobWindow time.Duration // Same list within this window is a duplicate; 0 disables
+	DuplicateJobAction string        // warn or reuse
+
+	// Kafka Bridge (workers; see cmd/verifier/kafka.go)
+	KafkaBrokers         []string // host:port; empty disables
+	KafkaGroupID         string
+	KafkaInputTopic      string
+	KafkaOutputTopic     string
+	KafkaDeadLetterTopic string
+	KafkaConsumers       int // Readers per worker process
+	KafkaBatchSize       int // Messages verified together
+
+	// Message Queue Workers (NATS JetStream or AMQP; see cmd/verifier/message-queue.go)
+	MQDriver     string // nats or amqp; empty disables
+	MQURL        string
+	MQRequests   string // Subject or queue
+	MQResults    string
+	MQDeadLetter string
+	MQGroup      string // NATS durable consumer
+	MQConsumers  int
+	MQBatchSize  int
+
+	// Object Storage List Jobs (see object-storage.go)
+	StorageCredentialsKey string // 64 hex chars sealing tenants' bucket keys; empty disables
+	ObjectListMaxEmails   int    // Largest list read from a bucket
+
+	// API Authentication (see cmd/verifier/auth.go)
+	APIKeyHashes   []string // SHA-256 hex digests of accepted X-API-Key values
+	APIKeyRequired bool     // Otherwise keyless requests run as AnonymousTenant
+	AdminKeyHashes []string // Digests of keys allowed on operator endpoints
+
+	// API Key Quotas (see quotas.go); 0 is unlimited
+	QuotaDaily      int64
+	QuotaMonthly    int64
+	TenantQuotas    map[string]Quota // Tenant ID -> quota replacing the defaults
+	QuotaFailClosed bool             // Refuse requests while Redis is unreachable too
+
+	// Per-Client API Rate Limits (see client-ratelimit.go); 0 requests is unlimited
+	ClientRateLimit       ClientRateLimit            // Shared by routes without their own entry
+	ClientRouteRateLimits map[string]ClientRateLimit // Route template (e.g. /v1/validate/batch) -> limit
+	TrustForwardedFor     bool                       // Identify keyless clients by X-Forwarded-For (behind a proxy)
+
+	// Warehouse Export (daily usage stats as CSV)
+	WarehouseExportDir      string // Mounted bucket or synced volume; empty disables
+	WarehouseExportInterval time.Duration
+
+	// Data Residency (see residency.go)
+	Regions       []Region
+	TenantRegions map[string]string // Tenant ID -> region name; unlisted tenants stay home
+
+	// Per-Tenant Overrides (see tenant-config.go)
+	Tenants map[string]TenantConfig // Tenant ID -> overrides of the settings above
+
+	// Per-Domain Policies (see domain-policy.go)
+	DomainPolicies map[string]DomainPolicy // Domain or *.domain pattern -> overrides
+
+	// Domain Lists (see domain-lists.go); domain or *.domain patterns
+	AllowlistDomains  []string // Always valid (internal domains)
+	BlocklistDomains  []string // Always invalid (spam farms)
+	NeverProbeDomains []string // Never probed (partners who complained)
+
+	// Task Scheduler
+	TaskSchedules map[string]string // Task name -> cron expression; unset tasks keep their defaults
+
+	// Tracing (OpenTelemetry)
+	OTLPEndpoint       string // OTLP/HTTP collector host:port; empty disables export
+	OTLPInsecure       bool
+	TracingServiceName string
+	TracingSampleRatio float64
+}
+
+// Default configuration
+func DefaultConfig() *Config {
+	return &Config{
+		SMTPConnectTimeout:       10 * time.Second,
+		SMTPReadTimeout:          15 * time.Second,
+		SMTPWriteTimeout:         15 * time.Second,
+		EHLOHostname:             "mail-validator.yourdomain.com",
+		MailFrom:                 "verify@mail-validator.yourdomain.com",
+		SMTPPoolMaxIdle:          2,
+		SMTPPoolIdleTimeout:      30 * time.Second,
+		MaxRcptPerConnection:     20,
+		WarehouseExportInterval:  1 * time.Hour,
+		ProbeBlockCooldown:       6 * time.Hour,
+		ProbeRetryDelay:          1 * time.Hour,
+		GreylistRetryDelay:       5 * time.Minute,
+		GreylistMaxAttempts:      3,
+		WebhookTimeout:           10 * time.Second,
+		WebhookMaxAttempts:       6,
+		WebhookRetryBackoff:      30 * time.Second,
+		WebhookRetention:         7 * 24 * time.Hour,
+		DNSTimeout:               5 * time.Second,
+		RedisRecoveryInterval:    2 * time.Second,
+		MXNegativeCacheTTL:       10 * time.Minute,
+		BatchConcurrency:         50,
+		MaxConcurrentPerDomain:   5,
+		MaxConcurrentPerMX:       50,
+		DomainRateLimit:          1 * time.Second,
+		DomainRateBurst:          1,
+		MXRateLimit:              100 * time.Millisecond,
+		MXRateBurst:              1,
+		MaxRetries:               3,
+		RetryBackoff:             2 * time.Second,
+		RetryBackoffFactor:       2.0,
+		EnableCatchAllDetection:  true,
+		CatchAllProbeCount:       2,
+		CatchAllDailyProbeBudget: 1000,
+		MXCacheTTL:               1 * time.Hour,
+		ResultCacheTTL:           7 * 24 * time.Hour,
+		DomainMetaCacheTTL:       24 * time.Hour,
+		LocalCacheTTL:            30 * time.Second,
+		LocalCacheMaxEntries:     50000,
+		MaxInFlightVerifications: 1000,
+		MaxSMTPSessions:          500,
+		DegradeCatchAllAt:        0.7,
+		DegradeEnrichmentAt:      0.9,
+		OverloadQueueTimeout:     5 * time.Second,
+		DMARCReportRetention:     90 * 24 * time.Hour,
+		JobRetention:             30 * 24 * time.Hour,
+		ShutdownGracePeriod:      30 * time.Second,
+		ProbeAuditRetention:      14 * 24 * time.Hour,
+		ResultFeedMaxLen:         10000,
+		SMTPProxyMode:            GateModeReject,
+		SMTPProxyVerifyTimeout:   5 * time.Second,
+		SMTPProxyMaxMessageSize:  25 << 20,
+		QueueStreams: map[string]string{
+			PriorityExpress:  "queue:validation:express",
+			PriorityStandard: "queue:validation:standard",
+			PriorityBulk:     "queue:validation:bulk",
+		},
+		QueueWeights: map[string]int{
+			PriorityExpress:  6,
+			PriorityStandard: 3,
+			PriorityBulk:     1,
+		},
+		QueueConsumerGroup:          "validators",
+		QueueConsumers:              10,
+		QueueReadCount:              100,
+		QueueBlockTime:              5 * time.Second,
+		DisposableBuiltin:           true,
+		DisposableRefreshInterval:   24 * time.Hour,
+		FreeProviderBuiltin:         true,
+		FreeProviderRefreshInterval: 24 * time.Hour,
+		DomainSecurityTimeout:       5 * time.Second,
+		DomainSecurityCacheTTL:      time.Hour,
+		DNSBLCacheTTL:               time.Hour,
+		APIKeyRequired:              true,
+		RiskyTLDs:                   []string{"xyz", "top", "click"},
+		ParkingMXHosts:              []string{"*.sedoparking.com", "*.parkingcrew.net", "*.bodis.com", "*.above.com"},
+		ParkedWildcardCheck:         true,
+		ParkedCacheTTL:              time.Hour,
+		TrapPatterns:                []string{`^spam[._-]?trap`, `honey[._-]?pot`, `^trap[._-]`},
+		TrapHistory:                 365 * 24 * time.Hour,
+		MilterMode:                  GateModeReject,
+		MilterVerifyTimeout:         5 * time.Second,
+		PolicyVerifyTimeout:         5 * time.Second,
+		LogLevel:                    "info",
+		LogFormat:                   "json",
+		DuplicateJobWindow:          24 * time.Hour,
+		DuplicateJobAction:          DuplicateWarn,
+		ObjectListMaxEmails:         10000000,
+		KafkaGroupID:                "email-validator",
+		KafkaInputTopic:             "verification-requests",
+		KafkaOutputTopic:            "verification-results",
+		KafkaDeadLetterTopic:        "verification-requests-dlq",
+		KafkaConsumers:              4,
+		KafkaBatchSize:              50,
+		MQRequests:                  "verification.requests",
+		MQResults:                   "verification.results",
+		MQDeadLetter:                "verification.dead_letter",
+		MQGroup:                     "email-validator",
+		MQConsumers:                 4,
+		MQBatchSize:                 50,
+		TracingServiceName:          "email-validator",
+		TracingSampleRatio:          1.0,
+	}
+}
+
+// ============================================================================
+// SMTP VERIFIER
+// ============================================================================
+
+type SMTPVerifier struct {
+	config       atomic.Pointer[Config] // swapped by Reload; read through cfg()
+	redis        *redis.Client
+	regionStores map[string]*redis.Client // tenant data per residency region
+	limiter      *loadLimiter
+	hints        *ProviderHints
+	rules        responseRules
+	egressNext   atomic.Uint32                  // round-robin position in EgressIPs
+	inflight     singleflight.Group             // fresh checks in progress, by email hash
+	local        *localCache                    // in-memory tier for domain records
+	banners      *localCache                    // last 220 greeting per MX host (see provider-fingerprint.go)
+	mxTLS        *localCache                    // last TLS details per MX host (see smtp-tls.go)
+	security     *localCache                    // MTA-STS and DANE findings per domain (see domain-security.go)
+	dnsbl        *localCache                    // DNSBL answers per address and zone (see mx-dnsbl.go)
+	parked       *localCache                    // Parked verdicts per domain (see risky-domains.go)
+	rdap         *localCache                    // Registration dates per domain (see spamtraps.go)
+	pool         *smtpPool                      // idle SMTP sessions per MX host and egress IP
+	dns          *dnsResolver                   // system resolver or configured DNSServers
+	health       map[*redis.Client]*redisHealth // soft-fail state per store
+	localLimits  *localRateLimiter              // rate limits while the home Redis is degraded
+	instanceID   string                         // identifies our own cache events
+	classifier   string                         // stamped on every result
+
+	cacheSkips      atomic.Int64 // counted for RedisStats
+	localRateLimits atomic.Int64
+	rescored        atomic.Int64 // counted for ClassifierStats
+	invalidated     atomic.Int64
+	tenantStats     tenantCounters // counted for TenantStats
+}
+
+func NewSMTPVerifier(config *Config, redisClient *redis.Client) *SMTPVerifier {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	v := &SMTPVerifier{
+		redis:        redisClient,
+		regionStores: newRegionStores(config.Regions),
+		limiter:      newLoadLimiter(config),
+		hints:        mustLoadProviderHints(config.ProviderHintsFile),
+		rules:        mustLoadResponseRules(config.ResponseRules),
+		local:        newLocalCache(config.LocalCacheTTL, config.LocalCacheMaxEntries),
+		banners:      newLocalCache(mxBannerTTL, mxBannerMaxEntries),
+		mxTLS:        newLocalCache(mxBannerTTL, mxBannerMaxEntries),
+		security:     newLocalCache(config.DomainSecurityCacheTTL, domainSecurityMaxEntries),
+		dnsbl:        newLocalCache(config.DNSBLCacheTTL, dnsblMaxEntries),
+		parked:       newLocalCache(config.ParkedCacheTTL, parkedMaxEntries),
+		rdap:         newLocalCache(rdapCacheTTL, rdapMaxEntries),
+		pool:         newSMTPPool(),
+		dns:          newDNSResolver(config.DNSServers, config.DNSTimeout),
+		localLimits:  newLocalRateLimiter(),
+		// Unique per process so a replica ignores its own events
+		instanceID: NewID(),
+	}
+	v.config.Store(config)
+	v.classifier = classifierVersion(v.rules, v.hints)
+	v.watchRedis()
+	return v
+}
+
+// Ping checks the Redis connections the verifier depends on
+func (v *SMTPVerifier) Ping(ctx context.Context) error {
+	if err := v.redis.Ping(ctx).Err(); err != nil {
+		return err
+	}
+	for name, store := range v.regionStores {
+		if err := store.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("region %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// LoadStats reports current in-flight work for health checks
+func (v *SMTPVerifier) LoadStats() LoadStats {
+	return v.limiter.stats()
+}
+
+// ============================================================================
+// PUBLIC API
+// ============================================================================
+
+// VerifyOptions tunes a single verification
+type VerifyOptions struct {
+	SkipCache bool   // Ignore any cached result; a fresh result overwrites it
+	Normalize bool   // Normalize the address even when NormalizeAddresses is off
+	Depth     string // DepthSyntax, DepthDNS, DepthSMTP or DepthDeep (default)
+	Debug     bool   // Check afresh and return the SMTP transcript
+}
+
+// Verify validates a single email address
+func (v *SMTPVerifier) Verify(ctx context.Context, email string) (*ValidationResult, error) {
+	return v.VerifyWithOptions(ctx, email, VerifyOptions{})
+}
+
+// VerifyWithOptions validates a single email address with per-call options
+func (v *SMTPVerifier) VerifyWithOptions(ctx context.Context, email string, opts VerifyOptions) (*ValidationResult, error) {
+	startTime := time.Now()
+
+	// Normalize email; the caller's form is kept when the mailbox form
+	// differs (see normalization.go)
+	email = strings.ToLower(strings.TrimSpace(email))
+	original := email
+	normalize := opts.Normalize || v.cfg().NormalizeAddresses
+	if normalize {
+		email = normalizeAddress(email)
+	}
+
+	// Generate email hash for caching
+	emailHash := hashEmail(email)
+	depth := opts.Depth
+	if depth == "" {
+		depth = DepthDeep
+	}
+
+	// Domain policies may cap the depth or force the verdict, and domain
+	// lists give verdicts; neither is answered from the cache (see
+	// domain-policy.go and domain-lists.go)
+	if policy := v.addressPolicy(email); policy != nil {
+		depth = policy.policyDepth(depth)
+		if policy.Status != "" {
+			opts.SkipCache = true
+		}
+	}
+	if v.addressListed(email) {
+		opts.SkipCache = true
+	}
+
+	// Debug checks record their SMTP dialog, so they are never answered
+	// from the cache or another caller's check (see smtp-transcript.go)
+	var transcript *smtpTranscript
+	if opts.Debug {
+		opts.SkipCache = true
+		transcript = &smtpTranscript{}
+		ctx = withTranscript(ctx, transcript)
+	}
+
+	ctx, span := tracer.Start(ctx, "Verify", trace.WithAttributes(attribute.String("email.hash", emailHash), attribute.String("verify.depth", depth)))
+	defer span.End()
+
+	// Check cache first; cached results are deep ones
+	if !opts.SkipCache && depth != DepthSyntax {
+		if cached, err := v.getCachedResult(ctx, emailHash); err == nil && cached != nil {
+			span.SetAttributes(attribute.Bool("cache.hit", true), attribute.String("result.status", string(cached.Status)))
+			cached.IsRole = isRoleAccount(email)
+			cached.IsFreeProvider = v.isFreeProvider(ctx, cached.Domain)
+			cached.AccountType = v.accountType(cached)
+			setQuality(cached)
+			v.recordUsage(ctx, cached, true)
+			if normalize {
+				return withNormalized(cached, original), nil
+			}
+			return cached, nil
+		}
+	}
+
+	// Identical deep verifications in flight share one check (see
+	// inflight.go); shallower ones run on their own
+	var result *ValidationResult
+	var err error
+	if depth == DepthDeep && transcript != nil {
+		result, err = v.verifyFresh(ctx, email, emailHash, startTime)
+	} else if depth == DepthDeep {
+		result, err = v.verifyShared(ctx, email, emailHash, startTime)
+	} else {
+		result, err = v.verifyAtDepth(ctx, email, emailHash, depth, startTime)
+	}
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	span.SetAttributes(
+		attribute.String("result.status", string(result.Status)),
+		attribute.String("result.reason", result.Reason),
+	)
+	if transcript != nil {
+		copied := *result
+		copied.Transcript = transcript.Lines()
+		result = &copied
+		slog.DebugContext(ctx, "smtp transcript", "email_hash", emailHash, "lines", len(copied.Transcript), "transcript", transcript.String())
+	}
+	if normalize {
+		return withNormalized(result, original), nil
+	}
+	return result, nil
+}
+
+// verifyFresh runs the uncached pipeline and stores, records and publishes
+// the result, unless another replica turns out to be checking the address
+func (v *SMTPVerifier) verifyFresh(ctx context.Context, email, emailHash string, startTime time.Time) (*ValidationResult, error) {
+	other, releaseInflight := v.claimInflight(ctx, emailHash)
+	if other != nil {
+		v.recordUsage(ctx, other, true)
+		return other, nil
+	}
+	defer releaseInflight()
+
+	// Reserve a verification slot; under load this may queue or fail fast
+	release, err := v.limiter.acquireVerification(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result, cacheable := v.runChecks(ctx, email, emailHash, DepthDeep, startTime)
+	result.Depth = DepthDeep
+	result.IsRole = isRoleAccount(email)
+	result.IsFreeProvider = result.Domain != "" && v.isFreeProvider(ctx, result.Domain)
+	result.AccountType = v.accountType(result)
+	v.checkTraps(ctx, email, emailHash, result)
+	setQuality(result)
+	if result.Reason == "greylisted" {
+		v.deferGreylisted(ctx, email, result)
+	}
+	slog.DebugContext(ctx, "verification finished",
+		"email_hash", emailHash,
+		"status", result.Status,
+		"reason", result.Reason,
+		"mx_host", result.MXHost,
+		"duration_ms", result.ValidationTimeMs,
+	)
+
+	// Step 5: Cache result (degraded, unprobed, blocked or greylisted
+	// results are incomplete, so never cached)
+	if cacheable && len(result.DegradedChecks) == 0 && result.CatchAllSkipped == "" && result.Reason != "probe_blocked" && result.Reason != "greylisted" {
+		v.cacheResult(ctx, emailHash, result)
+	} else {
+		v.rememberResult(ctx, emailHash, result)
+	}
+	v.recordVerification(ctx, result)
+	v.recordUsage(ctx, result, false)
+	v.publishResult(ctx, result)
+
+	return result, nil
+}
+
+// runChecks performs the uncached verification pipeline as far as depth. The
+// boolean reports whether the result came from a completed SMTP check and
+// may be cached.
+func (v *SMTPVerifier) runChecks(ctx context.Context, email, emailHash, depth string, startTime time.Time) (result *ValidationResult, cacheable bool) {
+	// Per-check durations for the v2 breakdown (see checks.go)
+	var timings checkTimings
+	defer func() {
+		timings.catchAll = result.timings.catchAll
+		timings.smtp -= timings.catchAll
+		result.timings = timings
+	}()
+	stepStart := time.Now()
+	step := func(d *time.Duration) {
+		*d = time.Since(stepStart)
+		stepStart = time.Now()
+	}
+
+	// Step 1: Syntax validation; the domain comes back as its ASCII form
+	// (see address-parser.go)
+	domain, addrErr := parseAddress(email)
+	step(&timings.syntax)
+	if addrErr != nil {
+		return v.createResult(email, emailHash, "", StatusInvalid, "syntax_error: "+addrErr.Code, 1.0, 0, "", "", nil, startTime), false
+	}
+	if depth == DepthSyntax {
+		return v.createResult(email, emailHash, domain, StatusUnknown, "syntax_valid", 0.3, 0, "", "", nil, startTime), false
+	}
+
+	// Configured domain lists (see domain-lists.go)
+	if listing := v.listedDomain(domain); listing != nil && (!listing.probing || depth == DepthSMTP || depth == DepthDeep) {
+		result := v.createResult(email, emailHash, domain, listing.status, listing.reason, listing.confidence, 0, "", "", nil, startTime)
+		result.DomainPolicy = listing.pattern
+		return result, false
+	}
+
+	// Verdicts set by a domain policy
+	policy, pattern := v.domainPolicy(domain)
+	if policy != nil {
+		defer func() { result.DomainPolicy = pattern }()
+		if policy.Status != "" {
+			return v.createResult(email, emailHash, domain, policy.Status, "domain_policy", 1.0, 0, "", "", nil, startTime), false
+		}
+	}
+	probing := depth == DepthSMTP || depth == DepthDeep
+
+	// Internal and connected tenant domains are answered by their
+	// directory, never probed
+	if dir := v.ldapDirectoryFor(domain); dir != nil && probing {
+		defer step(&timings.smtp)
+		return v.verifyLDAP(ctx, dir, email, emailHash, domain, startTime)
+	}
+
+	if conn := v.directoryConnectorFor(domain); conn != nil && probing {
+		defer step(&timings.smtp)
+		return v.verifyDirectory(ctx, conn, email, emailHash, domain, startTime)
+	}
+
+	// Disposable domains are flagged without touching DNS or SMTP
+	if depth == DepthDeep {
+		disposable := v.isDisposableDomain(ctx, domain)
+		step(&timings.disposable)
+		if disposable {
+			return v.disposableResult(email, emailHash, domain, nil, startTime), false
+		}
+	}
+
+	// Domains that complained about our probes (see probe-audit.go)
+	if probing && v.isDoNotVerify(ctx, domain) {
+		return v.createResult(email, emailHash, domain, StatusUnknown, "do_not_verify", 0.1, 0, "", "", nil, startTime), false
+	}
+
+	// Step 2: DNS MX lookup
+	mxRecords, err := v.getMXRecords(ctx, domain)
+	implicitMX := false
+	if err != nil || len(mxRecords) == 0 {
+		if v.cfg().ImplicitMXFallback {
+			mxRecords = v.implicitMXRecords(ctx, domain)
+		}
+		if len(mxRecords) == 0 {
+			result := v.createResult(email, emailHash, domain, StatusInvalid, "no_mx_records", 0.95, 0, "", "", nil, startTime)
+			result.DidYouMean = didYouMean(email, domain)
+			return result, false
+		}
+		implicitMX = true
+	}
+	step(&timings.mx)
+	if isNullMX(mxRecords) {
+		return v.createResult(email, emailHash, domain, StatusInvalid, "null_mx", 0.99, 0, "", "", mxRecords, startTime), false
+	}
+	if depth == DepthDNS {
+		result := v.createResult(email, emailHash, domain, StatusUnknown, "mx_found", 0.5, 0, "", "", mxRecords, startTime)
+		result.ImplicitMX = implicitMX
+		return result, false
+	}
+
+	// Risky TLDs and parked domains are flagged instead of probed (see
+	// risky-domains.go)
+	if reason := v.riskyDomain(ctx, domain, mxRecords); reason != "" {
+		return v.createResult(email, emailHash, domain, StatusRisky, reason, 0.8, 0, "", "", mxRecords, startTime), false
+	}
+
+	// Step 3: Domain metadata (known catch-all verdict) and, when enabled,
+	// MTA-STS, DANE and MX blocklist findings
+	var degraded []string
+	var domainMeta *DomainMetadata
+	var security *DomainSecurity
+	var blocklists []string
+	if v.limiter.level() < LoadShedEnrichment {
+		domainMeta, _ = v.getDomainMetadata(ctx, domain)
+		if depth == DepthDeep && v.cfg().DomainSecurityChecks {
+			security = v.domainSecurity(ctx, domain, mxRecords)
+		}
+		if depth == DepthDeep {
+			blocklists = v.mxBlocklists(ctx, mxRecords)
+		}
+	} else {
+		degraded = append(degraded, DegradedEnrichment)
+	}
+
+	// Step 4: SMTP verification
+	result, err = v.performSMTPVerification(ctx, email, domain, mxRecords, domainMeta, depth == DepthDeep)
+	step(&timings.smtp)
+	if err != nil {
+		result = v.createResult(email, emailHash, domain, StatusUnknown, fmt.Sprintf("smtp_error: %v", err), 0.2, 0, "", "", mxRecords, startTime)
+		result.ImplicitMX = implicitMX
+		result.DomainSecurity = security
+		result.MXBlocklisted, result.MXBlocklists = len(blocklists) > 0, blocklists
+		return result, false
+	}
+	result.ImplicitMX = implicitMX
+	result.DomainSecurity = security
+	result.MXBlocklisted, result.MXBlocklists = len(blocklists) > 0, blocklists
+	result.DegradedChecks = append(degraded, result.DegradedChecks...)
+
+	return result, true
+}
+
+// ============================================================================
+// SMTP VERIFICATION LOGIC
+// ============================================================================
+
+// performSMTPVerification probes each MX in turn; with catchAll, an accepted
+// address is followed by catch-all detection
+func (v *SMTPVerifier) performSMTPVerification(ctx context.Context, email, domain string, mxRecords []MXRecord, meta *DomainMetadata, catchAll bool) (*ValidationResult, error) {
+	startTime := time.Now()
+	emailHash := hashEmail(email)
+
+	// Try each MX record in priority order
+	var lastErr error
+	var blocked *ValidationResult
+	for _, mx := range mxRecords {
+		result, err := v.verifySMTPWithMX(ctx, email, domain, mx, meta, catchAll, startTime)
+		v.recordProviderHealth(ctx, mx.Exchange, result, err)
+		if err == nil {
+			// Successful verification; provider verdicts would repeat on every MX
+			if result.Status == StatusValid || result.Status == StatusInvalid || result.Status == StatusCatchAll || isProviderVerdict(result.Reason) {
+				return result, nil
+			}
+			if result.Reason == "probe_blocked" {
+				blocked = result
+			}
+		}
+		lastErr = err
+	}
+
+	// Blocked on every path we tried; the mailbox was never asked about
+	if blocked != nil {
+		v.scheduleProbeRetry(ctx, email)
+		return blocked, nil
+	}
+
+	// All MX records failed
+	return v.createResult(email, emailHash, domain, StatusUnknown, "all_mx_failed", 0.2, 0, "", "", mxRecords, startTime), lastErr
+}
+
+func (v *SMTPVerifier) verifySMTPWithMX(ctx context.Context, email, domain string, mx MXRecord, meta *DomainMetadata, catchAll bool, startTime time.Time) (*ValidationResult, error) {
+	emailHash := hashEmail(email)
+
+	// Acquire rate limit
+	if err := v.waitForRateLimit(ctx, domain, mx.Exchange); err != nil {
+		return nil, err
+	}
+
+	// Probe, moving to the next egress IP whenever the server blocks us.
+	// Replies are read with the provider's strategy (see provider-hints.go),
+	// known once the greeting is (see provider-fingerprint.go).
+	var provider *ProviderHint
+	var smtpCode int
+	var smtpResponse, egress string
+	var status ValidationStatus
+	var reason string
+	var confidence float64
+	var rule *ResponseRule
+	var held *heldTransaction
+	defer func() { v.finishTransaction(held) }()
+	hold := catchAll && v.wantsCatchAllProbe(meta)
+	for tried := 0; ; tried++ {
+		var ok bool
+		if egress, ok = v.pickEgress(ctx, mx.Exchange); !ok || tried == len(v.egressPaths(ctx)) {
+			result := v.createResult(email, emailHash, domain, StatusUnknown, "probe_blocked", 0.1, smtpCode, smtpResponse, mx.Exchange, []MXRecord{mx}, startTime)
+			if rule != nil {
+				result.ResponseRule = rule.Name
+			}
+			return result, nil
+		}
+
+		var err error
+		smtpCode, smtpResponse, held, err = v.handshakeWithRetries(ctx, email, mx.Exchange, egress, hold)
+		if errors.Is(err, errNoSMTPUTF8) {
+			result := v.createResult(email, emailHash, domain, StatusInvalid, "smtputf8_unsupported", 0.9, 0, "", mx.Exchange, []MXRecord{mx}, startTime)
+			result.EAI = true
+			return result, nil
+		}
+		rejectedAtConnect := errors.Is(err, errRejectedAtConnect)
+		if err != nil && !rejectedAtConnect {
+			return nil, err
+		}
+
+		provider = v.providerFor(mx.Exchange)
+		status, reason, confidence, rule = v.classifyReply(smtpCode, smtpResponse, provider)
+		if !rejectedAtConnect && reason != "probe_blocked" {
+			break
+		}
+		v.finishTransaction(held)
+		held = nil
+		v.markProbeBlocked(ctx, egress, mx.Exchange, smtpCode, smtpResponse)
+	}
+
+	// Correct for known provider behavior
+	probeCatchAll := true
+	if provider != nil {
+		status, reason, confidence, probeCatchAll = provider.apply(smtpCode, smtpResponse, status, reason, confidence)
+	}
+
+	// Check for catch-all if enabled and status is valid
+	isCatchAll := false
+	var degraded []string
+	var catchAllSkipped string
+	var catchAllTime time.Duration
+	if status == StatusValid && probeCatchAll && catchAll && v.cfg().EnableCatchAllDetection {
+		if v.limiter.level() < LoadShedCatchAll {
+			catchAllStart := time.Now()
+			isCatchAll, catchAllSkipped, _ = v.detectCatchAll(ctx, domain, mx, egress, meta, held)
+			catchAllTime = time.Since(catchAllStart)
+			if isCatchAll {
+				status = StatusCatchAll
+				reason = "catch_all_domain"
+				confidence = 0.5
+			}
+		} else {
+			degraded = append(degraded, DegradedCatchAll)
+		}
+	}
+
+	result := v.createResult(email, emailHash, domain, status, reason, confidence, smtpCode, smtpResponse, mx.Exchange, []MXRecord{mx}, startTime)
+	result.IsCatchAll = isCatchAll
+	result.CatchAllSkipped = catchAllSkipped
+	result.timings.catchAll = catchAllTime
+	result.DegradedChecks = degraded
+	result.EAI = needsSMTPUTF8(email)
+	if rule != nil && reason == rule.Reason {
+		result.ResponseRule = rule.Name
+	}
+	result.Provider = v.mailPlatform(mx.Exchange)
+	result.TLS = v.tlsFor(mx.Exchange)
+
+	return result, nil
+}
+
+// handshakeWithRetries runs smtpHandshake, backing off between retryable
+// failures
+func (v *SMTPVerifier) handshakeWithRetries(ctx context.Context, email, mxHost, egress string, hold bool) (int, string, *heldTransaction, error) {
+	var smtpCode int
+	var smtpResponse string
+	var held *heldTransaction
+	var err error
+
+	for attempt := 0; attempt < v.cfg().MaxRetries; attempt++ {
+		smtpCode, smtpResponse, held, err = v.smtpHandshake(ctx, email, mxHost, egress, hold)
+		if err == nil {
+			break
+		}
+
+		// Check if error is retryable
+		if !isRetryableError(err) {
+			break
+		}
+
+		// Exponential backoff
+		if attempt < v.cfg().MaxRetries-1 {
+			backoff := time.Duration(float64(v.cfg().RetryBackoff) * float64(attempt+1) * v.cfg().RetryBackoffFactor)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return 0, "", nil, ctx.Err()
+			}
+		}
+	}
+	return smtpCode, smtpResponse, held, err
+}
+
+// smtpHandshake performs the SMTP handshake from egress: EHLO -> MAIL FROM ->
+// RCPT TO, on a pooled connection when one is idle (see smtp-pool.go). A 5xx
+// greeting returns its code and text along with errRejectedAtConnect. With
+// hold, an accepted recipient's transaction stays open for catch-all probes
+// and is returned; the caller must finishTransaction it.
+func (v *SMTPVerifier) smtpHandshake(ctx context.Context, email, mxHost, egress string, hold bool) (code int, response string, held *heldTransaction, err error) {
+	ctx, span := tracer.Start(ctx, "smtpHandshake", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String("smtp.mx_host", mxHost)))
+	defer func() {
+		span.SetAttributes(attribute.Int("smtp.code", code))
+		endSpan(span, err)
+	}()
+
+	// Respect the per-domain and per-MX caps shared by all replicas, then
+	// this replica's session cap
+	rcpt, smtputf8 := smtpAddress(email)
+	domain := rcpt[strings.LastIndex(rcpt, "@")+1:]
+	releaseSlots, err := v.acquireTargetSlots(ctx, domain, mxHost)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	release, err := v.limiter.acquireSession(ctx)
+	if err != nil {
+		releaseSlots()
+		return 0, "", nil, err
+	}
+	releaseAll := func() {
+		release()
+		releaseSlots()
+	}
+	defer func() {
+		if held == nil {
+			releaseAll()
+		}
+	}()
+
+	session, err := v.openSMTPSession(ctx, domain, mxHost, egress)
+	if err != nil {
+		var greeting *textproto.Error
+		if errors.As(err, &greeting) && greeting.Code >= 500 {
+			return greeting.Code, greeting.Msg, nil, fmt.Errorf("%w: %d %s", errRejectedAtConnect, greeting.Code, greeting.Msg)
+		}
+		return 0, "", nil, err
+	}
+
+	// MAIL FROM; net/smtp adds the SMTPUTF8 parameter whenever the server
+	// offers it
+	if ok, _ := session.client.Extension("SMTPUTF8"); smtputf8 && !ok {
+		v.releaseSMTPSession(mxHost, egress, session, 250)
+		return 0, "", nil, errNoSMTPUTF8
+	}
+	if err := session.client.Mail(v.mailFrom(ctx)); err != nil {
+		session.close()
+		return 0, "", nil, fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+
+	// RCPT TO (this is the critical step)
+	err = session.client.Rcpt(rcpt)
+	session.rcpts++
+
+	// Extract SMTP code and response
+	smtpCode := 0
+	smtpResponse := ""
+
+	if err != nil {
+		// Parse error to extract SMTP code
+		smtpCode, smtpResponse = parseSMTPError(err)
+	} else {
+		// Success (250)
+		smtpCode = 250
+		smtpResponse = "Recipient OK"
+	}
+	v.recordProbe(ctx, rcpt, mxHost, egress, smtpCode)
+
+	if hold && (smtpCode == 250 || smtpCode == 251) {
+		held = &heldTransaction{session: session, mxHost: mxHost, egress: egress, lastCode: smtpCode, release: releaseAll}
+		return smtpCode, smtpResponse, held, nil
+	}
+
+	// Keep the connection for the next address, or QUIT
+	v.releaseSMTPSession(mxHost, egress, session, smtpCode)
+
+	return smtpCode, smtpResponse, nil, nil
+}
+
+// ============================================================================
+// CATCH-ALL DETECTION
+// ============================================================================
+
+// wantsCatchAllProbe reports whether an accepted address on the domain would
+// be followed by catch-all probes, so its transaction is worth holding open
+func (v *SMTPVerifier) wantsCatchAllProbe(meta *DomainMetadata) bool {
+	if !v.cfg().EnableCatchAllDetection || v.limiter.level() >= LoadShedCatchAll {
+		return false
+	}
+	_, known := meta.catchAllVerdict(v.cfg().ResultCacheTTL)
+	return !known
+}
+
+// detectCatchAll returns the domain's catch-all verdict, or why no probe was
+// run (see catchall-controls.go). Probes go out as further recipients on
+// held, the real address's open transaction, when there is one.
+func (v *SMTPVerifier) detectCatchAll(ctx context.Context, domain string, mx MXRecord, egress string, meta *DomainMetadata, held *heldTransaction) (bool, string, error) {
+	// Reuse a recent verdict from the domain record
+	if isCatchAll, ok := meta.catchAllVerdict(v.cfg().ResultCacheTTL); ok {
+		return isCatchAll, "", nil
+	}
+
+	// One probe sequence per domain across replicas; the rest wait for its
+	// verdict (see catchall-lock.go), without the held transaction's session
+	// and slots
+	release, isCatchAll, found, err := v.awaitCatchAllProbe(ctx, domain, func() { v.finishTransaction(held) })
+	if err != nil {
+		return false, "", err
+	}
+	if found {
+		return isCatchAll, "", nil
+	}
+	defer release()
+
+	// Kill switches and the daily budget
+	if skipped := v.reserveCatchAllProbes(ctx, domain, v.cfg().CatchAllProbeCount); skipped != "" {
+		return false, skipped, nil
+	}
+
+	// Generate random email addresses
+	probeEmails := make([]string, v.cfg().CatchAllProbeCount)
+	for i := 0; i < v.cfg().CatchAllProbeCount; i++ {
+		randomLocal := fmt.Sprintf("probeverify%d%d", time.Now().UnixNano(), i)
+		probeEmails[i] = randomLocal + "@" + domain
+	}
+
+	// Test random addresses
+	ctx = withProbeKind(ctx, ProbeKindCatchAll)
+	acceptCount := 0
+	codes := make([]int, len(probeEmails))
+	for i, probeEmail := range probeEmails {
+		smtpCode, ok := held.rcpt(v, probeEmail)
+		if ok {
+			v.recordProbe(ctx, probeEmail, held.mxHost, held.egress, smtpCode)
+		} else {
+			// No usable transaction; let go of its slots before a handshake
+			// of its own takes new ones, spaced out
+			v.finishTransaction(held)
+			if i > 0 {
+				select {
+				case <-time.After(catchAllProbeGap):
+				case <-ctx.Done():
+					return false, "", ctx.Err()
+				}
+			}
+			smtpCode, _, _, err = v.smtpHandshake(ctx, probeEmail, mx.Exchange, egress, false)
+			if err != nil {
+				smtpCode = 0
+			}
+		}
+		codes[i] = smtpCode
+		if smtpCode == 250 || smtpCode == 251 {
+			acceptCount++
+		}
+	}
+
+	// If all or most probes are accepted, it's likely a catch-all
+	isCatchAll = acceptCount >= (v.cfg().CatchAllProbeCount / 2)
+
+	// Record the verdict and what the probes got
+	v.recordCatchAll(ctx, domain, isCatchAll, codes)
+
+	return isCatchAll, "", nil
+}
+
+// ============================================================================
+// DNS MX LOOKUP
+// ============================================================================
+
+func (v *SMTPVerifier) getMXRecords(ctx context.Context, domain string) (records []MXRecord, err error) {
+	ctx, span := tracer.Start(ctx, "getMXRecords", trace.WithAttributes(attribute.String("dns.domain", domain)))
+	defer func() {
+		span.SetAttributes(attribute.Int("dns.mx_count", len(records)))
+		endSpan(span, err)
+	}()
+
+	// Check cache
+	if cached, err := v.getCachedMXRecords(ctx, domain); err == nil && len(cached) > 0 {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		return cached, nil
+	}
+	if v.cachedNoMX(ctx, domain) {
+		span.SetAttributes(attribute.Bool("cache.hit", true), attribute.Bool("cache.negative", true))
+		return nil, errNoMXCached
+	}
+
+	// Query DNS
+	mxs, err := v.dns.LookupMX(ctx, domain)
+	var dnsErr *net.DNSError
+	if (err == nil && len(mxs) == 0) || (errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		v.cacheNoMX(ctx, domain)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records = make([]MXRecord, 0, len(mxs))
+	for _, mx := range mxs {
+		if mx.Host == nullMXHost {
+			continue
+		}
+		records = append(records, MXRecord{
+			Exchange: strings.TrimSuffix(mx.Host, "."),
+			Priority: mx.Pref,
+		})
+	}
+	// RFC 7505: a lone "MX 0 ." means the domain accepts no mail. Listed
+	// alongside real hosts it is skipped, as an MTA would.
+	if len(records) == 0 && len(mxs) > 0 {
+		records = []MXRecord{{Exchange: nullMXHost}}
+	}
+
+	// Sort by priority
+	sortMXRecords(records)
+
+	// Cache results and snapshot them on the domain record
+	v.cacheMXRecords(ctx, domain, records)
+	v.recordMXSnapshot(ctx, domain, records)
+
+	return records, nil
+}
+
+// implicitMXRecords returns the domain itself as its only mail host when it
+// has an A or AAAA record, the fallback RFC 5321 prescribes when there is no
+// MX. Like a failed MX lookup, the answer is not cached.
+func (v *SMTPVerifier) implicitMXRecords(ctx context.Context, domain string) []MXRecord {
+	ctx, span := tracer.Start(ctx, "implicitMXRecords", trace.WithAttributes(attribute.String("dns.domain", domain)))
+	addrs, err := v.dns.LookupHost(ctx, domain)
+	span.SetAttributes(attribute.Int("dns.address_count", len(addrs)))
+	endSpan(span, err)
+	if err != nil || len(addrs) == 0 {
+		return nil
+	}
+	return []MXRecord{{Exchange: domain, Priority: 0}}
+}
+
+// isNullMX reports a domain that publishes only the RFC 7505 null MX
+func isNullMX(records []MXRecord) bool {
+	return len(records) == 1 && records[0].Exchange == nullMXHost
+}
+
+func sortMXRecords(records []MXRecord) {
+	// Simple bubble sort by priority
+	for i := 0; i < len(records)-1; i++ {
+		for j := i + 1; j < len(records); j++ {
+			if records[i].Priority > records[j].Priority {
+				records[i], records[j] = records[j], records[i]
+			}
+		}
+	}
+}
+
+// ============================================================================
+// CACHING LAYER
+// ============================================================================
+
+func (v *SMTPVerifier) getCachedResult(ctx context.Context, emailHash string) (*ValidationResult, error) {
+	store := v.TenantStore(ctx)
+	if v.skipCache(store) {
+		return nil, redis.Nil
+	}
+	key := v.tenantKey(ctx, "validation:result:"+emailHash)
+	val, err := store.Get(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var result ValidationResult
+	if err := json.Unmarshal([]byte(val), &result); err != nil {
+		return nil, err
+	}
+
+	// Verdicts from older classification logic are rescored or dropped
+	ok, changed := v.upgradeCached(&result)
+	if !ok {
+		return nil, redis.Nil
+	}
+	if changed {
+		if data, err := json.Marshal(&result); err == nil {
+			store.Set(ctx, key, data, redis.KeepTTL)
+		}
+	}
+
+	return &result, nil
+}
+
+func (v *SMTPVerifier) cacheResult(ctx context.Context, emailHash string, result *ValidationResult) error {
+	store := v.TenantStore(ctx)
+	if v.skipCache(store) {
+		return nil
+	}
+	key := v.tenantKey(ctx, "validation:result:"+emailHash)
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return store.Set(ctx, key, data, v.resultCacheTTL(ctx)).Err()
+}
+
+func (v *SMTPVerifier) getCachedMXRecords(ctx context.Context, domain string) ([]MXRecord, error) {
+	if v.skipCache(v.redis) {
+		return nil, redis.Nil
+	}
+	key := "mx:records:" + domain
+	val, err := v.redis.Get(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []MXRecord
+	if err := json.Unmarshal([]byte(val), &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (v *SMTPVerifier) cacheMXRecords(ctx context.Context, domain string, records []MXRecord) error {
+	if v.skipCache(v.redis) {
+		return nil
+	}
+	key := "mx:records:" + domain
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	return v.redis.Set(ctx, key, data, v.cfg().MXCacheTTL).Err()
+}
+
+// errNoMXCached is a remembered NXDOMAIN or no-MX answer
+var errNoMXCached = errors.New("no MX records (cached)")
+
+func mxNegativeKey(domain string) string {
+	return "mx:none:" + domain
+}
+
+// cachedNoMX reports a recent authoritative answer that domain has no MX
+func (v *SMTPVerifier) cachedNoMX(ctx context.Context, domain string) bool {
+	if v.cfg().MXNegativeCacheTTL <= 0 || v.skipCache(v.redis) {
+		return false
+	}
+	n, err := v.redis.Exists(ctx, mxNegativeKey(domain)).Result()
+	return err == nil && n > 0
+}
+
+// cacheNoMX remembers an NXDOMAIN or empty MX answer. Timeouts and server
+// failures are not remembered; the next request asks again.
+func (v *SMTPVerifier) cacheNoMX(ctx context.Context, domain string) {
+	if v.cfg().MXNegativeCacheTTL <= 0 || v.skipCache(v.redis) {
+		return
+	}
+	v.redis.Set(ctx, mxNegativeKey(domain), 1, v.cfg().MXNegativeCacheTTL)
+}
+
+// getDomainMetadata reads through the local tier; misses are remembered too
+func (v *SMTPVerifier) getDomainMetadata(ctx context.Context, domain string) (*DomainMetadata, error) {
+	key := domainMetaKey(domain)
+	if cached, ok := v.local.get(key); ok {
+		if meta := cached.(*DomainMetadata); meta != nil {
+			return meta, nil
+		}
+		return nil, redis.Nil
+	}
+	if v.skipCache(v.redis) {
+		return nil, redis.Nil
+	}
+
+	val, err := v.redis.Get(ctx, key).Result()
+	if err == redis.Nil {
+		v.local.set(key, (*DomainMetadata)(nil))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var meta DomainMetadata
+	if err := json.Unmarshal([]byte(val), &meta); err != nil {
+		return nil, err
+	}
+
+	v.local.set(key, &meta)
+	return &meta, nil
+}
+
+// ============================================================================
+// RATE LIMITING
+// ============================================================================
+
+// rateLimitTake is a token bucket per key: each holds up to a burst of
+// tokens and refills one per interval. A request takes one token from every
+// key or from none, so the domain and MX limits hold across replicas.
+// ARGV: now (ms), interval (ms) and burst per key, then the minimum key TTL
+// (ms), which keeps the last contact visible to the rate-limit API. Returns 0
+// when the tokens were taken, otherwise the ms to wait before trying again.
+var rateLimitTake = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local wait = 0
+local tokens = {}
+for i, key in ipairs(KEYS) do
+	local interval = tonumber(ARGV[2 * i])
+	local burst = tonumber(ARGV[2 * i + 1])
+	local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+	local t = tonumber(bucket[1]) or burst
+	local ts = tonumber(bucket[2]) or now
+	t = math.min(burst, t + math.max(0, now - ts) / interval)
+	if t < 1 then
+		wait = math.max(wait, math.ceil((1 - t) * interval))
+	end
+	tokens[i] = t
+end
+if wait > 0 then
+	return wait
+end
+for i, key in ipairs(KEYS) do
+	local interval = tonumber(ARGV[2 * i])
+	local burst = tonumber(ARGV[2 * i + 1])
+	redis.call('HSET', key, 'tokens', tostring(tokens[i] - 1), 'ts', now)
+	redis.call('PEXPIRE', key, math.max(math.ceil(interval * burst) + 1000, tonumber(ARGV[#ARGV])))
+end
+return 0
+`)
+
+const rateLimitMinTTL = time.Hour
+
+func rateLimitDomainKey(domain string) string {
+	return "ratelimit:bucket:domain:" + domain
+}
+
+func rateLimitMXKey(mxHost string) string {
+	return "ratelimit:bucket:mx:" + strings.ToLower(mxHost)
+}
+
+// rateLimitBucket is one token bucket a request must take from
+type rateLimitBucket struct {
+	key      string
+	interval time.Duration
+	burst    int
+}
+
+// waitForRateLimit blocks until both the domain and the MX host have a
+// token. If Redis is unreachable the buckets are kept in-process instead.
+func (v *SMTPVerifier) waitForRateLimit(ctx context.Context, domain, mxHost string) error {
+	var buckets []rateLimitBucket
+	if interval, burst := v.domainRateLimit(domain); interval > 0 {
+		buckets = append(buckets, rateLimitBucket{rateLimitDomainKey(domain), interval, burst})
+	}
+	if v.cfg().MXRateLimit > 0 {
+		buckets = append(buckets, rateLimitBucket{rateLimitMXKey(mxHost), v.cfg().MXRateLimit, max(v.cfg().MXRateBurst, 1)})
+	}
+	buckets = append(buckets, v.tenantRateLimits(ctx, domain, mxHost)...)
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	for {
+		wait, err := v.takeRateLimit(ctx, buckets)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.WarnContext(ctx, "rate limit unavailable, limiting in-process", "domain", domain, "mx_host", mxHost, "error", err)
+			wait = v.takeLocalRateLimit(buckets)
+		}
+		if wait == 0 {
+			return nil
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// takeRateLimit runs rateLimitTake, or the in-process buckets while the
+// home Redis is degraded
+func (v *SMTPVerifier) takeRateLimit(ctx context.Context, buckets []rateLimitBucket) (time.Duration, error) {
+	if v.redisDegraded(v.redis) {
+		return v.takeLocalRateLimit(buckets), nil
+	}
+	keys := make([]string, len(buckets))
+	argv := []interface{}{time.Now().UnixMilli()}
+	for i, b := range buckets {
+		keys[i] = b.key
+		argv = append(argv, b.interval.Milliseconds(), b.burst)
+	}
+	argv = append(argv, rateLimitMinTTL.Milliseconds())
+	wait, err := rateLimitTake.Run(ctx, v.redis, keys, argv...).Int64()
+	return time.Duration(wait) * time.Millisecond, err
+}
+
+func (v *SMTPVerifier) takeLocalRateLimit(buckets []rateLimitBucket) time.Duration {
+	v.localRateLimits.Add(1)
+	return v.localLimits.take(buckets)
+}
+
+// ============================================================================
+// HELPER FUNCTIONS
+// ============================================================================
+
+func (v *SMTPVerifier) createResult(email, emailHash, domain string, status ValidationStatus, reason string, confidence float64, smtpCode int, smtpResponse, mxHost string, mxRecords []MXRecord, startTime time.Time) *ValidationResult {
+	return &ValidationResult{
+		Email:             email,
+		EmailHash:         emailHash,
+		Domain:            domain,
+		Status:            status,
+		Reason:            reason,
+		Confidence:        confidence,
+		SMTPCode:          smtpCode,
+		SMTPResponse:      smtpResponse,
+		MXHost:            mxHost,
+		MXRecords:         mxRecords,
+		ClassifierVersion: v.classifier,
+		ValidationTimeMs:  time.Since(startTime).Milliseconds(),
+		CheckedAt:         time.Now(),
+	}
+}
+
+func (v *SMTPVerifier) disposableResult(email, emailHash, domain string, mxRecords []MXRecord, startTime time.Time) *ValidationResult {
+	result := v.createResult(email, emailHash, domain, StatusRisky, "disposable_domain", 0.9, 0, "", "", mxRecords, startTime)
+	result.IsDisposable = true
+	return result
+}
+
+func hashEmail(email string) string {
+	h := sha256.New()
+	h.Write([]byte(strings.ToLower(email)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func parseSMTPError(err error) (int, string) {
+	errStr := err.Error()
+
+	// Try to extract code from error string
+	// Format is usually: "550 User not found"
+	if len(errStr) >= 3 {
+		code := 0
+		fmt.Sscanf(errStr, "%d", &code)
+		if code >= 100 && code < 600 {
+			return code, errStr
+		}
+	}
+
+	return 0, errStr
+}
+
+func classifySMTPResponse(code int, response string) (ValidationStatus, string, float64) {
+	switch {
+	case code == 250 || code == 251:
+		return StatusValid, "mailbox_exists", 0.98
+
+	case code == 550 || code == 551 || code == 553:
+		return StatusInvalid, "mailbox_not_found", 0.95
+
+	case code == 450 || code == 451 || code == 452:
+		return StatusUnknown, "temporary_failure", 0.3
+
+	case code == 421:
+		return StatusUnknown, "rate_limited", 0.2
+
+	case code >= 500:
+		return StatusInvalid, fmt.Sprintf("smtp_error_%d", code), 0.7
+
+	default:
+		return StatusUnknown, "unknown_response", 0.1
+	}
+}
+
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	errStr := err.Error()
+
+	// Network errors are generally retryable
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) ||
+		strings.Contains(errStr, "timeout") ||
+		strings.Contains(errStr, "connection refused") ||
+		strings.Contains(errStr, "temporary failure") {
+		return true
+	}
+
+	// 4xx SMTP codes are temporary
+	code, _ := parseSMTPError(err)
+	if code >= 400 && code < 500 {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,172 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// SPAMTRAP HEURISTICS
+// ============================================================================
+
+// A spamtrap accepts mail like any mailbox; mailing one is what gets a
+// sender listed. Nothing proves an address is a trap, but deep results set
+// is_possible_trap, with the signals in trap_signals, when any of these
+// hold, so senders can leave the address out:
+//
+//	trap_pattern        the local part matches TrapPatterns (spamtrap@,
+//	                    honeypot@, ...)
+//	trap_operator_role  a role address on a TrapOperatorDomains domain
+//	recycled_domain     the address accepts mail, and the domain's RDAP
+//	                    registration date is later than our first check
+//	                    there: it expired and was registered again, a
+//	                    classic way of turning old addresses into traps
+//	bounced_before      the address accepts mail but hard-bounced in one
+//	                    of our checks, or is on the suppression list
+//
+// The verdict is unchanged. Hard bounces (invalid with mailbox_not_found or
+// mailbox_disabled) are remembered per address in trap:bounced:{email_hash}
+// and the first check of each domain in trap:first_seen:{domain}, both for
+// TrapHistory. Registration dates are kept per domain in memory; the RDAP
+// lookup is skipped under load and when TrapRDAPURL is empty.
+
+// Spamtrap signals
+const (
+	TrapPattern        = "trap_pattern"
+	TrapOperatorRole   = "trap_operator_role"
+	TrapRecycledDomain = "recycled_domain"
+	TrapBouncedBefore  = "bounced_before"
+)
+
+const (
+	trapBouncedPrefix   = "trap:bounced:"
+	trapFirstSeenPrefix = "trap:first_seen:"
+	rdapTimeout         = 5 * time.Second
+	rdapCacheTTL        = 24 * time.Hour
+	rdapMaxEntries      = 50000
+	rdapMaxResponseSize = 1 << 20
+)
+
+var rdapClient = &http.Client{Timeout: rdapTimeout}
+
+// checkTraps sets the trap flag and signals on a fresh deep result and
+// remembers hard bounces for later checks
+func (v *SMTPVerifier) checkTraps(ctx context.Context, email, emailHash string, result *ValidationResult) {
+	if result.Domain == "" {
+		return
+	}
+	cfg := v.cfg()
+	local := email[:strings.LastIndexByte(email, '@')]
+	accepting := result.Status == StatusValid || result.Status == StatusCatchAll
+
+	var signals []string
+	if trapPatternMatch(cfg.TrapPatterns, local) {
+		signals = append(signals, TrapPattern)
+	}
+	if result.IsRole && matchDomainPattern(result.Domain, func(pattern string) bool {
+		return slices.Contains(cfg.TrapOperatorDomains, pattern)
+	}) != "" {
+		signals = append(signals, TrapOperatorRole)
+	}
+	if accepting && cfg.TrapRDAPURL != "" && v.limiter.level() < LoadShedEnrichment && v.recycledDomain(ctx, result.Domain) {
+		signals = append(signals, TrapRecycledDomain)
+	}
+	if accepting && v.bouncedBefore(ctx, email, emailHash) {
+		signals = append(signals, TrapBouncedBefore)
+	}
+	result.IsPossibleTrap, result.TrapSignals = len(signals) > 0, signals
+
+	// Remember what later checks compare against
+	if err := v.redis.SetNX(ctx, trapFirstSeenPrefix+result.Domain, result.CheckedAt.Unix(), cfg.TrapHistory).Err(); err != nil {
+		slog.WarnContext(ctx, "domain first check not recorded", "domain", result.Domain, "error", err)
+	}
+	if result.Status == StatusInvalid && (result.Reason == "mailbox_not_found" || result.Reason == "mailbox_disabled") {
+		key := v.tenantKey(ctx, trapBouncedPrefix+emailHash)
+		if err := v.TenantStore(ctx).Set(ctx, key, result.CheckedAt.Unix(), cfg.TrapHistory).Err(); err != nil {
+			slog.WarnContext(ctx, "hard bounce not recorded", "email_hash", emailHash, "error", err)
+		}
+	}
+}
+
+// trapPatternMatch reports whether local matches any pattern
+func trapPatternMatch(patterns []string, local string) bool {
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err == nil && re.MatchString(local) {
+			return true
+		}
+	}
+	return false
+}
+
+// bouncedBefore reports a remembered hard bounce or a suppressed address
+func (v *SMTPVerifier) bouncedBefore(ctx context.Context, email, emailHash string) bool {
+	n, err := v.TenantStore(ctx).Exists(ctx, v.tenantKey(ctx, trapBouncedPrefix+emailHash)).Result()
+	if err == nil && n > 0 {
+		return true
+	}
+	suppressed, err := v.IsSuppressed(ctx, email)
+	return err == nil && suppressed
+}
+
+// recycledDomain reports whether domain was registered after our first
+// check there
+func (v *SMTPVerifier) recycledDomain(ctx context.Context, domain string) bool {
+	firstSeen, err := v.redis.Get(ctx, trapFirstSeenPrefix+domain).Int64()
+	if err != nil {
+		return false
+	}
+	registered, err := v.registrationDate(ctx, domain)
+	if err != nil {
+		slog.DebugContext(ctx, "rdap lookup failed", "domain", domain, "error", err)
+		return false
+	}
+	return registered.After(time.Unix(firstSeen, 0))
+}
+
+// registrationDate returns the registration event of domain's RDAP record;
+// zero when it has none
+func (v *SMTPVerifier) registrationDate(ctx context.Context, domain string) (time.Time, error) {
+	if cached, ok := v.rdap.get(domain); ok {
+		return cached.(time.Time), nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(v.cfg().TrapRDAPURL, "/")+"/"+domain, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+	resp, err := rdapClient.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("rdap returned %s", resp.Status)
+	}
+
+	var record struct {
+		Events []struct {
+			Action string    `json:"eventAction"`
+			Date   time.Time `json:"eventDate"`
+		} `json:"events"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, rdapMaxResponseSize)).Decode(&record); err != nil {
+		return time.Time{}, fmt.Errorf("rdap response: %w", err)
+	}
+	var registered time.Time
+	for _, event := range record.Events {
+		if event.Action == "registration" {
+			registered = event.Date
+		}
+	}
+	v.rdap.set(domain, registered)
+	return registered, nil
+}
@@ -0,0 +1,163 @@
+package verifier
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// PER-TENANT CONFIGURATION
+// ============================================================================
+
+// Hosted customers are tenants (one per API key; see tenant.go). A Tenants
+// entry overrides parts of Config for that tenant's verifications:
+//
+//   - mail_from and ehlo_hostname: the SMTP identity probes present. Pooled
+//     connections are kept per EHLO name, so tenants never share a greeting.
+//   - result_cache_ttl: how long the tenant's deep results are cached.
+//   - domain/mx rate limits: token buckets of the tenant's own, taken on top
+//     of the shared ones. A tenant can be held to a slower rate than the
+//     fleet, never a faster one, since the remote servers see one sender.
+//   - isolate_cache: results, recent results and in-flight markers are kept
+//     under tenant:{tenant}: rather than shared with other tenants, so the
+//     tenant neither reuses nor exposes anyone's verdicts.
+//
+// Domain records (MX, catch-all, disposable) describe the public internet
+// and stay shared. Listed tenants also get their own label on the per-tenant
+// metrics; other API keys are counted as "other" to bound cardinality.
+
+// TenantConfig overrides Config for one tenant; zero fields keep the global value
+type TenantConfig struct {
+	MailFrom        string        `yaml:"mail_from"`
+	EHLOHostname    string        `yaml:"ehlo_hostname"`
+	ResultCacheTTL  time.Duration `yaml:"result_cache_ttl"`
+	DomainRateLimit time.Duration `yaml:"domain_rate_limit"`
+	DomainRateBurst int           `yaml:"domain_rate_burst"`
+	MXRateLimit     time.Duration `yaml:"mx_rate_limit"`
+	MXRateBurst     int           `yaml:"mx_rate_burst"`
+	IsolateCache    bool          `yaml:"isolate_cache"`
+}
+
+// metricsOtherTenant labels API keys without a Tenants entry
+const metricsOtherTenant = "other"
+
+// TenantStat counts results served to one tenant with one status since the
+// process started
+type TenantStat struct {
+	Tenant        string
+	Status        ValidationStatus
+	Verifications int64
+	CacheHits     int64
+}
+
+type tenantStatKey struct {
+	tenant string
+	status ValidationStatus
+}
+
+type tenantCounters struct {
+	mu     sync.Mutex
+	counts map[tenantStatKey]*TenantStat
+}
+
+// tenantConfig returns the overrides for the tenant in ctx, or nil
+func (v *SMTPVerifier) tenantConfig(ctx context.Context) *TenantConfig {
+	if tc, ok := v.cfg().Tenants[TenantFrom(ctx)]; ok {
+		return &tc
+	}
+	return nil
+}
+
+func (v *SMTPVerifier) mailFrom(ctx context.Context) string {
+	if tc := v.tenantConfig(ctx); tc != nil && tc.MailFrom != "" {
+		return tc.MailFrom
+	}
+	return v.cfg().MailFrom
+}
+
+func (v *SMTPVerifier) ehloHostname(ctx context.Context) string {
+	if tc := v.tenantConfig(ctx); tc != nil && tc.EHLOHostname != "" {
+		return tc.EHLOHostname
+	}
+	return v.cfg().EHLOHostname
+}
+
+func (v *SMTPVerifier) resultCacheTTL(ctx context.Context) time.Duration {
+	if tc := v.tenantConfig(ctx); tc != nil && tc.ResultCacheTTL > 0 {
+		return tc.ResultCacheTTL
+	}
+	return v.cfg().ResultCacheTTL
+}
+
+// tenantKey namespaces a per-address key for tenants with isolate_cache
+func (v *SMTPVerifier) tenantKey(ctx context.Context, key string) string {
+	if tc := v.tenantConfig(ctx); tc != nil && tc.IsolateCache {
+		return "tenant:" + TenantFrom(ctx) + ":" + key
+	}
+	return key
+}
+
+// tenantRateLimits returns the tenant's own buckets for domain and mxHost
+func (v *SMTPVerifier) tenantRateLimits(ctx context.Context, domain, mxHost string) []rateLimitBucket {
+	tc := v.tenantConfig(ctx)
+	if tc == nil {
+		return nil
+	}
+	prefix := "ratelimit:bucket:tenant:" + TenantFrom(ctx) + ":"
+	var buckets []rateLimitBucket
+	if tc.DomainRateLimit > 0 {
+		buckets = append(buckets, rateLimitBucket{prefix + "domain:" + domain, tc.DomainRateLimit, max(tc.DomainRateBurst, 1)})
+	}
+	if tc.MXRateLimit > 0 {
+		buckets = append(buckets, rateLimitBucket{prefix + "mx:" + strings.ToLower(mxHost), tc.MXRateLimit, max(tc.MXRateBurst, 1)})
+	}
+	return buckets
+}
+
+// metricsTenant is the label for tenant on per-tenant metrics
+func (v *SMTPVerifier) metricsTenant(tenant string) string {
+	if _, ok := v.cfg().Tenants[tenant]; ok || tenant == DefaultTenant {
+		return tenant
+	}
+	return metricsOtherTenant
+}
+
+// countTenant adds a served result to the per-tenant metrics
+func (v *SMTPVerifier) countTenant(ctx context.Context, result *ValidationResult, cached bool) {
+	key := tenantStatKey{v.metricsTenant(TenantFrom(ctx)), result.Status}
+	v.tenantStats.mu.Lock()
+	defer v.tenantStats.mu.Unlock()
+	if v.tenantStats.counts == nil {
+		v.tenantStats.counts = make(map[tenantStatKey]*TenantStat)
+	}
+	stat := v.tenantStats.counts[key]
+	if stat == nil {
+		stat = &TenantStat{Tenant: key.tenant, Status: key.status}
+		v.tenantStats.counts[key] = stat
+	}
+	stat.Verifications++
+	if cached {
+		stat.CacheHits++
+	}
+}
+
+// TenantStats reports results served per tenant and status for /metrics
+func (v *SMTPVerifier) TenantStats() []TenantStat {
+	v.tenantStats.mu.Lock()
+	stats := make([]TenantStat, 0, len(v.tenantStats.counts))
+	for _, stat := range v.tenantStats.counts {
+		stats = append(stats, *stat)
+	}
+	v.tenantStats.mu.Unlock()
+
+	sort.Slice(stats, func(a, b int) bool {
+		if stats[a].Tenant != stats[b].Tenant {
+			return stats[a].Tenant < stats[b].Tenant
+		}
+		return stats[a].Status < stats[b].Status
+	})
+	return stats
+}
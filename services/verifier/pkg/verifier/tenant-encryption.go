@@ -0,0 +1,155 @@
+package verifier
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// TENANT ENCRYPTION KEYS
+// ============================================================================
+
+// A tenant can register one public key. Result files delivered to it are
+// then encrypted to that key, so contact data never sits in plaintext in the
+// tenant's downloads, inboxes or buckets. We only hold the public half and
+// cannot read what we sent. The key is tenant data and lives in the tenant's
+// residency region.
+
+// Encryption key formats
+const (
+	EncryptionAge = "age" // X25519 recipient, "age1..."
+	EncryptionPGP = "pgp" // ASCII-armored OpenPGP public key
+)
+
+// ErrNoTenant is returned when work without an API key tries to register a key
+var ErrNoTenant = errors.New("encryption keys belong to an API key")
+
+// EncryptionKey is a tenant's registered public key
+type EncryptionKey struct {
+	Format      string    `json:"format"`
+	PublicKey   string    `json:"public_key"`
+	Fingerprint string    `json:"fingerprint"` // age recipient, or PGP primary key fingerprint (hex)
+	CreatedAt   time.Time `json:"created_at"`
+
+	ageRecipients []age.Recipient
+	pgpEntities   openpgp.EntityList
+}
+
+// ParseEncryptionKey validates publicKey as a key usable for encryption
+func ParseEncryptionKey(format, publicKey string) (*EncryptionKey, error) {
+	key := &EncryptionKey{Format: format, PublicKey: strings.TrimSpace(publicKey), CreatedAt: time.Now().UTC()}
+	if err := key.parse(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (k *EncryptionKey) parse() error {
+	switch k.Format {
+	case EncryptionAge:
+		recipient, err := age.ParseX25519Recipient(k.PublicKey)
+		if err != nil {
+			return fmt.Errorf("invalid age recipient: %w", err)
+		}
+		k.ageRecipients = []age.Recipient{recipient}
+		k.Fingerprint = recipient.String()
+	case EncryptionPGP:
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(k.PublicKey))
+		if err != nil {
+			return fmt.Errorf("invalid PGP public key: %w", err)
+		}
+		if len(entities) != 1 {
+			return fmt.Errorf("want exactly one PGP key, got %d", len(entities))
+		}
+		if _, ok := entities[0].EncryptionKey(time.Now()); !ok {
+			return errors.New("PGP key has no valid encryption subkey")
+		}
+		if entities[0].PrivateKey != nil {
+			return errors.New("send the public key only")
+		}
+		k.pgpEntities = entities
+		k.Fingerprint = strings.ToUpper(hex.EncodeToString(entities[0].PrimaryKey.Fingerprint))
+	default:
+		return fmt.Errorf("format must be %q or %q", EncryptionAge, EncryptionPGP)
+	}
+	return nil
+}
+
+// Encrypt returns a writer that encrypts to the key into w; Close finishes
+// the ciphertext but does not close w
+func (k *EncryptionKey) Encrypt(w io.Writer, filename string) (io.WriteCloser, error) {
+	switch k.Format {
+	case EncryptionAge:
+		return age.Encrypt(w, k.ageRecipients...)
+	case EncryptionPGP:
+		return openpgp.Encrypt(w, k.pgpEntities, nil, &openpgp.FileHints{IsBinary: true, FileName: filename}, nil)
+	}
+	return nil, fmt.Errorf("unknown encryption format %q", k.Format)
+}
+
+// FileExtension is appended to the names of encrypted files
+func (k *EncryptionKey) FileExtension() string {
+	if k.Format == EncryptionPGP {
+		return ".gpg"
+	}
+	return ".age"
+}
+
+func encryptionKeyKey(tenant string) string {
+	return "tenant:encryption:" + tenant
+}
+
+// SetEncryptionKey registers key for the tenant in ctx, replacing any other
+func (v *SMTPVerifier) SetEncryptionKey(ctx context.Context, key *EncryptionKey) error {
+	tenant := TenantFrom(ctx)
+	if !keyedTenant(tenant) {
+		return ErrNoTenant
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return v.TenantStore(ctx).Set(ctx, encryptionKeyKey(tenant), data, 0).Err()
+}
+
+// EncryptionKey returns the key registered by the tenant in ctx, or nil
+func (v *SMTPVerifier) EncryptionKey(ctx context.Context) (*EncryptionKey, error) {
+	tenant := TenantFrom(ctx)
+	if !keyedTenant(tenant) {
+		return nil, nil
+	}
+	data, err := v.TenantStore(ctx).Get(ctx, encryptionKeyKey(tenant)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var key EncryptionKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+	if err := key.parse(); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// DeleteEncryptionKey goes back to plaintext delivery for the tenant in ctx
+func (v *SMTPVerifier) DeleteEncryptionKey(ctx context.Context) error {
+	tenant := TenantFrom(ctx)
+	if !keyedTenant(tenant) {
+		return ErrNoTenant
+	}
+	return v.TenantStore(ctx).Del(ctx, encryptionKeyKey(tenant)).Err()
+}
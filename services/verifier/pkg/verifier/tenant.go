@@ -0,0 +1,42 @@
+package verifier
+
+import "context"
+
+// ============================================================================
+// TENANTS
+// ============================================================================
+
+// The tenant travels in the context like the request ID, so usage stats can
+// be attributed no matter which path (HTTP, queue, workflow) ran the check.
+
+type tenantKey struct{}
+
+// DefaultTenant is recorded for work without a tenant: queue consumers,
+// scheduled tasks and the MTA integrations
+const DefaultTenant = "default"
+
+// AnonymousTenant is recorded for API requests without a key, where
+// auth.api_key_required is off; unlike DefaultTenant it is metered
+const AnonymousTenant = "anonymous"
+
+// keyedTenant reports whether tenant stands for one API key; per-key
+// settings such as encryption keys and bucket credentials need one
+func keyedTenant(tenant string) bool {
+	return tenant != DefaultTenant && tenant != AnonymousTenant
+}
+
+// WithTenant returns ctx carrying tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	if tenant == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant in ctx, or DefaultTenant
+func TenantFrom(ctx context.Context) string {
+	if tenant, _ := ctx.Value(tenantKey{}).(string); tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
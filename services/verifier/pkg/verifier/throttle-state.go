@@ -0,0 +1,166 @@
+package verifier
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// THROTTLE INTROSPECTION
+// ============================================================================
+
+// Read-only view of everything that can slow a domain down: token buckets,
+// session slots and benched egress IPs, so "why is this job crawling" can be
+// answered without reading Redis keys by hand.
+
+// Circuit states for an MX host, derived from probe blocks
+const (
+	CircuitClosed  = "closed"  // every egress IP may probe
+	CircuitPartial = "partial" // some egress IPs are benched
+	CircuitOpen    = "open"    // every egress IP is benched; probes end as probe_blocked
+)
+
+// ThrottleState is one token bucket and its session slots
+type ThrottleState struct {
+	IntervalMs   int64      `json:"interval_ms"` // 0 = not rate limited
+	Burst        int        `json:"burst"`
+	Tokens       float64    `json:"tokens"`  // Available now
+	WaitMs       int64      `json:"wait_ms"` // Before the next request may start
+	LastContact  *time.Time `json:"last_contact,omitempty"`
+	OpenSessions int64      `json:"open_sessions"`
+	MaxSessions  int        `json:"max_sessions"` // 0 = unlimited
+}
+
+// MXThrottleState adds the probe-block circuit for one MX host
+type MXThrottleState struct {
+	Host string `json:"host"`
+	ThrottleState
+	Circuit     string       `json:"circuit"`
+	ProbeBlocks []ProbeBlock `json:"probe_blocks"`
+}
+
+// RateLimitState is the throttle picture for a domain and its MX hosts
+type RateLimitState struct {
+	Domain   string            `json:"domain"`
+	Throttle ThrottleState     `json:"throttle"`
+	MXHosts  []MXThrottleState `json:"mx_hosts"`
+}
+
+// RateLimitState reports the domain's throttles and those of mxHosts; with
+// no hosts given, the domain's cached MX records are used (no DNS lookup)
+func (v *SMTPVerifier) RateLimitState(ctx context.Context, domain string, mxHosts []string) (*RateLimitState, error) {
+	domain = strings.ToLower(domain)
+	if len(mxHosts) == 0 {
+		records, _ := v.getCachedMXRecords(ctx, domain)
+		if len(records) == 0 {
+			if meta, err := v.getDomainMetadata(ctx, domain); err == nil && meta != nil {
+				records = meta.MXRecords
+			}
+		}
+		if !isNullMX(records) {
+			for _, mx := range records {
+				mxHosts = append(mxHosts, mx.Exchange)
+			}
+		}
+	}
+
+	state := &RateLimitState{Domain: domain, MXHosts: []MXThrottleState{}}
+	interval, burst := v.domainRateLimit(domain)
+	var err error
+	state.Throttle, err = v.throttleState(ctx, rateLimitDomainKey(domain), interval, burst,
+		concurrencyDomainPrefix+domain, v.cfg().MaxConcurrentPerDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range mxHosts {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		throttle, err := v.throttleState(ctx, rateLimitMXKey(host), v.cfg().MXRateLimit, v.cfg().MXRateBurst,
+			concurrencyMXPrefix+host, v.cfg().MaxConcurrentPerMX)
+		if err != nil {
+			return nil, err
+		}
+		blocks, err := v.probeBlocksFor(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		circuit := CircuitClosed
+		switch {
+		case len(blocks) == len(v.egressPaths(ctx)):
+			circuit = CircuitOpen
+		case len(blocks) > 0:
+			circuit = CircuitPartial
+		}
+		state.MXHosts = append(state.MXHosts, MXThrottleState{Host: host, ThrottleState: throttle, Circuit: circuit, ProbeBlocks: blocks})
+	}
+	return state, nil
+}
+
+// throttleState evaluates a bucket the way rateLimitTake would, without
+// taking a token
+func (v *SMTPVerifier) throttleState(ctx context.Context, bucketKey string, interval time.Duration, burst int, slotsKey string, maxSessions int) (ThrottleState, error) {
+	burst = max(burst, 1)
+	now := time.Now()
+	state := ThrottleState{IntervalMs: interval.Milliseconds(), Burst: burst, Tokens: float64(burst), MaxSessions: maxSessions}
+
+	pipe := v.redis.Pipeline()
+	bucket := pipe.HMGet(ctx, bucketKey, "tokens", "ts")
+	sessions := pipe.ZCount(ctx, slotsKey, strconv.FormatInt(now.UnixMilli(), 10), "+inf")
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return state, err
+	}
+	state.OpenSessions = sessions.Val()
+
+	vals := bucket.Val()
+	tokensRaw, _ := vals[0].(string)
+	tsRaw, _ := vals[1].(string)
+	ts, err := strconv.ParseInt(tsRaw, 10, 64)
+	if err != nil {
+		return state, nil // No recent contact; bucket is full
+	}
+	last := time.UnixMilli(ts)
+	state.LastContact = &last
+
+	if interval <= 0 {
+		return state, nil
+	}
+	tokens, _ := strconv.ParseFloat(tokensRaw, 64)
+	elapsed := math.Max(0, float64(now.UnixMilli()-ts))
+	state.Tokens = math.Min(float64(burst), tokens+elapsed/float64(interval.Milliseconds()))
+	if state.Tokens < 1 {
+		state.WaitMs = int64(math.Ceil((1 - state.Tokens) * float64(interval.Milliseconds())))
+	}
+	return state, nil
+}
+
+// probeBlocksFor lists the benched egress IPs for one MX host
+func (v *SMTPVerifier) probeBlocksFor(ctx context.Context, mxHost string) ([]ProbeBlock, error) {
+	paths := v.egressPaths(ctx)
+	pipe := v.redis.Pipeline()
+	responses := make([]*redis.StringCmd, len(paths))
+	ttls := make([]*redis.DurationCmd, len(paths))
+	for i, egress := range paths {
+		responses[i] = pipe.Get(ctx, probeBlockKey(egress, mxHost))
+		ttls[i] = pipe.PTTL(ctx, probeBlockKey(egress, mxHost))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	blocks := []ProbeBlock{}
+	for i, egress := range paths {
+		if responses[i].Err() != nil {
+			continue
+		}
+		if egress == "" {
+			egress = defaultEgress
+		}
+		blocks = append(blocks, ProbeBlock{EgressIP: egress, MXHost: mxHost, Response: responses[i].Val(), ExpiresAt: time.Now().Add(ttls[i].Val())})
+	}
+	return blocks, nil
+}
@@ -0,0 +1,88 @@
+package verifier
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ============================================================================
+// OPENTELEMETRY TRACING
+// ============================================================================
+
+// Spans cover Verify, MX lookups, SMTP handshakes and every Redis command
+// issued under a traced request. The embedding program installs the tracer
+// provider; until it does, the global no-op provider is used.
+
+// tracer resolves to whichever provider the embedding program installs
+var tracer = otel.Tracer("github.com/yourusername/email-validator/pkg/verifier")
+
+// endSpan records err (if any) and ends span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// RedisTracingHook creates a client span per Redis command or pipeline, but
+// only inside an existing trace so background polling stays out of it
+type RedisTracingHook struct{}
+
+func (RedisTracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisTracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return next(ctx, cmd)
+		}
+		ctx, span := tracer.Start(ctx, "redis "+strings.ToUpper(cmd.Name()),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "redis"),
+				attribute.String("db.operation", cmd.Name()),
+			),
+		)
+		err := next(ctx, cmd)
+		if err == redis.Nil {
+			span.SetAttributes(attribute.Bool("redis.miss", true))
+			endSpan(span, nil)
+		} else {
+			endSpan(span, err)
+		}
+		return err
+	}
+}
+
+func (RedisTracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return next(ctx, cmds)
+		}
+		ctx, span := tracer.Start(ctx, "redis pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "redis"),
+				attribute.Int("redis.pipeline.length", len(cmds)),
+			),
+		)
+		err := next(ctx, cmds)
+		if err == redis.Nil {
+			endSpan(span, nil)
+		} else {
+			endSpan(span, err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,128 @@
+package verifier
+
+import (
+	"strings"
+)
+
+// ============================================================================
+// DOMAIN TYPO SUGGESTIONS
+// ============================================================================
+
+// Domains without MX records are often misspellings of a large provider
+// (gmial.com, outlok.com). We compare against a short list of popular mail
+// domains and offer the closest one as did_you_mean.
+
+// suggestionDomains are the most common consumer mail domains
+var suggestionDomains = []string{
+	"gmail.com", "googlemail.com",
+	"outlook.com", "hotmail.com", "live.com", "msn.com",
+	"hotmail.co.uk", "hotmail.fr", "outlook.fr",
+	"yahoo.com", "yahoo.co.uk", "yahoo.fr", "ymail.com", "rocketmail.com",
+	"icloud.com", "me.com", "mac.com",
+	"aol.com", "protonmail.com", "proton.me",
+	"gmx.com", "gmx.de", "gmx.net", "web.de", "t-online.de",
+	"mail.com", "zoho.com", "fastmail.com",
+	"yandex.ru", "mail.ru", "qq.com", "163.com",
+	"comcast.net", "verizon.net", "att.net", "sbcglobal.net",
+	"orange.fr", "free.fr", "wanadoo.fr", "libero.it", "btinternet.com",
+}
+
+// maxTypoDistance is the largest edit distance still treated as a typo;
+// adjacent-key substitutions count half
+const maxTypoDistance = 2.0
+
+// qwertyRows is used to find physically adjacent keys
+var qwertyRows = []string{"1234567890-", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// keyPositions maps each key to its row and column
+var keyPositions = func() map[byte][2]int {
+	pos := make(map[byte][2]int)
+	for r, row := range qwertyRows {
+		for c := 0; c < len(row); c++ {
+			pos[row[c]] = [2]int{r, c}
+		}
+	}
+	return pos
+}()
+
+// suggestDomain returns the closest popular domain, or "" if none is near
+// enough. Exact matches never produce a suggestion.
+func suggestDomain(domain string) string {
+	domain = strings.ToLower(domain)
+	best := ""
+	bestDist := maxTypoDistance + 0.01
+	for _, candidate := range suggestionDomains {
+		if candidate == domain {
+			return ""
+		}
+		// Short domains need a tighter bound to avoid wild guesses
+		limit := maxTypoDistance
+		if len(candidate) <= 7 {
+			limit = 1
+		}
+		if d := typoDistance(domain, candidate); d <= limit && d < bestDist {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+// didYouMean rewrites email with the suggested domain
+func didYouMean(email, domain string) string {
+	suggestion := suggestDomain(domain)
+	if suggestion == "" {
+		return ""
+	}
+	return email[:strings.LastIndex(email, "@")+1] + suggestion
+}
+
+// typoDistance is the optimal string alignment distance (Levenshtein plus
+// adjacent transpositions), with cheaper substitutions for neighboring keys
+func typoDistance(a, b string) float64 {
+	rows, cols := len(a)+1, len(b)+1
+	d := make([][]float64, rows)
+	for i := range d {
+		d[i] = make([]float64, cols)
+		d[i][0] = float64(i)
+	}
+	for j := 0; j < cols; j++ {
+		d[0][j] = float64(j)
+	}
+
+	for i := 1; i < rows; i++ {
+		for j := 1; j < cols; j++ {
+			sub := 0.0
+			if a[i-1] != b[j-1] {
+				sub = 1
+				if adjacentKeys(a[i-1], b[j-1]) {
+					sub = 0.5
+				}
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+sub)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[rows-1][cols-1]
+}
+
+func adjacentKeys(x, y byte) bool {
+	px, okx := keyPositions[x]
+	py, oky := keyPositions[y]
+	if !okx || !oky {
+		return false
+	}
+	dr, dc := px[0]-py[0], px[1]-py[1]
+	return dr >= -1 && dr <= 1 && dc >= -1 && dc <= 1
+}
+
+func min3(a, b, c float64) float64 {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,118 @@
+package verifier
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// DAILY USAGE STATS
+// ============================================================================
+
+// Every verification served, fresh or cached, is counted per UTC day, tenant,
+// domain and status in stats:usage:{YYYYMMDD}. The warehouse exporter reads
+// completed days from here, so analytics never touch the result cache. The
+// same write meters the caller's API key against its quotas (see quotas.go).
+
+const (
+	usageStatsTTL = 8 * 24 * time.Hour // A week of export retries
+	usageFieldSep = "|"
+)
+
+// UsageRow is one aggregate: a day, tenant, domain and status
+type UsageRow struct {
+	Day             string           `json:"day"` // YYYY-MM-DD (UTC)
+	Tenant          string           `json:"tenant"`
+	Domain          string           `json:"domain"`
+	Status          ValidationStatus `json:"status"`
+	Verifications   int64            `json:"verifications"`
+	CacheHits       int64            `json:"cache_hits"`
+	TotalDurationMs int64            `json:"total_duration_ms"` // Fresh checks only
+}
+
+func usageStatsKey(day time.Time) string {
+	return "stats:usage:" + day.UTC().Format("20060102")
+}
+
+// recordUsage counts one verification served to the caller in ctx
+func (v *SMTPVerifier) recordUsage(ctx context.Context, result *ValidationResult, cached bool) {
+	if result == nil {
+		return
+	}
+	v.countTenant(ctx, result, cached)
+	tenant := strings.ReplaceAll(TenantFrom(ctx), usageFieldSep, "_")
+	field := tenant + usageFieldSep + result.Domain + usageFieldSep + string(result.Status) + usageFieldSep
+	key := usageStatsKey(time.Now())
+
+	pipe := v.TenantStore(ctx).Pipeline()
+	pipe.HIncrBy(ctx, key, field+"verifications", 1)
+	if cached {
+		pipe.HIncrBy(ctx, key, field+"cache_hits", 1)
+	} else {
+		pipe.HIncrBy(ctx, key, field+"duration_ms", result.ValidationTimeMs)
+	}
+	pipe.Expire(ctx, key, usageStatsTTL)
+	v.meterUsage(ctx, pipe, 1)
+	pipe.Exec(ctx)
+}
+
+// UsageStats returns the aggregates for one UTC day, sorted by tenant,
+// domain and status. Each region holds its own tenants' stats; see
+// WithRegion.
+func (v *SMTPVerifier) UsageStats(ctx context.Context, day time.Time) ([]UsageRow, error) {
+	rows := make(map[string]*UsageRow)
+	iter := v.TenantStore(ctx).HScan(ctx, usageStatsKey(day), 0, "", 1000).Iterator()
+	for iter.Next(ctx) {
+		field := iter.Val()
+		if !iter.Next(ctx) {
+			break
+		}
+		n, err := strconv.ParseInt(iter.Val(), 10, 64)
+		if err != nil {
+			continue
+		}
+		i := strings.LastIndex(field, usageFieldSep)
+		if i < 0 {
+			continue
+		}
+		group, metric := field[:i], field[i+1:]
+		parts := strings.SplitN(group, usageFieldSep, 3)
+		if len(parts) != 3 {
+			continue
+		}
+		row := rows[group]
+		if row == nil {
+			row = &UsageRow{Day: day.UTC().Format("2006-01-02"), Tenant: parts[0], Domain: parts[1], Status: ValidationStatus(parts[2])}
+			rows[group] = row
+		}
+		switch metric {
+		case "verifications":
+			row.Verifications = n
+		case "cache_hits":
+			row.CacheHits = n
+		case "duration_ms":
+			row.TotalDurationMs = n
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]UsageRow, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, *row)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].Tenant != out[b].Tenant {
+			return out[a].Tenant < out[b].Tenant
+		}
+		if out[a].Domain != out[b].Domain {
+			return out[a].Domain < out[b].Domain
+		}
+		return out[a].Status < out[b].Status
+	})
+	return out, nil
+}
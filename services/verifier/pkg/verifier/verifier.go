@@ -0,0 +1,24 @@
+// Package verifier checks whether an email address can receive mail: syntax,
+// disposable domains, MX records, an SMTP RCPT TO handshake and catch-all
+// detection, with results and domain records cached in Redis.
+//
+// The HTTP service in cmd/verifier is one consumer; other Go services can
+// embed the same engine:
+//
+//	v := verifier.NewSMTPVerifier(verifier.DefaultConfig(), redisClient)
+//	result, err := v.Verify(ctx, "user@example.com")
+package verifier
+
+import "context"
+
+// Verifier is the verification surface shared by every consumer
+type Verifier interface {
+	// Verify checks one address, using the cached result when there is one
+	Verify(ctx context.Context, email string) (*ValidationResult, error)
+	// VerifyWithOptions checks one address with per-call options
+	VerifyWithOptions(ctx context.Context, email string, opts VerifyOptions) (*ValidationResult, error)
+	// VerifyBatch checks many addresses, returning results in input order
+	VerifyBatch(ctx context.Context, emails []string) []*ValidationResult
+}
+
+var _ Verifier = (*SMTPVerifier)(nil)
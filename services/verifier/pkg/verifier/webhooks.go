@@ -0,0 +1,261 @@
+package verifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// WEBHOOK CALLBACKS
+// ============================================================================
+
+// Results that finish after the request returned (batches sent with a
+// callback_url, greylisted addresses retried later) are POSTed to the
+// caller's URL. Each delivery is tracked in the tenant's store:
+//
+//	webhook:{id}      JSON WebhookDelivery, kept WebhookRetention
+//	webhook:pending   sorted set: id -> unix time of the next attempt
+//
+// A delivery is attempted at once; a failure (no answer, or anything but
+// 2xx) is retried after WebhookRetryBackoff, doubling each time, up to
+// WebhookMaxAttempts. The webhook_deliveries task claims due retries with
+// ZREM on any worker.
+//
+// With WebhookSigningSecret set, requests carry
+//
+//	X-Webhook-Signature: t={unix time},v1={hex HMAC-SHA256 of "{t}.{body}"}
+//
+// so receivers can check the sender and reject replays.
+
+const (
+	webhookKeyPrefix  = "webhook:"
+	webhookPendingKey = "webhook:pending"
+	webhookRetryBatch = 100
+	webhookMaxErrLen  = 512
+)
+
+// Webhook events
+const (
+	WebhookBatchCompleted    = "batch.completed"
+	WebhookDeferredCompleted = "verification.completed" // A greylisted address's final result
+)
+
+// Webhook delivery states
+const (
+	WebhookWaiting   = "waiting"   // The payload is not ready yet
+	WebhookPending   = "pending"   // Being delivered or waiting for a retry
+	WebhookDelivered = "delivered" // The receiver answered 2xx
+	WebhookFailed    = "failed"    // Every attempt failed
+)
+
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	Event          string          `json:"event"`
+	URL            string          `json:"url"`
+	Tenant         string          `json:"tenant"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage `json:"-"`
+}
+
+// storedWebhook keeps the payload next to the delivery record
+type storedWebhook struct {
+	WebhookDelivery
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// webhookEnvelope is the body receivers get
+type webhookEnvelope struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+var webhookClient = &http.Client{}
+
+// CreateWebhook registers a delivery whose payload comes later, so the
+// caller can hand out its ID straight away
+func (v *SMTPVerifier) CreateWebhook(ctx context.Context, url, event string) (*WebhookDelivery, error) {
+	delivery := &WebhookDelivery{
+		ID:        NewID(),
+		Event:     event,
+		URL:       url,
+		Tenant:    TenantFrom(ctx),
+		Status:    WebhookWaiting,
+		CreatedAt: time.Now(),
+	}
+	return delivery, v.saveWebhook(ctx, delivery)
+}
+
+// SendWebhook sets the payload of a delivery and makes the first attempt
+func (v *SMTPVerifier) SendWebhook(ctx context.Context, delivery *WebhookDelivery, data interface{}) error {
+	payload, err := json.Marshal(webhookEnvelope{ID: delivery.ID, Event: delivery.Event, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		return err
+	}
+	delivery.Payload = payload
+	delivery.Status = WebhookPending
+	v.attemptWebhook(ctx, delivery)
+	return nil
+}
+
+// GetWebhook returns the caller's delivery, or redis.Nil
+func (v *SMTPVerifier) GetWebhook(ctx context.Context, id string) (*WebhookDelivery, error) {
+	delivery, err := v.loadWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Tenant != TenantFrom(ctx) {
+		return nil, redis.Nil
+	}
+	return delivery, nil
+}
+
+func (v *SMTPVerifier) saveWebhook(ctx context.Context, delivery *WebhookDelivery) error {
+	data, err := json.Marshal(storedWebhook{WebhookDelivery: *delivery, Payload: delivery.Payload})
+	if err != nil {
+		return err
+	}
+	return v.TenantStore(ctx).Set(ctx, webhookKeyPrefix+delivery.ID, data, v.cfg().WebhookRetention).Err()
+}
+
+func (v *SMTPVerifier) loadWebhook(ctx context.Context, id string) (*WebhookDelivery, error) {
+	data, err := v.TenantStore(ctx).Get(ctx, webhookKeyPrefix+id).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var stored storedWebhook
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	delivery := stored.WebhookDelivery
+	delivery.Payload = stored.Payload
+	return &delivery, nil
+}
+
+// attemptWebhook POSTs the payload once, then records the outcome and
+// schedules the next attempt if there is one
+func (v *SMTPVerifier) attemptWebhook(ctx context.Context, delivery *WebhookDelivery) {
+	delivery.Attempts++
+	delivery.NextAttemptAt = nil
+	code, err := v.postWebhook(ctx, delivery)
+	delivery.LastStatusCode = code
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		now := time.Now()
+		delivery.Status = WebhookDelivered
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= v.cfg().WebhookMaxAttempts:
+		delivery.Status = WebhookFailed
+		delivery.LastError = truncate(err.Error(), webhookMaxErrLen)
+		slog.WarnContext(ctx, "webhook delivery failed", "webhook_id", delivery.ID, "event", delivery.Event, "attempts", delivery.Attempts, "error", err)
+	default:
+		next := time.Now().Add(v.cfg().WebhookRetryBackoff << (delivery.Attempts - 1))
+		delivery.NextAttemptAt = &next
+		delivery.LastError = truncate(err.Error(), webhookMaxErrLen)
+	}
+
+	if err := v.saveWebhook(ctx, delivery); err != nil {
+		slog.WarnContext(ctx, "webhook state not saved", "webhook_id", delivery.ID, "error", err)
+		return
+	}
+	if delivery.NextAttemptAt != nil {
+		v.TenantStore(ctx).ZAdd(ctx, webhookPendingKey, redis.Z{Score: float64(delivery.NextAttemptAt.Unix()), Member: delivery.ID})
+	}
+}
+
+func (v *SMTPVerifier) postWebhook(ctx context.Context, delivery *WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.cfg().WebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", delivery.ID)
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	if v.cfg().WebhookSigningSecret != "" {
+		req.Header.Set("X-Webhook-Signature", signWebhook(v.cfg().WebhookSigningSecret, time.Now(), delivery.Payload))
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook returns the X-Webhook-Signature value for body sent at t
+func signWebhook(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryWebhooks retries due deliveries at home and in each region
+func (v *SMTPVerifier) RetryWebhooks(ctx context.Context) error {
+	err := v.retryWebhooks(WithRegion(ctx, ""))
+	for _, region := range v.cfg().Regions {
+		if regionErr := v.retryWebhooks(WithRegion(ctx, region.Name)); regionErr != nil && err == nil {
+			err = fmt.Errorf("region %s: %w", region.Name, regionErr)
+		}
+	}
+	return err
+}
+
+func (v *SMTPVerifier) retryWebhooks(ctx context.Context) error {
+	store := v.TenantStore(ctx)
+	due, err := store.ZRangeByScore(ctx, webhookPendingKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().Unix(), 10),
+		Count: webhookRetryBatch,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	for _, id := range due {
+		if claimed, _ := store.ZRem(ctx, webhookPendingKey, id).Result(); claimed == 0 {
+			continue
+		}
+		delivery, err := v.loadWebhook(ctx, id)
+		if err != nil {
+			continue // Expired
+		}
+		v.attemptWebhook(WithTenant(ctx, delivery.Tenant), delivery)
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -36,8 +36,16 @@ const Row = ({ index, style, data }) => {
             <div style={{ width: '20%', color: 'var(--text-secondary)' }}>
                 {item.domain || '-'}
             </div>
-            <div style={{ flex: 1, color: 'var(--text-secondary)', overflow: 'hidden', textOverflow: 'ellipsis', whiteSpace: 'nowrap' }}>
+            <div
+                style={{ flex: 1, color: 'var(--text-secondary)', overflow: 'hidden', textOverflow: 'ellipsis', whiteSpace: 'nowrap' }}
+                title={item.didYouMean ? `Did you mean ${item.didYouMean}?` : item.reason}
+            >
                 {item.reason}
+                {item.didYouMean && (
+                    <span style={{ color: 'var(--warning-color)', marginLeft: '8px' }}>
+                        Did you mean {item.didYouMean}?
+                    </span>
+                )}
             </div>
         </div>
     );
@@ -49,9 +49,14 @@ export async function validateWithServer(emails) {
     try {
         // Use production Go backend or environment variable
         const API_URL = import.meta.env.VITE_API_URL || 'http://localhost:8080';
+        // The server answers 401 without a key unless anonymous access is on
+        const headers = { 'Content-Type': 'application/json' };
+        if (import.meta.env.VITE_API_KEY) {
+            headers['X-API-Key'] = import.meta.env.VITE_API_KEY;
+        }
         const response = await fetch(`${API_URL}/v1/validate/batch`, {
             method: 'POST',
-            headers: { 'Content-Type': 'application/json' },
+            headers,
             body: JSON.stringify({ emails })
         });
 
@@ -71,7 +76,8 @@ export async function validateWithServer(emails) {
             reason: formatReason(result),
             isCatchAll: result.is_catch_all,
             confidence: result.confidence,
-            smtpCode: result.smtp_code
+            smtpCode: result.smtp_code,
+            didYouMean: result.did_you_mean
         }));
     } catch (error) {
         console.error('Validation error:', error);